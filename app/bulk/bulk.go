@@ -0,0 +1,53 @@
+// Package bulk menjalankan aksi satuan (handler endpoint biasa) untuk banyak item dalam satu request
+// dan merangkum respon setiap item. Package ini tidak mengakses database; otorisasi dan aksi diberikan pemanggil.
+package bulk
+
+import (
+	"encoding/json"
+	"log"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorMessage adalah pesan hasil item jika aksinya gagal tanpa menulis respon; detail error hanya dicatat di log.
+const ErrorMessage = "Terjadi kesalahan saat memproses item ini"
+
+// Run menjalankan action untuk setiap item. Action menulis respon ke c seperti endpoint satuan; respon itu
+// dibaca sebagai hasil item lalu dibuang, sehingga pemeriksaan otorisasi dan status setiap item sama persis
+// dengan endpoint satuan dan kegagalan satu item tidak menghentikan item lain.
+func Run(c *fiber.Ctx, items []model.BulkActionItem, action func(item model.BulkActionItem) error) error {
+	summary := model.BulkActionSummary{Total: len(items), Results: make([]model.BulkActionResult, 0, len(items))}
+
+	for _, item := range items {
+		result := model.BulkActionResult{ID: item.ID}
+
+		if err := action(item); err != nil {
+			log.Printf("bulk: item %s gagal diproses: %v", item.ID, err)
+			result.Code = fiber.StatusInternalServerError
+			result.Message = ErrorMessage
+		} else {
+			var resp helper.Response
+			if err := json.Unmarshal(c.Response().Body(), &resp); err != nil {
+				resp = helper.Response{Code: fiber.StatusInternalServerError, Message: "Respon aksi tidak terbaca"}
+			}
+			result.Code = c.Response().StatusCode()
+			result.Message = resp.Message
+			result.Data = resp.Data
+			result.Errors = resp.Errors
+		}
+		c.Response().ResetBody()
+
+		result.Success = result.Code >= 200 && result.Code < 300
+		if result.Success {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+		summary.Results = append(summary.Results, result)
+	}
+
+	return helper.Success(c, summary, "Aksi massal selesai diproses")
+}
@@ -0,0 +1,89 @@
+// Package comment memuat aturan komentar review prestasi: siapa yang melihat catatan internal,
+// validasi anchor dan penyusunan thread. Package ini murni (tanpa akses database).
+package comment
+
+import (
+	"regexp"
+	"sort"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+)
+
+// MaxBodyLength adalah panjang maksimal isi komentar (karakter).
+const MaxBodyLength = 5000
+
+// Path field konten prestasi yang boleh dijadikan anchor
+var fieldAnchorPattern = regexp.MustCompile(`^(title|description|achievementType|competitionTier|tags|attachments|details(\.[A-Za-z0-9_]+)*)$`)
+
+// IsReviewer mengecek apakah subject termasuk reviewer prestasi (boleh memverifikasi), sehingga
+// boleh membaca dan menulis catatan internal.
+func IsReviewer(s policy.Subject, r policy.Resource) bool {
+	return policy.Can(s, policy.ActionVerify, r).Allowed
+}
+
+// Visible mengecek apakah komentar boleh dilihat; catatan internal hanya untuk reviewer.
+func Visible(c model.AchievementComment, reviewer bool) bool {
+	return c.Visibility != model.CommentInternal || reviewer
+}
+
+// CanResolve: thread boleh ditandai selesai / dibuka kembali oleh reviewer atau pembuka thread.
+func CanResolve(thread model.AchievementComment, userID string, reviewer bool) bool {
+	return reviewer || (thread.AuthorID != nil && userID != "" && thread.AuthorID.String() == userID)
+}
+
+// ValidateAnchor memeriksa anchor komentar terhadap konten prestasi saat ini. Mengembalikan error per field.
+func ValidateAnchor(anchorType, anchorRef string, attachments []model.Attachment) map[string]string {
+	errs := map[string]string{}
+	switch anchorType {
+	case "":
+		if anchorRef != "" {
+			errs["anchor_type"] = "wajib diisi jika anchor_ref diisi"
+		}
+	case model.AnchorField:
+		if !fieldAnchorPattern.MatchString(anchorRef) {
+			errs["anchor_ref"] = "field tidak dikenal (title, description, achievementType, competitionTier, tags, attachments atau details.<field>)"
+		}
+	case model.AnchorAttachment:
+		found := false
+		for _, att := range attachments {
+			if att.FileURL == anchorRef {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs["anchor_ref"] = "lampiran tidak ditemukan (gunakan fileUrl lampiran)"
+		}
+	default:
+		errs["anchor_type"] = "harus field atau attachment"
+	}
+	return errs
+}
+
+// Threads menyusun komentar datar menjadi thread (urut waktu pembuatan) dan membuang yang tidak boleh
+// dilihat. Balasan yang thread-nya tidak terlihat ikut tersembunyi.
+func Threads(comments []model.AchievementComment, reviewer bool) []model.AchievementComment {
+	sorted := make([]model.AchievementComment, len(comments))
+	copy(sorted, comments)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	threads := []model.AchievementComment{}
+	index := map[string]int{}
+	for _, c := range sorted {
+		if c.ParentID == nil && Visible(c, reviewer) {
+			c.Replies = nil
+			index[c.ID.String()] = len(threads)
+			threads = append(threads, c)
+		}
+	}
+	for _, c := range sorted {
+		if c.ParentID == nil || !Visible(c, reviewer) {
+			continue
+		}
+		if i, ok := index[c.ParentID.String()]; ok {
+			threads[i].Replies = append(threads[i].Replies, c)
+		}
+	}
+	return threads
+}
@@ -0,0 +1,89 @@
+// Package delegation memuat aturan delegasi verifikasi dosen wali: validasi rentang tanggal, status
+// dan konversi ke delegasi policy. Package ini murni (tanpa akses database).
+package delegation
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+)
+
+// MaxDays adalah panjang maksimal satu delegasi (hari, inklusif); cuti lebih lama dibuat ulang.
+const MaxDays = 366
+
+var (
+	locationOnce sync.Once
+	location     *time.Location
+)
+
+// Location mengembalikan zona waktu penentu pergantian hari delegasi (APP_TIMEZONE, default Asia/Jakarta).
+// Validasi, status dan pemuatan delegasi aktif untuk otorisasi memakai zona yang sama.
+func Location() *time.Location {
+	locationOnce.Do(func() {
+		name := os.Getenv("APP_TIMEZONE")
+		if name == "" {
+			name = "Asia/Jakarta"
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			// Data zona waktu tidak tersedia: pakai WIB
+			loc = time.FixedZone("WIB", 7*60*60)
+		}
+		location = loc
+	})
+	return location
+}
+
+// Today mengembalikan tanggal (tanpa jam) dari waktu t menurut zona waktu t, pembanding rentang delegasi.
+func Today(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// CurrentDate mengembalikan tanggal hari ini menurut Location.
+func CurrentDate() time.Time {
+	return Today(time.Now().In(Location()))
+}
+
+// Validate memeriksa delegasi baru dan mengembalikan pesan error per field.
+func Validate(d model.AdvisorDelegation, today time.Time) map[string]string {
+	errs := map[string]string{}
+	if d.FromLecturerID == d.ToLecturerID {
+		errs["to_lecturer_id"] = "Dosen pengganti harus berbeda dari dosen wali"
+	}
+	switch {
+	case d.EndDate.Before(d.StartDate):
+		errs["end_date"] = "Tanggal selesai tidak boleh sebelum tanggal mulai"
+	case d.EndDate.Before(today):
+		errs["end_date"] = "Tanggal selesai tidak boleh di masa lalu"
+	case int(d.EndDate.Sub(d.StartDate).Hours()/24)+1 > MaxDays:
+		errs["end_date"] = fmt.Sprintf("Delegasi maksimal %d hari", MaxDays)
+	}
+	return errs
+}
+
+// Status menentukan status delegasi pada tanggal today.
+func Status(d model.AdvisorDelegation, today time.Time) string {
+	switch {
+	case d.RevokedAt != nil:
+		return model.DelegationRevoked
+	case today.Before(d.StartDate):
+		return model.DelegationScheduled
+	case today.After(d.EndDate):
+		return model.DelegationExpired
+	default:
+		return model.DelegationActive
+	}
+}
+
+// ToPolicy mengubah delegasi menjadi relasi delegate milik subject dosen pengganti.
+func ToPolicy(d model.AdvisorDelegation) policy.Delegation {
+	students := make([]string, 0, len(d.StudentIDs))
+	for _, id := range d.StudentIDs {
+		students = append(students, id.String())
+	}
+	return policy.Delegation{ID: d.ID.String(), FromLecturerID: d.FromLecturerID.String(), StudentIDs: students}
+}
@@ -0,0 +1,41 @@
+// Package lockout memuat aturan proteksi brute-force login: penguncian akun setelah beberapa kali gagal
+// berturut-turut dan penolakan IP yang terlalu sering gagal dalam satu window waktu.
+// Package ini murni (tanpa akses database).
+package lockout
+
+import "time"
+
+// Policy adalah konfigurasi lockout (LOGIN_MAX_ATTEMPTS, LOGIN_LOCKOUT_MINUTES, LOGIN_IP_MAX_ATTEMPTS,
+// LOGIN_IP_WINDOW_MINUTES).
+type Policy struct {
+	MaxAttempts   int           // kegagalan berturut-turut sebelum akun dikunci
+	LockDuration  time.Duration // lama penguncian akun
+	IPMaxAttempts int           // kegagalan dari satu IP di dalam IPWindow sebelum IP ditolak
+	IPWindow      time.Duration
+}
+
+// Locked memeriksa apakah akun masih dikunci pada waktu now.
+func Locked(lockedUntil *time.Time, now time.Time) bool {
+	return lockedUntil != nil && lockedUntil.After(now)
+}
+
+// IPWindowStart mengembalikan awal window penghitungan kegagalan per IP.
+func (p Policy) IPWindowStart(now time.Time) time.Time {
+	return now.Add(-p.IPWindow)
+}
+
+// IPBlocked memeriksa apakah jumlah kegagalan dari satu IP di dalam window sudah mencapai batas.
+func (p Policy) IPBlocked(failures int) bool {
+	return failures >= p.IPMaxAttempts
+}
+
+// RegisterFailure menghitung counter gagal login setelah satu kegagalan lagi. Jika counter mencapai
+// MaxAttempts, akun dikunci sampai now + LockDuration dan counter di-reset ke 0; lockedUntil nil
+// berarti akun belum dikunci.
+func (p Policy) RegisterFailure(failed int, now time.Time) (count int, lockedUntil *time.Time) {
+	if failed+1 >= p.MaxAttempts {
+		until := now.Add(p.LockDuration)
+		return 0, &until
+	}
+	return failed + 1, nil
+}
@@ -10,11 +10,16 @@ import (
 
 type AchievementStatus string
 
+// Transisi antar status diatur oleh package workflow.
 const (
-	StatusDraft     AchievementStatus = "draft"
-	StatusSubmitted AchievementStatus = "submitted"
-	StatusVerified  AchievementStatus = "verified"
-	StatusRejected  AchievementStatus = "rejected"
+	StatusDraft             AchievementStatus = "draft"
+	StatusSubmitted         AchievementStatus = "submitted"
+	StatusVerified          AchievementStatus = "verified"
+	StatusRejected          AchievementStatus = "rejected"
+	StatusInReview          AchievementStatus = "in_review"          // sebagian tahap verifikasi sudah disetujui
+	StatusRevisionRequested AchievementStatus = "revision_requested" // diminta perbaikan oleh verifikator
+	StatusWithdrawn         AchievementStatus = "withdrawn"          // ditarik kembali oleh mahasiswa
+	StatusArchived          AchievementStatus = "archived"           // diarsipkan admin, tidak bisa diubah lagi
 )
 
 type AchievementReference struct {
@@ -23,8 +28,17 @@ type AchievementReference struct {
 	MongoAchievementID string            `db:"mongo_achievement_id" json:"mongo_achievement_id"`
 	Status             AchievementStatus `db:"status" json:"status"`
 
+	// Pipeline verifikasi yang dipakai (dipatok saat persetujuan pertama) dan tahap yang sedang berjalan
+	PipelineID   *uuid.UUID `db:"pipeline_id" json:"pipeline_id,omitempty"`
+	CurrentStage int        `db:"current_stage" json:"current_stage"`
+
+	// Revisi konten (achievement_revisions) yang diajukan dan yang akhirnya disetujui verifikator
+	SubmittedRevision *int `db:"submitted_revision" json:"submitted_revision,omitempty"`
+	VerifiedRevision  *int `db:"verified_revision" json:"verified_revision,omitempty"`
+
 	SubmittedAt   *time.Time `db:"submitted_at" json:"submitted_at"`
 	VerifiedAt    *time.Time `db:"verified_at" json:"verified_at"`
+	RejectedAt    *time.Time `db:"rejected_at" json:"rejected_at"`
 	VerifiedBy    *uuid.UUID `db:"verified_by" json:"verified_by"`
 	RejectionNote *string    `db:"rejection_note" json:"rejection_note"`
 	DeletedAt     *time.Time `db:"deleted_at" json:"deleted_at"`
@@ -50,6 +64,7 @@ type AchievementMongo struct {
 	CreatedAt       time.Time              `bson:"createdAt" json:"createdAt"`
 	CompetitionTier string `bson:"competitionTier" json:"competitionTier"`
 	UpdatedAt       time.Time              `bson:"updatedAt" json:"updatedAt"`
+	Revision        int                    `bson:"revision" json:"revision"` // nomor revisi konten terakhir
 	DeletedAt 		*time.Time `db:"deleted_at" json:"deleted_at"`
 }
 
@@ -57,8 +72,66 @@ type Attachment struct {
 	FileName   string    `bson:"fileName" json:"fileName"`
 	FileURL    string    `bson:"fileUrl" json:"fileUrl"`
 	FileType   string    `bson:"fileType" json:"fileType"`
+	Category   string    `bson:"category,omitempty" json:"category,omitempty"` // kategori lampiran, misal certificate
 	UploadedAt time.Time `bson:"uploadedAt" json:"uploadedAt"`
 }
+// AchievementStatusEvent adalah satu baris log transisi status prestasi (tabel achievement_status_events).
+type AchievementStatusEvent struct {
+	ID             uuid.UUID          `json:"id"`
+	AchievementID  uuid.UUID          `json:"achievement_id"`
+	FromStatus     *AchievementStatus `json:"from_status"` // null untuk pembuatan prestasi / data migrasi
+	ToStatus       AchievementStatus  `json:"to_status"`
+	ActorID        *uuid.UUID         `json:"actor_id"` // null untuk API key
+	ActorName      string             `json:"actor_name,omitempty"`
+	ActorRole      string             `json:"actor_role"`
+	Note           string             `json:"note,omitempty"`
+	Revision       *int               `json:"revision,omitempty"`     // revisi konten yang diajukan / diputuskan
+	OnBehalfOf     *uuid.UUID         `json:"on_behalf_of,omitempty"` // dosen wali yang diwakili lewat delegasi
+	OnBehalfOfName string             `json:"on_behalf_of_name,omitempty"`
+	DelegationID   *uuid.UUID         `json:"delegation_id,omitempty"`
+	IPAddress      string             `json:"ip_address"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
 type RejectRequest struct {
 	RejectionNote string `json:"rejection_note"` // Catatan penolakan
-}
\ No newline at end of file
+}
+
+// VerifyRequest adalah body opsional persetujuan verifikasi.
+type VerifyRequest struct {
+	Revision *int   `json:"revision"` // revisi konten yang diperiksa verifikator
+	Note     string `json:"note"`     // catatan persetujuan
+}
+
+// BulkActionItem adalah satu prestasi pada aksi massal; Note dan Revision opsional per item.
+type BulkActionItem struct {
+	ID       string `json:"id"`
+	Note     string `json:"note"`
+	Revision *int   `json:"revision"` // hanya untuk verify
+}
+
+// BulkActionRequest adalah body bulk verify / reject. Prestasi disebut lewat ids (memakai note bersama)
+// dan / atau items (note per item menimpa note bersama).
+type BulkActionRequest struct {
+	IDs   []string         `json:"ids"`
+	Items []BulkActionItem `json:"items"`
+	Note  string           `json:"note"`
+}
+
+// BulkActionResult adalah hasil satu item aksi massal, berisi respon yang sama dengan endpoint satuan.
+type BulkActionResult struct {
+	ID      string      `json:"id"`
+	Success bool        `json:"success"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+	Errors  interface{} `json:"errors,omitempty"`
+}
+
+// BulkActionSummary adalah respon aksi massal.
+type BulkActionSummary struct {
+	Total     int                `json:"total"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []BulkActionResult `json:"results"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AchievementTypeDefinition adalah tipe prestasi yang didefinisikan admin (competition, publication, ...).
+// DetailsSchema adalah JSON Schema untuk field details, RequiredAttachments kategori lampiran yang wajib
+// ada sebelum diajukan, dan AllowedTiers tingkat kompetisi yang boleh dipakai (kosong = bebas).
+type AchievementTypeDefinition struct {
+	Code                string          `json:"code"`
+	Name                string          `json:"name"`
+	Description         string          `json:"description"`
+	DetailsSchema       json.RawMessage `json:"details_schema" swaggertype:"object"`
+	RequiredAttachments []string        `json:"required_attachments"`
+	AllowedTiers        []string        `json:"allowed_tiers"`
+	IsActive            bool            `json:"is_active"`
+	CreatedAt           time.Time       `json:"created_at"`
+	UpdatedAt           time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Nilai locals `auth_type` yang diset middleware Protected
+const (
+	AuthTypeUser   = "user"
+	AuthTypeAPIKey = "api_key"
+)
+
+// RoleAPIKey adalah nilai locals `role` untuk request yang diautentikasi dengan API key.
+const RoleAPIKey = "API Key"
+
+// APIKey adalah kredensial integrasi mesin (dashboard fakultas, sinkronisasi SIAKAD).
+// Key utuh hanya ditampilkan sekali saat dibuat; yang disimpan hanya prefix dan hash SHA-256.
+type APIKey struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Permissions []string   `json:"permissions"`
+	AllowedIPs  []string   `json:"allowed_ips"` // IP atau CIDR, kosong = semua IP
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  *string    `json:"last_used_ip"`
+	CreatedBy   *uuid.UUID `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+type APIKeyCreatedResponse struct {
+	APIKey
+	Key string `json:"key"` // hanya dikembalikan sekali
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Nilai kolom `action` pada audit log impersonation
+const (
+	AuditImpersonationStart   = "impersonation_start"
+	AuditImpersonationRequest = "impersonation_request"
+	AuditImpersonationBlocked = "impersonation_blocked"
+)
+
+// AuditLog mencatat aksi yang dilakukan admin atas nama user lain (impersonation).
+type AuditLog struct {
+	ID             uuid.UUID `json:"id"`
+	Action         string    `json:"action"`
+	ImpersonatorID uuid.UUID `json:"impersonator_id"`
+	UserID         uuid.UUID `json:"user_id"` // user yang di-impersonate
+	TokenID        string    `json:"token_id"`
+	Method         string    `json:"method,omitempty"`
+	Path           string    `json:"path,omitempty"`
+	StatusCode     int       `json:"status_code,omitempty"`
+	Reason         string    `json:"reason,omitempty"`
+	IPAddress      string    `json:"ip_address"`
+	UserAgent      string    `json:"user_agent"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ImpersonationResponse berisi access token sementara untuk bertindak sebagai user lain.
+// Tidak ada refresh token: setelah kadaluarsa admin harus memulai impersonation baru.
+type ImpersonationResponse struct {
+	Token          string       `json:"token"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	ImpersonatorID string       `json:"impersonator_id"`
+	User           UserResponse `json:"user"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Visibilitas komentar prestasi.
+const (
+	CommentPublic   = "public"   // terlihat mahasiswa pemilik dan reviewer
+	CommentInternal = "internal" // catatan antar reviewer, tidak terlihat mahasiswa
+)
+
+// Jenis anchor komentar: bagian konten prestasi yang dikomentari.
+const (
+	AnchorField      = "field"      // anchor_ref = path field, misal title atau details.rank
+	AnchorAttachment = "attachment" // anchor_ref = fileUrl lampiran
+)
+
+// AchievementComment adalah satu komentar review. Komentar tanpa ParentID membuka thread baru;
+// balasan selalu menempel ke komentar pembuka thread dan mengikuti visibilitasnya.
+type AchievementComment struct {
+	ID            uuid.UUID  `json:"id"`
+	AchievementID uuid.UUID  `json:"achievement_id"`
+	ParentID      *uuid.UUID `json:"parent_id,omitempty"`
+	AuthorID      *uuid.UUID `json:"author_id"`
+	AuthorName    string     `json:"author_name,omitempty"`
+	AuthorRole    string     `json:"author_role"`
+	Body          string     `json:"body"`
+	Visibility    string     `json:"visibility"`
+	AnchorType    string     `json:"anchor_type,omitempty"`
+	AnchorRef     string     `json:"anchor_ref,omitempty"`
+	Revision      *int       `json:"revision,omitempty"` // revisi konten saat komentar ditulis
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy    *uuid.UUID `json:"resolved_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+
+	Replies []AchievementComment `json:"replies,omitempty"`
+}
+
+// CommentRequest adalah body pembuatan komentar / balasan.
+type CommentRequest struct {
+	Body       string `json:"body"`
+	ParentID   string `json:"parent_id"`   // isi untuk membalas thread
+	Visibility string `json:"visibility"`  // public (default) atau internal; diabaikan untuk balasan
+	AnchorType string `json:"anchor_type"` // field / attachment (hanya komentar pembuka thread)
+	AnchorRef  string `json:"anchor_ref"`
+}
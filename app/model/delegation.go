@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status delegasi dosen wali, dihitung dari rentang tanggal dan pencabutan
+const (
+	DelegationScheduled = "scheduled"
+	DelegationActive    = "active"
+	DelegationExpired   = "expired"
+	DelegationRevoked   = "revoked"
+)
+
+// AdvisorDelegation adalah delegasi verifikasi dari dosen wali (from) ke dosen pengganti (to) selama
+// rentang tanggal, misalnya saat cuti / sabbatical. StudentIDs kosong berarti semua mahasiswa bimbingan.
+type AdvisorDelegation struct {
+	ID               uuid.UUID   `json:"id"`
+	FromLecturerID   uuid.UUID   `json:"from_lecturer_id"`
+	FromLecturerName string      `json:"from_lecturer_name"`
+	ToLecturerID     uuid.UUID   `json:"to_lecturer_id"`
+	ToLecturerName   string      `json:"to_lecturer_name"`
+	StudentIDs       []uuid.UUID `json:"student_ids"`
+	StartDate        time.Time   `json:"start_date"`
+	EndDate          time.Time   `json:"end_date"` // inklusif
+	Reason           string      `json:"reason,omitempty"`
+	Status           string      `json:"status"`
+	CreatedBy        *uuid.UUID  `json:"created_by"`
+	CreatedAt        time.Time   `json:"created_at"`
+	RevokedAt        *time.Time  `json:"revoked_at,omitempty"`
+	RevokedBy        *uuid.UUID  `json:"revoked_by,omitempty"`
+}
+
+// DelegationRequest adalah body pembuatan delegasi. from_lecturer_id kosong = dosen yang login.
+type DelegationRequest struct {
+	FromLecturerID string   `json:"from_lecturer_id"`
+	ToLecturerID   string   `json:"to_lecturer_id"`
+	StudentIDs     []string `json:"student_ids"` // kosong = semua mahasiswa bimbingan
+	StartDate      string   `json:"start_date"`  // YYYY-MM-DD
+	EndDate        string   `json:"end_date"`    // YYYY-MM-DD, inklusif
+	Reason         string   `json:"reason"`
+}
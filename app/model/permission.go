@@ -11,4 +11,6 @@ type Permission struct {
 	Resource    string    `json:"resource"`
 	Action      string    `json:"action"`
 	Description string    `json:"description"`
+	// true jika permission dipakai oleh CheckPermission di route (tidak boleh dihapus)
+	InUse       bool      `json:"in_use"`
 }
\ No newline at end of file
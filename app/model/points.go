@@ -0,0 +1,92 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Jenis aturan poin. Tepat satu aturan base (prioritas tertinggi yang cocok) menentukan poin dasar,
+// semua aturan bonus yang cocok ditambahkan, lalu semua multiplier yang cocok dikalikan.
+const (
+	PointsRuleBase       = "base"
+	PointsRuleBonus      = "bonus"
+	PointsRuleMultiplier = "multiplier"
+)
+
+// Alasan perhitungan poin.
+const (
+	PointsReasonVerify      = "verify"
+	PointsReasonRecalculate = "recalculate"
+)
+
+// PointsCondition membandingkan satu field details prestasi (boleh path bertitik, misal "team.size").
+// Op: eq, ne, in, gt, gte, lt, lte, exists. Field berisi array dibandingkan secara numerik lewat panjangnya.
+type PointsCondition struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// PointsRule adalah satu aturan poin. AchievementType / CompetitionTier kosong berarti semua;
+// ValidFrom / ValidUntil membatasi tanggal kegiatan prestasi (inklusif).
+type PointsRule struct {
+	ID              uuid.UUID         `json:"id"`
+	Name            string            `json:"name"`
+	Description     string            `json:"description"`
+	Kind            string            `json:"kind"`
+	AchievementType string            `json:"achievement_type"`
+	CompetitionTier string            `json:"competition_tier"`
+	Conditions      []PointsCondition `json:"conditions"`
+	ValidFrom       *time.Time        `json:"valid_from,omitempty"`
+	ValidUntil      *time.Time        `json:"valid_until,omitempty"`
+	Value           float64           `json:"value"` // poin untuk base / bonus, faktor untuk multiplier
+	Priority        int               `json:"priority"`
+	IsActive        bool              `json:"is_active"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// PointsBreakdownItem adalah kontribusi satu aturan terhadap total poin.
+type PointsBreakdownItem struct {
+	RuleID   uuid.UUID `json:"rule_id"`
+	RuleName string    `json:"rule_name"`
+	Kind     string    `json:"kind"`
+	Value    float64   `json:"value"`
+	Points   float64   `json:"points"` // perubahan poin akibat aturan ini
+}
+
+// PointsBreakdown adalah hasil perhitungan poin satu prestasi.
+type PointsBreakdown struct {
+	Total int                   `json:"total"`
+	Items []PointsBreakdownItem `json:"items"`
+}
+
+// AchievementPointsAward adalah satu catatan pemberian poin (saat verifikasi atau hitung ulang).
+type AchievementPointsAward struct {
+	ID            uuid.UUID       `json:"id"`
+	AchievementID uuid.UUID       `json:"achievement_id"`
+	Total         int             `json:"total"`
+	PreviousTotal *int            `json:"previous_total,omitempty"`
+	Breakdown     PointsBreakdown `json:"breakdown"`
+	Reason        string          `json:"reason"`
+	ActorID       *uuid.UUID      `json:"actor_id,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// PointsRecalculation merangkum hasil hitung ulang poin prestasi terverifikasi.
+type PointsRecalculation struct {
+	DryRun    bool                     `json:"dry_run"`
+	Processed int                      `json:"processed"`
+	Changed   int                      `json:"changed"`
+	Failed    int                      `json:"failed"`
+	Changes   []PointsRecalculationRow `json:"changes"`
+}
+
+// PointsRecalculationRow adalah satu prestasi yang poinnya berubah.
+type PointsRecalculationRow struct {
+	AchievementID uuid.UUID `json:"achievement_id"`
+	Previous      int       `json:"previous"`
+	Total         int       `json:"total"`
+	Error         string    `json:"error,omitempty"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Alasan terbentuknya satu revisi konten prestasi.
+const (
+	RevisionReasonBaseline   = "baseline"   // isi dokumen lama sebelum riwayat revisi dicatat (revisi 0)
+	RevisionReasonCreate     = "create"     // prestasi dibuat
+	RevisionReasonUpdate     = "update"     // konten prestasi diedit
+	RevisionReasonAttachment = "attachment" // lampiran ditambahkan
+)
+
+// Jenis perubahan satu field pada diff revisi.
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "changed"
+)
+
+// AchievementSnapshot adalah salinan lengkap konten prestasi pada satu revisi.
+type AchievementSnapshot struct {
+	AchievementType string                 `bson:"achievementType" json:"achievementType"`
+	Title           string                 `bson:"title" json:"title"`
+	Description     string                 `bson:"description" json:"description"`
+	CompetitionTier string                 `bson:"competitionTier" json:"competitionTier"`
+	Details         map[string]interface{} `bson:"details" json:"details"`
+	Tags            []string               `bson:"tags" json:"tags"`
+	Attachments     []Attachment           `bson:"attachments" json:"attachments"`
+}
+
+// AchievementRevision adalah satu revisi immutable di koleksi MongoDB achievement_revisions.
+type AchievementRevision struct {
+	ID            primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	AchievementID primitive.ObjectID   `bson:"achievementId" json:"achievementId"` // _id dokumen achievements
+	Revision      int                  `bson:"revision" json:"revision"`
+	Reason        string               `bson:"reason" json:"reason"`
+	CreatedBy     string               `bson:"createdBy,omitempty" json:"createdBy,omitempty"` // user id, kosong untuk API key
+	CreatedAt     time.Time            `bson:"createdAt" json:"createdAt"`
+	Snapshot      *AchievementSnapshot `bson:"snapshot,omitempty" json:"snapshot,omitempty"`
+}
+
+// RevisionChange adalah perubahan satu field antara dua revisi. Field memakai path bertitik untuk
+// details (misal details.rank); tags dan attachments dilaporkan per item.
+type RevisionChange struct {
+	Field  string      `json:"field"`
+	Change string      `json:"change"`
+	Old    interface{} `json:"old,omitempty"`
+	New    interface{} `json:"new,omitempty"`
+}
+
+// RevisionDiff adalah hasil perbandingan revisi dengan revisi pembandingnya (default revisi sebelumnya).
+type RevisionDiff struct {
+	Revision int              `json:"revision"`
+	Against  *int             `json:"against"` // null = dibandingkan dengan konten kosong (revisi pertama)
+	Changes  []RevisionChange `json:"changes"`
+}
@@ -6,9 +6,34 @@ import (
 	"github.com/google/uuid"
 )
 
+// Nama role bawaan. Hak akses ditentukan oleh permission dan package policy;
+// nama role hanya dipakai untuk menandai admin sistem dan provisioning akun.
+const (
+	RoleAdmin     = "Admin"
+	RoleMahasiswa = "Mahasiswa"
+	RoleDosenWali = "Dosen Wali"
+)
+
 type Role struct {
 	ID          uuid.UUID    `json:"id"`
 	Name        string       `json:"name"`
 	Description string       `json:"description"`
+	Require2FA  bool         `json:"require_2fa"`
 	CreatedAt   time.Time    `json:"created_at"`
-}
\ No newline at end of file
+
+	// Naik setiap kali permission role berubah; dibandingkan dengan claim `pv` di access token
+	PermissionVersion int      `json:"permission_version,omitempty"`
+	Permissions       []string `json:"permissions,omitempty"`
+}
+// RoleAssignment memberi user permission sebuah role di dalam satu unit akademik.
+// ScopeType kosong berarti berlaku untuk semua unit (tetap terbatas pada data akademik).
+type RoleAssignment struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	RoleID     uuid.UUID  `json:"role_id"`
+	RoleName   string     `json:"role_name"`
+	ScopeType  string     `json:"scope_type"`  // faculty / department / program / kosong
+	ScopeValue string     `json:"scope_value"` // nama fakultas / departemen / program studi
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Nilai klaim `token_type` pada JWT
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+
+	// Token sementara antara langkah password dan langkah 2FA saat login
+	TokenTypeTwoFactorChallenge = "2fa_challenge"
+	TokenTypeTwoFactorEnroll    = "2fa_enroll"
+)
+
+// Session merepresentasikan satu login (device) beserta family refresh token-nya.
+type Session struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	UserAgent     string     `json:"user_agent"`
+	IPAddress     string     `json:"ip_address"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason *string    `json:"revoked_reason,omitempty"`
+	Current       bool       `json:"current"` // true jika sesi ini milik token yang sedang dipakai
+}
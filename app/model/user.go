@@ -18,6 +18,13 @@ type User struct {
 	IsActive     bool      `json:"is_active"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+
+	LastLoginAt  *time.Time `json:"last_login_at"`
+	LastLoginIP  *string    `json:"last_login_ip"`
+	LockedUntil  *time.Time `json:"locked_until"`
+
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+	AuthSource       string `json:"auth_source"` // local | ldap
 }
 type LoginResponse struct {
     Token        string      `json:"token"`
@@ -26,6 +33,26 @@ type LoginResponse struct {
 }
 
 
+// TwoFactorChallengeResponse dikembalikan saat login jika user masih harus verifikasi 2FA
+// (atau wajib mendaftarkan 2FA terlebih dahulu sesuai kebijakan role).
+type TwoFactorChallengeResponse struct {
+    TwoFactorRequired bool   `json:"twoFactorRequired"`
+    SetupRequired     bool   `json:"setupRequired"`
+    ChallengeToken    string `json:"challengeToken"`
+    ExpiresIn         int    `json:"expiresIn"` // detik
+}
+
+type TwoFactorSetupResponse struct {
+    Secret     string `json:"secret"`
+    OTPAuthURL string `json:"otpauthUrl"`
+    QRCodePNG  string `json:"qrCodePng"` // data URI base64 (image/png)
+}
+
+type TwoFactorEnableResponse struct {
+    RecoveryCodes []string       `json:"recoveryCodes"`
+    Login         *LoginResponse `json:"login,omitempty"` // terisi jika aktivasi dilakukan saat login
+}
+
 type UserResponse struct {
     ID          string   `json:"id"`
     Username    string   `json:"username"`
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Jenis penyetuju satu tahap verifikasi.
+const (
+	ApproverAdvisor = "advisor" // dosen wali mahasiswa pemilik
+	ApproverRole    = "role"    // pemegang role assignment tertentu (misal Kemahasiswaan Fakultas)
+)
+
+// VerificationPipeline adalah urutan tahap verifikasi untuk prestasi dengan tingkat kompetisi /
+// tipe tertentu. Daftar kosong berarti berlaku untuk semua nilai.
+type VerificationPipeline struct {
+	ID               uuid.UUID           `json:"id"`
+	Name             string              `json:"name"`
+	Description      string              `json:"description"`
+	CompetitionTiers []string            `json:"competition_tiers"`
+	AchievementTypes []string            `json:"achievement_types"`
+	IsActive         bool                `json:"is_active"`
+	Stages           []VerificationStage `json:"stages"`
+	CreatedAt        time.Time           `json:"created_at"`
+}
+
+// VerificationStage adalah satu tahap pipeline. Tahap selesai setelah RequiredApprovals penyetuju
+// berbeda menyetujuinya (quorum).
+type VerificationStage struct {
+	ID                uuid.UUID  `json:"id"`
+	Position          int        `json:"position"`
+	Name              string     `json:"name"`
+	ApproverType      string     `json:"approver_type"`
+	ApproverRoleID    *uuid.UUID `json:"approver_role_id,omitempty"`
+	ApproverRole      string     `json:"approver_role,omitempty"`
+	ScopeLevel        string     `json:"scope_level"` // tingkat unit role assignment penyetuju (kosong = bebas)
+	RequiredApprovals int        `json:"required_approvals"`
+}
+
+// Stage mengembalikan tahap pada posisi tertentu (mulai dari 1).
+func (p VerificationPipeline) Stage(position int) (VerificationStage, bool) {
+	for _, s := range p.Stages {
+		if s.Position == position {
+			return s, true
+		}
+	}
+	return VerificationStage{}, false
+}
+
+// AchievementStageApproval adalah satu persetujuan tahap verifikasi pada pengajuan prestasi yang sedang berjalan.
+type AchievementStageApproval struct {
+	ID             uuid.UUID  `json:"id"`
+	AchievementID  uuid.UUID  `json:"achievement_id"`
+	StagePosition  int        `json:"stage_position"`
+	StageName      string     `json:"stage_name"`
+	ApproverID     *uuid.UUID `json:"approver_id,omitempty"` // kosong jika disetujui lewat API key
+	APIKeyID       *uuid.UUID `json:"api_key_id,omitempty"`  // API key penyetuju
+	ApproverName   string     `json:"approver_name,omitempty"`
+	ApproverRole   string     `json:"approver_role"`
+	Note           string     `json:"note,omitempty"`
+	OnBehalfOf     *uuid.UUID `json:"on_behalf_of,omitempty"` // dosen wali yang diwakili lewat delegasi
+	OnBehalfOfName string     `json:"on_behalf_of_name,omitempty"`
+	DelegationID   *uuid.UUID `json:"delegation_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// VerificationProgress merangkum posisi prestasi di pipeline verifikasinya.
+type VerificationProgress struct {
+	Status       AchievementStatus          `json:"status"`
+	Pipeline     VerificationPipeline       `json:"pipeline"`
+	CurrentStage int                        `json:"current_stage"`
+	Approvals    []AchievementStageApproval `json:"approvals"`
+}
+
+// Badge umur item antrean verifikasi, relatif terhadap SLA.
+const (
+	QueueBadgeFresh   = "fresh"    // belum separuh SLA
+	QueueBadgeDueSoon = "due_soon" // sudah lewat separuh SLA
+	QueueBadgeOverdue = "overdue"  // melewati SLA
+)
+
+// VerificationQueueItem adalah satu prestasi yang menunggu persetujuan reviewer pada tahap berjalan.
+type VerificationQueueItem struct {
+	AchievementID   uuid.UUID         `json:"achievement_id"`
+	StudentID       uuid.UUID         `json:"student_id"`
+	StudentName     string            `json:"student_name"`
+	StudentNIM      string            `json:"student_nim"`
+	Title           string            `json:"title"`
+	AchievementType string            `json:"achievement_type"`
+	CompetitionTier string            `json:"competition_tier"`
+	Status          AchievementStatus `json:"status"`
+	Stage           int               `json:"stage"`
+	StageName       string            `json:"stage_name"`
+	WaitingSince    time.Time         `json:"waiting_since"` // awal tahap berjalan (diajukan / tahap sebelumnya selesai)
+	WaitingHours    int               `json:"waiting_hours"`
+	SLADueAt        time.Time         `json:"sla_due_at"`
+	Badge           string            `json:"badge"`
+	EscalatedAt     *time.Time        `json:"escalated_at,omitempty"` // sudah dieskalasi ke admin departemen
+}
@@ -0,0 +1,304 @@
+// Package points menghitung poin prestasi dari aturan yang dikonfigurasi admin. Package ini murni
+// (tanpa database); aturan dimuat repository dan hasilnya disimpan service.
+package points
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sistempelaporan/app/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Operator kondisi yang didukung.
+const (
+	OpEq     = "eq"
+	OpNe     = "ne"
+	OpIn     = "in"
+	OpGt     = "gt"
+	OpGte    = "gte"
+	OpLt     = "lt"
+	OpLte    = "lte"
+	OpExists = "exists"
+)
+
+var validOps = map[string]bool{OpEq: true, OpNe: true, OpIn: true, OpGt: true, OpGte: true, OpLt: true, OpLte: true, OpExists: true}
+
+// Calculate menghitung poin prestasi: aturan base prioritas tertinggi yang cocok menjadi poin dasar,
+// bonus yang cocok ditambahkan, lalu multiplier yang cocok dikalikan. Total dibulatkan dan minimal 0.
+func Calculate(rules []model.PointsRule, a model.AchievementMongo) model.PointsBreakdown {
+	sorted := make([]model.PointsRule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority > sorted[j].Priority
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	breakdown := model.PointsBreakdown{Items: []model.PointsBreakdownItem{}}
+	var subtotal float64
+	var multipliers []model.PointsRule
+
+	baseFound := false
+	for _, r := range sorted {
+		if !Matches(r, a) {
+			continue
+		}
+		item := model.PointsBreakdownItem{RuleID: r.ID, RuleName: r.Name, Kind: r.Kind, Value: r.Value}
+		switch r.Kind {
+		case model.PointsRuleBase:
+			if baseFound {
+				continue
+			}
+			baseFound = true
+		case model.PointsRuleBonus:
+		case model.PointsRuleMultiplier:
+			multipliers = append(multipliers, r)
+			continue
+		default:
+			continue
+		}
+		item.Points = r.Value
+		subtotal += r.Value
+		breakdown.Items = append(breakdown.Items, item)
+	}
+
+	for _, r := range multipliers {
+		next := subtotal * r.Value
+		breakdown.Items = append(breakdown.Items, model.PointsBreakdownItem{
+			RuleID: r.ID, RuleName: r.Name, Kind: r.Kind, Value: r.Value, Points: next - subtotal,
+		})
+		subtotal = next
+	}
+
+	breakdown.Total = int(math.Round(math.Max(subtotal, 0)))
+	return breakdown
+}
+
+// Matches memeriksa apakah aturan aktif berlaku untuk prestasi.
+func Matches(r model.PointsRule, a model.AchievementMongo) bool {
+	if !r.IsActive {
+		return false
+	}
+	if r.AchievementType != "" && !strings.EqualFold(r.AchievementType, a.AchievementType) {
+		return false
+	}
+	if r.CompetitionTier != "" && !strings.EqualFold(r.CompetitionTier, a.CompetitionTier) {
+		return false
+	}
+
+	date := EventDate(a)
+	if r.ValidFrom != nil && date.Before(truncateDay(*r.ValidFrom)) {
+		return false
+	}
+	if r.ValidUntil != nil && !date.Before(truncateDay(*r.ValidUntil).AddDate(0, 0, 1)) {
+		return false
+	}
+
+	for _, cond := range r.Conditions {
+		if !conditionHolds(cond, a.Details) {
+			return false
+		}
+	}
+	return true
+}
+
+// EventDate adalah tanggal kegiatan prestasi dari details.eventDate (atau details.date),
+// jatuh ke tanggal pembuatan prestasi jika tidak ada atau formatnya tidak dikenal.
+func EventDate(a model.AchievementMongo) time.Time {
+	for _, key := range []string{"eventDate", "date"} {
+		switch v := a.Details[key].(type) {
+		case time.Time:
+			return v
+		case primitive.DateTime:
+			return v.Time()
+		case string:
+			for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+				if t, err := time.Parse(layout, v); err == nil {
+					return t
+				}
+			}
+		}
+	}
+	return a.CreatedAt
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// lookup mengambil nilai details pada path bertitik. Dokumen bersarang dari MongoDB bisa berupa
+// map biasa, primitive.M atau primitive.D.
+func lookup(details map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = details
+	for _, key := range strings.Split(path, ".") {
+		switch doc := current.(type) {
+		case map[string]interface{}:
+			v, ok := doc[key]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		case primitive.M:
+			v, ok := doc[key]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		case primitive.D:
+			found := false
+			for _, e := range doc {
+				if e.Key == key {
+					current, found = e.Value, true
+					break
+				}
+			}
+			if !found {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+func conditionHolds(cond model.PointsCondition, details map[string]interface{}) bool {
+	actual, ok := lookup(details, cond.Field)
+	if cond.Op == OpExists {
+		want, isBool := cond.Value.(bool)
+		if !isBool {
+			want = true
+		}
+		return ok == want
+	}
+	if !ok {
+		return false
+	}
+
+	switch cond.Op {
+	case OpEq:
+		return equal(actual, cond.Value)
+	case OpNe:
+		return !equal(actual, cond.Value)
+	case OpIn:
+		options, isList := cond.Value.([]interface{})
+		if !isList {
+			return false
+		}
+		for _, option := range options {
+			if equal(actual, option) {
+				return true
+			}
+		}
+		return false
+	case OpGt, OpGte, OpLt, OpLte:
+		x, okX := number(actual)
+		y, okY := number(cond.Value)
+		if !okX || !okY {
+			return false
+		}
+		switch cond.Op {
+		case OpGt:
+			return x > y
+		case OpGte:
+			return x >= y
+		case OpLt:
+			return x < y
+		}
+		return x <= y
+	}
+	return false
+}
+
+// equal membandingkan secara numerik jika kedua nilai angka, selain itu sebagai teks (case-insensitive).
+func equal(actual, expected interface{}) bool {
+	if x, ok := number(actual); ok {
+		if y, ok := number(expected); ok {
+			return x == y
+		}
+	}
+	return strings.EqualFold(fmt.Sprint(actual), fmt.Sprint(expected))
+}
+
+// number mengubah nilai ke float64. Array dihitung panjangnya (misal daftar anggota tim).
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	case []interface{}:
+		return float64(len(n)), true
+	case primitive.A:
+		return float64(len(n)), true
+	}
+	return 0, false
+}
+
+// ValidateRule memeriksa konfigurasi aturan dan mengembalikan pesan error per field.
+func ValidateRule(r model.PointsRule) map[string]string {
+	errs := map[string]string{}
+	if strings.TrimSpace(r.Name) == "" {
+		errs["name"] = "Nama aturan wajib diisi"
+	}
+
+	switch r.Kind {
+	case model.PointsRuleBase, model.PointsRuleBonus:
+	case model.PointsRuleMultiplier:
+		if r.Value < 0 {
+			errs["value"] = "Faktor multiplier tidak boleh negatif"
+		}
+	default:
+		errs["kind"] = "kind harus base, bonus atau multiplier"
+	}
+	if r.Kind == model.PointsRuleBase && r.Value < 0 {
+		errs["value"] = "Poin dasar tidak boleh negatif"
+	}
+
+	if r.ValidFrom != nil && r.ValidUntil != nil && r.ValidUntil.Before(*r.ValidFrom) {
+		errs["valid_until"] = "valid_until tidak boleh sebelum valid_from"
+	}
+
+	for i, cond := range r.Conditions {
+		field := fmt.Sprintf("conditions[%d]", i)
+		if strings.TrimSpace(cond.Field) == "" {
+			errs[field+".field"] = "Field kondisi wajib diisi"
+		}
+		if !validOps[cond.Op] {
+			errs[field+".op"] = "op harus eq, ne, in, gt, gte, lt, lte atau exists"
+			continue
+		}
+		switch cond.Op {
+		case OpIn:
+			if _, ok := cond.Value.([]interface{}); !ok {
+				errs[field+".value"] = "Nilai untuk op in harus berupa array"
+			}
+		case OpGt, OpGte, OpLt, OpLte:
+			if _, ok := number(cond.Value); !ok {
+				errs[field+".value"] = "Nilai untuk op perbandingan harus berupa angka"
+			}
+		case OpEq, OpNe:
+			if cond.Value == nil {
+				errs[field+".value"] = "Nilai kondisi wajib diisi"
+			}
+		}
+	}
+	return errs
+}
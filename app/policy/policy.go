@@ -0,0 +1,372 @@
+// Package policy menjawab pertanyaan "bolehkah subject S melakukan aksi A pada resource R"
+// berdasarkan permission subject dan relasinya dengan resource (pemilik, dosen wali, admin unit).
+// Package ini murni (tanpa akses database); data subject dan resource dimuat oleh pemanggil.
+package policy
+
+type Action string
+
+const (
+	ActionRead     Action = "read"
+	ActionCreate   Action = "create"
+	ActionUpdate   Action = "update"
+	ActionDelete   Action = "delete"
+	ActionSubmit   Action = "submit"
+	ActionVerify   Action = "verify"
+	ActionReject   Action = "reject"
+	ActionUpload   Action = "upload"
+	ActionManage   Action = "manage"
+	ActionArchive  Action = "archive"
+	ActionDelegate Action = "delegate" // mengelola delegasi verifikasi dosen wali
+)
+
+type ResourceKind string
+
+const (
+	ResourceAchievement ResourceKind = "achievement"
+	ResourceStudent     ResourceKind = "student"
+	ResourceLecturer    ResourceKind = "lecturer"
+	ResourceUser        ResourceKind = "user"
+)
+
+// Relation adalah hubungan subject dengan resource yang dapat memberi akses.
+type Relation string
+
+const (
+	RelationOwner     Relation = "owner"      // mahasiswa pemilik data / dosen pemilik profil
+	RelationAdvisor   Relation = "advisor"    // dosen wali dari mahasiswa pemilik
+	RelationDelegate  Relation = "delegate"   // dosen pengganti lewat delegasi aktif dari dosen wali
+	RelationUnitAdmin Relation = "unit_admin" // operator fakultas / departemen / prodi lewat role assignment berscope
+	RelationSelf      Relation = "self"       // akun user itu sendiri
+)
+
+// ScopeLevel adalah tingkat unit akademik sebuah role assignment.
+type ScopeLevel string
+
+const (
+	ScopeGlobal     ScopeLevel = "" // berlaku untuk semua unit
+	ScopeFaculty    ScopeLevel = "faculty"
+	ScopeDepartment ScopeLevel = "department"
+	ScopeProgram    ScopeLevel = "program"
+)
+
+// ValidScopeLevel mengecek apakah level dikenal.
+func ValidScopeLevel(level ScopeLevel) bool {
+	switch level {
+	case ScopeGlobal, ScopeFaculty, ScopeDepartment, ScopeProgram:
+		return true
+	}
+	return false
+}
+
+// Unit adalah satu unit akademik: fakultas, departemen atau program studi.
+type Unit struct {
+	Level ScopeLevel
+	Value string
+}
+
+// Covers mengecek apakah resource berada di dalam unit. Program studi tidak mencakup dosen
+// karena dosen hanya terdaftar di departemen.
+func (u Unit) Covers(r Resource) bool {
+	switch u.Level {
+	case ScopeGlobal:
+		return true
+	case ScopeFaculty:
+		return r.Faculty != "" && r.Faculty == u.Value
+	case ScopeDepartment:
+		return r.Department != "" && r.Department == u.Value
+	case ScopeProgram:
+		return r.Program != "" && r.Program == u.Value
+	}
+	return false
+}
+
+// Grant adalah permission dari role assignment yang hanya berlaku di dalam satu unit.
+type Grant struct {
+	Unit
+	Role        string // nama role asal grant, dipakai untuk mencocokkan penyetuju tahap verifikasi
+	Permissions []string
+}
+
+// HasPermission mengecek apakah grant memuat permission tertentu (kosong = tanpa permission khusus).
+func (g Grant) HasPermission(permission string) bool {
+	if permission == "" {
+		return true
+	}
+	for _, p := range g.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Delegation adalah delegasi dosen wali yang sedang aktif: subject bertindak atas nama dosen
+// FromLecturerID untuk mahasiswa bimbingannya (StudentIDs kosong = semua mahasiswa bimbingan).
+type Delegation struct {
+	ID             string
+	FromLecturerID string
+	StudentIDs     []string
+}
+
+// Covers mengecek apakah resource milik mahasiswa bimbingan dosen asal yang termasuk delegasi.
+func (d Delegation) Covers(r Resource) bool {
+	if r.AdvisorID == "" || r.AdvisorID != d.FromLecturerID {
+		return false
+	}
+	if len(d.StudentIDs) == 0 {
+		return true
+	}
+	for _, id := range d.StudentIDs {
+		if id == r.StudentID {
+			return true
+		}
+	}
+	return false
+}
+
+// Subject adalah pihak yang meminta akses.
+type Subject struct {
+	UserID      string
+	Permissions []string
+	Admin       bool         // admin sistem: lolos semua relasi, tetap butuh permission
+	Machine     bool         // API key: hanya dinilai dari permission eksplisit, tanpa relasi
+	StudentID   string       // profil mahasiswa milik subject (jika ada)
+	LecturerID  string       // profil dosen milik subject (jika ada)
+	Grants      []Grant      // role assignment berscope (operator fakultas / departemen / prodi)
+	Delegations []Delegation // delegasi dosen wali yang sedang aktif untuk subject
+}
+
+// HasPermission mengecek apakah subject memiliki permission tertentu.
+func (s Subject) HasPermission(permission string) bool {
+	for _, p := range s.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScopedPermission mengecek apakah subject memiliki permission secara global atau
+// lewat salah satu role assignment berscope.
+func (s Subject) HasScopedPermission(permission string) bool {
+	if s.HasPermission(permission) {
+		return true
+	}
+	for _, g := range s.Grants {
+		if g.HasPermission(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// DelegationFor mengembalikan delegasi aktif yang mencakup resource, jika ada.
+func (s Subject) DelegationFor(r Resource) (Delegation, bool) {
+	for _, d := range s.Delegations {
+		if d.Covers(r) {
+			return d, true
+		}
+	}
+	return Delegation{}, false
+}
+
+// unitGrant mengembalikan true jika ada grant yang mencakup resource dan memuat permission.
+func (s Subject) unitGrant(permission string, r Resource) bool {
+	for _, g := range s.Grants {
+		if g.HasPermission(permission) && g.Covers(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resource adalah data yang diakses beserta atribut relasinya.
+type Resource struct {
+	Kind       ResourceKind
+	UserID     string // akun pemilik (resource user)
+	StudentID  string // mahasiswa pemilik (achievement, student)
+	AdvisorID  string // dosen wali mahasiswa pemilik
+	LecturerID string // dosen (resource lecturer)
+	Program    string // program studi mahasiswa
+	Department string // departemen program studi / departemen dosen
+	Faculty    string // fakultas dari departemen
+}
+
+// Decision adalah hasil evaluasi policy.
+type Decision struct {
+	Allowed    bool
+	Relation   Relation    // relasi yang memberi akses (kosong untuk admin / API key)
+	Delegation *Delegation // delegasi yang memberi akses (relasi delegate)
+	Reason     string
+}
+
+// Permission eksplisit untuk API key pada resource yang bagi user cukup dibaca lewat relasi
+// (pemilik, dosen wali, admin unit) tanpa permission khusus.
+const (
+	StudentReadPermission  = "student:read"
+	LecturerReadPermission = "lecturer:read"
+)
+
+type rule struct {
+	permission string // kosong = tidak butuh permission khusus
+	relations  []Relation
+	machine    string // permission untuk API key jika permission kosong; kosong = API key selalu ditolak
+}
+
+var rules = map[ResourceKind]map[Action]rule{
+	ResourceAchievement: {
+		ActionRead:    {"achievement:read", []Relation{RelationOwner, RelationAdvisor, RelationDelegate, RelationUnitAdmin}, ""},
+		ActionCreate:  {"achievement:create", []Relation{RelationOwner, RelationUnitAdmin}, ""},
+		ActionUpdate:  {"achievement:update", []Relation{RelationOwner, RelationUnitAdmin}, ""},
+		ActionDelete:  {"achievement:delete", []Relation{RelationOwner, RelationUnitAdmin}, ""},
+		ActionSubmit:  {"achievement:submit", []Relation{RelationOwner, RelationUnitAdmin}, ""},
+		ActionUpload:  {"achievement:upload", []Relation{RelationOwner, RelationUnitAdmin}, ""},
+		ActionVerify:  {"achievement:verify", []Relation{RelationAdvisor, RelationDelegate, RelationUnitAdmin}, ""},
+		ActionReject:  {"achievement:reject", []Relation{RelationAdvisor, RelationDelegate, RelationUnitAdmin}, ""},
+		ActionArchive: {"achievement:archive", []Relation{RelationUnitAdmin}, ""},
+	},
+	ResourceStudent: {
+		ActionRead:   {"", []Relation{RelationOwner, RelationAdvisor, RelationDelegate, RelationUnitAdmin}, StudentReadPermission},
+		ActionManage: {"user:manage", []Relation{RelationUnitAdmin}, ""},
+	},
+	ResourceLecturer: {
+		ActionRead:     {"", []Relation{RelationOwner, RelationUnitAdmin}, LecturerReadPermission},
+		ActionDelegate: {"delegation:manage", []Relation{RelationOwner, RelationUnitAdmin}, ""},
+	},
+	// Akun user hanya untuk pemiliknya (dan admin); API key tidak pernah boleh membaca / mengubah akun
+	ResourceUser: {
+		ActionRead:   {"", []Relation{RelationSelf}, ""},
+		ActionUpdate: {"", []Relation{RelationSelf}, ""},
+	},
+}
+
+// machinePermission mengembalikan permission yang harus dimiliki API key untuk rule ini
+// (kosong = API key ditolak).
+func (rl rule) machinePermission() string {
+	if rl.permission != "" {
+		return rl.permission
+	}
+	return rl.machine
+}
+
+// canMachine menilai API key: tanpa relasi, hanya permission eksplisit yang memberi akses.
+func canMachine(s Subject, rl rule) Decision {
+	permission := rl.machinePermission()
+	if permission == "" {
+		return Decision{Reason: "API key tidak dapat mengakses resource ini"}
+	}
+	if !s.HasPermission(permission) {
+		return Decision{Reason: "tidak memiliki permission " + permission}
+	}
+	return Decision{Allowed: true}
+}
+
+// Can mengevaluasi apakah subject boleh melakukan action pada resource. Permission global
+// berlaku bersama relasi owner / advisor / self; permission dari role assignment berscope
+// hanya berlaku untuk resource di dalam unit assignment tersebut. API key dinilai terpisah
+// oleh canMachine.
+func Can(s Subject, action Action, r Resource) Decision {
+	rl, ok := rules[r.Kind][action]
+	if !ok {
+		return Decision{Reason: "aksi tidak dikenal untuk resource ini"}
+	}
+	if s.Machine {
+		return canMachine(s, rl)
+	}
+	global := rl.permission == "" || s.HasPermission(rl.permission)
+	if global && s.Admin {
+		return Decision{Allowed: true}
+	}
+
+	for _, rel := range rl.relations {
+		if rel == RelationUnitAdmin {
+			if s.unitGrant(rl.permission, r) {
+				return Decision{Allowed: true, Relation: rel}
+			}
+			continue
+		}
+		if global && rel == RelationDelegate {
+			if d, ok := s.DelegationFor(r); ok {
+				return Decision{Allowed: true, Relation: rel, Delegation: &d}
+			}
+			continue
+		}
+		if global && hasRelation(s, rel, r) {
+			return Decision{Allowed: true, Relation: rel}
+		}
+	}
+	if !global && !s.HasScopedPermission(rl.permission) {
+		return Decision{Reason: "tidak memiliki permission " + rl.permission}
+	}
+	return Decision{Reason: "tidak memiliki hubungan dengan resource ini"}
+}
+
+func hasRelation(s Subject, rel Relation, r Resource) bool {
+	switch rel {
+	case RelationOwner:
+		switch r.Kind {
+		case ResourceLecturer:
+			return s.LecturerID != "" && s.LecturerID == r.LecturerID
+		default:
+			return s.StudentID != "" && s.StudentID == r.StudentID
+		}
+	case RelationAdvisor:
+		return s.LecturerID != "" && s.LecturerID == r.AdvisorID
+	case RelationSelf:
+		return s.UserID != "" && s.UserID == r.UserID
+	}
+	return false
+}
+
+// Scope adalah cakupan data yang boleh dilihat subject pada daftar / laporan.
+// Kriteria yang terisi digabung dengan OR.
+type Scope struct {
+	All         bool
+	StudentID   string       // data milik mahasiswa ini
+	AdvisorID   string       // data mahasiswa bimbingan dosen ini
+	Units       []Unit       // data di dalam unit-unit ini (role assignment berscope)
+	Delegations []Delegation // data mahasiswa bimbingan dosen lain yang didelegasikan ke subject
+}
+
+// ListScope menentukan cakupan daftar resource kind untuk subject. permission adalah permission
+// yang dibutuhkan daftar tersebut; role assignment berscope hanya dihitung jika memuatnya.
+// ok = false berarti subject tidak punya relasi apa pun sehingga tidak boleh melihat data.
+// API key melihat semua data hanya jika memiliki permission eksplisit daftar tersebut.
+func ListScope(s Subject, kind ResourceKind, permission string) (scope Scope, ok bool) {
+	if s.Machine {
+		rl := rule{permission: permission}
+		if permission == "" {
+			rl = rules[kind][ActionRead]
+		}
+		if !canMachine(s, rl).Allowed {
+			return Scope{}, false
+		}
+		return Scope{All: true}, true
+	}
+	global := permission == "" || s.HasPermission(permission)
+	if global && s.Admin {
+		return Scope{All: true}, true
+	}
+
+	// Mahasiswa dan dosen wali hanya punya daftar prestasi; daftar mahasiswa / dosen khusus admin
+	if global && kind == ResourceAchievement {
+		scope.StudentID = s.StudentID
+		scope.AdvisorID = s.LecturerID
+		scope.Delegations = s.Delegations
+	}
+	for _, g := range s.Grants {
+		if !g.HasPermission(permission) {
+			continue
+		}
+		if g.Level == ScopeGlobal {
+			return Scope{All: true}, true
+		}
+		if kind == ResourceLecturer && g.Level == ScopeProgram {
+			continue
+		}
+		scope.Units = append(scope.Units, g.Unit)
+	}
+
+	ok = scope.StudentID != "" || scope.AdvisorID != "" || len(scope.Units) > 0 || len(scope.Delegations) > 0
+	return scope, ok
+}
@@ -0,0 +1,115 @@
+// Package queue memuat aturan antrean verifikasi: umur item terhadap SLA (badge) dan pengurutan.
+// Package ini murni (tanpa akses database).
+package queue
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"sistempelaporan/app/model"
+)
+
+// Kunci pengurutan antrean
+const (
+	SortWaiting = "waiting" // lama menunggu (default: paling lama dulu)
+	SortTier    = "tier"    // tingkat lomba (default: tertinggi dulu)
+	SortStudent = "student" // nama mahasiswa (default: A-Z)
+)
+
+// Peringkat tingkat lomba; tingkat yang tidak dikenal / kosong berperingkat 0
+var tierRank = map[string]int{
+	"internasional": 5,
+	"nasional":      4,
+	"provinsi":      3,
+	"regional":      2,
+	"lokal":         1,
+}
+
+// TierRank mengembalikan peringkat tingkat lomba (makin tinggi makin prioritas).
+func TierRank(tier string) int {
+	return tierRank[strings.ToLower(strings.TrimSpace(tier))]
+}
+
+// Badge menentukan badge umur item: overdue jika melewati SLA, due_soon jika sudah lewat separuh SLA.
+func Badge(waitingSince, now time.Time, sla time.Duration) string {
+	waited := now.Sub(waitingSince)
+	switch {
+	case waited >= sla:
+		return model.QueueBadgeOverdue
+	case waited*2 >= sla:
+		return model.QueueBadgeDueSoon
+	default:
+		return model.QueueBadgeFresh
+	}
+}
+
+// Apply mengisi lama menunggu, batas SLA dan badge item berdasarkan WaitingSince.
+func Apply(item *model.VerificationQueueItem, now time.Time, sla time.Duration) {
+	item.WaitingHours = int(now.Sub(item.WaitingSince).Hours())
+	item.SLADueAt = item.WaitingSince.Add(sla)
+	item.Badge = Badge(item.WaitingSince, now, sla)
+}
+
+// Sort mengurutkan antrean menurut kunci by. order "asc" / "desc" membalik arah default kunci;
+// kosong berarti arah default. Item yang setara diurutkan dari yang paling lama menunggu.
+func Sort(items []model.VerificationQueueItem, by, order string) error {
+	if by == "" {
+		by = SortWaiting
+	}
+
+	// compare bernilai positif jika a lebih besar menurut kunci, 0 jika setara
+	var compare func(a, b model.VerificationQueueItem) int
+	var defaultDesc bool
+	switch by {
+	case SortWaiting:
+		// Lebih lama menunggu = waiting_since lebih awal
+		compare = func(a, b model.VerificationQueueItem) int { return compareTime(b.WaitingSince, a.WaitingSince) }
+		defaultDesc = true
+	case SortTier:
+		compare = func(a, b model.VerificationQueueItem) int {
+			return TierRank(a.CompetitionTier) - TierRank(b.CompetitionTier)
+		}
+		defaultDesc = true
+	case SortStudent:
+		compare = func(a, b model.VerificationQueueItem) int {
+			return strings.Compare(strings.ToLower(a.StudentName), strings.ToLower(b.StudentName))
+		}
+	default:
+		return fmt.Errorf("kunci urutan tidak dikenal: %s (waiting, tier atau student)", by)
+	}
+
+	desc := defaultDesc
+	switch order {
+	case "":
+	case "asc":
+		desc = false
+	case "desc":
+		desc = true
+	default:
+		return fmt.Errorf("arah urutan tidak dikenal: %s (asc atau desc)", order)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if c := compare(items[i], items[j]); c != 0 {
+			if desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return items[i].WaitingSince.Before(items[j].WaitingSince)
+	})
+	return nil
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	default:
+		return 0
+	}
+}
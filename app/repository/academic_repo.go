@@ -2,19 +2,21 @@ package repository
 
 import (
     "sistempelaporan/app/model"
+    "sistempelaporan/app/policy"
     "sistempelaporan/database"
     "database/sql"
 )
 
 
-func GetAllStudents() ([]map[string]interface{}, error) {
+// GetAllStudents mengambil mahasiswa aktif di dalam scope (admin: semua, operator unit: unitnya).
+func GetAllStudents(scope policy.Scope) ([]map[string]interface{}, error) {
+    var args []interface{}
     query := `
         SELECT s.id, s.student_id, s.program_study, s.academic_year, u.full_name, u.email
         FROM students s
         JOIN users u ON s.user_id = u.id
-        WHERE u.is_active = true
-    `
-    rows, err := database.PostgresDB.Query(query)
+        WHERE u.is_active = true AND ` + studentScopeCondition(scope, &args)
+    rows, err := database.PostgresDB.Query(query, args...)
     if err != nil { return nil, err }
     defer rows.Close()
 
@@ -80,14 +82,15 @@ func GetAchievementsByStudentID(studentID string) ([]model.AchievementReference,
 }
 
 
-func GetAllLecturers() ([]map[string]interface{}, error) {
+// GetAllLecturers mengambil dosen aktif di dalam scope (operator prodi tidak mencakup dosen).
+func GetAllLecturers(scope policy.Scope) ([]map[string]interface{}, error) {
+    var args []interface{}
     query := `
         SELECT l.id, l.lecturer_id, l.department, u.full_name, u.email
         FROM lecturers l
         JOIN users u ON l.user_id = u.id
-        WHERE u.is_active = true
-    `
-    rows, err := database.PostgresDB.Query(query)
+        WHERE u.is_active = true AND ` + lecturerScopeCondition(scope, &args)
+    rows, err := database.PostgresDB.Query(query, args...)
     if err != nil { return nil, err }
     defer rows.Close()
 
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/database"
+
+	"github.com/google/uuid"
+)
+
+// insertStatusEvent mencatat satu transisi status di dalam transaksi pemanggil.
+func insertStatusEvent(ctx context.Context, tx *sql.Tx, event *model.AchievementStatusEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+
+	var fromStatus sql.NullString
+	if event.FromStatus != nil {
+		fromStatus = sql.NullString{String: string(*event.FromStatus), Valid: true}
+	}
+
+	query := `
+		INSERT INTO achievement_status_events
+			(id, achievement_id, from_status, to_status, actor_id, actor_role, note, revision, on_behalf_of, delegation_id,
+			 ip_address, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11, NOW())
+		RETURNING created_at
+	`
+	return tx.QueryRowContext(ctx, query,
+		event.ID, event.AchievementID, fromStatus, string(event.ToStatus),
+		event.ActorID, event.ActorRole, event.Note, event.Revision, event.OnBehalfOf, event.DelegationID, event.IPAddress,
+	).Scan(&event.CreatedAt)
+}
+
+// ListAchievementStatusEvents mengambil log transisi status prestasi (urut kronologis) beserta total event.
+// ID yang bukan UUID menghasilkan ErrResourceNotFound.
+func ListAchievementStatusEvents(achievementID string, limit, offset int) ([]model.AchievementStatusEvent, int64, error) {
+	if _, err := uuid.Parse(achievementID); err != nil {
+		return nil, 0, ErrResourceNotFound
+	}
+
+	var total int64
+	err := database.PostgresDB.QueryRow(
+		`SELECT COUNT(*) FROM achievement_status_events WHERE achievement_id = $1`, achievementID,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT e.id, e.achievement_id, e.from_status, e.to_status, e.actor_id, COALESCE(u.full_name, ''),
+		       e.actor_role, COALESCE(e.note, ''), e.revision, e.on_behalf_of, COALESCE(obu.full_name, ''), e.delegation_id,
+		       e.ip_address, e.created_at
+		FROM achievement_status_events e
+		LEFT JOIN users u ON u.id = e.actor_id
+		LEFT JOIN lecturers ob ON ob.id = e.on_behalf_of
+		LEFT JOIN users obu ON obu.id = ob.user_id
+		WHERE e.achievement_id = $1
+		ORDER BY e.created_at, e.id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := database.PostgresDB.Query(query, achievementID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []model.AchievementStatusEvent{}
+	for rows.Next() {
+		var e model.AchievementStatusEvent
+		var fromStatus sql.NullString
+		err := rows.Scan(&e.ID, &e.AchievementID, &fromStatus, &e.ToStatus, &e.ActorID, &e.ActorName,
+			&e.ActorRole, &e.Note, &e.Revision, &e.OnBehalfOf, &e.OnBehalfOfName, &e.DelegationID, &e.IPAddress, &e.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		if fromStatus.Valid {
+			from := model.AchievementStatus(fromStatus.String)
+			e.FromStatus = &from
+		}
+		events = append(events, e)
+	}
+	return events, total, rows.Err()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
 	"sistempelaporan/database"
 	"time"
 	"errors"
@@ -15,7 +16,8 @@ import (
 )
 
 
-func CreateAchievement(ref *model.AchievementReference, detail *model.AchievementMongo) error {
+// CreateAchievement menyimpan detail ke MongoDB lalu referensi + event pembuatan (created) ke PostgreSQL.
+func CreateAchievement(ref *model.AchievementReference, detail *model.AchievementMongo, created *model.AchievementStatusEvent) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -27,6 +29,7 @@ func CreateAchievement(ref *model.AchievementReference, detail *model.Achievemen
 	
 	detail.CreatedAt = now
 	detail.UpdatedAt = now
+	detail.Revision = 1
 
 	
 	result, err := collection.InsertOne(ctx, detail)
@@ -36,6 +39,14 @@ func CreateAchievement(ref *model.AchievementReference, detail *model.Achievemen
 
 	mongoID := result.InsertedID.(primitive.ObjectID).Hex()
 
+	// Isi awal prestasi menjadi revisi 1
+	detail.ID = result.InsertedID.(primitive.ObjectID)
+	if _, err := insertRevision(ctx, detail, model.RevisionReasonCreate, created.ActorID); err != nil {
+		collection.DeleteOne(ctx, bson.M{"_id": result.InsertedID})
+		return fmt.Errorf("gagal menyimpan revisi prestasi: %w", err)
+	}
+	created.Revision = &detail.Revision
+
 	query := `
 		INSERT INTO achievement_references (
 			id, student_id, mongo_achievement_id, status, created_at, updated_at
@@ -43,9 +54,7 @@ func CreateAchievement(ref *model.AchievementReference, detail *model.Achievemen
 	`
 	
 	
-	_, err = database.PostgresDB.Exec(query, 
-		ref.ID, ref.StudentID, mongoID, model.StatusDraft, now, now,
-	)
+	err = insertAchievementReference(ctx, query, ref, mongoID, now, created)
 
 	if err != nil {
 	
@@ -53,6 +62,7 @@ func CreateAchievement(ref *model.AchievementReference, detail *model.Achievemen
 		
 		
 		_, delErr := collection.DeleteOne(ctx, bson.M{"_id": result.InsertedID})
+		revisionCollection().DeleteMany(ctx, bson.M{"achievementId": result.InsertedID})
 		
 		if delErr != nil {
 			
@@ -69,11 +79,30 @@ func CreateAchievement(ref *model.AchievementReference, detail *model.Achievemen
 	return nil
 }
 
+func insertAchievementReference(ctx context.Context, query string, ref *model.AchievementReference, mongoID string, now time.Time, created *model.AchievementStatusEvent) error {
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query, ref.ID, ref.StudentID, mongoID, model.StatusDraft, now, now); err != nil {
+		return err
+	}
+
+	created.AchievementID = ref.ID
+	created.FromStatus = nil
+	created.ToStatus = model.StatusDraft
+	if err := insertStatusEvent(ctx, tx, created); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 
 type RepoFilter struct {
 	model.AchievementFilter
-	StudentID string 
-	AdvisorID string 
+	Scope policy.Scope // cakupan data subject (lihat policy.ListScope)
 }
 
 
@@ -83,25 +112,20 @@ func GetAllAchievements(filter RepoFilter) ([]model.AchievementReference, int64,
     var err error
 
     
+    var args []interface{}
     baseQuery := `
         FROM achievement_references ar
         JOIN students s ON ar.student_id = s.id
-        WHERE ar.deleted_at IS NULL
-    `
+        WHERE ar.deleted_at IS NULL AND ` + studentScopeCondition(filter.Scope, &args)
     
     if filter.Status != "" {
-        baseQuery += fmt.Sprintf(" AND ar.status = '%s'", filter.Status)
-    }
-    if filter.StudentID != "" {
-        baseQuery += fmt.Sprintf(" AND ar.student_id = '%s'", filter.StudentID)
-    }
-    if filter.AdvisorID != "" {
-        baseQuery += fmt.Sprintf(" AND s.advisor_id = '%s'", filter.AdvisorID)
+        args = append(args, filter.Status)
+        baseQuery += fmt.Sprintf(" AND ar.status = $%d", len(args))
     }
 
     
     countQuery := "SELECT COUNT(*) " + baseQuery
-    err = database.PostgresDB.QueryRow(countQuery).Scan(&totalData) 
+    err = database.PostgresDB.QueryRow(countQuery, args...).Scan(&totalData) 
     if err != nil {
         return nil, 0, err
     }
@@ -111,16 +135,16 @@ func GetAllAchievements(filter RepoFilter) ([]model.AchievementReference, int64,
         SELECT 
             ar.id, ar.student_id, ar.mongo_achievement_id, ar.status, 
             ar.created_at, ar.updated_at,  
-            ar.submitted_at, ar.verified_at, 
+            ar.submitted_at, ar.verified_at, ar.rejected_at, 
             ar.verified_by, ar.rejection_note, 
             ar.deleted_at                     
         ` + baseQuery + ` 
         ORDER BY ar.created_at DESC 
-        LIMIT $1 OFFSET $2
+        LIMIT ` + fmt.Sprintf("$%d OFFSET $%d", len(args)+1, len(args)+2) + `
     `
     
     offset := (filter.Page - 1) * filter.Limit
-    rows, err := database.PostgresDB.Query(selQuery, filter.Limit, offset)
+    rows, err := database.PostgresDB.Query(selQuery, append(args, filter.Limit, offset)...)
     if err != nil {
         log.Printf("DB Query Error: %v", err)
         return nil, 0, err
@@ -136,14 +160,14 @@ func GetAllAchievements(filter RepoFilter) ([]model.AchievementReference, int64,
      
         var sqlCreatedAt sql.NullTime    
 
-        var ifUpdatedAt, ifSubmittedAt, ifVerifiedAt, ifDeletedAt interface{}
+        var ifUpdatedAt, ifSubmittedAt, ifVerifiedAt, ifRejectedAt, ifDeletedAt interface{}
         var ifVerifiedBy, ifRejectionNote interface{} 
         
      
         err := rows.Scan(
             &ach.ID, &ach.StudentID, &ach.MongoAchievementID, &ach.Status, 
             &sqlCreatedAt, &ifUpdatedAt, 
-            &ifSubmittedAt, &ifVerifiedAt, 
+            &ifSubmittedAt, &ifVerifiedAt, &ifRejectedAt, 
             &ifVerifiedBy, &ifRejectionNote, 
             &ifDeletedAt, 
         )
@@ -171,6 +195,10 @@ func GetAllAchievements(filter RepoFilter) ([]model.AchievementReference, int64,
             t, parseErr := parseInterfaceTime(ifVerifiedAt)
             if parseErr == nil { ach.VerifiedAt = &t }
         }
+        if ifRejectedAt != nil { 
+            t, parseErr := parseInterfaceTime(ifRejectedAt)
+            if parseErr == nil { ach.RejectedAt = &t }
+        }
         if ifDeletedAt != nil { 
             t, parseErr := parseInterfaceTime(ifDeletedAt)
             if parseErr == nil { ach.DeletedAt = &t }
@@ -236,64 +264,128 @@ func GetAchievementDetailFromMongo(hexID string) (*model.AchievementMongo, error
 
 
 func FindAchievementByID(id string) (*model.AchievementReference, error) {
-	query := `SELECT id, student_id, mongo_achievement_id, status FROM achievement_references WHERE id = $1`
+	query := `SELECT id, student_id, mongo_achievement_id, status, pipeline_id, current_stage, submitted_revision, verified_revision FROM achievement_references WHERE id = $1`
 	
 	var ach model.AchievementReference
 	err := database.PostgresDB.QueryRow(query, id).Scan(
-		&ach.ID, &ach.StudentID, &ach.MongoAchievementID, &ach.Status,
+		&ach.ID, &ach.StudentID, &ach.MongoAchievementID, &ach.Status, &ach.PipelineID, &ach.CurrentStage,
+		&ach.SubmittedRevision, &ach.VerifiedRevision,
 	)
 	return &ach, err
 }
 
-func UpdateStatus(id string, status model.AchievementStatus, verifiedBy *uuid.UUID, note string) error {
-    
-    statusSubmittedString := "submitted"
-    statusVerifiedString := "verified"
-    statusRejectedString := "rejected"
+// ErrStatusConflict: status prestasi sudah berubah (atau prestasi dihapus) sejak dibaca.
+var ErrStatusConflict = errors.New("status prestasi sudah berubah")
+
+// UpdateStatus menjalankan transisi event.FromStatus -> event.ToStatus dan mencatat event-nya dalam satu
+// transaksi. Update hanya berhasil jika status di database masih FromStatus (optimistic locking),
+// sehingga dua transisi bersamaan tidak saling timpa.
+func UpdateStatus(event *model.AchievementStatusEvent) error {
+    if event.FromStatus == nil {
+        return errors.New("status asal transisi wajib diisi")
+    }
 
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    tx, err := database.PostgresDB.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if err := updateStatusTx(ctx, tx, event); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+// updateStatusTx menjalankan transisi status beserta event-nya di dalam transaksi pemanggil.
+func updateStatusTx(ctx context.Context, tx *sql.Tx, event *model.AchievementStatusEvent) error {
+
+    // verified_by & rejection_note menyimpan keputusan verifikator terakhir; diajukan ulang = dikosongkan,
+    // transisi lain (withdraw, reopen, archive) tidak mengubahnya.
     cleanQuery := `
         UPDATE achievement_references 
         SET 
             status = $1, 
-            verified_by = $2, 
-            rejection_note = $3, 
+            verified_by = CASE 
+                          WHEN $1 IN ('verified', 'rejected', 'revision_requested') THEN $2 
+                          WHEN $1 = 'submitted' THEN NULL 
+                          ELSE verified_by 
+                          END, 
+            rejection_note = CASE 
+                             WHEN $1 IN ('rejected', 'revision_requested') THEN $3 
+                             WHEN $1 IN ('submitted', 'verified') THEN NULL 
+                             ELSE rejection_note 
+                             END, 
             
             submitted_at = CASE 
-                            WHEN $1 = $4::achievement_status_type THEN NOW() 
+                            WHEN $1 = 'submitted' THEN NOW() 
                             ELSE submitted_at 
                            END,
             
             verified_at = CASE 
-                          WHEN $1 = $5::achievement_status_type OR $1 = $6::achievement_status_type THEN NOW() 
+                          WHEN $1 = 'verified' THEN NOW() 
+                          WHEN $1 = 'submitted' THEN NULL 
                           ELSE verified_at 
                           END,
+            rejected_at = CASE 
+                          WHEN $1 = 'rejected' THEN NOW() 
+                          WHEN $1 = 'submitted' THEN NULL 
+                          ELSE rejected_at 
+                          END,
+
+            -- Pengajuan (ulang) memulai pipeline verifikasi dari tahap pertama
+            pipeline_id = CASE 
+                          WHEN $1 = 'submitted' THEN NULL 
+                          ELSE pipeline_id 
+                          END,
+            current_stage = CASE 
+                            WHEN $1 = 'submitted' THEN 1 
+                            WHEN $1 = 'in_review' THEN current_stage + 1 
+                            ELSE current_stage 
+                            END,
+
+            -- Revisi konten yang diajukan dipatok; revisi itulah yang tercatat disetujui saat verified
+            submitted_revision = CASE 
+                                 WHEN $1 = 'submitted' THEN $6::INT 
+                                 ELSE submitted_revision 
+                                 END,
+            verified_revision = CASE 
+                                WHEN $1 = 'verified' THEN COALESCE(submitted_revision, $6::INT) 
+                                WHEN $1 = 'submitted' THEN NULL 
+                                ELSE verified_revision 
+                                END,
             
             updated_at = NOW()
-        WHERE id = $7
+        WHERE id = $4 AND status = $5 AND deleted_at IS NULL
     `
-    
-    
-    achievementUUID, err := uuid.Parse(id)
-    if err != nil {
-        return err 
-    }
 
-    
-    _, err = database.PostgresDB.Exec(cleanQuery, 
-        string(status),                
-        verifiedBy,                     
-        note,                           
-        statusSubmittedString,          
-        statusVerifiedString,           
-        statusRejectedString,           
-        achievementUUID,                
+    result, err := tx.ExecContext(ctx, cleanQuery, 
+        string(event.ToStatus),                
+        event.ActorID,                     
+        event.Note,                           
+        event.AchievementID,                
+        string(*event.FromStatus),
+        event.Revision,
     )
-    
     if err != nil {
          log.Printf("DB EXEC ERROR (UpdateStatus): %v", err)
+         return err
+    }
+    if err := expectOneRow(result, ErrStatusConflict); err != nil {
+        return err
+    }
+
+    if event.ToStatus == model.StatusSubmitted {
+        _, err := tx.ExecContext(ctx, `DELETE FROM achievement_stage_approvals WHERE achievement_id = $1`, event.AchievementID)
+        if err != nil {
+            return err
+        }
     }
 
-    return err
+    return insertStatusEvent(ctx, tx, event)
 }
 
 func SoftDeleteAchievementTransaction(postgresID string, mongoHexID string) error {
@@ -372,72 +464,56 @@ func GetStatsByStatus() (map[string]int, error) {
 }
 
 
-func UpdateAchievementDetail(mongoHexID string, updateData model.AchievementMongo) error {
+// UpdateAchievementDetail memperbarui konten prestasi dan menyimpan hasilnya sebagai revisi baru.
+// Mengembalikan nomor revisi baru.
+func UpdateAchievementDetail(mongoHexID string, updateData model.AchievementMongo, actor *uuid.UUID) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	objID, err := primitive.ObjectIDFromHex(mongoHexID)
 	if err != nil {
-		return fmt.Errorf("invalid object id: %w", err)
-	}
-	collection := database.MongoD.Collection("achievements")
-
-
-	setFields := bson.M{}
-
-	
-	if updateData.Title != "" {
-		setFields["title"] = updateData.Title
-	}
-	if updateData.Description != "" {
-		setFields["description"] = updateData.Description
-	}
-	if updateData.AchievementType != "" {
-		setFields["achievementType"] = updateData.AchievementType
-	}
-
-    if len(updateData.Tags) > 0 {
-        setFields["tags"] = updateData.Tags
-    }
-    if updateData.Points != 0 {
-        setFields["points"] = updateData.Points
-    }
-
-	if updateData.Details != nil && len(updateData.Details) > 0 {
-		setFields["details"] = updateData.Details
+		return 0, fmt.Errorf("invalid object id: %w", err)
 	}
 
 
-
-	setFields["updatedAt"] = time.Now()
-
-	if len(setFields) == 0 {
-		
+	apply := func(doc *model.AchievementMongo) {
+		if updateData.Title != "" {
+			doc.Title = updateData.Title
+		}
+		if updateData.Description != "" {
+			doc.Description = updateData.Description
+		}
+		if updateData.AchievementType != "" {
+			doc.AchievementType = updateData.AchievementType
+		}
+		if updateData.CompetitionTier != "" {
+			doc.CompetitionTier = updateData.CompetitionTier
+		}
+		if len(updateData.Tags) > 0 {
+			doc.Tags = updateData.Tags
+		}
+		// points tidak diambil dari request: hanya ditulis oleh perhitungan aturan poin saat verifikasi
+		if len(updateData.Details) > 0 {
+			doc.Details = updateData.Details
+		}
 	}
 
-	update := bson.M{"$set": setFields}
-
-	result, err := collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
-	
+	rev, err := updateWithRevision(ctx, objID, apply, model.RevisionReasonUpdate, actor)
     if err != nil {
-        return fmt.Errorf("gagal update ke mongo: %w", err)
-    }
-
-    if result.ModifiedCount == 0 && result.MatchedCount == 1 {
-       
-        return nil 
+        return 0, fmt.Errorf("gagal update ke mongo: %w", err)
     }
     
-	return nil
+	return rev, nil
 }
 
-func AddAttachmentToMongo(mongoHexID string, attachment model.Attachment) error {
+// AddAttachmentToMongo menambahkan lampiran; daftar lampiran baru disimpan sebagai revisi baru.
+func AddAttachmentToMongo(mongoHexID string, attachment model.Attachment, actor *uuid.UUID) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	objID, err := primitive.ObjectIDFromHex(mongoHexID)
 	if err != nil {
-		return fmt.Errorf("invalid MongoDB ID format: %w", err)
+		return 0, fmt.Errorf("invalid MongoDB ID format: %w", err)
 	}
 
 	collection := database.MongoD.Collection("achievements")
@@ -449,81 +525,18 @@ func AddAttachmentToMongo(mongoHexID string, attachment model.Attachment) error
         bson.M{"$set": bson.M{"attachments": []model.Attachment{}}}, // Action: Set ke array kosong
     )
    
-	update := bson.M{
-		"$push": bson.M{"attachments": attachment},
+	apply := func(doc *model.AchievementMongo) {
+		doc.Attachments = append(doc.Attachments, attachment)
 	}
 
-
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	rev, err := updateWithRevision(ctx, objID, apply, model.RevisionReasonAttachment, actor)
 	
 	if err != nil {
 		
-		return fmt.Errorf("gagal menambahkan attachment: %w", err)
+		return 0, fmt.Errorf("gagal menambahkan attachment: %w", err)
 	}
 	
-	return nil
-}
-
-func GetAchievementHistory(id string) (map[string]interface{}, error) {
-    query := `
-        SELECT created_at, submitted_at, verified_at, rejection_note, status 
-        FROM achievement_references 
-        WHERE id = $1
-    `
-    
-    var history struct {
-        CreatedAt    time.Time
-        SubmittedAt  *time.Time
-        VerifiedAt   *time.Time
-        RejectionNote *string
-        Status       string
-    }
-
-    err := database.PostgresDB.QueryRow(query, id).Scan(
-        &history.CreatedAt, &history.SubmittedAt, &history.VerifiedAt, 
-        &history.RejectionNote, &history.Status,
-    )
-    if err != nil {
-        return nil, err
-    }
-
-
-    var logs []map[string]interface{}
-
-    logs = append(logs, map[string]interface{}{
-        "status": "draft",
-        "timestamp": history.CreatedAt,
-        "description": "Prestasi dibuat (draft)",
-    })
-
-
-    if history.SubmittedAt != nil {
-        logs = append(logs, map[string]interface{}{
-            "status": "submitted",
-            "timestamp": *history.SubmittedAt,
-            "description": "Diajukan untuk verifikasi",
-        })
-    }
-
-    if history.VerifiedAt != nil {
-        desc := "Prestasi telah diverifikasi"
-        status := "verified"
-        if history.Status == "rejected" {
-            desc = "Prestasi ditolak. Alasan: " + *history.RejectionNote
-            status = "rejected"
-        }
-        
-        logs = append(logs, map[string]interface{}{
-            "status": status,
-            "timestamp": *history.VerifiedAt,
-            "description": desc,
-        })
-    }
-
-    return map[string]interface{}{
-        "achievement_id": id,
-        "history": logs,
-    }, nil
+	return rev, nil
 }
 
 func GetLecturerIDByUserID(userID string) (string, error) {
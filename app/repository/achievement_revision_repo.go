@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/revision"
+	"sistempelaporan/database"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revisi prestasi tidak ditemukan")
+	ErrRevisionConflict = errors.New("konten prestasi sedang diubah bersamaan, coba lagi")
+)
+
+// Snapshot revisi yang tidak pernah dipakai dokumen (proses berhenti di antara menyimpan snapshot dan
+// mengubah dokumen) dianggap yatim setelah batas ini dan boleh dibuang.
+const orphanRevisionAfter = time.Minute
+
+// Revisi disimpan utuh (snapshot) dan tidak pernah diubah; nomor revisi diambil dari counter
+// "revision" di dokumen achievements. Index unik {achievementId, revision} dibuat saat koneksi MongoDB
+// (database.ConnectMongo), sehingga satu nomor revisi hanya bisa dimiliki satu snapshot.
+func revisionCollection() *mongo.Collection {
+	return database.MongoD.Collection("achievement_revisions")
+}
+
+// insertRevision menyimpan snapshot doc sebagai revisi doc.Revision dan mengembalikan _id snapshot.
+func insertRevision(ctx context.Context, doc *model.AchievementMongo, reason string, actor *uuid.UUID) (interface{}, error) {
+	snapshot := revision.Snapshot(*doc)
+	rev := model.AchievementRevision{
+		AchievementID: doc.ID,
+		Revision:      doc.Revision,
+		Reason:        reason,
+		CreatedAt:     time.Now(),
+		Snapshot:      &snapshot,
+	}
+	if actor != nil {
+		rev.CreatedBy = actor.String()
+	}
+	result, err := revisionCollection().InsertOne(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	return result.InsertedID, nil
+}
+
+// ensureBaselineRevision menyimpan isi dokumen lama (dibuat sebelum revisi dicatat) sebagai revisi 0.
+// Upsert bersama index unik membuatnya aman dipanggil berulang / bersamaan.
+func ensureBaselineRevision(ctx context.Context, objID primitive.ObjectID) (*model.AchievementMongo, error) {
+	var doc model.AchievementMongo
+	if err := database.MongoD.Collection("achievements").FindOne(ctx, bson.M{"_id": objID}).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc.Revision > 0 {
+		return &doc, nil
+	}
+
+	snapshot := revision.Snapshot(doc)
+	_, err := revisionCollection().UpdateOne(ctx,
+		bson.M{"achievementId": objID, "revision": 0},
+		bson.M{"$setOnInsert": model.AchievementRevision{
+			AchievementID: objID,
+			Revision:      0,
+			Reason:        model.RevisionReasonBaseline,
+			CreatedAt:     doc.UpdatedAt,
+			Snapshot:      &snapshot,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// Upsert bersamaan: revisi 0 sudah disimpan proses lain
+		err = nil
+	}
+	return &doc, err
+}
+
+// updateWithRevision mengubah konten prestasi dengan apply sebagai revisi baru. Snapshot revisi N+1
+// disimpan lebih dulu, baru dokumen dipindah dari revisi N ke N+1 (dicocokkan pada revision: N), sehingga
+// revisi yang tercatat di dokumen selalu punya snapshot. Jika dokumen sudah diubah proses lain, snapshot
+// dibuang dan perubahan diulang dari isi terbaru. Mengembalikan nomor revisi baru.
+func updateWithRevision(ctx context.Context, objID primitive.ObjectID, apply func(doc *model.AchievementMongo), reason string, actor *uuid.UUID) (int, error) {
+	achievements := database.MongoD.Collection("achievements")
+
+	for attempt := 0; attempt < 3; attempt++ {
+		current, err := ensureBaselineRevision(ctx, objID)
+		if err != nil {
+			return 0, err
+		}
+
+		next := *current
+		apply(&next)
+		next.Revision = current.Revision + 1
+		next.UpdatedAt = time.Now()
+
+		snapshotID, err := insertRevision(ctx, &next, reason, actor)
+		if mongo.IsDuplicateKeyError(err) {
+			// Nomor revisi sudah dipakai: dokumen sudah maju atau snapshot-nya yatim
+			if err := discardOrphanRevision(ctx, objID, next.Revision); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		filter := bson.M{"_id": objID, "revision": current.Revision}
+		if current.Revision == 0 {
+			// Dokumen lama belum punya field revision
+			filter["revision"] = bson.M{"$in": bson.A{0, nil}}
+		}
+		result, err := achievements.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+			"title":           next.Title,
+			"description":     next.Description,
+			"achievementType": next.AchievementType,
+			"competitionTier": next.CompetitionTier,
+			"details":         next.Details,
+			"tags":            next.Tags,
+			"attachments":     next.Attachments,
+			"updatedAt":       next.UpdatedAt,
+			"revision":        next.Revision,
+		}})
+		if err == nil && result.MatchedCount == 1 {
+			return next.Revision, nil
+		}
+
+		// Dokumen tidak jadi berpindah ke revisi ini: snapshot-nya tidak boleh tertinggal
+		if _, delErr := revisionCollection().DeleteOne(ctx, bson.M{"_id": snapshotID}); delErr != nil && err == nil {
+			err = delErr
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return 0, ErrRevisionConflict
+}
+
+// discardOrphanRevision membuang snapshot revisi rev yang tidak pernah dipakai dokumen dan sudah
+// lebih lama dari orphanRevisionAfter. Snapshot yang masih baru mungkin milik proses yang sedang berjalan.
+func discardOrphanRevision(ctx context.Context, objID primitive.ObjectID, rev int) error {
+	adopted, err := database.MongoD.Collection("achievements").CountDocuments(ctx,
+		bson.M{"_id": objID, "revision": bson.M{"$gte": rev}})
+	if err != nil || adopted > 0 {
+		return err
+	}
+	_, err = revisionCollection().DeleteOne(ctx, bson.M{
+		"achievementId": objID,
+		"revision":      rev,
+		"createdAt":     bson.M{"$lt": time.Now().Add(-orphanRevisionAfter)},
+	})
+	return err
+}
+
+// CurrentRevision mengembalikan nomor revisi konten prestasi saat ini (dipakai untuk mematok revisi
+// yang diajukan ke verifikasi).
+func CurrentRevision(mongoHexID string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(mongoHexID)
+	if err != nil {
+		return 0, err
+	}
+	doc, err := ensureBaselineRevision(ctx, objID)
+	if err != nil {
+		return 0, err
+	}
+	return doc.Revision, nil
+}
+
+// ListRevisions mengambil daftar revisi (tanpa snapshot, urut nomor revisi) beserta totalnya.
+func ListRevisions(mongoHexID string, limit, offset int) ([]model.AchievementRevision, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(mongoHexID)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter := bson.M{"achievementId": objID}
+
+	total, err := revisionCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetProjection(bson.M{"snapshot": 0}).
+		SetSort(bson.D{{Key: "revision", Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := revisionCollection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []model.AchievementRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, 0, err
+	}
+	return revisions, total, nil
+}
+
+// FindRevision mengambil satu revisi lengkap dengan snapshot-nya.
+func FindRevision(mongoHexID string, rev int) (*model.AchievementRevision, error) {
+	return findRevision(mongoHexID, bson.M{"revision": rev}, nil)
+}
+
+// FindPreviousRevision mengambil revisi terakhir sebelum rev (ErrRevisionNotFound jika rev revisi pertama).
+func FindPreviousRevision(mongoHexID string, rev int) (*model.AchievementRevision, error) {
+	return findRevision(mongoHexID, bson.M{"revision": bson.M{"$lt": rev}}, bson.D{{Key: "revision", Value: -1}})
+}
+
+func findRevision(mongoHexID string, filter bson.M, sort bson.D) (*model.AchievementRevision, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(mongoHexID)
+	if err != nil {
+		return nil, err
+	}
+	filter["achievementId"] = objID
+
+	opts := options.FindOne()
+	if sort != nil {
+		opts.SetSort(sort)
+	}
+	var rev model.AchievementRevision
+	err = revisionCollection().FindOne(ctx, filter, opts).Decode(&rev)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
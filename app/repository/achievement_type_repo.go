@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/database"
+
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	ErrAchievementTypeNotFound = errors.New("achievement type not found")
+	ErrAchievementTypeExists   = errors.New("kode tipe prestasi sudah dipakai")
+	ErrAchievementTypeInUse    = errors.New("tipe prestasi masih dipakai prestasi")
+)
+
+const achievementTypeSelect = `
+	SELECT code, name, COALESCE(description, ''), details_schema, required_attachments, allowed_tiers,
+	       is_active, created_at, updated_at
+	FROM achievement_types
+`
+
+func scanAchievementType(row rowScanner) (*model.AchievementTypeDefinition, error) {
+	var t model.AchievementTypeDefinition
+	var detailsSchema []byte
+	err := row.Scan(&t.Code, &t.Name, &t.Description, &detailsSchema, pq.Array(&t.RequiredAttachments),
+		pq.Array(&t.AllowedTiers), &t.IsActive, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	t.DetailsSchema = detailsSchema
+	return &t, nil
+}
+
+// ListAchievementTypes mengambil definisi tipe prestasi. activeOnly = true untuk daftar yang boleh dipilih mahasiswa.
+func ListAchievementTypes(activeOnly bool) ([]model.AchievementTypeDefinition, error) {
+	query := achievementTypeSelect
+	if activeOnly {
+		query += ` WHERE is_active`
+	}
+	rows, err := database.PostgresDB.Query(query + ` ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := []model.AchievementTypeDefinition{}
+	for rows.Next() {
+		t, err := scanAchievementType(rows)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, *t)
+	}
+	return types, rows.Err()
+}
+
+func FindAchievementType(code string) (*model.AchievementTypeDefinition, error) {
+	t, err := scanAchievementType(database.PostgresDB.QueryRow(achievementTypeSelect+` WHERE code = $1`, code))
+	if err == sql.ErrNoRows {
+		return nil, ErrAchievementTypeNotFound
+	}
+	return t, err
+}
+
+// CreateAchievementType menyimpan definisi tipe prestasi baru.
+func CreateAchievementType(t *model.AchievementTypeDefinition) error {
+	query := `
+		INSERT INTO achievement_types
+			(code, name, description, details_schema, required_attachments, allowed_tiers, is_active, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, NOW(), NOW())
+		RETURNING created_at, updated_at
+	`
+	err := database.PostgresDB.QueryRow(query, t.Code, t.Name, t.Description, []byte(t.DetailsSchema),
+		pq.Array(t.RequiredAttachments), pq.Array(t.AllowedTiers), t.IsActive).Scan(&t.CreatedAt, &t.UpdatedAt)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrAchievementTypeExists
+	}
+	return err
+}
+
+// UpdateAchievementType mengganti definisi tipe prestasi. Prestasi lama baru divalidasi ulang saat diedit.
+func UpdateAchievementType(t *model.AchievementTypeDefinition) error {
+	query := `
+		UPDATE achievement_types
+		SET name = $2, description = NULLIF($3, ''), details_schema = $4, required_attachments = $5,
+		    allowed_tiers = $6, is_active = $7, updated_at = NOW()
+		WHERE code = $1
+		RETURNING created_at, updated_at
+	`
+	err := database.PostgresDB.QueryRow(query, t.Code, t.Name, t.Description, []byte(t.DetailsSchema),
+		pq.Array(t.RequiredAttachments), pq.Array(t.AllowedTiers), t.IsActive).Scan(&t.CreatedAt, &t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrAchievementTypeNotFound
+	}
+	return err
+}
+
+// DeleteAchievementType menghapus tipe prestasi yang belum pernah dipakai. Tipe yang sudah dipakai
+// cukup dinonaktifkan (is_active = false).
+func DeleteAchievementType(code string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	used, err := database.MongoD.Collection("achievements").CountDocuments(ctx, bson.M{"achievementType": code})
+	if err != nil {
+		return err
+	}
+	if used > 0 {
+		return ErrAchievementTypeInUse
+	}
+
+	result, err := database.PostgresDB.Exec(`DELETE FROM achievement_types WHERE code = $1`, code)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result, ErrAchievementTypeNotFound)
+}
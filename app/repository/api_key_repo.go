@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/database"
+
+	"github.com/lib/pq"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+const apiKeyColumns = `
+	id, name, prefix, permissions, allowed_ips, expires_at, last_used_at, last_used_ip,
+	created_by, created_at, revoked_at
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (*model.APIKey, error) {
+	var k model.APIKey
+	err := row.Scan(
+		&k.ID, &k.Name, &k.Prefix, pq.Array(&k.Permissions), pq.Array(&k.AllowedIPs),
+		&k.ExpiresAt, &k.LastUsedAt, &k.LastUsedIP, &k.CreatedBy, &k.CreatedAt, &k.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if k.Permissions == nil {
+		k.Permissions = []string{}
+	}
+	if k.AllowedIPs == nil {
+		k.AllowedIPs = []string{}
+	}
+	return &k, nil
+}
+
+func CreateAPIKey(key *model.APIKey, keyHash string) error {
+	query := `
+		INSERT INTO api_keys (id, name, prefix, key_hash, permissions, allowed_ips, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at
+	`
+	return database.PostgresDB.QueryRow(query,
+		key.ID, key.Name, key.Prefix, keyHash, pq.Array(key.Permissions), pq.Array(key.AllowedIPs),
+		key.ExpiresAt, key.CreatedBy,
+	).Scan(&key.CreatedAt)
+}
+
+func ListAPIKeys() ([]model.APIKey, error) {
+	rows, err := database.PostgresDB.Query(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]model.APIKey, 0)
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+func FindAPIKeyByID(id string) (*model.APIKey, error) {
+	k, err := scanAPIKey(database.PostgresDB.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	return k, err
+}
+
+// FindActiveAPIKeyByHash mencari key yang belum dicabut. Kadaluarsa dicek oleh pemanggil
+// agar bisa memberi pesan yang jelas.
+func FindActiveAPIKeyByHash(keyHash string) (*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL`
+	k, err := scanAPIKey(database.PostgresDB.QueryRow(query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	return k, err
+}
+
+// UpdateAPIKey mengubah nama, permission, allowlist IP dan masa berlaku key yang belum dicabut.
+func UpdateAPIKey(id, name string, permissions, allowedIPs []string, expiresAt *time.Time) error {
+	query := `
+		UPDATE api_keys SET name = $2, permissions = $3, allowed_ips = $4, expires_at = $5
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+	result, err := database.PostgresDB.Exec(query, id, name, pq.Array(permissions), pq.Array(allowedIPs), expiresAt)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result, ErrAPIKeyNotFound)
+}
+
+func RevokeAPIKey(id string) error {
+	result, err := database.PostgresDB.Exec(`UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result, ErrAPIKeyNotFound)
+}
+
+// TouchAPIKey mencatat pemakaian terakhir. Ditulis paling sering sekali per menit per key
+// agar request beruntun tidak membebani database.
+func TouchAPIKey(id, ip string) error {
+	query := `
+		UPDATE api_keys SET last_used_at = NOW(), last_used_ip = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute' OR last_used_ip IS DISTINCT FROM $2)
+	`
+	_, err := database.PostgresDB.Exec(query, id, ip)
+	return err
+}
+
+func expectOneRow(result sql.Result, notFound error) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/database"
+)
+
+// CreateAuditLog menyimpan satu entri audit log.
+func CreateAuditLog(entry *model.AuditLog) error {
+	query := `
+		INSERT INTO audit_logs (action, impersonator_id, user_id, token_id, method, path, status_code, reason, ip_address, user_agent, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), $8, $9, $10, NOW())
+	`
+	_, err := database.PostgresDB.Exec(query,
+		entry.Action, entry.ImpersonatorID, entry.UserID, entry.TokenID, entry.Method, entry.Path,
+		entry.StatusCode, entry.Reason, entry.IPAddress, entry.UserAgent,
+	)
+	return err
+}
+
+// ListAuditLogs mengambil audit log terbaru, opsional difilter per admin dan/atau per user yang di-impersonate.
+func ListAuditLogs(impersonatorID, userID string, limit int) ([]model.AuditLog, error) {
+	var conditions []string
+	var args []interface{}
+	if impersonatorID != "" {
+		args = append(args, impersonatorID)
+		conditions = append(conditions, fmt.Sprintf("impersonator_id = $%d", len(args)))
+	}
+	if userID != "" {
+		args = append(args, userID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+
+	query := `
+		SELECT id, action, impersonator_id, user_id, token_id, COALESCE(method, ''), COALESCE(path, ''),
+		       COALESCE(status_code, 0), COALESCE(reason, ''), ip_address, user_agent, created_at
+		FROM audit_logs
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+	rows, err := database.PostgresDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []model.AuditLog{}
+	for rows.Next() {
+		var l model.AuditLog
+		if err := rows.Scan(&l.ID, &l.Action, &l.ImpersonatorID, &l.UserID, &l.TokenID, &l.Method, &l.Path,
+			&l.StatusCode, &l.Reason, &l.IPAddress, &l.UserAgent, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
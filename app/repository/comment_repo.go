@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/database"
+
+	"github.com/google/uuid"
+)
+
+var ErrCommentNotFound = errors.New("komentar tidak ditemukan")
+
+const commentSelect = `
+	SELECT c.id, c.achievement_id, c.parent_id, c.author_id, COALESCE(u.full_name, ''), c.author_role, c.body,
+	       c.visibility, COALESCE(c.anchor_type, ''), COALESCE(c.anchor_ref, ''), c.revision,
+	       c.resolved_at, c.resolved_by, c.created_at
+	FROM achievement_comments c
+	LEFT JOIN users u ON u.id = c.author_id
+`
+
+func scanComment(row rowScanner) (*model.AchievementComment, error) {
+	var c model.AchievementComment
+	err := row.Scan(&c.ID, &c.AchievementID, &c.ParentID, &c.AuthorID, &c.AuthorName, &c.AuthorRole, &c.Body,
+		&c.Visibility, &c.AnchorType, &c.AnchorRef, &c.Revision, &c.ResolvedAt, &c.ResolvedBy, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListComments mengambil semua komentar prestasi (datar, urut waktu). Penyaringan visibilitas
+// dan penyusunan thread dilakukan package comment.
+func ListComments(achievementID string) ([]model.AchievementComment, error) {
+	rows, err := database.PostgresDB.Query(commentSelect+` WHERE c.achievement_id = $1 ORDER BY c.created_at, c.id`, achievementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []model.AchievementComment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *c)
+	}
+	return comments, rows.Err()
+}
+
+// FindComment mengambil satu komentar milik prestasi tertentu.
+func FindComment(achievementID, commentID string) (*model.AchievementComment, error) {
+	if _, err := uuid.Parse(commentID); err != nil {
+		return nil, ErrCommentNotFound
+	}
+	c, err := scanComment(database.PostgresDB.QueryRow(
+		commentSelect+` WHERE c.achievement_id = $1 AND c.id = $2`, achievementID, commentID))
+	if err == sql.ErrNoRows {
+		return nil, ErrCommentNotFound
+	}
+	return c, err
+}
+
+// CreateComment menyimpan komentar baru.
+func CreateComment(c *model.AchievementComment) error {
+	c.ID = uuid.New()
+	query := `
+		INSERT INTO achievement_comments
+			(id, achievement_id, parent_id, author_id, author_role, body, visibility, anchor_type, anchor_ref, revision, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10, NOW())
+		RETURNING created_at
+	`
+	return database.PostgresDB.QueryRow(query, c.ID, c.AchievementID, c.ParentID, c.AuthorID, c.AuthorRole, c.Body,
+		c.Visibility, c.AnchorType, c.AnchorRef, c.Revision).Scan(&c.CreatedAt)
+}
+
+// SetCommentResolved menandai thread selesai (resolved = true) atau membukanya kembali.
+func SetCommentResolved(c *model.AchievementComment, resolved bool, actor *uuid.UUID) error {
+	query := `
+		UPDATE achievement_comments
+		SET resolved_at = CASE WHEN $2 THEN NOW() END,
+		    resolved_by = CASE WHEN $2 THEN $3::UUID END
+		WHERE id = $1 AND parent_id IS NULL
+		RETURNING resolved_at, resolved_by
+	`
+	err := database.PostgresDB.QueryRow(query, c.ID, resolved, actor).Scan(&c.ResolvedAt, &c.ResolvedBy)
+	if err == sql.ErrNoRows {
+		return ErrCommentNotFound
+	}
+	return err
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"sistempelaporan/app/delegation"
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrDelegationNotFound = errors.New("delegasi tidak ditemukan")
+
+const delegationSelect = `
+	SELECT d.id, d.from_lecturer_id, COALESCE(fu.full_name, ''), d.to_lecturer_id, COALESCE(tu.full_name, ''),
+	       d.student_ids, d.start_date, d.end_date, COALESCE(d.reason, ''), d.created_by, d.created_at,
+	       d.revoked_at, d.revoked_by
+	FROM advisor_delegations d
+	JOIN lecturers l ON l.id = d.from_lecturer_id
+	LEFT JOIN users fu ON fu.id = l.user_id
+	LEFT JOIN lecturers tl ON tl.id = d.to_lecturer_id
+	LEFT JOIN users tu ON tu.id = tl.user_id
+`
+
+func scanDelegation(row rowScanner) (*model.AdvisorDelegation, error) {
+	var d model.AdvisorDelegation
+	var students []string
+	err := row.Scan(&d.ID, &d.FromLecturerID, &d.FromLecturerName, &d.ToLecturerID, &d.ToLecturerName,
+		pq.Array(&students), &d.StartDate, &d.EndDate, &d.Reason, &d.CreatedBy, &d.CreatedAt,
+		&d.RevokedAt, &d.RevokedBy)
+	if err != nil {
+		return nil, err
+	}
+	d.StudentIDs = make([]uuid.UUID, 0, len(students))
+	for _, s := range students {
+		if id, err := uuid.Parse(s); err == nil {
+			d.StudentIDs = append(d.StudentIDs, id)
+		}
+	}
+	return &d, nil
+}
+
+func queryDelegations(query string, args ...interface{}) ([]model.AdvisorDelegation, error) {
+	rows, err := database.PostgresDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	delegations := []model.AdvisorDelegation{}
+	for rows.Next() {
+		d, err := scanDelegation(rows)
+		if err != nil {
+			return nil, err
+		}
+		delegations = append(delegations, *d)
+	}
+	return delegations, rows.Err()
+}
+
+// ListDelegations mengambil delegasi yang dosen asalnya berada di dalam scope, ditambah delegasi
+// dari / ke dosen lecturerID (kosong = tidak ada). Urut tanggal mulai terbaru.
+func ListDelegations(scope policy.Scope, lecturerID string) ([]model.AdvisorDelegation, error) {
+	args := []interface{}{sql.NullString{String: lecturerID, Valid: lecturerID != ""}}
+	condition := `(d.from_lecturer_id = $1::UUID OR d.to_lecturer_id = $1::UUID OR ` + lecturerScopeCondition(scope, &args) + `)`
+	return queryDelegations(delegationSelect+` WHERE `+condition+` ORDER BY d.start_date DESC, d.created_at DESC`, args...)
+}
+
+// FindDelegation mengambil satu delegasi.
+func FindDelegation(id string) (*model.AdvisorDelegation, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrDelegationNotFound
+	}
+	d, err := scanDelegation(database.PostgresDB.QueryRow(delegationSelect+` WHERE d.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrDelegationNotFound
+	}
+	return d, err
+}
+
+// CreateDelegation menyimpan delegasi baru.
+func CreateDelegation(d *model.AdvisorDelegation) error {
+	d.ID = uuid.New()
+	students := make([]string, 0, len(d.StudentIDs))
+	for _, id := range d.StudentIDs {
+		students = append(students, id.String())
+	}
+
+	query := `
+		INSERT INTO advisor_delegations
+			(id, from_lecturer_id, to_lecturer_id, student_ids, start_date, end_date, reason, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, NOW())
+		RETURNING created_at
+	`
+	return database.PostgresDB.QueryRow(query, d.ID, d.FromLecturerID, d.ToLecturerID, pq.Array(students),
+		d.StartDate, d.EndDate, d.Reason, d.CreatedBy).Scan(&d.CreatedAt)
+}
+
+// HasOverlappingDelegation memeriksa apakah sudah ada delegasi belum dicabut dari dosen from ke dosen to
+// yang rentang tanggalnya beririsan dengan start..end (inklusif).
+func HasOverlappingDelegation(from, to uuid.UUID, start, end time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM advisor_delegations
+			WHERE from_lecturer_id = $1 AND to_lecturer_id = $2 AND revoked_at IS NULL
+			  AND start_date <= $4 AND end_date >= $3
+		)
+	`
+	var exists bool
+	err := database.PostgresDB.QueryRow(query, from, to, start, end).Scan(&exists)
+	return exists, err
+}
+
+// RevokeDelegation mencabut delegasi yang belum dicabut; berlaku seketika untuk request berikutnya.
+func RevokeDelegation(d *model.AdvisorDelegation, actor *uuid.UUID) error {
+	query := `
+		UPDATE advisor_delegations SET revoked_at = NOW(), revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING revoked_at, revoked_by
+	`
+	err := database.PostgresDB.QueryRow(query, d.ID, actor).Scan(&d.RevokedAt, &d.RevokedBy)
+	if err == sql.ErrNoRows {
+		return ErrDelegationNotFound
+	}
+	return err
+}
+
+// StudentsNotAdvisedBy mengembalikan ID mahasiswa (dari studentIDs) yang bukan bimbingan dosen lecturerID.
+func StudentsNotAdvisedBy(lecturerID string, studentIDs []uuid.UUID) ([]string, error) {
+	ids := make([]string, 0, len(studentIDs))
+	for _, id := range studentIDs {
+		ids = append(ids, id.String())
+	}
+	query := `
+		SELECT x.id FROM UNNEST($2::UUID[]) AS x(id)
+		WHERE NOT EXISTS (SELECT 1 FROM students s WHERE s.id = x.id AND s.advisor_id = $1)
+	`
+	rows, err := database.PostgresDB.Query(query, lecturerID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	missing := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		missing = append(missing, id)
+	}
+	return missing, rows.Err()
+}
+
+// loadActiveDelegations memuat delegasi yang sedang berlaku untuk dosen pengganti lecturerID. Tanggal hari ini
+// diambil dari delegation.CurrentDate (bukan CURRENT_DATE database) agar sama dengan status yang ditampilkan.
+func loadActiveDelegations(lecturerID string) ([]policy.Delegation, error) {
+	query := delegationSelect + `
+		WHERE d.to_lecturer_id = $1 AND d.revoked_at IS NULL
+		  AND $2::DATE BETWEEN d.start_date AND d.end_date
+	`
+	delegations, err := queryDelegations(query, lecturerID, delegation.CurrentDate().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	var active []policy.Delegation
+	for _, d := range delegations {
+		active = append(active, delegation.ToPolicy(d))
+	}
+	return active, nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"database/sql"
+
+	"sistempelaporan/database"
+	"sistempelaporan/keymanager"
+)
+
+// JWTKeyStore menyimpan key JWT di PostgreSQL agar semua instance API memakai key yang sama.
+type JWTKeyStore struct{}
+
+func (JWTKeyStore) LoadKeys() ([]keymanager.StoredKey, error) {
+	query := `
+		SELECT kid, algorithm, private_key, created_at, expires_at
+		FROM jwt_signing_keys
+		WHERE expires_at IS NULL OR expires_at > NOW()
+	`
+	rows, err := database.PostgresDB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []keymanager.StoredKey
+	for rows.Next() {
+		var k keymanager.StoredKey
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&k.ID, &k.Algorithm, &k.PrivateKey, &k.CreatedAt, &expiresAt); err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			k.ExpiresAt = expiresAt.Time
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (JWTKeyStore) SaveKey(k keymanager.StoredKey) error {
+	var expiresAt interface{}
+	if !k.ExpiresAt.IsZero() {
+		expiresAt = k.ExpiresAt
+	}
+	query := `
+		INSERT INTO jwt_signing_keys (kid, algorithm, private_key, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := database.PostgresDB.Exec(query, k.ID, k.Algorithm, k.PrivateKey, k.CreatedAt, expiresAt)
+	return err
+}
+
+func (JWTKeyStore) DeleteExpiredKeys() error {
+	_, err := database.PostgresDB.Exec(`DELETE FROM jwt_signing_keys WHERE expires_at <= NOW()`)
+	return err
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"sistempelaporan/app/model"
+	"sistempelaporan/authenticator"
+	"sistempelaporan/database"
+)
+
+// ListLDAPGroupRoleMappings mengembalikan pemetaan grup LDAP -> role, urut prioritas.
+func ListLDAPGroupRoleMappings() ([]authenticator.GroupRoleRule, error) {
+	query := `
+		SELECT m.group_dn, r.id, r.name, m.priority
+		FROM ldap_group_role_mappings m
+		JOIN roles r ON r.id = m.role_id
+		ORDER BY m.priority, m.created_at
+	`
+	rows, err := database.PostgresDB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []authenticator.GroupRoleRule
+	for rows.Next() {
+		var rule authenticator.GroupRoleRule
+		if err := rows.Scan(&rule.GroupDN, &rule.RoleID, &rule.RoleName, &rule.Priority); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// ListActiveUsersBySource mengembalikan user aktif dengan auth_source tertentu.
+func ListActiveUsersBySource(source string) ([]model.User, error) {
+	query := `
+		SELECT u.id, u.username, u.email, u.full_name, u.role_id, r.name
+		FROM users u
+		JOIN roles r ON r.id = u.role_id
+		WHERE u.auth_source = $1 AND u.is_active = true
+	`
+	rows, err := database.PostgresDB.Query(query, source)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.User
+	for rows.Next() {
+		var u model.User
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.FullName, &u.RoleID, &u.Role.Name); err != nil {
+			return nil, err
+		}
+		u.Role.ID = u.RoleID
+		u.AuthSource = source
+		u.IsActive = true
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"time"
+
+	"sistempelaporan/app/lockout"
+	"sistempelaporan/database"
+
+	"github.com/google/uuid"
+)
+
+// RecordLoginAttempt mencatat setiap percobaan login (berhasil maupun gagal).
+// userID bernilai nil jika username tidak dikenal.
+func RecordLoginAttempt(userID *uuid.UUID, username, ip, userAgent string, success bool, reason string) error {
+	query := `
+		INSERT INTO login_attempts (user_id, username, ip_address, user_agent, success, reason, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`
+	_, err := database.PostgresDB.Exec(query, userID, username, ip, userAgent, success, reason)
+	return err
+}
+
+// CountFailedLoginsByIP menghitung percobaan login gagal dari satu IP sejak waktu tertentu.
+func CountFailedLoginsByIP(ip string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM login_attempts
+		WHERE ip_address = $1 AND success = false AND attempted_at >= $2
+	`
+	var total int
+	err := database.PostgresDB.QueryRow(query, ip, since).Scan(&total)
+	return total, err
+}
+
+// RegisterFailedLogin menaikkan counter gagal login user sesuai policy (baris user dikunci selama
+// penghitungan agar kegagalan bersamaan tidak hilang). Mengembalikan waktu akhir penguncian jika
+// akun baru saja dikunci.
+func RegisterFailedLogin(userID string, policy lockout.Policy) (*time.Time, error) {
+	tx, err := database.PostgresDB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var failed int
+	if err := tx.QueryRow(`SELECT failed_login_attempts FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&failed); err != nil {
+		return nil, err
+	}
+
+	count, lockedUntil := policy.RegisterFailure(failed, time.Now())
+	query := `
+		UPDATE users
+		SET failed_login_attempts = $2, locked_until = COALESCE($3::TIMESTAMP, locked_until)
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(query, userID, count, lockedUntil); err != nil {
+		return nil, err
+	}
+	return lockedUntil, tx.Commit()
+}
+
+// RegisterSuccessfulLogin me-reset counter gagal login dan mencatat waktu & IP login terakhir.
+func RegisterSuccessfulLogin(userID string, ip string) error {
+	query := `
+		UPDATE users
+		SET failed_login_attempts = 0, locked_until = NULL,
+		    last_login_at = NOW(), last_login_ip = $2
+		WHERE id = $1
+	`
+	_, err := database.PostgresDB.Exec(query, userID, ip)
+	return err
+}
+
+// UnlockUser membuka kunci akun yang terkunci karena gagal login berulang.
+func UnlockUser(userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return ErrUserNotFound
+	}
+	query := `
+		UPDATE users
+		SET failed_login_attempts = 0, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	result, err := database.PostgresDB.Exec(query, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/database"
+	"sistempelaporan/oidc"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrOIDCStateInvalid     = errors.New("oidc state invalid")
+	ErrOIDCIdentityConflict = errors.New("oidc identity already linked")
+	ErrRoleNotFound         = errors.New("role not found")
+)
+
+// SaveOIDCAuthRequest menyimpan state login SSO yang sedang berjalan. State kadaluarsa ikut dibersihkan.
+// linkUserID diisi jika alur dimulai oleh user yang sudah login untuk menghubungkan akun SSO.
+func SaveOIDCAuthRequest(stateHash, nonce, codeVerifier, linkUserID string, expiresAt time.Time) error {
+	if _, err := database.PostgresDB.Exec(`DELETE FROM oidc_auth_requests WHERE expires_at <= NOW()`); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO oidc_auth_requests (state_hash, nonce, code_verifier, link_user_id, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, '')::UUID, $5)
+	`
+	_, err := database.PostgresDB.Exec(query, stateHash, nonce, codeVerifier, linkUserID, expiresAt)
+	return err
+}
+
+// ConsumeOIDCAuthRequest mengambil dan menghapus state (sekali pakai). Mengembalikan nonce, code_verifier
+// dan user yang meminta penghubungan akun (kosong untuk login biasa).
+func ConsumeOIDCAuthRequest(stateHash string) (nonce, codeVerifier, linkUserID string, err error) {
+	query := `
+		DELETE FROM oidc_auth_requests
+		WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING nonce, code_verifier, COALESCE(link_user_id::TEXT, '')
+	`
+	err = database.PostgresDB.QueryRow(query, stateHash).Scan(&nonce, &codeVerifier, &linkUserID)
+	if err == sql.ErrNoRows {
+		return "", "", "", ErrOIDCStateInvalid
+	}
+	return nonce, codeVerifier, linkUserID, err
+}
+
+// FindUserByOIDCSubject mencari user aktif yang sudah terhubung ke identitas SSO (issuer + sub).
+func FindUserByOIDCSubject(issuer, subject string) (*model.User, error) {
+	return findLoginUser("u.oidc_issuer = $1 AND u.oidc_subject = $2", issuer, subject)
+}
+
+// FindLoginUserByEmail mencari user aktif berdasarkan email untuk proses login SSO.
+func FindLoginUserByEmail(email string) (*model.User, error) {
+	return findLoginUser("LOWER(u.email) = LOWER($1)", email)
+}
+
+// LinkOIDCIdentity menghubungkan user dengan identitas SSO. Gagal jika user sudah terhubung ke subject lain
+// atau identitas SSO sudah terhubung ke user lain.
+func LinkOIDCIdentity(userID, issuer, subject string) error {
+	query := `
+		UPDATE users SET oidc_issuer = $2, oidc_subject = $3, updated_at = NOW()
+		WHERE id = $1 AND (oidc_subject IS NULL OR (oidc_issuer = $2 AND oidc_subject = $3))
+	`
+	result, err := database.PostgresDB.Exec(query, userID, issuer, subject)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		// identitas SSO sudah terhubung ke user lain
+		return ErrOIDCIdentityConflict
+	}
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrOIDCIdentityConflict
+	}
+	return nil
+}
+
+// ListOIDCRoleMappings mengembalikan aturan claim -> role (nama role) untuk provisioning SSO.
+func ListOIDCRoleMappings() ([]oidc.RoleMappingRule, error) {
+	query := `
+		SELECT m.claim, m.value, r.name, m.priority
+		FROM oidc_role_mappings m
+		JOIN roles r ON r.id = m.role_id
+		ORDER BY m.priority, m.created_at
+	`
+	rows, err := database.PostgresDB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []oidc.RoleMappingRule
+	for rows.Next() {
+		var rule oidc.RoleMappingRule
+		if err := rows.Scan(&rule.Claim, &rule.Value, &rule.Role, &rule.Priority); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// FindRoleIDByName mengembalikan ID role berdasarkan nama.
+func FindRoleIDByName(name string) (string, error) {
+	var id string
+	err := database.PostgresDB.QueryRow(`SELECT id FROM roles WHERE name = $1`, name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", ErrRoleNotFound
+	}
+	return id, err
+}
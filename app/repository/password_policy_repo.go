@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"sistempelaporan/database"
+	"sistempelaporan/passwordpolicy"
+)
+
+// RolePasswordPolicy adalah kebijakan password milik satu role.
+type RolePasswordPolicy struct {
+	RoleID   string `json:"role_id"`
+	RoleName string `json:"role_name"`
+	IsCustom bool   `json:"is_custom"` // false = role memakai kebijakan default
+	passwordpolicy.Policy
+}
+
+// GetPasswordPolicyByRoleID mengembalikan kebijakan role, atau kebijakan default jika belum diatur.
+func GetPasswordPolicyByRoleID(roleID string) (passwordpolicy.Policy, error) {
+	query := `
+		SELECT min_length, require_uppercase, require_lowercase, require_digit, require_symbol,
+		       history_count, reject_common, reject_personal_info
+		FROM password_policies WHERE role_id = $1
+	`
+	var p passwordpolicy.Policy
+	err := database.PostgresDB.QueryRow(query, roleID).Scan(
+		&p.MinLength, &p.RequireUppercase, &p.RequireLowercase, &p.RequireDigit, &p.RequireSymbol,
+		&p.HistoryCount, &p.RejectCommon, &p.RejectPersonalInfo,
+	)
+	if err == sql.ErrNoRows {
+		return passwordpolicy.Default(), nil
+	}
+	return p, err
+}
+
+// ListPasswordPolicies mengembalikan kebijakan password semua role.
+func ListPasswordPolicies() ([]RolePasswordPolicy, error) {
+	query := `
+		SELECT r.id, r.name, pp.role_id IS NOT NULL,
+		       pp.min_length, pp.require_uppercase, pp.require_lowercase, pp.require_digit, pp.require_symbol,
+		       pp.history_count, pp.reject_common, pp.reject_personal_info
+		FROM roles r
+		LEFT JOIN password_policies pp ON pp.role_id = r.id
+		ORDER BY r.name
+	`
+	rows, err := database.PostgresDB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := make([]RolePasswordPolicy, 0)
+	for rows.Next() {
+		var rp RolePasswordPolicy
+		var minLength, historyCount sql.NullInt64
+		var upper, lower, digit, symbol, common, personal sql.NullBool
+		if err := rows.Scan(&rp.RoleID, &rp.RoleName, &rp.IsCustom,
+			&minLength, &upper, &lower, &digit, &symbol, &historyCount, &common, &personal); err != nil {
+			return nil, err
+		}
+
+		if rp.IsCustom {
+			rp.Policy = passwordpolicy.Policy{
+				MinLength:          int(minLength.Int64),
+				RequireUppercase:   upper.Bool,
+				RequireLowercase:   lower.Bool,
+				RequireDigit:       digit.Bool,
+				RequireSymbol:      symbol.Bool,
+				HistoryCount:       int(historyCount.Int64),
+				RejectCommon:       common.Bool,
+				RejectPersonalInfo: personal.Bool,
+			}
+		} else {
+			rp.Policy = passwordpolicy.Default()
+		}
+		policies = append(policies, rp)
+	}
+	return policies, rows.Err()
+}
+
+// UpsertPasswordPolicy menyimpan kebijakan password untuk role.
+func UpsertPasswordPolicy(roleID string, p passwordpolicy.Policy) error {
+	query := `
+		INSERT INTO password_policies (role_id, min_length, require_uppercase, require_lowercase, require_digit,
+		                               require_symbol, history_count, reject_common, reject_personal_info, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		ON CONFLICT (role_id) DO UPDATE SET
+			min_length = EXCLUDED.min_length,
+			require_uppercase = EXCLUDED.require_uppercase,
+			require_lowercase = EXCLUDED.require_lowercase,
+			require_digit = EXCLUDED.require_digit,
+			require_symbol = EXCLUDED.require_symbol,
+			history_count = EXCLUDED.history_count,
+			reject_common = EXCLUDED.reject_common,
+			reject_personal_info = EXCLUDED.reject_personal_info,
+			updated_at = NOW()
+	`
+	_, err := database.PostgresDB.Exec(query, roleID, p.MinLength, p.RequireUppercase, p.RequireLowercase,
+		p.RequireDigit, p.RequireSymbol, p.HistoryCount, p.RejectCommon, p.RejectPersonalInfo)
+	return err
+}
+
+// DeletePasswordPolicy menghapus kebijakan khusus role sehingga kembali memakai default.
+func DeletePasswordPolicy(roleID string) error {
+	_, err := database.PostgresDB.Exec(`DELETE FROM password_policies WHERE role_id = $1`, roleID)
+	return err
+}
+
+// GetRecentPasswordHashes mengembalikan hash password saat ini diikuti riwayat terbaru (tanpa duplikat).
+func GetRecentPasswordHashes(userID string, limit int) ([]string, error) {
+	query := `
+		SELECT password_hash FROM (
+			SELECT password_hash, NOW() AS created_at, 0 AS ord FROM users WHERE id = $1
+			UNION ALL
+			(SELECT password_hash, created_at, 1 AS ord FROM password_history
+			 WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2)
+		) h
+		ORDER BY ord, created_at DESC
+	`
+	rows, err := database.PostgresDB.Query(query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	hashes := make([]string, 0, limit+1)
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		if !seen[h] {
+			seen[h] = true
+			hashes = append(hashes, h)
+		}
+	}
+	return hashes, rows.Err()
+}
+
+// recordPasswordHistory dipanggil di dalam transaksi yang sama dengan perubahan password.
+func recordPasswordHistory(ctx context.Context, tx *sql.Tx, userID, passwordHash string) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO password_history (user_id, password_hash) VALUES ($1, $2)`, userID, passwordHash)
+	return err
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"sistempelaporan/database"
+)
+
+// ErrPasswordResetTokenInvalid dikembalikan jika token reset tidak ada, sudah dipakai, atau kadaluarsa.
+var ErrPasswordResetTokenInvalid = errors.New("password reset token invalid")
+
+// CreatePasswordResetToken menyimpan hash token reset baru. Token lama yang belum dipakai dibatalkan
+// sehingga hanya link terakhir yang berlaku.
+func CreatePasswordResetToken(userID, tokenHash, requestedIP string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO password_reset_tokens (user_id, token_hash, requested_ip, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.ExecContext(ctx, query, userID, tokenHash, requestedIP, expiresAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindPasswordResetTokenUser mengembalikan user_id pemilik token reset yang masih berlaku (tanpa memakainya).
+func FindPasswordResetTokenUser(tokenHash string) (string, error) {
+	query := `
+		SELECT t.user_id FROM password_reset_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1 AND t.used_at IS NULL AND t.expires_at > NOW() AND u.is_active = true
+	`
+	var userID string
+	err := database.PostgresDB.QueryRow(query, tokenHash).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", ErrPasswordResetTokenInvalid
+	}
+	return userID, err
+}
+
+// ResetPasswordWithToken memakai token reset (sekali pakai) dan mengganti password dalam satu transaksi.
+// Kunci akun akibat gagal login juga dibuka. Mengembalikan user_id pemilik token.
+func ResetPasswordWithToken(tokenHash, newPasswordHash string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// Klaim token secara atomik: request paralel dengan token yang sama hanya satu yang berhasil
+	var userID string
+	claim := `
+		UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`
+	if err := tx.QueryRowContext(ctx, claim, tokenHash).Scan(&userID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrPasswordResetTokenInvalid
+		}
+		return "", err
+	}
+
+	update := `
+		UPDATE users
+		SET password_hash = $2, password_changed_at = NOW(),
+		    failed_login_attempts = 0, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND is_active = true
+	`
+	result, err := tx.ExecContext(ctx, update, userID, newPasswordHash)
+	if err != nil {
+		return "", err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return "", err
+	} else if rows == 0 {
+		return "", ErrPasswordResetTokenInvalid
+	}
+
+	if err := recordPasswordHistory(ctx, tx, userID, newPasswordHash); err != nil {
+		return "", err
+	}
+
+	// Token lain milik user yang belum dipakai ikut dibatalkan
+	if _, err := tx.ExecContext(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/database"
+)
+
+func scanPermission(row rowScanner) (*model.Permission, error) {
+	var p model.Permission
+	if err := row.Scan(&p.ID, &p.Name, &p.Resource, &p.Action, &p.Description); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+const permissionSelect = `SELECT id, name, resource, action, COALESCE(description, '') FROM permissions`
+
+func ListPermissions() ([]model.Permission, error) {
+	rows, err := database.PostgresDB.Query(permissionSelect + ` ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perms := []model.Permission{}
+	for rows.Next() {
+		p, err := scanPermission(rows)
+		if err != nil {
+			return nil, err
+		}
+		perms = append(perms, *p)
+	}
+	return perms, rows.Err()
+}
+
+func FindPermissionByID(id string) (*model.Permission, error) {
+	p, err := scanPermission(database.PostgresDB.QueryRow(permissionSelect+` WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrPermissionNotFound
+	}
+	return p, err
+}
+
+// CreatePermission menyimpan permission baru; resource dan action diambil dari nama (resource:action).
+func CreatePermission(p *model.Permission) error {
+	p.Resource, p.Action, _ = strings.Cut(p.Name, ":")
+
+	query := `
+		INSERT INTO permissions (id, name, resource, action, description)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (name) DO NOTHING
+	`
+	result, err := database.PostgresDB.Exec(query, p.ID, p.Name, p.Resource, p.Action, p.Description)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result, ErrPermissionExists)
+}
+
+func UpdatePermissionDescription(id, description string) error {
+	result, err := database.PostgresDB.Exec(`UPDATE permissions SET description = $2 WHERE id = $1`, id, description)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result, ErrPermissionNotFound)
+}
+
+// DeletePermission menghapus permission dan menaikkan versi permission semua role yang memakainya.
+func DeletePermission(id string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE roles SET permission_version = permission_version + 1
+		WHERE id IN (SELECT role_id FROM role_permissions WHERE permission_id = $1)
+		RETURNING id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	var roleIDs []string
+	for rows.Next() {
+		var roleID string
+		if err := rows.Scan(&roleID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		roleIDs = append(roleIDs, roleID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM permissions WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectOneRow(result, ErrPermissionNotFound); err != nil {
+		return nil, err
+	}
+	return roleIDs, tx.Commit()
+}
+
+// EnsurePermissions mendaftarkan permission yang dipakai route tetapi belum ada di tabel.
+// Mengembalikan jumlah permission yang baru ditambahkan.
+func EnsurePermissions(names []string) (int, error) {
+	query := `
+		INSERT INTO permissions (name, resource, action, description)
+		VALUES ($1, $2, $3, 'Didaftarkan otomatis dari route')
+		ON CONFLICT (name) DO NOTHING
+	`
+	added := 0
+	for _, name := range names {
+		resource, action, _ := strings.Cut(name, ":")
+		result, err := database.PostgresDB.Exec(query, name, resource, action)
+		if err != nil {
+			return added, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/database"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrPointsRuleNotFound = errors.New("points rule not found")
+
+const pointsRuleSelect = `
+	SELECT id, name, COALESCE(description, ''), kind, achievement_type, competition_tier, conditions,
+	       valid_from, valid_until, value, priority, is_active, created_at, updated_at
+	FROM points_rules
+`
+
+func scanPointsRule(row rowScanner) (*model.PointsRule, error) {
+	var r model.PointsRule
+	var conditions []byte
+	err := row.Scan(&r.ID, &r.Name, &r.Description, &r.Kind, &r.AchievementType, &r.CompetitionTier, &conditions,
+		&r.ValidFrom, &r.ValidUntil, &r.Value, &r.Priority, &r.IsActive, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(conditions, &r.Conditions); err != nil {
+		return nil, fmt.Errorf("kondisi aturan %s tidak valid: %w", r.ID, err)
+	}
+	if r.Conditions == nil {
+		r.Conditions = []model.PointsCondition{}
+	}
+	return &r, nil
+}
+
+// ListPointsRules mengambil semua aturan poin (aktif maupun tidak), urut prioritas tertinggi.
+func ListPointsRules() ([]model.PointsRule, error) {
+	rows, err := database.PostgresDB.Query(pointsRuleSelect + ` ORDER BY priority DESC, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []model.PointsRule{}
+	for rows.Next() {
+		r, err := scanPointsRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *r)
+	}
+	return rules, rows.Err()
+}
+
+func FindPointsRule(id string) (*model.PointsRule, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrPointsRuleNotFound
+	}
+	r, err := scanPointsRule(database.PostgresDB.QueryRow(pointsRuleSelect+` WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrPointsRuleNotFound
+	}
+	return r, err
+}
+
+// CreatePointsRule menyimpan aturan poin baru.
+func CreatePointsRule(r *model.PointsRule) error {
+	conditions, err := json.Marshal(r.Conditions)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO points_rules
+			(id, name, description, kind, achievement_type, competition_tier, conditions,
+			 valid_from, valid_until, value, priority, is_active, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW(), NOW())
+		RETURNING created_at, updated_at
+	`
+	return database.PostgresDB.QueryRow(query, r.ID, r.Name, r.Description, r.Kind, r.AchievementType,
+		r.CompetitionTier, conditions, r.ValidFrom, r.ValidUntil, r.Value, r.Priority, r.IsActive,
+	).Scan(&r.CreatedAt, &r.UpdatedAt)
+}
+
+// UpdatePointsRule mengganti seluruh isi aturan poin. Poin prestasi yang sudah diverifikasi baru
+// berubah setelah hitung ulang dijalankan.
+func UpdatePointsRule(r *model.PointsRule) error {
+	conditions, err := json.Marshal(r.Conditions)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE points_rules
+		SET name = $2, description = NULLIF($3, ''), kind = $4, achievement_type = $5, competition_tier = $6,
+		    conditions = $7, valid_from = $8, valid_until = $9, value = $10, priority = $11, is_active = $12,
+		    updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+	err = database.PostgresDB.QueryRow(query, r.ID, r.Name, r.Description, r.Kind, r.AchievementType,
+		r.CompetitionTier, conditions, r.ValidFrom, r.ValidUntil, r.Value, r.Priority, r.IsActive,
+	).Scan(&r.CreatedAt, &r.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrPointsRuleNotFound
+	}
+	return err
+}
+
+func DeletePointsRule(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrPointsRuleNotFound
+	}
+	result, err := database.PostgresDB.Exec(`DELETE FROM points_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result, ErrPointsRuleNotFound)
+}
+
+// RecordPointsAward menyimpan rincian perhitungan poin satu prestasi.
+func RecordPointsAward(award *model.AchievementPointsAward) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertPointsAward(ctx, tx, award); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertPointsAward menyimpan rincian poin di dalam transaksi pemanggil.
+func insertPointsAward(ctx context.Context, tx *sql.Tx, award *model.AchievementPointsAward) error {
+	if award.ID == uuid.Nil {
+		award.ID = uuid.New()
+	}
+	breakdown, err := json.Marshal(award.Breakdown)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO achievement_points_awards
+			(id, achievement_id, total, previous_total, breakdown, reason, actor_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		RETURNING created_at
+	`
+	return tx.QueryRowContext(ctx, query, award.ID, award.AchievementID, award.Total, award.PreviousTotal,
+		breakdown, award.Reason, award.ActorID).Scan(&award.CreatedAt)
+}
+
+// ListPointsAwards mengambil riwayat perhitungan poin prestasi, terbaru lebih dulu.
+func ListPointsAwards(achievementID string) ([]model.AchievementPointsAward, error) {
+	query := `
+		SELECT id, achievement_id, total, previous_total, breakdown, reason, actor_id, created_at
+		FROM achievement_points_awards
+		WHERE achievement_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := database.PostgresDB.Query(query, achievementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	awards := []model.AchievementPointsAward{}
+	for rows.Next() {
+		var a model.AchievementPointsAward
+		var breakdown []byte
+		err := rows.Scan(&a.ID, &a.AchievementID, &a.Total, &a.PreviousTotal, &breakdown, &a.Reason, &a.ActorID, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(breakdown, &a.Breakdown); err != nil {
+			return nil, err
+		}
+		awards = append(awards, a)
+	}
+	return awards, rows.Err()
+}
+
+// SetAchievementPoints menulis total poin ke dokumen prestasi di MongoDB (termasuk nilai 0).
+func SetAchievementPoints(mongoHexID string, points int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(mongoHexID)
+	if err != nil {
+		return fmt.Errorf("invalid object id: %w", err)
+	}
+	_, err = database.MongoD.Collection("achievements").UpdateOne(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"points": points, "updatedAt": time.Now()}},
+	)
+	return err
+}
+
+// VerifiedAchievement adalah prestasi terverifikasi yang diperiksa saat hitung ulang poin.
+type VerifiedAchievement struct {
+	model.AchievementReference
+	HasPointsAward bool // false = belum ada rincian poin (misal terverifikasi sebelum aturan poin dipakai)
+}
+
+// ListVerifiedAchievements mengambil prestasi terverifikasi per batch (keyset pada id) untuk hitung ulang poin.
+func ListVerifiedAchievements(afterID uuid.UUID, limit int) ([]VerifiedAchievement, error) {
+	query := `
+		SELECT r.id, r.student_id, r.mongo_achievement_id, r.status,
+		       EXISTS (SELECT 1 FROM achievement_points_awards w WHERE w.achievement_id = r.id)
+		FROM achievement_references r
+		WHERE r.status = 'verified' AND r.deleted_at IS NULL AND r.id > $1
+		ORDER BY r.id
+		LIMIT $2
+	`
+	rows, err := database.PostgresDB.Query(query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refs := []VerifiedAchievement{}
+	for rows.Next() {
+		var ref VerifiedAchievement
+		if err := rows.Scan(&ref.ID, &ref.StudentID, &ref.MongoAchievementID, &ref.Status, &ref.HasPointsAward); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
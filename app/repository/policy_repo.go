@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sistempelaporan/app/policy"
+	"sistempelaporan/database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrResourceNotFound = errors.New("resource not found")
+
+// LoadPolicySubject melengkapi subject dengan profil mahasiswa / dosen, role assignment berscope dan
+// delegasi dosen wali yang sedang berlaku untuk user.
+func LoadPolicySubject(subject *policy.Subject) error {
+	query := `
+		SELECT (SELECT id FROM students WHERE user_id = $1),
+		       (SELECT id FROM lecturers WHERE user_id = $1)
+	`
+	var studentID, lecturerID sql.NullString
+	if err := database.PostgresDB.QueryRow(query, subject.UserID).Scan(&studentID, &lecturerID); err != nil {
+		return err
+	}
+	subject.StudentID = studentID.String
+	subject.LecturerID = lecturerID.String
+
+	grants, err := loadPolicyGrants(subject.UserID)
+	if err != nil {
+		return err
+	}
+	subject.Grants = grants
+
+	if subject.LecturerID != "" {
+		delegations, err := loadActiveDelegations(subject.LecturerID)
+		if err != nil {
+			return err
+		}
+		subject.Delegations = delegations
+	}
+	return nil
+}
+
+// loadPolicyGrants memuat permission tiap role assignment user beserta unitnya.
+func loadPolicyGrants(userID string) ([]policy.Grant, error) {
+	query := `
+		SELECT ra.scope_type, ra.scope_value, r.name,
+		       COALESCE(ARRAY_AGG(p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+		FROM role_assignments ra
+		JOIN roles r ON r.id = ra.role_id
+		LEFT JOIN role_permissions rp ON rp.role_id = ra.role_id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		WHERE ra.user_id = $1
+		GROUP BY ra.id, r.name
+	`
+	rows, err := database.PostgresDB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []policy.Grant
+	for rows.Next() {
+		var g policy.Grant
+		var level string
+		if err := rows.Scan(&level, &g.Value, &g.Role, pq.Array(&g.Permissions)); err != nil {
+			return nil, err
+		}
+		g.Level = policy.ScopeLevel(level)
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
+
+// AchievementPolicyResource memuat atribut relasi prestasi (mahasiswa pemilik, dosen wali, unit akademik).
+func AchievementPolicyResource(achievementID string) (policy.Resource, error) {
+	query := `
+		SELECT s.id, s.advisor_id, COALESCE(s.program_study, ''),
+		       COALESCE(sp.department, ''), COALESCE(sp.faculty, '')
+		FROM achievement_references ar
+		JOIN students s ON ar.student_id = s.id
+		LEFT JOIN study_programs sp ON sp.name = s.program_study
+		WHERE ar.id = $1 AND ar.deleted_at IS NULL
+	`
+	return loadStudentOwnedResource(policy.ResourceAchievement, query, achievementID)
+}
+
+// StudentPolicyResource memuat atribut relasi mahasiswa (dosen wali, unit akademik).
+func StudentPolicyResource(studentID string) (policy.Resource, error) {
+	query := `
+		SELECT s.id, s.advisor_id, COALESCE(s.program_study, ''),
+		       COALESCE(sp.department, ''), COALESCE(sp.faculty, '')
+		FROM students s
+		LEFT JOIN study_programs sp ON sp.name = s.program_study
+		WHERE s.id = $1
+	`
+	return loadStudentOwnedResource(policy.ResourceStudent, query, studentID)
+}
+
+func loadStudentOwnedResource(kind policy.ResourceKind, query, id string) (policy.Resource, error) {
+	resource := policy.Resource{Kind: kind}
+	if _, err := uuid.Parse(id); err != nil {
+		return resource, ErrResourceNotFound
+	}
+
+	var advisorID sql.NullString
+	err := database.PostgresDB.QueryRow(query, id).Scan(
+		&resource.StudentID, &advisorID, &resource.Program, &resource.Department, &resource.Faculty,
+	)
+	if err == sql.ErrNoRows {
+		return resource, ErrResourceNotFound
+	}
+	resource.AdvisorID = advisorID.String
+	return resource, err
+}
+
+// LecturerPolicyResource memuat atribut relasi dosen (departemen, fakultas).
+func LecturerPolicyResource(lecturerID string) (policy.Resource, error) {
+	resource := policy.Resource{Kind: policy.ResourceLecturer}
+	if _, err := uuid.Parse(lecturerID); err != nil {
+		return resource, ErrResourceNotFound
+	}
+
+	query := `
+		SELECT l.id, COALESCE(l.department, ''),
+		       COALESCE((SELECT faculty FROM study_programs WHERE department = l.department LIMIT 1), '')
+		FROM lecturers l
+		WHERE l.id = $1
+	`
+	err := database.PostgresDB.QueryRow(query, lecturerID).Scan(&resource.LecturerID, &resource.Department, &resource.Faculty)
+	if err == sql.ErrNoRows {
+		return resource, ErrResourceNotFound
+	}
+	return resource, err
+}
+
+// unitValues memisahkan nilai unit per level.
+func unitValues(units []policy.Unit) map[policy.ScopeLevel][]string {
+	values := map[policy.ScopeLevel][]string{}
+	for _, u := range units {
+		values[u.Level] = append(values[u.Level], u.Value)
+	}
+	return values
+}
+
+// studentScopeCondition menerjemahkan scope menjadi kondisi SQL atas tabel students (alias s).
+// Nilai scope ditambahkan ke args sebagai parameter berikutnya.
+func studentScopeCondition(scope policy.Scope, args *[]interface{}) string {
+	if scope.All {
+		return "TRUE"
+	}
+
+	var conds []string
+	add := func(cond string, value interface{}) {
+		*args = append(*args, value)
+		conds = append(conds, fmt.Sprintf(cond, len(*args)))
+	}
+	if scope.StudentID != "" {
+		add("s.id = $%d", scope.StudentID)
+	}
+	if scope.AdvisorID != "" {
+		add("s.advisor_id = $%d", scope.AdvisorID)
+	}
+	for _, d := range scope.Delegations {
+		if len(d.StudentIDs) == 0 {
+			add("s.advisor_id = $%d", d.FromLecturerID)
+			continue
+		}
+		*args = append(*args, d.FromLecturerID, pq.Array(d.StudentIDs))
+		conds = append(conds, fmt.Sprintf("(s.advisor_id = $%d AND s.id = ANY($%d))", len(*args)-1, len(*args)))
+	}
+	units := unitValues(scope.Units)
+	if v := units[policy.ScopeProgram]; len(v) > 0 {
+		add("s.program_study = ANY($%d)", pq.Array(v))
+	}
+	if v := units[policy.ScopeDepartment]; len(v) > 0 {
+		add("s.program_study IN (SELECT name FROM study_programs WHERE department = ANY($%d))", pq.Array(v))
+	}
+	if v := units[policy.ScopeFaculty]; len(v) > 0 {
+		add("s.program_study IN (SELECT name FROM study_programs WHERE faculty = ANY($%d))", pq.Array(v))
+	}
+
+	if len(conds) == 0 {
+		return "FALSE"
+	}
+	return "(" + strings.Join(conds, " OR ") + ")"
+}
+
+// lecturerScopeCondition menerjemahkan scope menjadi kondisi SQL atas tabel lecturers (alias l).
+func lecturerScopeCondition(scope policy.Scope, args *[]interface{}) string {
+	if scope.All {
+		return "TRUE"
+	}
+
+	var conds []string
+	add := func(cond string, value interface{}) {
+		*args = append(*args, value)
+		conds = append(conds, fmt.Sprintf(cond, len(*args)))
+	}
+	units := unitValues(scope.Units)
+	if v := units[policy.ScopeDepartment]; len(v) > 0 {
+		add("l.department = ANY($%d)", pq.Array(v))
+	}
+	if v := units[policy.ScopeFaculty]; len(v) > 0 {
+		add("l.department IN (SELECT department FROM study_programs WHERE faculty = ANY($%d))", pq.Array(v))
+	}
+
+	if len(conds) == 0 {
+		return "FALSE"
+	}
+	return "(" + strings.Join(conds, " OR ") + ")"
+}
+
+// StudentIDsInScope mengembalikan ID mahasiswa di dalam scope (dipakai untuk filter MongoDB).
+func StudentIDsInScope(scope policy.Scope) ([]string, error) {
+	var args []interface{}
+	query := `SELECT s.id FROM students s WHERE ` + studentScopeCondition(scope, &args)
+
+	rows, err := database.PostgresDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// AcademicUnitExists mengecek apakah unit (fakultas / departemen / prodi) terdaftar di study_programs.
+func AcademicUnitExists(unit policy.Unit) (bool, error) {
+	column := map[policy.ScopeLevel]string{
+		policy.ScopeFaculty:    "faculty",
+		policy.ScopeDepartment: "department",
+		policy.ScopeProgram:    "name",
+	}[unit.Level]
+	if column == "" {
+		return false, nil
+	}
+
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM study_programs WHERE ` + column + ` = $1)`
+	err := database.PostgresDB.QueryRow(query, unit.Value).Scan(&exists)
+	return exists, err
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"sistempelaporan/app/policy"
 	"sistempelaporan/database"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,18 +13,10 @@ import (
 )
 
 
-func GetTopStudentsStats(targetID string, role string) ([]map[string]interface{}, error) {
+func GetTopStudentsStats(scope policy.Scope) ([]map[string]interface{}, error) {
 	
-	filterClause := "WHERE ar.status = 'verified' AND ar.deleted_at IS NULL "
 	var params []interface{}
-
-	if role == "Mahasiswa" {
-		filterClause += "AND s.id = $1 "
-		params = append(params, targetID)
-	} else if role == "Dosen Wali" {
-		filterClause += "AND s.advisor_id = $1 "
-		params = append(params, targetID)
-	}
+	filterClause := "WHERE ar.status = 'verified' AND ar.deleted_at IS NULL AND " + studentScopeCondition(scope, &params)
 
 	query := fmt.Sprintf(`
         SELECT u.full_name, s.student_id, COUNT(ar.id) as total_achievements
@@ -53,25 +46,16 @@ func GetTopStudentsStats(targetID string, role string) ([]map[string]interface{}
 }
 
 
-func GetMonthlyTrendStats(targetID string, role string) ([]map[string]interface{}, error) {
-	filterClause := "WHERE status IN ('submitted', 'verified') AND deleted_at IS NULL "
+func GetMonthlyTrendStats(scope policy.Scope) ([]map[string]interface{}, error) {
 	var params []interface{}
-
-	if role == "Mahasiswa" {
-		filterClause += "AND student_id = $1 "
-		params = append(params, targetID)
-	} else if role == "Dosen Wali" {
-		
-		filterClause = `
-            JOIN students s ON achievement_references.student_id = s.id 
-            WHERE status IN ('submitted', 'verified') 
-            AND s.advisor_id = $1 AND deleted_at IS NULL `
-		params = append(params, targetID)
-	}
+	filterClause := `
+            JOIN students s ON ar.student_id = s.id 
+            WHERE ar.status IN ('submitted', 'in_review', 'verified') 
+            AND ar.deleted_at IS NULL AND ` + studentScopeCondition(scope, &params)
 
 	query := fmt.Sprintf(`
-        SELECT TO_CHAR(submitted_at, 'YYYY-MM') as month, COUNT(*) 
-        FROM achievement_references 
+        SELECT TO_CHAR(ar.submitted_at, 'YYYY-MM') as month, COUNT(*) 
+        FROM achievement_references ar 
         %s
         GROUP BY month 
         ORDER BY month DESC 
@@ -93,7 +77,7 @@ func GetMonthlyTrendStats(targetID string, role string) ([]map[string]interface{
 }
 
 
-func GetAchievementTypeDistribution(targetID string, role string) ([]map[string]interface{}, error) {
+func GetAchievementTypeDistribution(scope policy.Scope) ([]map[string]interface{}, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -103,11 +87,11 @@ func GetAchievementTypeDistribution(targetID string, role string) ([]map[string]
 	matchStage := bson.D{{Key: "achievementType", Value: bson.D{{Key: "$ne", Value: ""}}}}
 	
 	
-	if role == "Mahasiswa" {
-		matchStage = append(matchStage, bson.E{Key: "student_id", Value: targetID})
-	} else if role == "Dosen Wali" {
-		
-		matchStage = append(matchStage, bson.E{Key: "advisor_id", Value: targetID})
+	// Dokumen Mongo tidak menyimpan dosen wali / unit, jadi scope diterjemahkan ke daftar mahasiswa dulu
+	if !scope.All {
+		studentIDs, err := StudentIDsInScope(scope)
+		if err != nil { return nil, err }
+		matchStage = append(matchStage, bson.E{Key: "studentId", Value: bson.D{{Key: "$in", Value: studentIDs}}})
 	}
 
 	pipeline := mongo.Pipeline{
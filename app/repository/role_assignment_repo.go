@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/database"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrRoleAssignmentNotFound = errors.New("role assignment not found")
+	ErrRoleAssignmentExists   = errors.New("role assignment sudah ada")
+)
+
+// ListRoleAssignments mengambil role assignment berscope milik user.
+func ListRoleAssignments(userID string) ([]model.RoleAssignment, error) {
+	query := `
+		SELECT ra.id, ra.user_id, ra.role_id, r.name, ra.scope_type, ra.scope_value, ra.created_by, ra.created_at
+		FROM role_assignments ra
+		JOIN roles r ON r.id = ra.role_id
+		WHERE ra.user_id = $1
+		ORDER BY ra.created_at
+	`
+	rows, err := database.PostgresDB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []model.RoleAssignment{}
+	for rows.Next() {
+		var a model.RoleAssignment
+		err := rows.Scan(&a.ID, &a.UserID, &a.RoleID, &a.RoleName, &a.ScopeType, &a.ScopeValue, &a.CreatedBy, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
+
+// CreateRoleAssignment menyimpan role assignment baru. Kombinasi user, role dan scope harus unik.
+func CreateRoleAssignment(a *model.RoleAssignment) error {
+	query := `
+		INSERT INTO role_assignments (id, user_id, role_id, scope_type, scope_value, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING created_at
+	`
+	err := database.PostgresDB.QueryRow(query, a.ID, a.UserID, a.RoleID, a.ScopeType, a.ScopeValue, a.CreatedBy).
+		Scan(&a.CreatedAt)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrRoleAssignmentExists
+	}
+	return err
+}
+
+// DeleteRoleAssignment menghapus role assignment milik user.
+func DeleteRoleAssignment(userID, assignmentID string) error {
+	result, err := database.PostgresDB.Exec(`DELETE FROM role_assignments WHERE id = $1 AND user_id = $2`, assignmentID, userID)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result, ErrRoleAssignmentNotFound)
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/database"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrRoleInUse             = errors.New("role masih dipakai user")
+	ErrRoleNameTaken         = errors.New("nama role sudah dipakai")
+	ErrPermissionNotFound    = errors.New("permission not found")
+	ErrPermissionExists      = errors.New("permission sudah ada")
+	ErrPermissionNotAttached = errors.New("permission tidak terpasang pada role")
+)
+
+// GetEffectiveRolePermissions mengembalikan versi permission role beserta permission efektifnya
+// (Admin selalu mendapat user:read_all). Role yang tidak ada menghasilkan versi 0 tanpa permission.
+func GetEffectiveRolePermissions(roleID string) (int, []string, error) {
+	var roleName string
+	var version int
+	err := database.PostgresDB.QueryRow(`SELECT name, permission_version FROM roles WHERE id = $1`, roleID).Scan(&roleName, &version)
+	if err == sql.ErrNoRows {
+		return 0, []string{}, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	perms, err := GetPermissionsByRoleID(roleID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if strings.EqualFold(roleName, model.RoleAdmin) {
+		hasReadAll := false
+		for _, p := range perms {
+			if p == "user:read_all" {
+				hasReadAll = true
+				break
+			}
+		}
+		if !hasReadAll {
+			perms = append(perms, "user:read_all")
+		}
+	}
+	return version, perms, nil
+}
+
+const roleSelect = `
+	SELECT r.id, r.name, COALESCE(r.description, ''), r.require_2fa, r.created_at, r.permission_version,
+	       COALESCE(ARRAY_AGG(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+	FROM roles r
+	LEFT JOIN role_permissions rp ON rp.role_id = r.id
+	LEFT JOIN permissions p ON p.id = rp.permission_id
+`
+
+func scanRole(row rowScanner) (*model.Role, error) {
+	var role model.Role
+	err := row.Scan(&role.ID, &role.Name, &role.Description, &role.Require2FA, &role.CreatedAt,
+		&role.PermissionVersion, pq.Array(&role.Permissions))
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// ListRoles mengambil semua role beserta nama permission-nya.
+func ListRoles() ([]model.Role, error) {
+	rows, err := database.PostgresDB.Query(roleSelect + ` GROUP BY r.id ORDER BY r.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []model.Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+	return roles, rows.Err()
+}
+
+func FindRoleByID(id string) (*model.Role, error) {
+	role, err := scanRole(database.PostgresDB.QueryRow(roleSelect+` WHERE r.id = $1 GROUP BY r.id`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrRoleNotFound
+	}
+	return role, err
+}
+
+// CreateRole membuat role baru beserta permission awalnya (berdasarkan nama) dalam satu transaksi.
+func CreateRole(role *model.Role, permissionNames []string) error {
+	if _, err := FindRoleIDByName(role.Name); err == nil {
+		return ErrRoleNameTaken
+	} else if !errors.Is(err, ErrRoleNotFound) {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO roles (id, name, description, require_2fa, permission_version, created_at)
+		VALUES ($1, $2, $3, $4, 1, NOW())
+		RETURNING created_at
+	`
+	if err := tx.QueryRowContext(ctx, query, role.ID, role.Name, role.Description, role.Require2FA).Scan(&role.CreatedAt); err != nil {
+		return err
+	}
+	if err := attachPermissions(ctx, tx, role.ID.String(), permissionNames); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateRole mengubah nama, deskripsi, dan kewajiban 2FA role.
+func UpdateRole(id, name, description string, require2FA bool) error {
+	if existingID, err := FindRoleIDByName(name); err == nil && existingID != id {
+		return ErrRoleNameTaken
+	} else if err != nil && !errors.Is(err, ErrRoleNotFound) {
+		return err
+	}
+
+	result, err := database.PostgresDB.Exec(
+		`UPDATE roles SET name = $2, description = $3, require_2fa = $4 WHERE id = $1`,
+		id, name, description, require2FA,
+	)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result, ErrRoleNotFound)
+}
+
+// DeleteRole menghapus role yang tidak lagi dipakai user mana pun.
+func DeleteRole(id string) error {
+	var users int
+	query := `SELECT (SELECT COUNT(*) FROM users WHERE role_id = $1) + (SELECT COUNT(*) FROM role_assignments WHERE role_id = $1)`
+	if err := database.PostgresDB.QueryRow(query, id).Scan(&users); err != nil {
+		return err
+	}
+	if users > 0 {
+		return ErrRoleInUse
+	}
+
+	result, err := database.PostgresDB.Exec(`DELETE FROM roles WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result, ErrRoleNotFound)
+}
+
+// AttachPermissionsToRole memasang permission (berdasarkan nama) ke role dan menaikkan versi permission-nya.
+func AttachPermissionsToRole(roleID string, permissionNames []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := bumpRolePermissionVersion(ctx, tx, roleID); err != nil {
+		return err
+	}
+	if err := attachPermissions(ctx, tx, roleID, permissionNames); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DetachPermissionFromRole melepas satu permission dari role dan menaikkan versi permission-nya.
+func DetachPermissionFromRole(roleID, permissionID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = $1 AND permission_id = $2`, roleID, permissionID)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result, ErrPermissionNotAttached); err != nil {
+		return err
+	}
+	if err := bumpRolePermissionVersion(ctx, tx, roleID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func attachPermissions(ctx context.Context, tx *sql.Tx, roleID string, permissionNames []string) error {
+	if len(permissionNames) == 0 {
+		return nil
+	}
+
+	var found int
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM permissions WHERE name = ANY($1)`, pq.Array(permissionNames)).Scan(&found)
+	if err != nil {
+		return err
+	}
+	if found != len(permissionNames) {
+		return ErrPermissionNotFound
+	}
+
+	query := `
+		INSERT INTO role_permissions (role_id, permission_id)
+		SELECT $1, id FROM permissions WHERE name = ANY($2)
+		ON CONFLICT DO NOTHING
+	`
+	_, err = tx.ExecContext(ctx, query, roleID, pq.Array(permissionNames))
+	return err
+}
+
+func bumpRolePermissionVersion(ctx context.Context, tx *sql.Tx, roleID string) error {
+	result, err := tx.ExecContext(ctx, `UPDATE roles SET permission_version = permission_version + 1 WHERE id = $1`, roleID)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(result, ErrRoleNotFound)
+}
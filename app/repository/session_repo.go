@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/session"
+	"sistempelaporan/database"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenReused   = errors.New("refresh token reused")
+	ErrSessionRevoked       = errors.New("session revoked")
+	ErrSessionExpired       = errors.New("session expired")
+	ErrSessionNotFound      = errors.New("session not found")
+)
+
+// CreateSession menyimpan sesi baru beserta hash refresh token pertamanya dalam satu transaksi.
+func CreateSession(session *model.Session, refreshTokenHash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sessionQuery := `
+		INSERT INTO sessions (id, user_id, user_agent, ip_address, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW(), $5)
+		RETURNING created_at, last_used_at
+	`
+	err = tx.QueryRowContext(ctx, sessionQuery,
+		session.ID, session.UserID, session.UserAgent, session.IPAddress, session.ExpiresAt,
+	).Scan(&session.CreatedAt, &session.LastUsedAt)
+	if err != nil {
+		return err
+	}
+
+	tokenQuery := `INSERT INTO session_refresh_tokens (session_id, token_hash) VALUES ($1, $2)`
+	if _, err = tx.ExecContext(ctx, tokenQuery, session.ID, refreshTokenHash); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RotateRefreshToken menukar refresh token lama (sekali pakai) dengan yang baru.
+// Jika token lama ternyata sudah pernah dipakai, seluruh sesi (token family) dicabut
+// dan ErrRefreshTokenReused dikembalikan.
+func RotateRefreshToken(sessionID, oldTokenHash, newTokenHash string, newExpiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	lookupQuery := `
+		SELECT t.id, t.used_at, s.expires_at, s.revoked_at
+		FROM session_refresh_tokens t
+		JOIN sessions s ON t.session_id = s.id
+		WHERE t.token_hash = $1 AND t.session_id = $2
+		FOR UPDATE
+	`
+	var tokenID string
+	var usedAt, revokedAt *time.Time
+	var expiresAt time.Time
+	err = tx.QueryRowContext(ctx, lookupQuery, oldTokenHash, sessionID).Scan(&tokenID, &usedAt, &expiresAt, &revokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrRefreshTokenNotFound
+		}
+		return err
+	}
+
+	switch session.DecideRotation(usedAt, revokedAt, expiresAt, time.Now()) {
+	case session.RotationRevoked:
+		return ErrSessionRevoked
+	case session.RotationExpired:
+		return ErrSessionExpired
+	case session.RotationReused:
+		// Reuse detection: token lama dipakai ulang -> kemungkinan dicuri, cabut seluruh family
+		revokeQuery := `UPDATE sessions SET revoked_at = NOW(), revoked_reason = 'token_reuse' WHERE id = $1`
+		if _, err := tx.ExecContext(ctx, revokeQuery, sessionID); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return ErrRefreshTokenReused
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE session_refresh_tokens SET used_at = NOW() WHERE id = $1`, tokenID); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `INSERT INTO session_refresh_tokens (session_id, token_hash) VALUES ($1, $2)`, sessionID, newTokenHash); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE sessions SET last_used_at = NOW(), expires_at = $2 WHERE id = $1`, sessionID, newExpiresAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeSession mencabut satu sesi sehingga refresh token-nya tidak bisa dipakai lagi.
+func RevokeSession(sessionID string, reason string) error {
+	query := `
+		UPDATE sessions SET revoked_at = NOW(), revoked_reason = $2
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+	_, err := database.PostgresDB.Exec(query, sessionID, reason)
+	return err
+}
+
+// ListActiveSessionsByUser mengambil semua sesi user yang belum dicabut dan belum kadaluarsa.
+func ListActiveSessionsByUser(userID string) ([]model.Session, error) {
+	query := `
+		SELECT id, user_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+		       created_at, last_used_at, expires_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`
+	rows, err := database.PostgresDB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]model.Session, 0)
+	for rows.Next() {
+		var s model.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// IsSessionActive dipakai middleware agar access token dari sesi yang dicabut langsung ditolak.
+func IsSessionActive(sessionID string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM sessions WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW())`
+	var active bool
+	err := database.PostgresDB.QueryRow(query, sessionID).Scan(&active)
+	return active, err
+}
+
+// FindSession mengambil satu sesi (termasuk yang sudah dicabut / kadaluarsa).
+func FindSession(sessionID string) (*model.Session, error) {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return nil, ErrSessionNotFound
+	}
+	query := `
+		SELECT id, user_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+		       created_at, last_used_at, expires_at, revoked_at, revoked_reason
+		FROM sessions
+		WHERE id = $1
+	`
+	var s model.Session
+	err := database.PostgresDB.QueryRow(query, sessionID).Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress,
+		&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt, &s.RevokedReason)
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// RevokeAllUserSessions mencabut semua sesi aktif user ("logout di semua perangkat").
+func RevokeAllUserSessions(userID, reason string) (int64, error) {
+	query := `
+		UPDATE sessions SET revoked_at = NOW(), revoked_reason = $2
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+	result, err := database.PostgresDB.Exec(query, userID, reason)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// RevokeOtherUserSessions mencabut semua sesi aktif user kecuali sesi yang sedang dipakai.
+func RevokeOtherUserSessions(userID, keepSessionID, reason string) (int64, error) {
+	query := `
+		UPDATE sessions SET revoked_at = NOW(), revoked_reason = $3
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL
+	`
+	result, err := database.PostgresDB.Exec(query, userID, keepSessionID, reason)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
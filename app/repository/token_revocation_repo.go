@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"sistempelaporan/database"
+)
+
+// TokenRevocationStore menyimpan token JWT yang sudah dicabut (logout) berdasarkan klaim `jti`.
+// Entri cukup disimpan sampai token kadaluarsa, setelah itu boleh dihapus oleh sweeper.
+type TokenRevocationStore interface {
+	Revoke(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+	DeleteExpired() (int64, error)
+}
+
+// PostgresRevocationStore berbagi daftar token yang dicabut ke semua instance API lewat PostgreSQL.
+type PostgresRevocationStore struct{}
+
+func (PostgresRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, expires_at, revoked_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (jti) DO NOTHING
+	`
+	_, err := database.PostgresDB.Exec(query, jti, expiresAt)
+	return err
+}
+
+func (PostgresRevocationStore) IsRevoked(jti string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1 AND expires_at > NOW())`
+	var revoked bool
+	err := database.PostgresDB.QueryRow(query, jti).Scan(&revoked)
+	return revoked, err
+}
+
+func (PostgresRevocationStore) DeleteExpired() (int64, error) {
+	result, err := database.PostgresDB.Exec(`DELETE FROM revoked_tokens WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// MemoryRevocationStore menyimpan token yang dicabut di memori proses.
+// Hanya cocok untuk development atau deployment satu instance.
+type MemoryRevocationStore struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{entries: make(map[string]time.Time)}
+}
+
+func (m *MemoryRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[jti] = expiresAt
+	return nil
+}
+
+func (m *MemoryRevocationStore) IsRevoked(jti string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	expiresAt, found := m.entries[jti]
+	return found && time.Now().Before(expiresAt), nil
+}
+
+func (m *MemoryRevocationStore) DeleteExpired() (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deleted int64
+	now := time.Now()
+	for jti, expiresAt := range m.entries {
+		if !now.Before(expiresAt) {
+			delete(m.entries, jti)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+var revocationStore TokenRevocationStore = PostgresRevocationStore{}
+
+// SetRevocationStore mengganti implementasi store yang dipakai (misal untuk testing).
+func SetRevocationStore(store TokenRevocationStore) {
+	revocationStore = store
+}
+
+func RevokeToken(jti string, expiresAt time.Time) error {
+	return revocationStore.Revoke(jti, expiresAt)
+}
+
+func IsTokenRevoked(jti string) (bool, error) {
+	return revocationStore.IsRevoked(jti)
+}
+
+// StartRevocationSweeper menjalankan goroutine yang menghapus entri kadaluarsa secara berkala.
+// Panggil fungsi stop yang dikembalikan untuk menghentikannya.
+func StartRevocationSweeper(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				deleted, err := revocationStore.DeleteExpired()
+				if err != nil {
+					log.Printf("Gagal membersihkan revoked token: %v", err)
+					continue
+				}
+				if deleted > 0 {
+					log.Printf("Membersihkan %d revoked token yang sudah kadaluarsa", deleted)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"sistempelaporan/database"
+)
+
+// TOTPState adalah data 2FA milik user yang tidak pernah dikirim ke client.
+type TOTPState struct {
+	Secret   string
+	Enabled  bool
+	LastStep *int64
+}
+
+func GetTOTPState(userID string) (*TOTPState, error) {
+	query := `SELECT totp_secret, totp_enabled, totp_last_step FROM users WHERE id = $1`
+
+	var state TOTPState
+	var secret sql.NullString
+	err := database.PostgresDB.QueryRow(query, userID).Scan(&secret, &state.Enabled, &state.LastStep)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	state.Secret = secret.String
+	return &state, nil
+}
+
+// SaveTOTPSecret menyimpan secret baru yang belum aktif (menunggu konfirmasi kode pertama).
+func SaveTOTPSecret(userID, secret string) error {
+	query := `UPDATE users SET totp_secret = $2, updated_at = NOW() WHERE id = $1 AND totp_enabled = false`
+	_, err := database.PostgresDB.Exec(query, userID, secret)
+	return err
+}
+
+// EnableTOTP mengaktifkan 2FA dan mengganti seluruh recovery code dalam satu transaksi.
+func EnableTOTP(userID string, step int64, recoveryCodeHashes []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET totp_enabled = true, totp_enabled_at = NOW(), totp_last_step = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, userID, step); err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DisableTOTP menonaktifkan 2FA, menghapus secret dan semua recovery code.
+func DisableTOTP(userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET totp_enabled = false, totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConsumeTOTPStep menandai time-step sebagai terpakai. Mengembalikan false jika kode
+// dari step yang sama (atau lebih lama) sudah pernah dipakai (replay).
+func ConsumeTOTPStep(userID string, step int64) (bool, error) {
+	query := `
+		UPDATE users SET totp_last_step = $2
+		WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)
+	`
+	result, err := database.PostgresDB.Exec(query, userID, step)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// ReplaceRecoveryCodes mengganti semua recovery code user dengan set baru.
+func ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		query := `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+		if _, err := tx.ExecContext(ctx, query, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode memakai satu recovery code (sekali pakai).
+func UseRecoveryCode(userID, codeHash string) (bool, error) {
+	query := `
+		UPDATE user_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+	result, err := database.PostgresDB.Exec(query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
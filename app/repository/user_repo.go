@@ -7,38 +7,48 @@ import (
 	"sistempelaporan/app/model"
 	"sistempelaporan/database"
 	"time"
-	"sync"
 	"strings"
 )
 
 
 
+// ErrUserNotFound dikembalikan ketika user tidak ada atau sudah tidak aktif.
+var ErrUserNotFound = errors.New("user not found")
+
 func FindUserByUsername(username string) (*model.User, error) {
+	return findLoginUser("u.username = $1", username)
+}
+
+// findLoginUser memuat data user aktif yang dibutuhkan proses login berdasarkan kondisi tertentu.
+func findLoginUser(condition string, args ...interface{}) (*model.User, error) {
 
 	query := `
-		SELECT u.id, u.username, u.email, u.password_hash, u.full_name, u.role_id, r.name 
+		SELECT u.id, u.username, u.email, u.password_hash, u.full_name, u.role_id, r.name,
+		       u.locked_until, u.totp_enabled, r.require_2fa, u.auth_source
 		FROM users u
 		JOIN roles r ON u.role_id = r.id
-		WHERE u.username = $1 AND u.is_active = true
+		WHERE ` + condition + ` AND u.is_active = true
 	`
 
 	var user model.User
 	var roleName string
 
 	
-	row := database.PostgresDB.QueryRow(query, username)
+	row := database.PostgresDB.QueryRow(query, args...)
 	err := row.Scan(
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash, 
 		&user.FullName, &user.RoleID, &roleName,
+		&user.LockedUntil, &user.TwoFactorEnabled, &user.Role.Require2FA, &user.AuthSource,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 
+	user.Role.ID = user.RoleID
 	user.Role.Name = roleName
 	return &user, nil
 }
@@ -48,7 +58,9 @@ func FindUserByID(id string) (*model.User, error) {
     query := `
         SELECT u.id, u.username, u.email, u.full_name, u.role_id, 
                u.is_active, u.created_at, u.updated_at,
-               r.name, r.description     -- <-- FIX: Tambahkan r.description
+               r.name, r.description,    -- <-- FIX: Tambahkan r.description
+               u.last_login_at, u.last_login_ip, u.locked_until,
+               u.totp_enabled, r.require_2fa, u.auth_source
         FROM users u
         JOIN roles r ON u.role_id = r.id
         WHERE u.id = $1
@@ -70,11 +82,17 @@ func FindUserByID(id string) (*model.User, error) {
         &user.UpdatedAt, 
         &roleName,
         &roleDescription,    
+        &user.LastLoginAt,
+        &user.LastLoginIP,
+        &user.LockedUntil,
+        &user.TwoFactorEnabled,
+        &user.Role.Require2FA,
+        &user.AuthSource,
     )
 
     if err != nil {
         if err == sql.ErrNoRows {
-            return nil, errors.New("user not found")
+            return nil, ErrUserNotFound
         }
         return nil, err
     }
@@ -86,6 +104,16 @@ func FindUserByID(id string) (*model.User, error) {
     return &user, nil
 }
 
+// GetPasswordHashByUserID mengambil hash password untuk konfirmasi aksi sensitif.
+func GetPasswordHashByUserID(userID string) (string, error) {
+	var hash string
+	err := database.PostgresDB.QueryRow(`SELECT password_hash FROM users WHERE id = $1`, userID).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", ErrUserNotFound
+	}
+	return hash, err
+}
+
 func GetPermissionsByRoleID(roleID string) ([]string, error) {
 	query := `
 		SELECT p.name 
@@ -149,12 +177,15 @@ func CreateUserWithProfile(user *model.User, student *model.Student, lecturer *m
 
 	
 	userQuery := `
-		INSERT INTO users (id, username, email, password_hash, full_name, role_id, is_active, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO users (id, username, email, password_hash, full_name, role_id, is_active, created_at, auth_source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id
 	`
+	if user.AuthSource == "" {
+		user.AuthSource = "local"
+	}
 	err = tx.QueryRowContext(ctx, userQuery, 
-		user.ID, user.Username, user.Email, user.PasswordHash, user.FullName, user.RoleID, true, time.Now(),
+		user.ID, user.Username, user.Email, user.PasswordHash, user.FullName, user.RoleID, true, time.Now(), user.AuthSource,
 	).Scan(&user.ID)
 
 	if err != nil {
@@ -171,6 +202,12 @@ func CreateUserWithProfile(user *model.User, student *model.Student, lecturer *m
 		return err
 	}
 
+	if user.PasswordHash != "" {
+		if err := recordPasswordHistory(ctx, tx, user.ID.String(), user.PasswordHash); err != nil {
+			return err
+		}
+	}
+
 	
 	if student != nil {
 		studentQuery := `
@@ -204,7 +241,8 @@ func AssignAdvisorToStudent(studentID string, advisorID string) error {
 func FindAllUsers() ([]model.User, error) {
     query := `
         SELECT u.id, u.username, u.email, u.full_name, u.role_id, r.name, 
-               u.is_active, u.created_at, u.updated_at, r.description -- <-- FIX: Tambahkan semua field
+               u.is_active, u.created_at, u.updated_at, r.description, -- <-- FIX: Tambahkan semua field
+               u.auth_source
         FROM users u
         JOIN roles r ON u.role_id = r.id
         WHERE u.is_active = true
@@ -235,6 +273,7 @@ func FindAllUsers() ([]model.User, error) {
             &user.CreatedAt,  
             &user.UpdatedAt,  
             &roleDescription, 
+            &user.AuthSource,
         )
         if err != nil {
             return nil, err
@@ -281,34 +320,53 @@ func DeleteUserByID(id string) error {
     return err
 }
 
-
-var tokenBlacklist = make(map[string]time.Time)
-var mutex sync.RWMutex
-
-func SetTokenBlacklist(token string, ttl time.Duration) error {
-	mutex.Lock()
-	defer mutex.Unlock()
-	
-
-	tokenBlacklist[token] = time.Now().Add(ttl)
-	
-	return nil
+// FindActiveUserByEmail mencari user aktif berdasarkan email (dipakai untuk lupa password).
+func FindActiveUserByEmail(email string) (*model.User, error) {
+	query := `
+		SELECT id, username, email, full_name, auth_source
+		FROM users
+		WHERE LOWER(email) = LOWER($1) AND is_active = true
+	`
+	var user model.User
+	err := database.PostgresDB.QueryRow(query, email).Scan(&user.ID, &user.Username, &user.Email, &user.FullName, &user.AuthSource)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
 }
 
-func IsTokenBlacklisted(token string) bool {
-	mutex.RLock()
-	defer mutex.RUnlock()
+// UpdateUserPassword mengganti hash password user dan mencatatnya di riwayat password.
+func UpdateUserPassword(userID, passwordHash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	expTime, found := tokenBlacklist[token]
-	if !found {
-		return false 
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	
-	if time.Now().Before(expTime) {
-		return true 
+	query := `
+		UPDATE users
+		SET password_hash = $2, password_changed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND is_active = true
+	`
+	result, err := tx.ExecContext(ctx, query, userID, passwordHash)
+	if err != nil {
+		return err
 	}
-
-	
-	return false 
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	if err := recordPasswordHistory(ctx, tx, userID, passwordHash); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
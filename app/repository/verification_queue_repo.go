@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/database"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// VerificationCandidate adalah prestasi yang sedang dalam proses verifikasi beserta atribut relasi
+// mahasiswanya dan awal tahap berjalan, cukup untuk menyaring antrean tanpa query per item.
+type VerificationCandidate struct {
+	Achievement    model.AchievementReference
+	Resource       policy.Resource
+	StudentName    string
+	StudentNIM     string
+	WaitingSince   time.Time  // event submitted / in_review terakhir
+	ApprovedByUser bool       // user sudah menyetujui tahap berjalan
+	EscalatedAt    *time.Time // notifikasi eskalasi untuk tahap & waktu tunggu yang sama
+}
+
+// Query kandidat antrean; $1 = user yang dicek persetujuannya (boleh kosong untuk API key).
+// Awal tahap berjalan = event submitted / in_review terakhir, karena setiap tahap yang selesai
+// mencatat transisi ke in_review.
+const verificationCandidateQuery = `
+	SELECT q.id, q.student_id, q.mongo_achievement_id, q.status, q.pipeline_id, q.current_stage, q.submitted_revision,
+	       q.advisor_id, q.program_study, q.department, q.faculty, q.full_name, q.nim, q.waiting_since,
+	       EXISTS (
+	           SELECT 1 FROM achievement_stage_approvals a
+	           WHERE a.achievement_id = q.id AND a.stage_position = q.current_stage
+	             AND a.approver_id = NULLIF($1, '')::UUID
+	       ),
+	       (SELECT MAX(x.notified_at) FROM verification_escalations x
+	        WHERE x.achievement_id = q.id AND x.stage_position = q.current_stage AND x.waiting_since = q.waiting_since)
+	FROM (
+	    SELECT ar.id, ar.student_id, ar.mongo_achievement_id, ar.status, ar.pipeline_id, ar.current_stage, ar.submitted_revision,
+	           s.advisor_id, COALESCE(s.program_study, '') AS program_study,
+	           COALESCE(sp.department, '') AS department, COALESCE(sp.faculty, '') AS faculty,
+	           u.full_name, s.student_id AS nim,
+	           COALESCE(
+	               (SELECT MAX(e.created_at) FROM achievement_status_events e
+	                WHERE e.achievement_id = ar.id AND e.to_status IN ('submitted', 'in_review')),
+	               ar.submitted_at, ar.updated_at
+	           ) AS waiting_since
+	    FROM achievement_references ar
+	    JOIN students s ON ar.student_id = s.id
+	    JOIN users u ON s.user_id = u.id
+	    LEFT JOIN study_programs sp ON sp.name = s.program_study
+	    WHERE ar.status IN ('submitted', 'in_review') AND ar.deleted_at IS NULL AND %s
+	) q
+`
+
+func listVerificationCandidates(condition string, args []interface{}) ([]VerificationCandidate, error) {
+	rows, err := database.PostgresDB.Query(fmt.Sprintf(verificationCandidateQuery, condition), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []VerificationCandidate{}
+	for rows.Next() {
+		var c VerificationCandidate
+		var advisorID sql.NullString
+		ach := &c.Achievement
+		err := rows.Scan(&ach.ID, &ach.StudentID, &ach.MongoAchievementID, &ach.Status, &ach.PipelineID, &ach.CurrentStage,
+			&ach.SubmittedRevision, &advisorID, &c.Resource.Program, &c.Resource.Department, &c.Resource.Faculty,
+			&c.StudentName, &c.StudentNIM, &c.WaitingSince, &c.ApprovedByUser, &c.EscalatedAt)
+		if err != nil {
+			return nil, err
+		}
+		c.Resource.Kind = policy.ResourceAchievement
+		c.Resource.StudentID = ach.StudentID.String()
+		c.Resource.AdvisorID = advisorID.String
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// ListVerificationCandidates mengambil prestasi dalam proses verifikasi di dalam scope. Apakah user
+// termasuk penyetuju tahap berjalan ditentukan service lewat pipeline masing-masing prestasi.
+func ListVerificationCandidates(scope policy.Scope, userID string) ([]VerificationCandidate, error) {
+	args := []interface{}{userID}
+	condition := studentScopeCondition(scope, &args)
+	return listVerificationCandidates(condition, args)
+}
+
+// ListOverdueVerifications mengambil prestasi yang tahap berjalannya dimulai sebelum cutoff dan
+// belum pernah dieskalasi untuk tahap itu. Eskalasi yang sedang diklaim instance lain ikut terambil;
+// ClaimVerificationEscalation yang menyaringnya.
+func ListOverdueVerifications(cutoff time.Time) ([]VerificationCandidate, error) {
+	candidates, err := listVerificationCandidates("TRUE", []interface{}{""})
+	if err != nil {
+		return nil, err
+	}
+	overdue := []VerificationCandidate{}
+	for _, c := range candidates {
+		if c.EscalatedAt == nil && c.WaitingSince.Before(cutoff) {
+			overdue = append(overdue, c)
+		}
+	}
+	return overdue, nil
+}
+
+// AchievementSummaries mengambil judul, tipe dan tingkat lomba beberapa prestasi sekaligus,
+// dikelompokkan per mongo id (hex).
+func AchievementSummaries(hexIDs []string) (map[string]model.AchievementMongo, error) {
+	summaries := map[string]model.AchievementMongo{}
+
+	objIDs := make([]primitive.ObjectID, 0, len(hexIDs))
+	for _, hex := range hexIDs {
+		if objID, err := primitive.ObjectIDFromHex(hex); err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	if len(objIDs) == 0 {
+		return summaries, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetProjection(bson.M{"title": 1, "achievementType": 1, "competitionTier": 1})
+	cursor, err := database.MongoD.Collection("achievements").Find(ctx, bson.M{"_id": bson.M{"$in": objIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc model.AchievementMongo
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		summaries[doc.ID.Hex()] = doc
+	}
+	return summaries, cursor.Err()
+}
+
+// EscalationContact adalah admin departemen penerima eskalasi.
+type EscalationContact struct {
+	FullName string
+	Email    string
+}
+
+// DepartmentEscalationContacts mengambil user aktif dengan role assignment berscope departemen yang
+// role-nya memuat permission achievement:escalation.
+func DepartmentEscalationContacts(department string) ([]EscalationContact, error) {
+	query := `
+		SELECT DISTINCT u.full_name, u.email
+		FROM role_assignments ra
+		JOIN users u ON u.id = ra.user_id
+		JOIN role_permissions rp ON rp.role_id = ra.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE ra.scope_type = 'department' AND ra.scope_value = $1
+		  AND p.name = 'achievement:escalation' AND u.is_active = TRUE AND u.email <> ''
+		ORDER BY u.full_name
+	`
+	rows, err := database.PostgresDB.Query(query, department)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []EscalationContact{}
+	for rows.Next() {
+		var c EscalationContact
+		if err := rows.Scan(&c.FullName, &c.Email); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, c)
+	}
+	return contacts, rows.Err()
+}
+
+// ClaimVerificationEscalation mengklaim eskalasi tahap berjalan sebelum notifikasinya dikirim, sehingga
+// hanya satu instance yang mengirim email. Klaim yang belum ditandai terkirim dan dibuat sebelum staleBefore
+// (instance pengklaim berhenti di tengah jalan) boleh diambil alih. claimed = false berarti eskalasi sudah
+// terkirim atau sedang dikirim instance lain.
+func ClaimVerificationEscalation(c VerificationCandidate, staleBefore time.Time) (id uuid.UUID, claimed bool, err error) {
+	query := `
+		INSERT INTO verification_escalations (achievement_id, stage_position, waiting_since, department)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (achievement_id, stage_position, waiting_since) DO UPDATE
+		SET escalated_at = CURRENT_TIMESTAMP
+		WHERE verification_escalations.notified_at IS NULL AND verification_escalations.escalated_at < $5
+		RETURNING id
+	`
+	err = database.PostgresDB.QueryRow(query, c.Achievement.ID, c.Achievement.CurrentStage, c.WaitingSince,
+		c.Resource.Department, staleBefore).Scan(&id)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	return id, true, nil
+}
+
+// MarkVerificationEscalationNotified menandai eskalasi yang diklaim sudah terkirim ke notified admin departemen.
+func MarkVerificationEscalationNotified(id uuid.UUID, notified int) error {
+	query := `UPDATE verification_escalations SET notified_at = CURRENT_TIMESTAMP, notified_count = $2 WHERE id = $1`
+	_, err := database.PostgresDB.Exec(query, id, notified)
+	return err
+}
+
+// ReleaseVerificationEscalation melepas klaim yang notifikasinya gagal dikirim agar dicoba lagi pada
+// putaran berikutnya.
+func ReleaseVerificationEscalation(id uuid.UUID) error {
+	query := `DELETE FROM verification_escalations WHERE id = $1 AND notified_at IS NULL`
+	_, err := database.PostgresDB.Exec(query, id)
+	return err
+}
@@ -0,0 +1,429 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/workflow"
+	"sistempelaporan/database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrPipelineNotFound     = errors.New("verification pipeline not found")
+	ErrPipelineInUse        = errors.New("pipeline masih dipakai prestasi yang sedang diverifikasi")
+	ErrStageAlreadyApproved = errors.New("tahap sudah disetujui oleh penyetuju ini")
+)
+
+const pipelineSelect = `
+	SELECT id, name, COALESCE(description, ''), competition_tiers, achievement_types, is_active, created_at
+	FROM verification_pipelines
+`
+
+func scanPipeline(row rowScanner) (*model.VerificationPipeline, error) {
+	var p model.VerificationPipeline
+	err := row.Scan(&p.ID, &p.Name, &p.Description, pq.Array(&p.CompetitionTiers), pq.Array(&p.AchievementTypes),
+		&p.IsActive, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// loadPipelineStages mengisi tahap (urut posisi) untuk setiap pipeline.
+func loadPipelineStages(pipelines []*model.VerificationPipeline) error {
+	if len(pipelines) == 0 {
+		return nil
+	}
+
+	byID := map[uuid.UUID]*model.VerificationPipeline{}
+	ids := make([]string, 0, len(pipelines))
+	for _, p := range pipelines {
+		p.Stages = []model.VerificationStage{}
+		byID[p.ID] = p
+		ids = append(ids, p.ID.String())
+	}
+
+	query := `
+		SELECT st.pipeline_id, st.id, st.position, st.name, st.approver_type, st.approver_role_id,
+		       COALESCE(r.name, ''), st.scope_level, st.required_approvals
+		FROM verification_stages st
+		LEFT JOIN roles r ON r.id = st.approver_role_id
+		WHERE st.pipeline_id = ANY($1::uuid[])
+		ORDER BY st.pipeline_id, st.position
+	`
+	rows, err := database.PostgresDB.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pipelineID uuid.UUID
+		var s model.VerificationStage
+		err := rows.Scan(&pipelineID, &s.ID, &s.Position, &s.Name, &s.ApproverType, &s.ApproverRoleID,
+			&s.ApproverRole, &s.ScopeLevel, &s.RequiredApprovals)
+		if err != nil {
+			return err
+		}
+		if p, ok := byID[pipelineID]; ok {
+			p.Stages = append(p.Stages, s)
+		}
+	}
+	return rows.Err()
+}
+
+// ListVerificationPipelines mengambil semua pipeline verifikasi beserta tahapnya.
+func ListVerificationPipelines() ([]model.VerificationPipeline, error) {
+	rows, err := database.PostgresDB.Query(pipelineSelect + ` ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []*model.VerificationPipeline
+	for rows.Next() {
+		p, err := scanPipeline(rows)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadPipelineStages(refs); err != nil {
+		return nil, err
+	}
+
+	pipelines := make([]model.VerificationPipeline, 0, len(refs))
+	for _, p := range refs {
+		pipelines = append(pipelines, *p)
+	}
+	return pipelines, nil
+}
+
+// FindVerificationPipeline mengambil satu pipeline beserta tahapnya.
+func FindVerificationPipeline(id string) (*model.VerificationPipeline, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrPipelineNotFound
+	}
+
+	p, err := scanPipeline(database.PostgresDB.QueryRow(pipelineSelect+` WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrPipelineNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := loadPipelineStages([]*model.VerificationPipeline{p}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ResolveVerificationPipeline memilih pipeline aktif untuk tingkat kompetisi dan tipe prestasi.
+// Pipeline yang mencocokkan lebih banyak kriteria didahulukan; nil jika tidak ada yang cocok.
+func ResolveVerificationPipeline(competitionTier, achievementType string) (*model.VerificationPipeline, error) {
+	query := `
+		SELECT id FROM verification_pipelines
+		WHERE is_active
+		  AND (cardinality(competition_tiers) = 0 OR LOWER($1) = ANY(competition_tiers))
+		  AND (cardinality(achievement_types) = 0 OR LOWER($2) = ANY(achievement_types))
+		ORDER BY (cardinality(competition_tiers) > 0)::int + (cardinality(achievement_types) > 0)::int DESC, created_at
+		LIMIT 1
+	`
+	var id string
+	err := database.PostgresDB.QueryRow(query, competitionTier, achievementType).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return FindVerificationPipeline(id)
+}
+
+// CreateVerificationPipeline menyimpan pipeline beserta tahapnya dalam satu transaksi.
+func CreateVerificationPipeline(p *model.VerificationPipeline) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO verification_pipelines (id, name, description, competition_tiers, achievement_types, is_active, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, NOW())
+		RETURNING created_at
+	`
+	err = tx.QueryRowContext(ctx, query, p.ID, p.Name, p.Description, pq.Array(p.CompetitionTiers),
+		pq.Array(p.AchievementTypes), p.IsActive).Scan(&p.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if err := insertPipelineStages(ctx, tx, p); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateVerificationPipeline mengganti data dan seluruh tahap pipeline. Ditolak selama ada prestasi
+// yang sedang diverifikasi dengan pipeline ini agar posisi tahapnya tidak bergeser.
+func UpdateVerificationPipeline(p *model.VerificationPipeline) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ensurePipelineIdle(ctx, tx, p.ID); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE verification_pipelines
+		SET name = $2, description = NULLIF($3, ''), competition_tiers = $4, achievement_types = $5, is_active = $6
+		WHERE id = $1
+	`
+	result, err := tx.ExecContext(ctx, query, p.ID, p.Name, p.Description, pq.Array(p.CompetitionTiers),
+		pq.Array(p.AchievementTypes), p.IsActive)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result, ErrPipelineNotFound); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM verification_stages WHERE pipeline_id = $1`, p.ID); err != nil {
+		return err
+	}
+	if err := insertPipelineStages(ctx, tx, p); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteVerificationPipeline menghapus pipeline yang tidak sedang dipakai verifikasi.
+// Prestasi yang sudah selesai tetap tersimpan dengan pipeline_id NULL.
+func DeleteVerificationPipeline(id string) error {
+	pipelineID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrPipelineNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ensurePipelineIdle(ctx, tx, pipelineID); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM verification_pipelines WHERE id = $1`, pipelineID)
+	if err != nil {
+		return err
+	}
+	if err := expectOneRow(result, ErrPipelineNotFound); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func ensurePipelineIdle(ctx context.Context, tx *sql.Tx, pipelineID uuid.UUID) error {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM achievement_references
+			WHERE pipeline_id = $1 AND status IN ('submitted', 'in_review') AND deleted_at IS NULL
+		)
+	`
+	var inUse bool
+	if err := tx.QueryRowContext(ctx, query, pipelineID).Scan(&inUse); err != nil {
+		return err
+	}
+	if inUse {
+		return ErrPipelineInUse
+	}
+	return nil
+}
+
+func insertPipelineStages(ctx context.Context, tx *sql.Tx, p *model.VerificationPipeline) error {
+	query := `
+		INSERT INTO verification_stages
+			(id, pipeline_id, position, name, approver_type, approver_role_id, scope_level, required_approvals)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	for i := range p.Stages {
+		s := &p.Stages[i]
+		if s.ID == uuid.Nil {
+			s.ID = uuid.New()
+		}
+		_, err := tx.ExecContext(ctx, query, s.ID, p.ID, s.Position, s.Name, s.ApproverType, s.ApproverRoleID,
+			s.ScopeLevel, s.RequiredApprovals)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StageApproval adalah satu persetujuan tahap yang akan dicatat oleh ApproveStage.
+type StageApproval struct {
+	PipelineID *uuid.UUID // nil = pipeline default (tidak dipatok)
+	Stage      model.VerificationStage
+	// Event transisi yang dijalankan jika quorum tahap tercapai. AchievementID, FromStatus,
+	// ActorID, ActorRole dan Note-nya juga dipakai sebagai data persetujuan.
+	Event *model.AchievementStatusEvent
+	// APIKeyID diisi jika disetujui lewat API key (Event.ActorID kosong), agar satu API key
+	// hanya dihitung sebagai satu penyetuju.
+	APIKeyID *uuid.UUID
+	// Award adalah rincian poin yang sudah dihitung untuk tahap terakhir; disimpan dalam transaksi
+	// yang sama jika transisi benar-benar menghasilkan status verified.
+	Award *model.AchievementPointsAward
+}
+
+// ApproveStage mencatat persetujuan tahap dan, jika jumlah penyetuju berbeda sudah memenuhi quorum,
+// menjalankan transisi Event (beserta rincian poin Award jika prestasi menjadi verified) dalam transaksi
+// yang sama. Prestasi dikunci (FOR UPDATE) sehingga dua persetujuan bersamaan tidak menyelesaikan tahap
+// dua kali. Mengembalikan jumlah persetujuan tahap dan apakah tahap selesai.
+func ApproveStage(a StageApproval) (int, bool, error) {
+	event := a.Event
+	if event == nil || event.FromStatus == nil {
+		return 0, false, errors.New("event transisi tahap wajib diisi")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := database.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	var status model.AchievementStatus
+	var currentStage int
+	var pinned *uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+		SELECT status, current_stage, pipeline_id FROM achievement_references
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`, event.AchievementID).Scan(&status, &currentStage, &pinned)
+	if err == sql.ErrNoRows {
+		return 0, false, ErrStatusConflict
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if status != *event.FromStatus || currentStage != a.Stage.Position {
+		return 0, false, ErrStatusConflict
+	}
+
+	switch {
+	case pinned != nil && (a.PipelineID == nil || *pinned != *a.PipelineID):
+		return 0, false, ErrStatusConflict
+	case pinned == nil && a.PipelineID != nil:
+		_, err := tx.ExecContext(ctx, `UPDATE achievement_references SET pipeline_id = $2 WHERE id = $1`,
+			event.AchievementID, *a.PipelineID)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO achievement_stage_approvals
+			(id, achievement_id, stage_position, stage_name, approver_id, approver_api_key_id, approver_role, note,
+			 on_behalf_of, delegation_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, NOW())
+	`, uuid.New(), event.AchievementID, a.Stage.Position, a.Stage.Name, event.ActorID, a.APIKeyID, event.ActorRole, event.Note,
+		event.OnBehalfOf, event.DelegationID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return 0, false, ErrStageAlreadyApproved
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Quorum dihitung dari penyetuju berbeda (user / API key), bukan jumlah baris
+	rows, err := tx.QueryContext(ctx, `
+		SELECT approver_id, approver_api_key_id FROM achievement_stage_approvals
+		WHERE achievement_id = $1 AND stage_position = $2
+	`, event.AchievementID, a.Stage.Position)
+	if err != nil {
+		return 0, false, err
+	}
+	var stageApprovals []model.AchievementStageApproval
+	for rows.Next() {
+		var approval model.AchievementStageApproval
+		if err := rows.Scan(&approval.ApproverID, &approval.APIKeyID); err != nil {
+			rows.Close()
+			return 0, false, err
+		}
+		stageApprovals = append(stageApprovals, approval)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, false, err
+	}
+	approvals := workflow.CountApprovers(stageApprovals)
+
+	completed := approvals >= a.Stage.RequiredApprovals
+	if completed {
+		if err := updateStatusTx(ctx, tx, event); err != nil {
+			return 0, false, err
+		}
+		if event.ToStatus == model.StatusVerified && a.Award != nil {
+			if err := insertPointsAward(ctx, tx, a.Award); err != nil {
+				return 0, false, err
+			}
+		}
+	}
+	return approvals, completed, tx.Commit()
+}
+
+// ListStageApprovals mengambil persetujuan tahap pengajuan prestasi yang sedang berjalan.
+func ListStageApprovals(achievementID string) ([]model.AchievementStageApproval, error) {
+	query := `
+		SELECT a.id, a.achievement_id, a.stage_position, a.stage_name, a.approver_id, a.approver_api_key_id, COALESCE(u.full_name, ''),
+		       a.approver_role, COALESCE(a.note, ''), a.on_behalf_of, COALESCE(obu.full_name, ''), a.delegation_id, a.created_at
+		FROM achievement_stage_approvals a
+		LEFT JOIN users u ON u.id = a.approver_id
+		LEFT JOIN lecturers ob ON ob.id = a.on_behalf_of
+		LEFT JOIN users obu ON obu.id = ob.user_id
+		WHERE a.achievement_id = $1
+		ORDER BY a.stage_position, a.created_at
+	`
+	rows, err := database.PostgresDB.Query(query, achievementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []model.AchievementStageApproval{}
+	for rows.Next() {
+		var a model.AchievementStageApproval
+		err := rows.Scan(&a.ID, &a.AchievementID, &a.StagePosition, &a.StageName, &a.ApproverID, &a.APIKeyID, &a.ApproverName,
+			&a.ApproverRole, &a.Note, &a.OnBehalfOf, &a.OnBehalfOfName, &a.DelegationID, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, a)
+	}
+	return approvals, rows.Err()
+}
@@ -0,0 +1,150 @@
+// Package revision membandingkan dua snapshot konten prestasi per field.
+package revision
+
+import (
+	"reflect"
+	"sort"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/schema"
+)
+
+// Snapshot mengambil bagian konten (yang direvisi) dari dokumen prestasi.
+func Snapshot(a model.AchievementMongo) model.AchievementSnapshot {
+	return model.AchievementSnapshot{
+		AchievementType: a.AchievementType,
+		Title:           a.Title,
+		Description:     a.Description,
+		CompetitionTier: a.CompetitionTier,
+		Details:         a.Details,
+		Tags:            a.Tags,
+		Attachments:     a.Attachments,
+	}
+}
+
+// Diff mengembalikan perubahan dari old ke new dengan urutan tetap: field teks, details (urut path),
+// tags lalu attachments. Lampiran dicocokkan lewat fileUrl.
+func Diff(old, new model.AchievementSnapshot) []model.RevisionChange {
+	changes := []model.RevisionChange{}
+
+	texts := []struct {
+		field    string
+		old, new string
+	}{
+		{"title", old.Title, new.Title},
+		{"description", old.Description, new.Description},
+		{"achievementType", old.AchievementType, new.AchievementType},
+		{"competitionTier", old.CompetitionTier, new.CompetitionTier},
+	}
+	for _, t := range texts {
+		if change, ok := compare(t.field, t.old, t.new); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	oldDetails, _ := schema.Normalize(old.Details).(map[string]interface{})
+	newDetails, _ := schema.Normalize(new.Details).(map[string]interface{})
+	changes = append(changes, diffMap("details", oldDetails, newDetails)...)
+
+	changes = append(changes, diffTags(old.Tags, new.Tags)...)
+	changes = append(changes, diffAttachments(old.Attachments, new.Attachments)...)
+	return changes
+}
+
+func compare(field string, old, new interface{}) (model.RevisionChange, bool) {
+	switch {
+	case isEmpty(old) && isEmpty(new):
+		return model.RevisionChange{}, false
+	case isEmpty(old):
+		return model.RevisionChange{Field: field, Change: model.ChangeAdded, New: new}, true
+	case isEmpty(new):
+		return model.RevisionChange{Field: field, Change: model.ChangeRemoved, Old: old}, true
+	case reflect.DeepEqual(old, new):
+		return model.RevisionChange{}, false
+	}
+	return model.RevisionChange{Field: field, Change: model.ChangeModified, Old: old, New: new}, true
+}
+
+func isEmpty(v interface{}) bool {
+	return v == nil || v == ""
+}
+
+// diffMap membandingkan dokumen bersarang sampai ke field daun; array dibandingkan utuh.
+func diffMap(path string, old, new map[string]interface{}) []model.RevisionChange {
+	keys := make([]string, 0, len(old)+len(new))
+	for k := range old {
+		keys = append(keys, k)
+	}
+	for k := range new {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := []model.RevisionChange{}
+	for _, k := range keys {
+		field := path + "." + k
+		oldMap, oldIsMap := old[k].(map[string]interface{})
+		newMap, newIsMap := new[k].(map[string]interface{})
+		if oldIsMap && newIsMap {
+			changes = append(changes, diffMap(field, oldMap, newMap)...)
+			continue
+		}
+		if change, ok := compare(field, old[k], new[k]); ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func diffTags(old, new []string) []model.RevisionChange {
+	changes := []model.RevisionChange{}
+	for _, tag := range old {
+		if !containsString(new, tag) {
+			changes = append(changes, model.RevisionChange{Field: "tags", Change: model.ChangeRemoved, Old: tag})
+		}
+	}
+	for _, tag := range new {
+		if !containsString(old, tag) {
+			changes = append(changes, model.RevisionChange{Field: "tags", Change: model.ChangeAdded, New: tag})
+		}
+	}
+	return changes
+}
+
+func diffAttachments(old, new []model.Attachment) []model.RevisionChange {
+	changes := []model.RevisionChange{}
+	for _, att := range old {
+		if _, ok := findAttachment(new, att.FileURL); !ok {
+			changes = append(changes, model.RevisionChange{Field: "attachments", Change: model.ChangeRemoved, Old: att})
+		}
+	}
+	for _, att := range new {
+		prev, ok := findAttachment(old, att.FileURL)
+		if !ok {
+			changes = append(changes, model.RevisionChange{Field: "attachments", Change: model.ChangeAdded, New: att})
+		} else if prev.FileName != att.FileName || prev.Category != att.Category {
+			changes = append(changes, model.RevisionChange{Field: "attachments", Change: model.ChangeModified, Old: prev, New: att})
+		}
+	}
+	return changes
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func findAttachment(list []model.Attachment, url string) (model.Attachment, bool) {
+	for _, att := range list {
+		if att.FileURL == url {
+			return att, true
+		}
+	}
+	return model.Attachment{}, false
+}
@@ -0,0 +1,61 @@
+package schema
+
+import (
+	"strings"
+
+	"sistempelaporan/app/model"
+)
+
+// ValidateAchievement memvalidasi tingkat kompetisi dan details prestasi terhadap definisi tipenya.
+// error hanya dikembalikan jika JSON Schema milik definisi itu sendiri rusak.
+func ValidateAchievement(def model.AchievementTypeDefinition, a model.AchievementMongo) (map[string]string, error) {
+	errs := map[string]string{}
+
+	if len(def.AllowedTiers) > 0 {
+		allowed := false
+		for _, tier := range def.AllowedTiers {
+			if strings.EqualFold(tier, a.CompetitionTier) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			errs["competitionTier"] = "harus salah satu dari: " + strings.Join(def.AllowedTiers, ", ")
+		}
+	}
+
+	if len(def.DetailsSchema) == 0 {
+		return errs, nil
+	}
+	s, err := Parse(def.DetailsSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	var details interface{} = map[string]interface{}{}
+	if a.Details != nil {
+		details = a.Details
+	}
+	for field, msg := range s.Validate(details, "details") {
+		errs[field] = msg
+	}
+	return errs, nil
+}
+
+// MissingAttachments mengembalikan kategori lampiran wajib yang belum diunggah.
+func MissingAttachments(def model.AchievementTypeDefinition, attachments []model.Attachment) map[string]string {
+	errs := map[string]string{}
+	for _, category := range def.RequiredAttachments {
+		found := false
+		for _, att := range attachments {
+			if strings.EqualFold(att.Category, category) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs["attachments."+category] = "lampiran " + category + " wajib diunggah"
+		}
+	}
+	return errs
+}
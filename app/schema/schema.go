@@ -0,0 +1,291 @@
+// Package schema memvalidasi details prestasi terhadap JSON Schema milik tipe prestasinya.
+// Yang didukung adalah subset JSON Schema yang dipakai definisi tipe: type, title, description, properties, required,
+// additionalProperties, items, enum, minimum, maximum, minLength, maxLength, pattern, format
+// (date, date-time, email, uri), minItems dan maxItems.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Schema adalah satu node JSON Schema.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+var (
+	validTypes   = map[string]bool{"": true, "object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true}
+	validFormats = map[string]bool{"": true, "date": true, "date-time": true, "email": true, "uri": true}
+)
+
+// Parse membaca JSON Schema dan memastikan hanya memakai kata kunci yang didukung dengan benar.
+// Kata kunci lain (const, oneOf, $ref, ...) ditolak di semua tingkat, karena aturannya tidak akan ditegakkan.
+func Parse(raw []byte) (*Schema, error) {
+	var s Schema
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return nil, fmt.Errorf("kata kunci %s tidak didukung", field)
+		}
+		return nil, fmt.Errorf("JSON Schema tidak valid: %w", err)
+	}
+	if dec.More() {
+		return nil, errors.New("JSON Schema tidak valid: ada data setelah schema")
+	}
+	if s.Type != "object" {
+		return nil, errors.New("JSON Schema details harus bertipe object")
+	}
+	if err := s.compile("details"); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schema) compile(path string) error {
+	if !validTypes[s.Type] {
+		return fmt.Errorf("%s: type %q tidak didukung", path, s.Type)
+	}
+	if !validFormats[s.Format] {
+		return fmt.Errorf("%s: format %q tidak didukung", path, s.Format)
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: pattern tidak valid: %w", path, err)
+		}
+		s.pattern = re
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok && len(s.Properties) > 0 {
+			return fmt.Errorf("%s: field wajib %q tidak ada di properties", path, name)
+		}
+	}
+	for name, prop := range s.Properties {
+		if prop == nil {
+			return fmt.Errorf("%s.%s: schema kosong", path, name)
+		}
+		if err := prop.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(path + "[]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate memvalidasi value dan mengembalikan pesan error per path field (misal "details.rank").
+func (s *Schema) Validate(value interface{}, path string) map[string]string {
+	errs := map[string]string{}
+	s.validate(Normalize(value), path, errs)
+	return errs
+}
+
+func (s *Schema) validate(value interface{}, path string, errs map[string]string) {
+	if value == nil {
+		errs[path] = "tidak boleh null"
+		return
+	}
+	if !s.checkType(value) {
+		errs[path] = "harus bertipe " + s.Type
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		errs[path] = "harus salah satu dari: " + enumText(s.Enum)
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if field, ok := v[name]; !ok || field == nil || field == "" {
+				errs[path+"."+name] = "wajib diisi"
+			}
+		}
+		for name, field := range v {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					errs[path+"."+name] = "field tidak dikenal"
+				}
+				continue
+			}
+			if _, reported := errs[path+"."+name]; reported {
+				continue
+			}
+			prop.validate(field, path+"."+name, errs)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			errs[path] = fmt.Sprintf("minimal %d item", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			errs[path] = fmt.Sprintf("maksimal %d item", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			errs[path] = fmt.Sprintf("panjang minimal %d karakter", *s.MinLength)
+		} else if s.MaxLength != nil && length > *s.MaxLength {
+			errs[path] = fmt.Sprintf("panjang maksimal %d karakter", *s.MaxLength)
+		} else if s.pattern != nil && !s.pattern.MatchString(v) {
+			errs[path] = "format tidak sesuai pola " + s.Pattern
+		} else if msg := checkFormat(s.Format, v); msg != "" {
+			errs[path] = msg
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			errs[path] = fmt.Sprintf("minimal %v", *s.Minimum)
+		} else if s.Maximum != nil && v > *s.Maximum {
+			errs[path] = fmt.Sprintf("maksimal %v", *s.Maximum)
+		}
+	}
+}
+
+func (s *Schema) checkType(value interface{}) bool {
+	switch s.Type {
+	case "":
+		return true
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	}
+	return false
+}
+
+func checkFormat(format, v string) string {
+	switch format {
+	case "date":
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			return "format tanggal harus YYYY-MM-DD"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			return "format waktu harus RFC3339"
+		}
+	case "email":
+		if _, err := mail.ParseAddress(v); err != nil {
+			return "format email tidak valid"
+		}
+	case "uri":
+		if u, err := url.Parse(v); err != nil || u.Scheme == "" || u.Host == "" {
+			return "format URL tidak valid"
+		}
+	}
+	return ""
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, option := range Normalize(enum).([]interface{}) {
+		if reflect.DeepEqual(option, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumText(enum []interface{}) string {
+	parts := make([]string, 0, len(enum))
+	for _, option := range enum {
+		parts = append(parts, fmt.Sprint(option))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Normalize menyeragamkan nilai hasil decode JSON maupun MongoDB: dokumen menjadi map, array menjadi
+// []interface{} dan semua angka menjadi float64.
+func Normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = Normalize(item)
+		}
+		return out
+	case primitive.M:
+		return Normalize(map[string]interface{}(v))
+	case primitive.D:
+		out := make(map[string]interface{}, len(v))
+		for _, e := range v {
+			out[e.Key] = Normalize(e.Value)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = Normalize(item)
+		}
+		return out
+	case primitive.A:
+		return Normalize([]interface{}(v))
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case primitive.DateTime:
+		return v.Time().UTC().Format(time.RFC3339)
+	}
+	return value
+}
@@ -1,8 +1,11 @@
 package service
 
 import (
+	"errors"
+	"sistempelaporan/app/policy"
 	"sistempelaporan/app/repository"
 	"sistempelaporan/helper"
+	"sistempelaporan/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -10,15 +13,26 @@ import (
 
 // GetStudents godoc
 // @Summary      Dapatkan semua mahasiswa
-// @Description  Mengambil daftar lengkap mahasiswa dari database PostgreSQL
+// @Description  Mengambil daftar mahasiswa dari database PostgreSQL. Admin melihat semua mahasiswa,
+// @Description  operator fakultas / departemen / prodi hanya mahasiswa di unitnya.
 // @Tags         Students
 // @Produce      json
 // @Success      200  {object}  helper.Response
+// @Failure      403  {object}  helper.Response
 // @Failure      500  {object}  helper.Response
 // @Router       /students [get]
 // @Security     BearerAuth
 func GetStudents(c *fiber.Ctx) error {
-	data, err := repository.GetAllStudents()
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+	scope, ok := policy.ListScope(subject, policy.ResourceStudent, "")
+	if !ok {
+		return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: tidak memiliki akses ke daftar mahasiswa", nil)
+	}
+
+	data, err := repository.GetAllStudents(scope)
 	if err != nil {
 		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data mahasiswa", err.Error())
 	}
@@ -68,7 +82,8 @@ func GetStudentAchievements(c *fiber.Ctx) error {
 
 // UpdateStudentAdvisor godoc
 // @Summary      Update Dosen Wali
-// @Description  Mengatur atau mengganti ID Dosen Wali untuk mahasiswa tertentu
+// @Description  Mengatur atau mengganti ID Dosen Wali untuk mahasiswa tertentu.
+// @Description  Operator unit hanya boleh mengatur mahasiswa dan dosen di dalam unitnya.
 // @Tags         Students
 // @Accept       json
 // @Produce      json
@@ -86,6 +101,31 @@ func UpdateStudentAdvisor(c *fiber.Ctx) error {
 		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
 	}
 
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+	student, err := repository.StudentPolicyResource(studentID)
+	if errors.Is(err, repository.ErrResourceNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Mahasiswa tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+	if decision := policy.Can(subject, policy.ActionManage, student); !decision.Allowed {
+		return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: "+decision.Reason, nil)
+	}
+	lecturer, err := repository.LecturerPolicyResource(req.AdvisorID)
+	if errors.Is(err, repository.ErrResourceNotFound) {
+		return helper.Error(c, fiber.StatusBadRequest, "Dosen wali tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+	if decision := policy.Can(subject, policy.ActionRead, lecturer); !decision.Allowed {
+		return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: dosen wali berada di luar unit Anda", nil)
+	}
+
 	if err := repository.AssignAdvisorToStudent(studentID, req.AdvisorID); err != nil {
 		return helper.Error(c, fiber.StatusInternalServerError, "Gagal update dosen wali", err.Error())
 	}
@@ -96,12 +136,22 @@ func UpdateStudentAdvisor(c *fiber.Ctx) error {
 
 // GetLecturers godoc
 // @Summary      Dapatkan semua dosen
+// @Description  Admin melihat semua dosen, operator fakultas / departemen hanya dosen di unitnya.
 // @Tags         Lecturers
 // @Produce      json
 // @Router       /lecturers [get]
 // @Security     BearerAuth
 func GetLecturers(c *fiber.Ctx) error {
-	data, err := repository.GetAllLecturers()
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+	scope, ok := policy.ListScope(subject, policy.ResourceLecturer, "user:read_all")
+	if !ok {
+		return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: tidak memiliki akses ke daftar dosen", nil)
+	}
+
+	data, err := repository.GetAllLecturers(scope)
 	if err != nil {
 		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data dosen", err.Error())
 	}
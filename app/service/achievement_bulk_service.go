@@ -0,0 +1,109 @@
+package service
+
+import (
+	"strings"
+
+	"sistempelaporan/app/bulk"
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Batas jumlah prestasi per permintaan aksi massal
+const maxBulkItems = 100
+
+// parseBulkRequest menggabungkan ids dan items menjadi daftar item unik (urutan dipertahankan).
+// Jika ok = false, respon error sudah ditulis.
+func parseBulkRequest(c *fiber.Ctx) ([]model.BulkActionItem, bool, error) {
+	var req model.BulkActionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, false, helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	items := make([]model.BulkActionItem, 0, len(req.IDs)+len(req.Items))
+	for _, id := range req.IDs {
+		items = append(items, model.BulkActionItem{ID: id})
+	}
+	items = append(items, req.Items...)
+
+	seen := map[string]bool{}
+	unique := items[:0]
+	for _, item := range items {
+		item.ID = strings.TrimSpace(item.ID)
+		if item.ID == "" || seen[item.ID] {
+			continue
+		}
+		seen[item.ID] = true
+		if strings.TrimSpace(item.Note) == "" {
+			item.Note = req.Note
+		}
+		unique = append(unique, item)
+	}
+
+	if len(unique) == 0 {
+		return nil, false, helper.Error(c, fiber.StatusBadRequest, "Daftar prestasi wajib diisi", map[string]string{"ids": "wajib diisi"})
+	}
+	if len(unique) > maxBulkItems {
+		return nil, false, helper.Error(c, fiber.StatusBadRequest, "Terlalu banyak prestasi dalam satu permintaan",
+			map[string]string{"ids": "maksimal 100 prestasi"})
+	}
+	return unique, true, nil
+}
+
+// runBulk menjalankan aksi satuan untuk setiap item lewat bulk.Run, didahului pemeriksaan akses baca
+// yang setara dengan AuthorizeResource("student_read") pada route satuan.
+func runBulk(c *fiber.Ctx, items []model.BulkActionItem, action func(item model.BulkActionItem) error) error {
+	return bulk.Run(c, items, func(item model.BulkActionItem) error {
+		if ok, err := authorizeAchievement(c, item.ID, policy.ActionRead); !ok {
+			return err
+		}
+		return action(item)
+	})
+}
+
+// BulkVerifyAchievements godoc
+// @Summary      Setujui Banyak Prestasi
+// @Description  Menjalankan persetujuan verifikasi (sama seperti /achievements/{id}/verify) untuk setiap prestasi,
+// @Description  maksimal 100 per permintaan. Setiap item diperiksa otorisasi, tahap dan statusnya sendiri; hasil per
+// @Description  item ada di results sehingga kegagalan sebagian tidak membatalkan item lain.
+// @Tags         Achievements
+// @Accept       json
+// @Produce      json
+// @Param        body  body      model.BulkActionRequest  true  "ids / items dan catatan bersama"
+// @Success      200   {object}  helper.Response{data=model.BulkActionSummary}
+// @Failure      400   {object}  helper.Response
+// @Router       /achievements/bulk/verify [post]
+// @Security     BearerAuth
+func BulkVerifyAchievements(c *fiber.Ctx) error {
+	items, ok, err := parseBulkRequest(c)
+	if !ok {
+		return err
+	}
+	return runBulk(c, items, func(item model.BulkActionItem) error {
+		return verifyAchievement(c, item.ID, model.VerifyRequest{Revision: item.Revision, Note: item.Note})
+	})
+}
+
+// BulkRejectAchievements godoc
+// @Summary      Tolak Banyak Prestasi
+// @Description  Menolak setiap prestasi (sama seperti /achievements/{id}/reject), maksimal 100 per permintaan.
+// @Description  Catatan penolakan wajib: note bersama atau note per item. Hasil per item ada di results.
+// @Tags         Achievements
+// @Accept       json
+// @Produce      json
+// @Param        body  body      model.BulkActionRequest  true  "ids / items dan catatan penolakan"
+// @Success      200   {object}  helper.Response{data=model.BulkActionSummary}
+// @Failure      400   {object}  helper.Response
+// @Router       /achievements/bulk/reject [post]
+// @Security     BearerAuth
+func BulkRejectAchievements(c *fiber.Ctx) error {
+	items, ok, err := parseBulkRequest(c)
+	if !ok {
+		return err
+	}
+	return runBulk(c, items, func(item model.BulkActionItem) error {
+		return rejectAchievement(c, item.ID, item.Note)
+	})
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"sistempelaporan/app/comment"
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/helper"
+	"sistempelaporan/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// commentAccess memastikan subject boleh membaca prestasi lalu menentukan apakah ia reviewer
+// (boleh melihat catatan internal). Jika ok = false, respon error sudah ditulis.
+func commentAccess(c *fiber.Ctx, achievementID string) (policy.Subject, bool, bool, error) {
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return subject, false, false, helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+
+	resource, err := repository.AchievementPolicyResource(achievementID)
+	if errors.Is(err, repository.ErrResourceNotFound) {
+		return subject, false, false, helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", nil)
+	}
+	if err != nil {
+		return subject, false, false, helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+
+	if decision := policy.Can(subject, policy.ActionRead, resource); !decision.Allowed {
+		return subject, false, false, helper.Error(c, fiber.StatusForbidden, "Akses ditolak: "+decision.Reason, nil)
+	}
+	return subject, comment.IsReviewer(subject, resource), true, nil
+}
+
+// GetAchievementComments godoc
+// @Summary      Daftar Komentar Review Prestasi
+// @Description  Komentar dikelompokkan per thread (balasan di replies), urut waktu. Catatan internal hanya
+// @Description  ditampilkan untuk reviewer (pihak yang boleh memverifikasi prestasi).
+// @Tags         Achievement Comments
+// @Produce      json
+// @Param        id        path      string  true   "Achievement ID"
+// @Param        resolved  query     bool    false  "Filter thread selesai (true) / terbuka (false)"
+// @Success      200       {object}  helper.Response{data=[]model.AchievementComment}
+// @Router       /achievements/{id}/comments [get]
+// @Security     BearerAuth
+func GetAchievementComments(c *fiber.Ctx) error {
+	achievementID := c.Params("id")
+
+	_, reviewer, ok, err := commentAccess(c, achievementID)
+	if !ok {
+		return err
+	}
+
+	comments, err := repository.ListComments(achievementID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil komentar", err.Error())
+	}
+	threads := comment.Threads(comments, reviewer)
+
+	if resolved := c.Query("resolved"); resolved != "" {
+		wantResolved := resolved == "true"
+		filtered := []model.AchievementComment{}
+		for _, t := range threads {
+			if (t.ResolvedAt != nil) == wantResolved {
+				filtered = append(filtered, t)
+			}
+		}
+		threads = filtered
+	}
+	return helper.Success(c, threads, "Daftar komentar prestasi")
+}
+
+// CreateAchievementComment godoc
+// @Summary      Tulis Komentar Review
+// @Description  Tanpa parent_id membuka thread baru, opsional dengan anchor ke field (anchor_type=field, misal
+// @Description  anchor_ref=details.rank) atau lampiran (anchor_type=attachment, anchor_ref=fileUrl). Dengan parent_id
+// @Description  menjadi balasan thread tersebut dan mengikuti visibilitasnya. visibility=internal hanya untuk reviewer.
+// @Tags         Achievement Comments
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                true  "Achievement ID"
+// @Param        body  body      model.CommentRequest  true  "Komentar"
+// @Success      201   {object}  helper.Response{data=model.AchievementComment}
+// @Failure      400   {object}  helper.Response
+// @Failure      403   {object}  helper.Response
+// @Router       /achievements/{id}/comments [post]
+// @Security     BearerAuth
+func CreateAchievementComment(c *fiber.Ctx) error {
+	achievementID := c.Params("id")
+
+	var req model.CommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		return helper.Error(c, fiber.StatusBadRequest, "Data komentar tidak valid", map[string]string{"body": "wajib diisi"})
+	}
+	if utf8.RuneCountInString(req.Body) > comment.MaxBodyLength {
+		return helper.Error(c, fiber.StatusBadRequest, "Data komentar tidak valid", map[string]string{"body": "terlalu panjang"})
+	}
+
+	ach, err := repository.FindAchievementByID(achievementID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", nil)
+	}
+	_, reviewer, ok, err := commentAccess(c, achievementID)
+	if !ok {
+		return err
+	}
+
+	newComment := &model.AchievementComment{
+		AchievementID: ach.ID,
+		AuthorID:      currentUserID(c),
+		Body:          req.Body,
+	}
+	newComment.AuthorRole, _ = c.Locals("role").(string)
+
+	if req.ParentID != "" {
+		// Balasan selalu menempel ke komentar pembuka thread dan mengikuti visibilitasnya
+		if req.AnchorType != "" || req.AnchorRef != "" {
+			return helper.Error(c, fiber.StatusBadRequest, "Data komentar tidak valid",
+				map[string]string{"anchor_type": "anchor hanya untuk komentar pembuka thread"})
+		}
+		thread, err := repository.FindComment(achievementID, req.ParentID)
+		if err == nil && thread.ParentID != nil {
+			thread, err = repository.FindComment(achievementID, thread.ParentID.String())
+		}
+		if errors.Is(err, repository.ErrCommentNotFound) || (err == nil && !comment.Visible(*thread, reviewer)) {
+			return helper.Error(c, fiber.StatusNotFound, "Thread komentar tidak ditemukan", nil)
+		}
+		if err != nil {
+			return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil komentar", err.Error())
+		}
+		newComment.ParentID = &thread.ID
+		newComment.Visibility = thread.Visibility
+	} else {
+		newComment.Visibility = strings.ToLower(strings.TrimSpace(req.Visibility))
+		if newComment.Visibility == "" {
+			newComment.Visibility = model.CommentPublic
+		}
+		if newComment.Visibility != model.CommentPublic && newComment.Visibility != model.CommentInternal {
+			return helper.Error(c, fiber.StatusBadRequest, "Data komentar tidak valid",
+				map[string]string{"visibility": "harus public atau internal"})
+		}
+		if newComment.Visibility == model.CommentInternal && !reviewer {
+			return helper.Error(c, fiber.StatusForbidden, "Catatan internal hanya untuk reviewer prestasi", nil)
+		}
+
+		newComment.AnchorType = strings.ToLower(strings.TrimSpace(req.AnchorType))
+		newComment.AnchorRef = strings.TrimSpace(req.AnchorRef)
+		if newComment.AnchorType != "" || newComment.AnchorRef != "" {
+			detail, err := repository.GetAchievementDetailFromMongo(ach.MongoAchievementID)
+			if err != nil {
+				return helper.Error(c, fiber.StatusNotFound, "Detail prestasi tidak ditemukan", nil)
+			}
+			if errs := comment.ValidateAnchor(newComment.AnchorType, newComment.AnchorRef, detail.Attachments); len(errs) > 0 {
+				return helper.Error(c, fiber.StatusBadRequest, "Data komentar tidak valid", errs)
+			}
+		}
+	}
+
+	// Komentar dicatat terhadap revisi konten yang sedang dilihat
+	revision, err := repository.CurrentRevision(ach.MongoAchievementID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil revisi prestasi", err.Error())
+	}
+	newComment.Revision = &revision
+
+	if err := repository.CreateComment(newComment); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan komentar", err.Error())
+	}
+	return helper.Created(c, newComment, "Komentar berhasil ditambahkan")
+}
+
+// ResolveAchievementComment godoc
+// @Summary      Tandai Thread Komentar Selesai
+// @Description  Hanya komentar pembuka thread. Boleh dilakukan reviewer atau pembuka thread.
+// @Tags         Achievement Comments
+// @Param        id         path      string  true  "Achievement ID"
+// @Param        commentId  path      string  true  "Comment ID (pembuka thread)"
+// @Success      200        {object}  helper.Response{data=model.AchievementComment}
+// @Router       /achievements/{id}/comments/{commentId}/resolve [post]
+// @Security     BearerAuth
+func ResolveAchievementComment(c *fiber.Ctx) error {
+	return setCommentResolved(c, true)
+}
+
+// UnresolveAchievementComment godoc
+// @Summary      Buka Kembali Thread Komentar
+// @Description  Membatalkan tanda selesai thread. Boleh dilakukan reviewer atau pembuka thread.
+// @Tags         Achievement Comments
+// @Param        id         path      string  true  "Achievement ID"
+// @Param        commentId  path      string  true  "Comment ID (pembuka thread)"
+// @Success      200        {object}  helper.Response{data=model.AchievementComment}
+// @Router       /achievements/{id}/comments/{commentId}/unresolve [post]
+// @Security     BearerAuth
+func UnresolveAchievementComment(c *fiber.Ctx) error {
+	return setCommentResolved(c, false)
+}
+
+func setCommentResolved(c *fiber.Ctx, resolved bool) error {
+	achievementID := c.Params("id")
+
+	subject, reviewer, ok, err := commentAccess(c, achievementID)
+	if !ok {
+		return err
+	}
+
+	thread, err := repository.FindComment(achievementID, c.Params("commentId"))
+	if errors.Is(err, repository.ErrCommentNotFound) || (err == nil && !comment.Visible(*thread, reviewer)) {
+		return helper.Error(c, fiber.StatusNotFound, "Komentar tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil komentar", err.Error())
+	}
+	if thread.ParentID != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Hanya komentar pembuka thread yang bisa ditandai selesai", nil)
+	}
+	if !comment.CanResolve(*thread, subject.UserID, reviewer) {
+		return helper.Error(c, fiber.StatusForbidden, "Hanya reviewer atau pembuka thread yang bisa mengubah status thread", nil)
+	}
+
+	var actor *uuid.UUID
+	if resolved {
+		actor = currentUserID(c)
+	}
+	if err := repository.SetCommentResolved(thread, resolved, actor); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengubah status thread", err.Error())
+	}
+
+	message := "Thread komentar dibuka kembali"
+	if resolved {
+		message = "Thread komentar ditandai selesai"
+	}
+	return helper.Success(c, thread, message)
+}
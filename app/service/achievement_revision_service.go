@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/app/revision"
+	"sistempelaporan/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// checkApprovedRevision memastikan persetujuan berlaku untuk revisi konten yang diajukan (revision nil =
+// tidak disebut verifikator). Prestasi yang diajukan sebelum revisi dicatat dipatok ke revisinya saat ini.
+// Jika ok = false, respon error sudah ditulis.
+func checkApprovedRevision(c *fiber.Ctx, ach *model.AchievementReference, revision *int) (bool, error) {
+	if ach.SubmittedRevision == nil {
+		current, err := repository.CurrentRevision(ach.MongoAchievementID)
+		if err != nil {
+			return false, helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil revisi prestasi", err.Error())
+		}
+		ach.SubmittedRevision = &current
+	}
+
+	if revision != nil && *revision != *ach.SubmittedRevision {
+		return false, helper.Error(c, fiber.StatusConflict, "Revisi yang diperiksa bukan revisi yang diajukan",
+			fiber.Map{"revision": *revision, "submitted_revision": *ach.SubmittedRevision})
+	}
+	return true, nil
+}
+
+// GetAchievementRevisions godoc
+// @Summary      Daftar Revisi Konten Prestasi
+// @Description  Setiap pembuatan, edit dan unggah lampiran menyimpan snapshot konten prestasi sebagai revisi immutable.
+// @Description  Daftar ini tanpa snapshot; gunakan endpoint diff untuk melihat perubahan tiap revisi.
+// @Tags         Achievements
+// @Produce      json
+// @Param        id     path      string  true   "Achievement ID"
+// @Param        page   query     int     false  "Halaman (default 1)"
+// @Param        limit  query     int     false  "Jumlah per halaman (default 20, maks 100)"
+// @Success      200    {object}  helper.Response{data=[]model.AchievementRevision}
+// @Router       /achievements/{id}/revisions [get]
+// @Security     BearerAuth
+func GetAchievementRevisions(c *fiber.Ctx) error {
+	achievementID := c.Params("id")
+
+	ach, err := repository.FindAchievementByID(achievementID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", nil)
+	}
+	if ok, err := authorizeAchievement(c, achievementID, policy.ActionRead); !ok {
+		return err
+	}
+
+	page, limit := helper.Pagination(c)
+
+	revisions, total, err := repository.ListRevisions(ach.MongoAchievementID, limit, (page-1)*limit)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil revisi prestasi", err.Error())
+	}
+
+	return helper.SuccessWithMeta(c, revisions, helper.PageMeta(page, limit, total), "Daftar revisi prestasi")
+}
+
+// GetAchievementRevisionDiff godoc
+// @Summary      Diff Revisi Prestasi
+// @Description  Perubahan per field (title, description, details, tags, attachments) dari revisi pembanding ke revisi :rev.
+// @Description  Default pembandingnya revisi sebelumnya; revisi pertama dibandingkan dengan konten kosong.
+// @Tags         Achievements
+// @Produce      json
+// @Param        id       path      string  true   "Achievement ID"
+// @Param        rev      path      int     true   "Nomor revisi"
+// @Param        against  query     int     false  "Nomor revisi pembanding (misal revisi yang terakhir diajukan)"
+// @Success      200      {object}  helper.Response{data=model.RevisionDiff}
+// @Failure      404      {object}  helper.Response
+// @Router       /achievements/{id}/revisions/{rev}/diff [get]
+// @Security     BearerAuth
+func GetAchievementRevisionDiff(c *fiber.Ctx) error {
+	achievementID := c.Params("id")
+
+	rev, err := strconv.Atoi(c.Params("rev"))
+	if err != nil || rev < 0 {
+		return helper.Error(c, fiber.StatusBadRequest, "Nomor revisi tidak valid", nil)
+	}
+
+	ach, err := repository.FindAchievementByID(achievementID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", nil)
+	}
+	if ok, err := authorizeAchievement(c, achievementID, policy.ActionRead); !ok {
+		return err
+	}
+
+	target, err := repository.FindRevision(ach.MongoAchievementID, rev)
+	if errors.Is(err, repository.ErrRevisionNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Revisi tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil revisi prestasi", err.Error())
+	}
+
+	var base *model.AchievementRevision
+	if against := c.Query("against"); against != "" {
+		n, convErr := strconv.Atoi(against)
+		if convErr != nil {
+			return helper.Error(c, fiber.StatusBadRequest, "Nomor revisi pembanding tidak valid", nil)
+		}
+		base, err = repository.FindRevision(ach.MongoAchievementID, n)
+	} else {
+		base, err = repository.FindPreviousRevision(ach.MongoAchievementID, rev)
+		if errors.Is(err, repository.ErrRevisionNotFound) {
+			base, err = nil, nil
+		}
+	}
+	if errors.Is(err, repository.ErrRevisionNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Revisi pembanding tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil revisi prestasi", err.Error())
+	}
+
+	diff := model.RevisionDiff{Revision: target.Revision}
+	var old model.AchievementSnapshot
+	if base != nil {
+		diff.Against = &base.Revision
+		old = *base.Snapshot
+	}
+	diff.Changes = revision.Diff(old, *target.Snapshot)
+	return helper.Success(c, diff, "Diff revisi prestasi")
+}
@@ -1,15 +1,20 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
 	"sistempelaporan/app/repository"
+	"sistempelaporan/app/workflow"
 	"sistempelaporan/helper"
+	"sistempelaporan/middleware"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -17,7 +22,9 @@ import (
 
 // SubmitAchievement godoc
 // @Summary      Submit Prestasi Baru
-// @Description  Mahasiswa atau Admin dapat menambahkan laporan prestasi baru ke dalam sistem.
+// @Description  Mahasiswa atau Admin dapat menambahkan laporan prestasi baru ke dalam sistem. achievementType harus
+// @Description  tipe prestasi aktif (lihat /achievement-types); details divalidasi dengan JSON Schema tipe tersebut
+// @Description  dan kesalahan per field dikembalikan di errors.
 // @Tags         Achievements
 // @Accept       json
 // @Produce      json
@@ -27,33 +34,44 @@ import (
 // @Router       /achievements [post]
 // @Security     BearerAuth
 func SubmitAchievement(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
-	role := c.Locals("role").(string)
-
 	var req model.AchievementMongo
 	if err := c.BodyParser(&req); err != nil {
 		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", err.Error())
 	}
 
-	var targetStudentID uuid.UUID
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
 
-	if strings.EqualFold(role, "Admin") {
+	// Mahasiswa selalu membuat prestasi untuk dirinya sendiri; pihak lain wajib menyebut mahasiswa target
+	targetID := subject.StudentID
+	if targetID == "" {
 		if req.StudentID == "" {
-			return helper.Error(c, fiber.StatusBadRequest, "Admin wajib menyertakan 'studentId' Mahasiswa target di body request.", nil)
+			return helper.Error(c, fiber.StatusBadRequest, "Wajib menyertakan 'studentId' Mahasiswa target di body request.", nil)
 		}
-		studentUUID, err := uuid.Parse(req.StudentID)
-		if err != nil {
+		if _, err := uuid.Parse(req.StudentID); err != nil {
 			return helper.Error(c, fiber.StatusBadRequest, "Format Student ID target tidak valid.", nil)
 		}
-		targetStudentID = studentUUID
-	} else if strings.EqualFold(role, "Mahasiswa") {
-		student, err := repository.FindStudentByUserID(userID)
-		if err != nil || student.ID == uuid.Nil {
-			return helper.Error(c, fiber.StatusForbidden, "Profil mahasiswa tidak ditemukan atau terhubung.", nil)
-		}
-		targetStudentID = student.ID
-	} else {
-		return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: Hanya Mahasiswa dan Admin yang dapat membuat prestasi.", nil)
+		targetID = req.StudentID
+	}
+
+	student, err := repository.StudentPolicyResource(targetID)
+	if errors.Is(err, repository.ErrResourceNotFound) {
+		return helper.Error(c, fiber.StatusBadRequest, "Mahasiswa target tidak ditemukan.", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+	student.Kind = policy.ResourceAchievement
+	if decision := policy.Can(subject, policy.ActionCreate, student); !decision.Allowed {
+		return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: "+decision.Reason, nil)
+	}
+	targetStudentID, _ := uuid.Parse(targetID)
+
+	// Validasi details terhadap JSON Schema tipe prestasi
+	if _, ok, err := validateAchievementInput(c, &req); !ok {
+		return err
 	}
 
 	refID := uuid.New()
@@ -69,7 +87,7 @@ func SubmitAchievement(c *fiber.Ctx) error {
 	req.CreatedAt = now
 	req.UpdatedAt = now
 
-	if err := repository.CreateAchievement(&ref, &req); err != nil {
+	if err := repository.CreateAchievement(&ref, &req, newStatusEvent(c, nil, "")); err != nil {
 		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan prestasi", err.Error())
 	}
 
@@ -83,14 +101,11 @@ func SubmitAchievement(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        page    query     int     false  "Halaman"
 // @Param        limit   query     int     false  "Jumlah per halaman"
-// @Param        status  query     string  false  "Filter Status (draft, submitted, verified, rejected)"
+// @Param        status  query     string  false  "Filter Status (draft, submitted, in_review, verified, rejected, revision_requested, withdrawn, archived)"
 // @Success      200     {object}  helper.Response
 // @Router       /achievements [get]
 // @Security     BearerAuth
 func GetListAchievements(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
-	roleName := c.Locals("role").(string)
-
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
 	filter := model.AchievementFilter{
@@ -101,19 +116,15 @@ func GetListAchievements(c *fiber.Ctx) error {
 
 	repoFilter := repository.RepoFilter{AchievementFilter: filter}
 
-	if roleName == "Mahasiswa" {
-		student, err := repository.FindStudentByUserID(userID)
-		if err != nil {
-			return helper.Error(c, fiber.StatusForbidden, "Profil mahasiswa invalid", err.Error())
-		}
-		repoFilter.StudentID = student.ID.String()
-	} else if roleName == "Dosen Wali" {
-		lecturer, err := repository.FindLecturerByUserID(userID)
-		if err != nil {
-			return helper.Error(c, fiber.StatusForbidden, "Profil dosen invalid", err.Error())
-		}
-		repoFilter.AdvisorID = lecturer.ID.String()
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+	scope, ok := policy.ListScope(subject, policy.ResourceAchievement, "achievement:read")
+	if !ok {
+		return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: akun ini tidak terhubung dengan data mahasiswa mana pun", nil)
 	}
+	repoFilter.Scope = scope
 
 	data, total, err := repository.GetAllAchievements(repoFilter)
 	if err != nil {
@@ -160,18 +171,19 @@ func GetAchievementDetail(c *fiber.Ctx) error {
 
 // UpdateAchievement godoc
 // @Summary      Update Konten Prestasi
-// @Description  Memperbarui data prestasi yang masih berstatus 'draft'.
+// @Description  Memperbarui data prestasi yang berstatus 'draft', 'revision_requested' atau 'rejected'.
+// @Description  Hasil perubahan divalidasi dengan JSON Schema tipe prestasi; kesalahan per field dikembalikan di errors.
+// @Description  Field points diabaikan; poin hanya dihitung oleh aturan poin saat verifikasi.
 // @Tags         Achievements
 // @Accept       json
 // @Produce      json
 // @Param        id    path      string                  true  "Achievement ID"
 // @Param        body  body      model.AchievementMongo  true  "Data Update"
 // @Success      200   {object}  helper.Response
+// @Failure      409   {object}  helper.Response
 // @Router       /achievements/{id} [put]
 // @Security     BearerAuth
 func UpdateAchievement(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
-	role := c.Locals("role").(string)
 	achievementID := c.Params("id")
 
 	var newData model.AchievementMongo
@@ -184,29 +196,45 @@ func UpdateAchievement(c *fiber.Ctx) error {
 		return helper.Error(c, fiber.StatusNotFound, "Data prestasi tidak ditemukan", nil)
 	}
 
-	isAuthorized := false
-	if strings.EqualFold(role, "Admin") {
-		isAuthorized = true
-	} else {
-		student, findErr := repository.FindStudentByUserID(userID)
-		if findErr == nil && student.ID != uuid.Nil && ach.StudentID == student.ID {
-			isAuthorized = true
-		}
+	if ok, err := authorizeAchievement(c, achievementID, policy.ActionUpdate); !ok {
+		return err
 	}
 
-	if !isAuthorized {
-		return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: Anda tidak memiliki hak edit resource ini.", nil)
+	if !workflow.Editable(ach.Status) {
+		return helper.Error(c, fiber.StatusBadRequest, "Prestasi hanya dapat diedit saat berstatus draft, revision_requested atau rejected.", nil)
 	}
 
-	if ach.Status != model.StatusDraft {
-		return helper.Error(c, fiber.StatusBadRequest, "Prestasi hanya dapat diedit saat berstatus Draft.", nil)
+	// Validasi hasil akhir (data lama + perubahan) terhadap schema tipe prestasi
+	current, err := repository.GetAchievementDetailFromMongo(ach.MongoAchievementID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "Detail prestasi tidak ditemukan", nil)
+	}
+	merged := *current
+	if newData.AchievementType != "" {
+		merged.AchievementType = newData.AchievementType
+	}
+	if newData.CompetitionTier != "" {
+		merged.CompetitionTier = newData.CompetitionTier
+	}
+	if len(newData.Details) > 0 {
+		merged.Details = newData.Details
+	}
+	if _, ok, err := validateAchievementInput(c, &merged); !ok {
+		return err
+	}
+	if newData.AchievementType != "" {
+		newData.AchievementType = merged.AchievementType
 	}
 
-	if err := repository.UpdateAchievementDetail(ach.MongoAchievementID, newData); err != nil {
+	revision, err := repository.UpdateAchievementDetail(ach.MongoAchievementID, newData, currentUserID(c))
+	if errors.Is(err, repository.ErrRevisionConflict) {
+		return helper.Error(c, fiber.StatusConflict, "Prestasi sedang diubah bersamaan, silakan coba lagi", err.Error())
+	}
+	if err != nil {
 		return helper.Error(c, fiber.StatusInternalServerError, "Gagal update data", err.Error())
 	}
 
-	return helper.Success(c, nil, "Data berhasil diperbarui")
+	return helper.Success(c, fiber.Map{"revision": revision}, "Data berhasil diperbarui")
 }
 
 // DeleteAchievement godoc
@@ -218,11 +246,6 @@ func UpdateAchievement(c *fiber.Ctx) error {
 // @Router       /achievements/{id} [delete]
 // @Security     BearerAuth
 func DeleteAchievement(c *fiber.Ctx) error {
-    // Ambil data dari Locals (Middleware)
-    userID := c.Locals("user_id").(string)
-    roleString := c.Locals("role").(string)
-    userRole := strings.ToUpper(roleString)
-    
     achievementID := c.Params("id")
 
     // Cari datanya dulu
@@ -231,23 +254,15 @@ func DeleteAchievement(c *fiber.Ctx) error {
         return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", nil)
     }
 
-    // LOGIKA OTORISASI
-    // 1. Ambil StudentID milik user yang sedang login
-    mhsID, _ := repository.GetStudentIDByUserID(userID)
-    
-    // 2. Cek apakah dia Admin atau Pemilik data
-    isOwner := (ach.StudentID.String() == mhsID)
-    isAdmin := (userRole == "ADMIN")
-
-    if !isAdmin && !isOwner {
-        return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: Anda tidak memiliki wewenang.", nil)
+    // LOGIKA OTORISASI: Admin atau pemilik data (lihat package policy)
+    if ok, err := authorizeAchievement(c, achievementID, policy.ActionDelete); !ok {
+        return err
     }
 
-    // 3. (Opsional) Mahasiswa tidak boleh hapus jika sudah diverifikasi
-   // Pastikan membandingkan dengan nilai string "draft"
-if strings.ToLower(string(ach.Status)) != "draft" {
-    return helper.Error(c, fiber.StatusForbidden, "Hanya data berstatus DRAFT yang boleh dihapus.", nil)
-}
+    // 3. Prestasi yang sedang / sudah diproses verifikasi tidak boleh dihapus
+    if !workflow.Deletable(ach.Status) {
+        return helper.Error(c, fiber.StatusForbidden, "Hanya data berstatus draft atau withdrawn yang boleh dihapus.", nil)
+    }
     // Jalankan penghapusan
     if err := repository.SoftDeleteAchievementTransaction(achievementID, ach.MongoAchievementID); err != nil {
         return helper.Error(c, fiber.StatusInternalServerError, "Gagal menghapus data", err.Error())
@@ -258,15 +273,14 @@ if strings.ToLower(string(ach.Status)) != "draft" {
 
 // RequestVerification godoc
 // @Summary      Ajukan Verifikasi
-// @Description  Mengubah status prestasi dari 'draft' menjadi 'submitted' untuk diperiksa Dosen Wali.
+// @Description  Mengubah status prestasi dari 'draft', 'revision_requested' atau 'rejected' menjadi 'submitted'
+// @Description  untuk diperiksa Dosen Wali. Semua kategori lampiran wajib tipe prestasi harus sudah diunggah.
 // @Tags         Achievements
 // @Param        id   path      string  true  "Achievement ID"
 // @Success      200  {object}  helper.Response
 // @Router       /achievements/{id}/submit [post]
 // @Security     BearerAuth
 func RequestVerification(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
-	role := c.Locals("role").(string)
 	achievementID := c.Params("id")
 
 	ach, err := repository.FindAchievementByID(achievementID)
@@ -274,82 +288,198 @@ func RequestVerification(c *fiber.Ctx) error {
 		return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", err.Error())
 	}
 
-	if ach.Status != model.StatusDraft {
-		return helper.Error(c, fiber.StatusBadRequest, "Hanya status draft yang bisa diajukan", nil)
+	if ok, err := authorizeAchievement(c, achievementID, policy.ActionSubmit); !ok {
+		return err
 	}
-
-	isAuthorized := false
-	if strings.EqualFold(role, "Admin") {
-		isAuthorized = true
-	} else if strings.EqualFold(role, "Mahasiswa") {
-		student, findErr := repository.FindStudentByUserID(userID)
-		if findErr == nil && student.ID != uuid.Nil && ach.StudentID == student.ID {
-			isAuthorized = true
-		}
+	// Lampiran wajib tipe prestasi harus lengkap sebelum diperiksa verifikator
+	if ok, err := requireAttachments(c, ach); !ok {
+		return err
 	}
 
-	if !isAuthorized {
-		return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: Anda bukan pemilik prestasi ini.", nil)
+	// Patok revisi konten yang diajukan; revisi inilah yang diperiksa dan disetujui verifikator
+	revision, err := repository.CurrentRevision(ach.MongoAchievementID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil revisi prestasi", err.Error())
 	}
+	ach.SubmittedRevision = &revision
 
-	if err := repository.UpdateStatus(achievementID, model.StatusSubmitted, nil, ""); err != nil {
-		return helper.Error(c, fiber.StatusInternalServerError, "Gagal update status", err.Error())
+	if ok, err := transitionAchievement(c, ach, policy.ActionSubmit, workflow.EventSubmit, ""); !ok {
+		return err
 	}
 
-	return helper.Success(c, nil, "Berhasil diajukan untuk verifikasi")
+	return helper.Success(c, fiber.Map{"submitted_revision": revision}, "Berhasil diajukan untuk verifikasi")
 }
 
 // VerifyAchievement godoc
 // @Summary      Setujui Prestasi
-// @Description  Dosen Wali menyetujui prestasi mahasiswa bimbingannya.
+// @Description  Menyetujui tahap verifikasi yang sedang berjalan. Pipeline verifikasi dipilih dari tingkat kompetisi
+// @Description  dan tipe prestasi (default: satu tahap oleh Dosen Wali). Tahap selesai setelah quorum penyetujunya
+// @Description  tercapai; tahap non-terakhir memindahkan prestasi ke 'in_review', tahap terakhir ke 'verified'
+// @Description  dan baru saat itu poin dihitung dari aturan poin (rinciannya di /achievements/{id}/points).
+// @Description  Yang disetujui adalah revisi konten yang diajukan (submitted_revision); jika body menyebut revision
+// @Description  lain (verifikator melihat konten yang berbeda), persetujuan ditolak dengan 409.
 // @Tags         Achievements
-// @Param        id   path      string  true  "Achievement ID"
+// @Accept       json
+// @Param        id    path      string                       true   "Achievement ID"
+// @Param        body  body      model.VerifyRequest  false  "Revisi yang diperiksa verifikator dan catatan"
 // @Success      200  {object}  helper.Response
+// @Failure      403  {object}  helper.Response
+// @Failure      409  {object}  helper.Response
 // @Router       /achievements/{id}/verify [post]
 // @Security     BearerAuth
 func VerifyAchievement(c *fiber.Ctx) error {
-    lecturerUserID := c.Locals("user_id").(string)
-    achievementID := c.Params("id")
+    var req model.VerifyRequest
+    if len(c.Body()) > 0 {
+        if err := c.BodyParser(&req); err != nil {
+            return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+        }
+    }
+    return verifyAchievement(c, c.Params("id"), req)
+}
 
+// verifyAchievement menjalankan satu persetujuan verifikasi dan menulis responnya (dipakai juga oleh bulk verify).
+func verifyAchievement(c *fiber.Ctx, achievementID string, req model.VerifyRequest) error {
     // 1. Cari referensi prestasi
     ach, err := repository.FindAchievementByID(achievementID)
     if err != nil {
         return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", err.Error())
     }
 
-    // 2. Ambil detail dari MongoDB untuk menghitung poin
+    decision, ok, err := achievementDecision(c, achievementID, policy.ActionVerify)
+    if !ok {
+        return err
+    }
+
+    // 2. Ambil detail dari MongoDB (pemilihan pipeline & perhitungan poin)
     detail, err := repository.GetAchievementDetailFromMongo(ach.MongoAchievementID)
     if err != nil {
          return helper.Error(c, fiber.StatusNotFound, "Detail prestasi tidak ditemukan", nil)
     }
 
-    // 3. LOGIKA POIN: Hitung poin berdasarkan tingkat kompetisi (CompetitionTier)
-    // Misal: Internasional = 100, Nasional = 50, dst.
-    finalPoints := 0
-    switch strings.ToLower(detail.CompetitionTier) {
-    case "internasional":
-        finalPoints = 100
-    case "nasional":
-        finalPoints = 50
-    default:
-        finalPoints = 10
+    // Verifikator boleh menyebut revisi yang ia periksa; persetujuan ditolak jika bukan revisi yang diajukan
+    if ok, err := checkApprovedRevision(c, ach, req.Revision); !ok {
+        return err
+    }
+
+    // 3. Tentukan tahap berjalan & pastikan user termasuk penyetujunya
+    pipeline, stage, ok, err := verificationStage(c, ach, detail)
+    if !ok {
+        return err
+    }
+
+    to, ok, err := checkTransition(c, ach, workflow.StageEvent(pipeline, stage), decision)
+    if !ok {
+        return err
+    }
+
+    // 4. Catat persetujuan; status baru berubah setelah quorum tahap tercapai
+    note := fmt.Sprintf("Tahap %d (%s) disetujui", stage.Position, stage.Name)
+    if req.Note = strings.TrimSpace(req.Note); req.Note != "" {
+        note += ": " + req.Note
+    }
+    statusEvent := newStatusEvent(c, ach, note)
+    statusEvent.ToStatus = to
+    if stage.ApproverType == model.ApproverAdvisor {
+        // Tahap dosen wali yang disetujui dosen pengganti dicatat atas nama dosen wali
+        applyDelegation(statusEvent, decision)
+    }
+
+    // 5. Poin tahap terakhir dihitung lebih dulu agar rinciannya tersimpan bersama status verified
+    var award *model.AchievementPointsAward
+    if to == model.StatusVerified {
+        rules, err := repository.ListPointsRules()
+        if err != nil {
+            return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil aturan poin", err.Error())
+        }
+        award = newPointsAward(rules, ach, detail, model.PointsReasonVerify, statusEvent.ActorID)
     }
 
-    // 4. Update Poin di MongoDB
-    updateData := model.AchievementMongo{Points: finalPoints}
-    repository.UpdateAchievementDetail(ach.MongoAchievementID, updateData)
+    approvals, completed, err := repository.ApproveStage(repository.StageApproval{
+        PipelineID: pipelineRef(pipeline),
+        Stage:      stage,
+        Event:      statusEvent,
+        APIKeyID:   currentAPIKeyID(c),
+        Award:      award,
+    })
+    if errors.Is(err, repository.ErrStageAlreadyApproved) {
+        return helper.Error(c, fiber.StatusConflict, "Anda sudah menyetujui tahap ini", nil)
+    }
+    if errors.Is(err, repository.ErrStatusConflict) {
+        return helper.Error(c, fiber.StatusConflict, "Status prestasi sudah berubah, muat ulang data lalu coba lagi", nil)
+    }
+    if err != nil {
+        return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan persetujuan", err.Error())
+    }
+
+    progress := fiber.Map{
+        "revision":           ach.SubmittedRevision,
+        "stage":              stage.Position,
+        "stage_name":         stage.Name,
+        "approvals":          approvals,
+        "required_approvals": stage.RequiredApprovals,
+    }
+    if !completed {
+        progress["status"] = ach.Status
+        return helper.Success(c, progress, "Persetujuan dicatat, menunggu penyetuju lain pada tahap ini")
+    }
+    progress["status"] = to
+    if to != model.StatusVerified {
+        progress["next_stage"] = stage.Position + 1
+        return helper.Success(c, progress, "Tahap verifikasi selesai, diteruskan ke tahap berikutnya")
+    }
 
-    // 5. Update Status di Postgres (Verified)
-    lecturerUUID, _ := uuid.Parse(lecturerUserID)
-    if err := repository.UpdateStatus(achievementID, model.StatusVerified, &lecturerUUID, ""); err != nil {
-        return helper.Error(c, fiber.StatusInternalServerError, "Gagal verifikasi", err.Error())
+    // 6. Total poin di MongoDB hanya salinan; jika gagal ditulis, hitung ulang poin menyelaraskannya
+    if err := repository.SetAchievementPoints(ach.MongoAchievementID, award.Total); err != nil {
+        log.Printf("Poin prestasi %s tercatat tetapi gagal ditulis ke MongoDB: %v", ach.ID, err)
     }
 
-    return helper.Success(c, fiber.Map{"points_awarded": finalPoints}, "Prestasi diverifikasi dan poin diberikan")
+    progress["points_awarded"] = award.Total
+    progress["points_breakdown"] = award.Breakdown.Items
+    return helper.Success(c, progress, "Prestasi diverifikasi dan poin diberikan")
+}
+
+// GetVerificationProgress godoc
+// @Summary      Progres Verifikasi Prestasi
+// @Description  Menampilkan pipeline verifikasi prestasi, tahap yang sedang berjalan dan persetujuan yang sudah masuk.
+// @Tags         Achievements
+// @Produce      json
+// @Param        id   path      string  true  "Achievement ID"
+// @Success      200  {object}  helper.Response{data=model.VerificationProgress}
+// @Router       /achievements/{id}/verification [get]
+// @Security     BearerAuth
+func GetVerificationProgress(c *fiber.Ctx) error {
+	achievementID := c.Params("id")
+
+	ach, err := repository.FindAchievementByID(achievementID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", err.Error())
+	}
+	if ok, err := authorizeAchievement(c, achievementID, policy.ActionRead); !ok {
+		return err
+	}
+
+	pipeline, err := achievementPipeline(ach, nil)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menentukan pipeline verifikasi", err.Error())
+	}
+	approvals, err := repository.ListStageApprovals(achievementID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil persetujuan tahap", err.Error())
+	}
+
+	progress := model.VerificationProgress{
+		Status:       ach.Status,
+		Pipeline:     pipeline,
+		CurrentStage: ach.CurrentStage,
+		Approvals:    approvals,
+	}
+	return helper.Success(c, progress, "Progres verifikasi prestasi")
 }
+
 // RejectAchievement godoc
 // @Summary      Tolak Prestasi
-// @Description  Dosen Wali menolak prestasi dengan catatan alasan penolakan.
+// @Description  Penyetuju tahap berjalan menolak prestasi berstatus 'submitted' / 'in_review' dengan catatan alasan penolakan.
+// @Description  Mahasiswa dapat memperbaiki lalu mengajukan ulang prestasi yang ditolak.
 // @Tags         Achievements
 // @Accept       json
 // @Param        id    path      string              true  "Achievement ID"
@@ -358,15 +488,16 @@ func VerifyAchievement(c *fiber.Ctx) error {
 // @Router       /achievements/{id}/reject [post]
 // @Security     BearerAuth
 func RejectAchievement(c *fiber.Ctx) error {
-	lecturerUserID := c.Locals("user_id").(string)
-	achievementID := c.Params("id")
-
 	var req model.RejectRequest
 	if err := c.BodyParser(&req); err != nil {
 		return helper.Error(c, fiber.StatusBadRequest, "Invalid request body", err.Error())
 	}
+	return rejectAchievement(c, c.Params("id"), req.RejectionNote)
+}
 
-	if req.RejectionNote == "" {
+// rejectAchievement menolak satu prestasi dan menulis responnya (dipakai juga oleh bulk reject).
+func rejectAchievement(c *fiber.Ctx, achievementID string, note string) error {
+	if strings.TrimSpace(note) == "" {
 		return helper.Error(c, fiber.StatusBadRequest, "Catatan penolakan wajib diisi", nil)
 	}
 
@@ -375,18 +506,115 @@ func RejectAchievement(c *fiber.Ctx) error {
 		return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", err.Error())
 	}
 
-	if ach.Status != model.StatusSubmitted {
-		return helper.Error(c, fiber.StatusBadRequest, "Status harus 'submitted'", nil)
+	if ok, err := requireStageApprover(c, ach); !ok {
+		return err
+	}
+	if ok, err := transitionAchievement(c, ach, policy.ActionReject, workflow.EventReject, note); !ok {
+		return err
 	}
 
-	lecturerUUID, _ := uuid.Parse(lecturerUserID)
-	verifiedByPointer := &lecturerUUID
+	return helper.Success(c, nil, "Prestasi berhasil ditolak")
+}
 
-	if err := repository.UpdateStatus(achievementID, model.StatusRejected, verifiedByPointer, req.RejectionNote); err != nil {
-		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menolak prestasi", err.Error())
+// RequestRevision godoc
+// @Summary      Minta Revisi Prestasi
+// @Description  Penyetuju tahap berjalan meminta mahasiswa memperbaiki prestasi berstatus 'submitted' / 'in_review'. Catatan revisi wajib diisi;
+// @Description  mahasiswa mengedit lalu mengajukan ulang lewat /submit.
+// @Tags         Achievements
+// @Accept       json
+// @Param        id    path      string               true  "Achievement ID"
+// @Param        body  body      model.RejectRequest  true  "Catatan Revisi"
+// @Success      200   {object}  helper.Response
+// @Failure      400   {object}  helper.Response
+// @Failure      409   {object}  helper.Response
+// @Router       /achievements/{id}/request-revision [post]
+// @Security     BearerAuth
+func RequestRevision(c *fiber.Ctx) error {
+	var req model.RejectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+	if strings.TrimSpace(req.RejectionNote) == "" {
+		return helper.Error(c, fiber.StatusBadRequest, "Catatan revisi wajib diisi", nil)
 	}
 
-	return helper.Success(c, nil, "Prestasi berhasil ditolak")
+	ach, err := repository.FindAchievementByID(c.Params("id"))
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", err.Error())
+	}
+
+	if ok, err := requireStageApprover(c, ach); !ok {
+		return err
+	}
+	if ok, err := transitionAchievement(c, ach, policy.ActionVerify, workflow.EventRequestRevision, req.RejectionNote); !ok {
+		return err
+	}
+	return helper.Success(c, nil, "Permintaan revisi berhasil dikirim")
+}
+
+// WithdrawAchievement godoc
+// @Summary      Tarik Pengajuan Prestasi
+// @Description  Mahasiswa menarik prestasi yang sedang diajukan, diminta revisi atau ditolak. Prestasi yang ditarik
+// @Description  bisa dibuka kembali menjadi draft atau dihapus.
+// @Tags         Achievements
+// @Param        id   path      string  true  "Achievement ID"
+// @Success      200  {object}  helper.Response
+// @Failure      400  {object}  helper.Response
+// @Failure      409  {object}  helper.Response
+// @Router       /achievements/{id}/withdraw [post]
+// @Security     BearerAuth
+func WithdrawAchievement(c *fiber.Ctx) error {
+	ach, err := repository.FindAchievementByID(c.Params("id"))
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", err.Error())
+	}
+
+	if ok, err := transitionAchievement(c, ach, policy.ActionSubmit, workflow.EventWithdraw, ""); !ok {
+		return err
+	}
+	return helper.Success(c, nil, "Pengajuan prestasi berhasil ditarik")
+}
+
+// ReopenAchievement godoc
+// @Summary      Buka Kembali Prestasi
+// @Description  Mengembalikan prestasi berstatus 'withdrawn' menjadi 'draft' agar bisa diedit dan diajukan lagi.
+// @Tags         Achievements
+// @Param        id   path      string  true  "Achievement ID"
+// @Success      200  {object}  helper.Response
+// @Failure      400  {object}  helper.Response
+// @Router       /achievements/{id}/reopen [post]
+// @Security     BearerAuth
+func ReopenAchievement(c *fiber.Ctx) error {
+	ach, err := repository.FindAchievementByID(c.Params("id"))
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", err.Error())
+	}
+
+	if ok, err := transitionAchievement(c, ach, policy.ActionSubmit, workflow.EventReopen, ""); !ok {
+		return err
+	}
+	return helper.Success(c, nil, "Prestasi dibuka kembali sebagai draft")
+}
+
+// ArchiveAchievement godoc
+// @Summary      Arsipkan Prestasi (Admin)
+// @Description  Mengarsipkan prestasi berstatus 'verified', 'rejected' atau 'withdrawn'. Prestasi arsip tidak bisa diubah lagi.
+// @Tags         Achievements
+// @Param        id   path      string  true  "Achievement ID"
+// @Success      200  {object}  helper.Response
+// @Failure      400  {object}  helper.Response
+// @Router       /achievements/{id}/archive [post]
+// @Security     BearerAuth
+func ArchiveAchievement(c *fiber.Ctx) error {
+	ach, err := repository.FindAchievementByID(c.Params("id"))
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", err.Error())
+	}
+
+	if ok, err := transitionAchievement(c, ach, policy.ActionArchive, workflow.EventArchive, ""); !ok {
+		return err
+	}
+	return helper.Success(c, nil, "Prestasi berhasil diarsipkan")
 }
 
 // UploadAttachment godoc
@@ -396,12 +624,12 @@ func RejectAchievement(c *fiber.Ctx) error {
 // @Accept       mpfd
 // @Param        id    path      string  true  "Achievement ID"
 // @Param        file  formData  file    true  "File Lampiran"
+// @Param        category  formData  string  false  "Kategori lampiran (misal certificate), dipakai untuk lampiran wajib tipe prestasi"
 // @Success      201   {object}  helper.Response
+// @Failure      409   {object}  helper.Response
 // @Router       /achievements/{id}/attachments [post]
 // @Security     BearerAuth
 func UploadAttachment(c *fiber.Ctx) error {
-	userID := c.Locals("user_id").(string)
-	role := c.Locals("role").(string)
 	achievementID := c.Params("id")
 
 	file, err := c.FormFile("file")
@@ -409,6 +637,11 @@ func UploadAttachment(c *fiber.Ctx) error {
 		return helper.Error(c, fiber.StatusBadRequest, "File wajib diupload", nil)
 	}
 
+	// Cek hak akses sebelum file disimpan ke disk
+	if ok, err := authorizeAchievement(c, achievementID, policy.ActionUpload); !ok {
+		return err
+	}
+
 	filename := fmt.Sprintf("%s_%s", uuid.New().String(), filepath.Base(file.Filename))
 	savePath := fmt.Sprintf("./uploads/%s", filename)
 
@@ -421,47 +654,134 @@ func UploadAttachment(c *fiber.Ctx) error {
 		return helper.Error(c, fiber.StatusNotFound, "Data tidak ditemukan", nil)
 	}
 
-	isAuthorized := false
-	if strings.EqualFold(role, "Admin") {
-		isAuthorized = true
-	} else if strings.EqualFold(role, "Mahasiswa") {
-		student, findErr := repository.FindStudentByUserID(userID)
-		if findErr == nil && student.ID != uuid.Nil && ach.StudentID == student.ID {
-			isAuthorized = true
-		}
-	}
-
-	if !isAuthorized {
-		return helper.Error(c, fiber.StatusForbidden, "Akses ditolak", nil)
-	}
-
-	if ach.Status != model.StatusDraft {
-		return helper.Error(c, fiber.StatusBadRequest, "Status harus Draft", nil)
+	if !workflow.Editable(ach.Status) {
+		return helper.Error(c, fiber.StatusBadRequest, "Lampiran hanya dapat ditambahkan saat prestasi masih bisa diedit", nil)
 	}
 
 	fileURL := "http://localhost:3000/uploads/" + filename
 	att := model.Attachment{FileName: file.Filename, FileURL: fileURL, FileType: filepath.Ext(filename), UploadedAt: time.Now()}
+	att.Category = strings.ToLower(strings.TrimSpace(c.FormValue("category")))
 
-	if err := repository.AddAttachmentToMongo(ach.MongoAchievementID, att); err != nil {
+	revision, err := repository.AddAttachmentToMongo(ach.MongoAchievementID, att, currentUserID(c))
+	if errors.Is(err, repository.ErrRevisionConflict) {
+		return helper.Error(c, fiber.StatusConflict, "Prestasi sedang diubah bersamaan, silakan coba lagi", err.Error())
+	}
+	if err != nil {
 		return helper.Error(c, fiber.StatusInternalServerError, "Gagal update database", err.Error())
 	}
 
-	return helper.Created(c, fiber.Map{"url": fileURL}, "File berhasil diupload")
+	return helper.Created(c, fiber.Map{"url": fileURL, "revision": revision}, "File berhasil diupload")
 }
 
 // GetHistory godoc
 // @Summary      Riwayat Status Prestasi
-// @Description  Melihat log perubahan status prestasi.
+// @Description  Log lengkap transisi status prestasi (urut kronologis): status asal dan tujuan, aktor, role aktor,
+// @Description  catatan, IP dan waktu. Setiap pengajuan ulang maupun penolakan berulang tercatat sebagai event tersendiri.
 // @Tags         Achievements
-// @Param        id   path      string  true  "Achievement ID"
-// @Success      200  {object}  helper.Response
+// @Produce      json
+// @Param        id     path      string  true   "Achievement ID"
+// @Param        page   query     int     false  "Halaman (default 1)"
+// @Param        limit  query     int     false  "Jumlah per halaman (default 20, maks 100)"
+// @Success      200    {object}  helper.Response{data=[]model.AchievementStatusEvent}
+// @Failure      404    {object}  helper.Response
 // @Router       /achievements/{id}/history [get]
 // @Security     BearerAuth
 func GetHistory(c *fiber.Ctx) error {
 	achievementID := c.Params("id")
-	historyData, err := repository.GetAchievementHistory(achievementID)
+
+	page, limit := helper.Pagination(c)
+
+	events, total, err := repository.ListAchievementStatusEvents(achievementID, limit, (page-1)*limit)
+	if errors.Is(err, repository.ErrResourceNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", nil)
+	}
 	if err != nil {
-		return helper.Error(c, fiber.StatusNotFound, "History tidak ditemukan", err.Error())
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil history status", err.Error())
 	}
-	return helper.Success(c, historyData, "History status berhasil diambil")
-}
\ No newline at end of file
+
+	return helper.SuccessWithMeta(c, events, helper.PageMeta(page, limit, total), "History status berhasil diambil")
+}
+// authorizeAchievement mengevaluasi policy untuk aksi pada prestasi. Jika ok = false,
+// respon error sudah ditulis dan err harus langsung dikembalikan handler.
+func authorizeAchievement(c *fiber.Ctx, achievementID string, action policy.Action) (bool, error) {
+	_, ok, err := achievementDecision(c, achievementID, action)
+	return ok, err
+}
+
+func achievementDecision(c *fiber.Ctx, achievementID string, action policy.Action) (policy.Decision, bool, error) {
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return policy.Decision{}, false, helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+
+	resource, err := repository.AchievementPolicyResource(achievementID)
+	if errors.Is(err, repository.ErrResourceNotFound) {
+		return policy.Decision{}, false, helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", nil)
+	}
+	if err != nil {
+		return policy.Decision{}, false, helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+
+	decision := policy.Can(subject, action, resource)
+	if !decision.Allowed {
+		return decision, false, helper.Error(c, fiber.StatusForbidden, "Akses ditolak: "+decision.Reason, nil)
+	}
+	return decision, true, nil
+}
+
+// transitionAchievement memeriksa policy untuk action, menjalankan event pada state machine workflow
+// dari status saat ini, lalu menyimpan status baru. Jika ok = false, respon error sudah ditulis.
+func transitionAchievement(c *fiber.Ctx, ach *model.AchievementReference, action policy.Action, event workflow.Event, note string) (bool, error) {
+	decision, ok, err := achievementDecision(c, ach.ID.String(), action)
+	if !ok {
+		return false, err
+	}
+
+	to, ok, err := checkTransition(c, ach, event, decision)
+	if !ok {
+		return false, err
+	}
+
+	statusEvent := newStatusEvent(c, ach, note)
+	statusEvent.ToStatus = to
+	applyDelegation(statusEvent, decision)
+
+	err = repository.UpdateStatus(statusEvent)
+	if errors.Is(err, repository.ErrStatusConflict) {
+		return false, helper.Error(c, fiber.StatusConflict, "Status prestasi sudah berubah, muat ulang data lalu coba lagi", nil)
+	}
+	if err != nil {
+		return false, helper.Error(c, fiber.StatusInternalServerError, "Gagal update status", err.Error())
+	}
+	ach.Status = to
+	return true, nil
+}
+
+// checkTransition menjalankan event pada state machine workflow dari status prestasi saat ini.
+// Jika ok = false, respon error sudah ditulis.
+func checkTransition(c *fiber.Ctx, ach *model.AchievementReference, event workflow.Event, decision policy.Decision) (model.AchievementStatus, bool, error) {
+	to, err := workflow.Transition(ach.Status, event, workflow.ActorFor(decision))
+	if errors.Is(err, workflow.ErrActorNotAllowed) {
+		return to, false, helper.Error(c, fiber.StatusForbidden, "Akses ditolak: "+err.Error(), nil)
+	}
+	if err != nil {
+		return to, false, helper.Error(c, fiber.StatusBadRequest, "Aksi tidak dapat dilakukan pada status prestasi saat ini",
+			fiber.Map{"status": ach.Status, "event": event})
+	}
+	return to, true, nil
+}
+
+// newStatusEvent menyiapkan event log status atas nama user yang login (API key tidak punya user).
+// ach nil berarti prestasi baru dibuat.
+func newStatusEvent(c *fiber.Ctx, ach *model.AchievementReference, note string) *model.AchievementStatusEvent {
+	event := &model.AchievementStatusEvent{Note: note, IPAddress: c.IP()}
+	event.ActorRole, _ = c.Locals("role").(string)
+	event.ActorID = currentUserID(c)
+	if ach != nil {
+		from := ach.Status
+		event.AchievementID = ach.ID
+		event.FromStatus = &from
+		event.Revision = ach.SubmittedRevision
+	}
+	return event
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/app/schema"
+	"sistempelaporan/helper"
+	"sistempelaporan/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Kode tipe prestasi: huruf kecil, angka dan underscore (misal community_service)
+var achievementTypeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+const achievementTypeManage = "achievement_type:manage"
+
+// validateAchievementInput memeriksa tipe, tingkat kompetisi dan details prestasi terhadap definisi tipenya,
+// lalu menyeragamkan kode tipe. Jika ok = false, respon error (dengan error per field) sudah ditulis.
+func validateAchievementInput(c *fiber.Ctx, a *model.AchievementMongo) (*model.AchievementTypeDefinition, bool, error) {
+	a.AchievementType = strings.ToLower(strings.TrimSpace(a.AchievementType))
+	if a.AchievementType == "" {
+		return nil, false, helper.Error(c, fiber.StatusBadRequest, "Data prestasi tidak valid",
+			map[string]string{"achievementType": "wajib diisi"})
+	}
+
+	def, err := repository.FindAchievementType(a.AchievementType)
+	if errors.Is(err, repository.ErrAchievementTypeNotFound) || (err == nil && !def.IsActive) {
+		return nil, false, helper.Error(c, fiber.StatusBadRequest, "Data prestasi tidak valid",
+			map[string]string{"achievementType": "tipe prestasi tidak dikenal: " + a.AchievementType})
+	}
+	if err != nil {
+		return nil, false, helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil tipe prestasi", err.Error())
+	}
+
+	errs, err := schema.ValidateAchievement(*def, *a)
+	if err != nil {
+		return nil, false, helper.Error(c, fiber.StatusInternalServerError, "Schema tipe prestasi rusak", err.Error())
+	}
+	if len(errs) > 0 {
+		return nil, false, helper.Error(c, fiber.StatusBadRequest, "Data prestasi tidak valid", errs)
+	}
+	return def, true, nil
+}
+
+// requireAttachments memastikan lampiran wajib tipe prestasi sudah diunggah sebelum diajukan.
+// Jika ok = false, respon error sudah ditulis.
+func requireAttachments(c *fiber.Ctx, ach *model.AchievementReference) (bool, error) {
+	detail, err := repository.GetAchievementDetailFromMongo(ach.MongoAchievementID)
+	if err != nil {
+		return false, helper.Error(c, fiber.StatusNotFound, "Detail prestasi tidak ditemukan", nil)
+	}
+
+	def, err := repository.FindAchievementType(detail.AchievementType)
+	if errors.Is(err, repository.ErrAchievementTypeNotFound) {
+		return false, helper.Error(c, fiber.StatusBadRequest, "Tipe prestasi tidak dikenal, perbarui data prestasi terlebih dahulu",
+			map[string]string{"achievementType": "tipe prestasi tidak dikenal: " + detail.AchievementType})
+	}
+	if err != nil {
+		return false, helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil tipe prestasi", err.Error())
+	}
+
+	if missing := schema.MissingAttachments(*def, detail.Attachments); len(missing) > 0 {
+		return false, helper.Error(c, fiber.StatusBadRequest, "Lampiran wajib belum lengkap", missing)
+	}
+	return true, nil
+}
+
+type achievementTypeRequest struct {
+	Code                string          `json:"code"`
+	Name                string          `json:"name"`
+	Description         string          `json:"description"`
+	DetailsSchema       json.RawMessage `json:"details_schema"`
+	RequiredAttachments []string        `json:"required_attachments"`
+	AllowedTiers        []string        `json:"allowed_tiers"`
+	IsActive            *bool           `json:"is_active"`
+}
+
+// parseAchievementTypeRequest membaca dan memvalidasi body tipe prestasi. code kosong berarti kode diambil
+// dari body (create). Jika ok = false, respon error sudah ditulis.
+func parseAchievementTypeRequest(c *fiber.Ctx, code string) (*model.AchievementTypeDefinition, bool, error) {
+	var req achievementTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, false, helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+	if code == "" {
+		code = strings.ToLower(strings.TrimSpace(req.Code))
+	}
+
+	def := &model.AchievementTypeDefinition{
+		Code:                code,
+		Name:                strings.TrimSpace(req.Name),
+		Description:         strings.TrimSpace(req.Description),
+		DetailsSchema:       req.DetailsSchema,
+		RequiredAttachments: normalizeMatchValues(req.RequiredAttachments),
+		AllowedTiers:        normalizeMatchValues(req.AllowedTiers),
+		IsActive:            req.IsActive == nil || *req.IsActive,
+	}
+	if len(def.DetailsSchema) == 0 || string(def.DetailsSchema) == "null" {
+		def.DetailsSchema = json.RawMessage(`{"type": "object"}`)
+	}
+
+	errs := map[string]string{}
+	if !achievementTypeCodePattern.MatchString(def.Code) {
+		errs["code"] = "Kode hanya huruf kecil, angka dan underscore (2-50 karakter)"
+	}
+	if def.Name == "" {
+		errs["name"] = "Nama tipe prestasi wajib diisi"
+	}
+	if _, err := schema.Parse(def.DetailsSchema); err != nil {
+		errs["details_schema"] = err.Error()
+	}
+	if len(errs) > 0 {
+		return nil, false, helper.Error(c, fiber.StatusBadRequest, "Data tipe prestasi tidak valid", errs)
+	}
+	return def, true, nil
+}
+
+// GetAchievementTypes godoc
+// @Summary      Daftar Tipe Prestasi
+// @Description  Menampilkan tipe prestasi beserta JSON Schema details, lampiran wajib dan tingkat kompetisi yang diizinkan.
+// @Description  Tipe nonaktif hanya ditampilkan untuk pengelola tipe prestasi.
+// @Tags         Achievement Types
+// @Produce      json
+// @Success      200  {object}  helper.Response{data=[]model.AchievementTypeDefinition}
+// @Router       /achievement-types [get]
+// @Security     BearerAuth
+func GetAchievementTypes(c *fiber.Ctx) error {
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+
+	types, err := repository.ListAchievementTypes(!subject.HasPermission(achievementTypeManage))
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil tipe prestasi", err.Error())
+	}
+	return helper.Success(c, types, "Daftar tipe prestasi")
+}
+
+// GetAchievementType godoc
+// @Summary      Detail Tipe Prestasi
+// @Tags         Achievement Types
+// @Produce      json
+// @Param        code  path      string  true  "Kode tipe prestasi"
+// @Success      200   {object}  helper.Response{data=model.AchievementTypeDefinition}
+// @Failure      404   {object}  helper.Response
+// @Router       /achievement-types/{code} [get]
+// @Security     BearerAuth
+func GetAchievementType(c *fiber.Ctx) error {
+	def, err := repository.FindAchievementType(c.Params("code"))
+	if errors.Is(err, repository.ErrAchievementTypeNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Tipe prestasi tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil tipe prestasi", err.Error())
+	}
+	return helper.Success(c, def, "Detail tipe prestasi")
+}
+
+// CreateAchievementType godoc
+// @Summary      Buat Tipe Prestasi (Admin)
+// @Description  details_schema adalah JSON Schema (type object) untuk field details. Kata kunci yang didukung: type,
+// @Description  properties, required, additionalProperties, items, enum, minimum, maximum, minLength, maxLength,
+// @Description  pattern, format (date, date-time, email, uri), minItems, maxItems. required_attachments adalah
+// @Description  kategori lampiran yang wajib diunggah sebelum prestasi diajukan.
+// @Tags         Achievement Types
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "code, name, description, details_schema, required_attachments, allowed_tiers, is_active"
+// @Success      201   {object}  helper.Response{data=model.AchievementTypeDefinition}
+// @Failure      400   {object}  helper.Response
+// @Failure      409   {object}  helper.Response
+// @Router       /achievement-types [post]
+// @Security     BearerAuth
+func CreateAchievementType(c *fiber.Ctx) error {
+	def, ok, err := parseAchievementTypeRequest(c, "")
+	if !ok {
+		return err
+	}
+
+	err = repository.CreateAchievementType(def)
+	if errors.Is(err, repository.ErrAchievementTypeExists) {
+		return helper.Error(c, fiber.StatusConflict, "Kode tipe prestasi sudah dipakai", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan tipe prestasi", err.Error())
+	}
+	return helper.Created(c, def, "Tipe prestasi berhasil dibuat")
+}
+
+// UpdateAchievementType godoc
+// @Summary      Ubah Tipe Prestasi (Admin)
+// @Description  Mengganti definisi tipe prestasi. Prestasi yang sudah ada divalidasi dengan schema baru saat berikutnya diedit.
+// @Tags         Achievement Types
+// @Accept       json
+// @Produce      json
+// @Param        code  path      string  true  "Kode tipe prestasi"
+// @Param        body  body      object  true  "name, description, details_schema, required_attachments, allowed_tiers, is_active"
+// @Success      200   {object}  helper.Response{data=model.AchievementTypeDefinition}
+// @Failure      404   {object}  helper.Response
+// @Router       /achievement-types/{code} [put]
+// @Security     BearerAuth
+func UpdateAchievementType(c *fiber.Ctx) error {
+	def, ok, err := parseAchievementTypeRequest(c, c.Params("code"))
+	if !ok {
+		return err
+	}
+
+	err = repository.UpdateAchievementType(def)
+	if errors.Is(err, repository.ErrAchievementTypeNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Tipe prestasi tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengubah tipe prestasi", err.Error())
+	}
+	return helper.Success(c, def, "Tipe prestasi berhasil diubah")
+}
+
+// DeleteAchievementType godoc
+// @Summary      Hapus Tipe Prestasi (Admin)
+// @Description  Hanya tipe yang belum pernah dipakai prestasi; tipe yang sudah dipakai dinonaktifkan lewat is_active.
+// @Tags         Achievement Types
+// @Param        code  path      string  true  "Kode tipe prestasi"
+// @Success      200   {object}  helper.Response
+// @Failure      404   {object}  helper.Response
+// @Failure      409   {object}  helper.Response
+// @Router       /achievement-types/{code} [delete]
+// @Security     BearerAuth
+func DeleteAchievementType(c *fiber.Ctx) error {
+	err := repository.DeleteAchievementType(c.Params("code"))
+	if errors.Is(err, repository.ErrAchievementTypeNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Tipe prestasi tidak ditemukan", nil)
+	}
+	if errors.Is(err, repository.ErrAchievementTypeInUse) {
+		return helper.Error(c, fiber.StatusConflict, "Tipe prestasi sudah dipakai, nonaktifkan saja lewat is_active", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menghapus tipe prestasi", err.Error())
+	}
+	return helper.Success(c, nil, "Tipe prestasi berhasil dihapus")
+}
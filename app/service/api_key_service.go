@@ -0,0 +1,209 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/helper"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Format permission: resource:action (misal achievement:read)
+var permissionPattern = regexp.MustCompile(`^[a-z_]+:[a-z_]+$`)
+
+type apiKeyRequest struct {
+	Name        string     `json:"name"`
+	Permissions []string   `json:"permissions"`
+	AllowedIPs  []string   `json:"allowed_ips"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// validate merapikan input lalu mengembalikan daftar kesalahan per field (kosong = valid).
+func (r *apiKeyRequest) validate() map[string]string {
+	errs := map[string]string{}
+
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		errs["name"] = "Nama API key wajib diisi"
+	}
+
+	perms := make([]string, 0, len(r.Permissions))
+	seen := map[string]bool{}
+	for _, p := range r.Permissions {
+		p = strings.TrimSpace(p)
+		if !permissionPattern.MatchString(p) {
+			errs["permissions"] = "Format permission tidak valid: " + p
+			continue
+		}
+		if !seen[p] {
+			seen[p] = true
+			perms = append(perms, p)
+		}
+	}
+	if len(perms) == 0 && errs["permissions"] == "" {
+		errs["permissions"] = "Minimal satu permission wajib dipilih"
+	}
+	r.Permissions = perms
+
+	ips := make([]string, 0, len(r.AllowedIPs))
+	for _, ip := range r.AllowedIPs {
+		ip = strings.TrimSpace(ip)
+		if !helper.ValidIPOrCIDR(ip) {
+			errs["allowed_ips"] = "Alamat IP / CIDR tidak valid: " + ip
+			continue
+		}
+		ips = append(ips, ip)
+	}
+	r.AllowedIPs = ips
+
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(time.Now()) {
+		errs["expires_at"] = "Masa berlaku harus di masa depan"
+	}
+	return errs
+}
+
+// CreateAPIKey godoc
+// @Summary      Buat API Key (Admin)
+// @Description  Membuat API key untuk integrasi mesin dengan subset permission, allowlist IP dan masa berlaku opsional.
+// @Description  Key utuh hanya ditampilkan sekali di respon ini. Kirim lewat header X-API-Key.
+// @Tags         API Keys (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "name, permissions, allowed_ips, expires_at"
+// @Success      201   {object}  helper.Response{data=model.APIKeyCreatedResponse}
+// @Failure      400   {object}  helper.Response
+// @Router       /api-keys [post]
+// @Security     BearerAuth
+func CreateAPIKey(c *fiber.Ctx) error {
+	var req apiKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		return helper.Error(c, fiber.StatusBadRequest, "Data API key tidak valid", errs)
+	}
+
+	rawKey, prefix, err := helper.GenerateAPIKey()
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal membuat API key", nil)
+	}
+
+	key := model.APIKey{
+		ID:          uuid.New(),
+		Name:        req.Name,
+		Prefix:      prefix,
+		Permissions: req.Permissions,
+		AllowedIPs:  req.AllowedIPs,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if creatorID, err := uuid.Parse(c.Locals("user_id").(string)); err == nil {
+		key.CreatedBy = &creatorID
+	}
+
+	if err := repository.CreateAPIKey(&key, helper.HashAPIKey(rawKey)); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan API key", err.Error())
+	}
+
+	return helper.Created(c, model.APIKeyCreatedResponse{APIKey: key, Key: rawKey},
+		"API key berhasil dibuat. Simpan key ini, key tidak akan ditampilkan lagi.")
+}
+
+// GetAPIKeys godoc
+// @Summary      Daftar API Key (Admin)
+// @Description  Menampilkan semua API key (tanpa secret), termasuk waktu dan IP pemakaian terakhir.
+// @Tags         API Keys (Admin)
+// @Produce      json
+// @Success      200  {object}  helper.Response{data=[]model.APIKey}
+// @Router       /api-keys [get]
+// @Security     BearerAuth
+func GetAPIKeys(c *fiber.Ctx) error {
+	keys, err := repository.ListAPIKeys()
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data API key", err.Error())
+	}
+	return helper.Success(c, keys, "Daftar API key")
+}
+
+// GetAPIKeyByID godoc
+// @Summary      Detail API Key (Admin)
+// @Tags         API Keys (Admin)
+// @Produce      json
+// @Param        id   path      string  true  "API Key ID"
+// @Success      200  {object}  helper.Response{data=model.APIKey}
+// @Failure      404  {object}  helper.Response
+// @Router       /api-keys/{id} [get]
+// @Security     BearerAuth
+func GetAPIKeyByID(c *fiber.Ctx) error {
+	key, err := repository.FindAPIKeyByID(c.Params("id"))
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "API key tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data API key", err.Error())
+	}
+	return helper.Success(c, key, "Detail API key")
+}
+
+// UpdateAPIKey godoc
+// @Summary      Ubah API Key (Admin)
+// @Description  Mengubah nama, permission, allowlist IP dan masa berlaku. Secret key tidak berubah.
+// @Tags         API Keys (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true  "API Key ID"
+// @Param        body  body      object  true  "name, permissions, allowed_ips, expires_at"
+// @Success      200   {object}  helper.Response{data=model.APIKey}
+// @Failure      404   {object}  helper.Response
+// @Router       /api-keys/{id} [put]
+// @Security     BearerAuth
+func UpdateAPIKey(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req apiKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+	if errs := req.validate(); len(errs) > 0 {
+		return helper.Error(c, fiber.StatusBadRequest, "Data API key tidak valid", errs)
+	}
+
+	err := repository.UpdateAPIKey(id, req.Name, req.Permissions, req.AllowedIPs, req.ExpiresAt)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "API key tidak ditemukan atau sudah dicabut", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengubah API key", err.Error())
+	}
+
+	key, err := repository.FindAPIKeyByID(id)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data API key", err.Error())
+	}
+	return helper.Success(c, key, "API key berhasil diubah")
+}
+
+// RevokeAPIKey godoc
+// @Summary      Cabut API Key (Admin)
+// @Description  Mencabut API key secara permanen; request berikutnya dengan key ini langsung ditolak.
+// @Tags         API Keys (Admin)
+// @Param        id   path      string  true  "API Key ID"
+// @Success      200  {object}  helper.Response
+// @Failure      404  {object}  helper.Response
+// @Router       /api-keys/{id} [delete]
+// @Security     BearerAuth
+func RevokeAPIKey(c *fiber.Ctx) error {
+	err := repository.RevokeAPIKey(c.Params("id"))
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "API key tidak ditemukan atau sudah dicabut", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mencabut API key", err.Error())
+	}
+	return helper.Success(c, nil, "API key berhasil dicabut")
+}
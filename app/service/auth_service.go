@@ -1,37 +1,60 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"sistempelaporan/app/lockout"
 	"sistempelaporan/app/model"
 	"sistempelaporan/app/repository"
+	"sistempelaporan/authenticator"
 	"sistempelaporan/helper"
+	"sistempelaporan/keymanager"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/google/uuid"
 )
 
-// Helper: Ambil JWT Secret konsisten dari satu sumber
-func getJWTSecret() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return []byte("rahasia_negara_api")
+// Helper: Ambil konfigurasi integer dari environment dengan nilai default
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// Konfigurasi proteksi brute-force login
+func loginLockoutPolicy() lockout.Policy {
+	return lockout.Policy{
+		MaxAttempts:   getEnvInt("LOGIN_MAX_ATTEMPTS", 5),
+		LockDuration:  time.Duration(getEnvInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+		IPMaxAttempts: getEnvInt("LOGIN_IP_MAX_ATTEMPTS", 20),
+		IPWindow:      time.Duration(getEnvInt("LOGIN_IP_WINDOW_MINUTES", 15)) * time.Minute,
 	}
-	return []byte(secret)
 }
 
 // Login godoc
 // @Summary      Login Pengguna
 // @Description  Otentikasi pengguna menggunakan username dan password untuk mendapatkan JWT Token.
+// @Description  Password diverifikasi sesuai sumber autentikasi akun (bcrypt lokal atau bind LDAP).
+// @Description  Akun dikunci sementara setelah beberapa kali gagal login, dan IP dengan terlalu banyak kegagalan akan ditolak.
+// @Description  Jika 2FA aktif atau diwajibkan untuk role user, respon berisi challenge token untuk /auth/2fa/verify.
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
 // @Param        login  body      object  true  "Kredensial Login (Username & Password)"
 // @Success      200    {object}  helper.Response{data=model.LoginResponse} // <-- PERBAIKAN DI SINI
 // @Failure      401    {object}  helper.Response
+// @Failure      423    {object}  helper.Response
+// @Failure      429    {object}  helper.Response
+// @Failure      503    {object}  helper.Response
 // @Router       /auth/login [post]
 func Login(c *fiber.Ctx) error {
 	var input struct {
@@ -43,36 +66,95 @@ func Login(c *fiber.Ctx) error {
 		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
 	}
 
+	ip := c.IP()
+	userAgent := c.Get("User-Agent")
+
+	// 1. Tolak IP yang sudah terlalu banyak gagal login dalam window waktu tertentu
+	lockPolicy := loginLockoutPolicy()
+	failedFromIP, err := repository.CountFailedLoginsByIP(ip, lockPolicy.IPWindowStart(time.Now()))
+	if err == nil && lockPolicy.IPBlocked(failedFromIP) {
+		return helper.Error(c, fiber.StatusTooManyRequests, "Terlalu banyak percobaan login gagal dari alamat ini. Coba lagi nanti.", nil)
+	}
+
 	user, err := repository.FindUserByUsername(input.Username)
 	if err != nil {
+		repository.RecordLoginAttempt(nil, input.Username, ip, userAgent, false, "user_not_found")
 		return helper.Error(c, fiber.StatusUnauthorized, "Username atau password salah", nil)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
-		return helper.Error(c, fiber.StatusUnauthorized, "Username atau password salah", nil)
+	// 2. Akun yang sedang dikunci tidak boleh login walaupun password benar
+	if lockout.Locked(user.LockedUntil, time.Now()) {
+		repository.RecordLoginAttempt(&user.ID, user.Username, ip, userAgent, false, "account_locked")
+		return helper.Error(c, fiber.StatusLocked, "Akun dikunci sementara karena terlalu banyak percobaan login gagal.", fiber.Map{"locked_until": user.LockedUntil})
 	}
 
-	perms, _ := repository.GetPermissionsByRoleID(user.RoleID.String())
+	err = authenticateUser(user, input.Password)
+	if authenticator.IsUnavailable(err) {
+		repository.RecordLoginAttempt(&user.ID, user.Username, ip, userAgent, false, "auth_backend_unavailable")
+		return helper.Error(c, fiber.StatusServiceUnavailable, "Layanan autentikasi sedang tidak tersedia, coba lagi nanti.", nil)
+	}
+	if err != nil {
+		repository.RecordLoginAttempt(&user.ID, user.Username, ip, userAgent, false, "invalid_password")
 
-	if strings.EqualFold(user.Role.Name, "Admin") {
-		hasReadAll := false
-		for _, p := range perms {
-			if p == "user:read_all" {
-				hasReadAll = true
-				break
-			}
-		}
-		if !hasReadAll {
-			perms = append(perms, "user:read_all")
+		lockedUntil, lockErr := repository.RegisterFailedLogin(user.ID.String(), lockPolicy)
+		if lockErr == nil && lockedUntil != nil {
+			return helper.Error(c, fiber.StatusLocked, "Akun dikunci sementara karena terlalu banyak percobaan login gagal.", fiber.Map{"locked_until": lockedUntil})
 		}
+		return helper.Error(c, fiber.StatusUnauthorized, "Username atau password salah", nil)
 	}
 
-	accessToken, refreshToken, err := generateTokens(user, perms)
+	// 3. Role dengan 2FA wajib (atau user yang sudah mengaktifkan 2FA) harus melewati langkah kedua
+	if user.TwoFactorEnabled || user.Role.Require2FA {
+		return respondTwoFactorChallenge(c, user)
+	}
+
+	response, err := finishLogin(c, user)
 	if err != nil {
 		return helper.Error(c, fiber.StatusInternalServerError, "Gagal generate token", nil)
 	}
 
-	response := model.LoginResponse{
+	return helper.Success(c, response, "Login berhasil")
+}
+
+// authenticateUser memverifikasi password lewat authenticator sesuai auth_source user (bcrypt atau LDAP).
+func authenticateUser(user *model.User, password string) error {
+	authn, err := authenticator.For(user.AuthSource)
+	if err != nil {
+		return err
+	}
+	return authn.Authenticate(authenticator.Account{Username: user.Username, PasswordHash: user.PasswordHash}, password)
+}
+
+// verifyUserPassword mengonfirmasi password user yang sedang login untuk aksi sensitif.
+func verifyUserPassword(userID, password string) error {
+	user, err := repository.FindUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if authenticator.ManagesPassword(user.AuthSource) {
+		if user.PasswordHash, err = repository.GetPasswordHashByUserID(userID); err != nil {
+			return err
+		}
+	}
+	return authenticateUser(user, password)
+}
+
+// finishLogin mencatat login yang berhasil lalu membuat sesi dan token baru
+func finishLogin(c *fiber.Ctx, user *model.User) (*model.LoginResponse, error) {
+	ip := c.IP()
+	repository.RecordLoginAttempt(&user.ID, user.Username, ip, c.Get("User-Agent"), true, "")
+	if err := repository.RegisterSuccessfulLogin(user.ID.String(), ip); err != nil {
+		return nil, err
+	}
+
+	perms := loadPermissions(user)
+
+	accessToken, refreshToken, err := startSession(c, user, perms)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoginResponse{
 		Token:        accessToken,
 		RefreshToken: refreshToken,
 		User: model.UserResponse{
@@ -82,14 +164,13 @@ func Login(c *fiber.Ctx) error {
 			Role:        user.Role.Name,
 			Permissions: perms,
 		},
-	}
-
-	return helper.Success(c, response, "Login berhasil")
+	}, nil
 }
 
 // RefreshToken godoc
 // @Summary      Perbarui Token
-// @Description  Memperbarui Access Token yang kadaluarsa menggunakan Refresh Token yang valid.
+// @Description  Menukar Refresh Token (sekali pakai) dengan pasangan Access & Refresh Token baru.
+// @Description  Jika refresh token lama dipakai ulang, seluruh sesi dari token tersebut dicabut.
 // @Tags         Authentication
 // @Produce      json
 // @Param        Authorization  header    string  true  "Bearer {refresh_token}"
@@ -107,12 +188,10 @@ func RefreshToken(c *fiber.Ctx) error {
 		return helper.Error(c, fiber.StatusUnauthorized, "Refresh token is empty", nil)
 	}
 
-	token, err := jwt.Parse(refreshTokenString, func(token *jwt.Token) (interface{}, error) {
-		return getJWTSecret(), nil
-	})
+	token, err := keymanager.Default().Parse(refreshTokenString)
 
 	if err != nil || !token.Valid {
-		return helper.Error(c, fiber.StatusUnauthorized, "Refresh token tidak valid atau kadaluarsa", err.Error())
+		return helper.Error(c, fiber.StatusUnauthorized, "Refresh token tidak valid atau kadaluarsa", nil)
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
@@ -120,20 +199,41 @@ func RefreshToken(c *fiber.Ctx) error {
 		return helper.Error(c, fiber.StatusUnauthorized, "Token claims invalid", nil)
 	}
 
+	// Access token tidak boleh dipakai sebagai refresh token
+	if tokenType, _ := claims["token_type"].(string); tokenType != model.TokenTypeRefresh {
+		return helper.Error(c, fiber.StatusUnauthorized, "Token bukan refresh token", nil)
+	}
+
 	userIDStr, _ := claims["user_id"].(string)
+	sessionID, _ := claims["sid"].(string)
+	if sessionID == "" {
+		return helper.Error(c, fiber.StatusUnauthorized, "Token claims invalid", nil)
+	}
 
 	user, err := repository.FindUserByID(userIDStr)
-	if err != nil {
+	if err != nil || !user.IsActive {
 		return helper.Error(c, fiber.StatusUnauthorized, "User tidak ditemukan", nil)
 	}
 	
-	perms, _ := repository.GetPermissionsByRoleID(user.RoleID.String())
+	perms := loadPermissions(user)
 	
-	newAccess, newRefresh, err := generateTokens(user, perms)
+	newAccess, newRefresh, err := generateTokens(user, perms, sessionID)
 	if err != nil {
 		return helper.Error(c, fiber.StatusInternalServerError, "Gagal refresh token", err.Error())
 	}
 
+	err = repository.RotateRefreshToken(sessionID, hashToken(refreshTokenString), hashToken(newRefresh), time.Now().Add(refreshTokenTTL))
+	switch {
+	case errors.Is(err, repository.ErrRefreshTokenReused):
+		return helper.Error(c, fiber.StatusUnauthorized, "Refresh token sudah pernah dipakai. Sesi ini telah dicabut, silakan login ulang.", nil)
+	case errors.Is(err, repository.ErrRefreshTokenNotFound),
+		errors.Is(err, repository.ErrSessionRevoked),
+		errors.Is(err, repository.ErrSessionExpired):
+		return helper.Error(c, fiber.StatusUnauthorized, "Sesi tidak valid atau sudah berakhir, silakan login ulang.", nil)
+	case err != nil:
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal refresh token", err.Error())
+	}
+
 	return helper.Success(c, &model.LoginResponse{
 		Token:        newAccess,
 		RefreshToken: newRefresh,
@@ -149,27 +249,24 @@ func RefreshToken(c *fiber.Ctx) error {
 
 // Logout godoc
 // @Summary      Logout Pengguna
-// @Description  Mengakhiri sesi pengguna dan memasukkan token ke dalam daftar blacklist.
+// @Description  Mengakhiri sesi pengguna: access token dicabut (berdasarkan jti) dan refresh token sesi ini tidak berlaku lagi.
 // @Tags         Authentication
 // @Produce      json
 // @Success      200  {object}  helper.Response
 // @Router       /auth/logout [post]
 // @Security     BearerAuth
 func Logout(c *fiber.Ctx) error {
-	tokenString := strings.Replace(c.Get("Authorization"), "Bearer ", "", 1)
-	
-	token, _ := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return getJWTSecret(), nil
-	})
-	
-	claims, _ := token.Claims.(jwt.MapClaims)
-	expTimeUnix := int64(claims["exp"].(float64))
-	
-	expiration := time.Unix(expTimeUnix, 0)
-	ttl := expiration.Sub(time.Now())
-	
-	if err := repository.SetTokenBlacklist(tokenString, ttl); err != nil {
-		// Log error
+	jti, _ := c.Locals("jti").(string)
+	expiration, _ := c.Locals("token_exp").(time.Time)
+	if err := repository.RevokeToken(jti, expiration); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mencabut token", err.Error())
+	}
+
+	// Cabut juga sesinya agar refresh token tidak bisa dipakai lagi
+	if sessionID, _ := c.Locals("sid").(string); sessionID != "" {
+		if err := repository.RevokeSession(sessionID, "logout"); err != nil {
+			return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengakhiri sesi", err.Error())
+		}
 	}
 	
 	return helper.Success(c, nil, "Logout berhasil")
@@ -192,25 +289,81 @@ func GetProfile(c *fiber.Ctx) error {
 	return helper.Success(c, user, "Profil user")
 }
 
-func generateTokens(user *model.User, perms []string) (string, string, error) {
-	secret := getJWTSecret()
+const (
+	accessTokenTTL  = 2 * time.Hour
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// loadPermissions mengambil permission efektif role user (Admin selalu mendapat user:read_all)
+// dan mencatat versi permission role ke user.Role untuk claim `pv`.
+func loadPermissions(user *model.User) []string {
+	version, perms, _ := repository.GetEffectiveRolePermissions(user.RoleID.String())
+	user.Role.PermissionVersion = version
+	return perms
+}
+
+// startSession membuat sesi server-side baru dan menerbitkan pasangan token untuknya
+func startSession(c *fiber.Ctx, user *model.User, perms []string) (string, string, error) {
+	session := model.Session{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		UserAgent: c.Get("User-Agent"),
+		IPAddress: c.IP(),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}
 
+	accessToken, refreshToken, err := generateTokens(user, perms, session.ID.String())
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := repository.CreateSession(&session, hashToken(refreshToken)); err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// hashToken menghasilkan SHA-256 (hex) dari token; hanya hash yang disimpan di database
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateTokens(user *model.User, perms []string, sessionID string) (string, string, error) {
 	claims := jwt.MapClaims{
 		"user_id":     user.ID,
 		"role":        user.Role.Name,
+		"role_id":     user.RoleID,
+		"pv":          user.Role.PermissionVersion,
 		"permissions": perms,
-		"exp":         time.Now().Add(2 * time.Hour).Unix(),
+		"token_type":  model.TokenTypeAccess,
+		"sid":         sessionID,
+		"jti":         uuid.NewString(),
+		"exp":         time.Now().Add(accessTokenTTL).Unix(),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, err := token.SignedString(secret)
+	t, err := keymanager.Default().Sign(claims)
 	if err != nil { return "", "", err }
 
 	refreshClaims := jwt.MapClaims{
-		"user_id": user.ID,
-		"exp":     time.Now().Add(7 * 24 * time.Hour).Unix(),
+		"user_id":    user.ID,
+		"token_type": model.TokenTypeRefresh,
+		"sid":        sessionID,
+		"jti":        uuid.NewString(),
+		"exp":        time.Now().Add(refreshTokenTTL).Unix(),
 	}
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	rt, err := refreshToken.SignedString(secret)
+	rt, err := keymanager.Default().Sign(refreshClaims)
 
 	return t, rt, err
-}
\ No newline at end of file
+}
+
+// GetJWKS godoc
+// @Summary      JSON Web Key Set
+// @Description  Public key (RS256/EdDSA) yang dipakai untuk memverifikasi JWT, dipilih berdasarkan header kid. Key HS256 tidak pernah dipublikasikan.
+// @Tags         Authentication
+// @Produce      json
+// @Success      200  {object}  keymanager.JWKS
+// @Router       /.well-known/jwks.json [get]
+func GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(keymanager.Default().JWKS())
+}
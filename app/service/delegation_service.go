@@ -0,0 +1,237 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"sistempelaporan/app/delegation"
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/helper"
+	"sistempelaporan/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// DelegatePermission dibutuhkan untuk mengelola delegasi: secara global untuk delegasi milik sendiri
+// (dosen wali) atau semua dosen (admin), atau lewat role assignment berscope untuk dosen di unitnya.
+const DelegatePermission = "delegation:manage"
+
+// applyDelegation menandai event dilakukan atas nama dosen wali jika akses diberikan lewat delegasi.
+func applyDelegation(event *model.AchievementStatusEvent, decision policy.Decision) {
+	if decision.Relation != policy.RelationDelegate || decision.Delegation == nil {
+		return
+	}
+	if id, err := uuid.Parse(decision.Delegation.ID); err == nil {
+		event.DelegationID = &id
+	}
+	if id, err := uuid.Parse(decision.Delegation.FromLecturerID); err == nil {
+		event.OnBehalfOf = &id
+	}
+}
+
+// authorizeDelegation memastikan subject boleh mengelola delegasi dosen lecturerID.
+// Jika ok = false, respon error sudah ditulis.
+func authorizeDelegation(c *fiber.Ctx, subject policy.Subject, lecturerID string, notFoundStatus int) (bool, error) {
+	lecturer, err := repository.LecturerPolicyResource(lecturerID)
+	if errors.Is(err, repository.ErrResourceNotFound) {
+		return false, helper.Error(c, notFoundStatus, "Dosen wali tidak ditemukan", nil)
+	}
+	if err != nil {
+		return false, helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+	if decision := policy.Can(subject, policy.ActionDelegate, lecturer); !decision.Allowed {
+		return false, helper.Error(c, fiber.StatusForbidden, "Akses ditolak: "+decision.Reason, nil)
+	}
+	return true, nil
+}
+
+// GetAdvisorDelegations godoc
+// @Summary      Daftar Delegasi Dosen Wali
+// @Description  Delegasi dari / ke dosen yang login, ditambah delegasi dosen di unit pengelola (admin: semua).
+// @Description  status dihitung dari rentang tanggal: scheduled, active, expired atau revoked.
+// @Tags         Advisor Delegations
+// @Produce      json
+// @Param        status  query     string  false  "Filter status (scheduled, active, expired, revoked)"
+// @Success      200     {object}  helper.Response{data=[]model.AdvisorDelegation}
+// @Router       /advisor-delegations [get]
+// @Security     BearerAuth
+func GetAdvisorDelegations(c *fiber.Ctx) error {
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+	scope, ok := policy.ListScope(subject, policy.ResourceLecturer, DelegatePermission)
+	if !ok && subject.LecturerID == "" {
+		return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: tidak memiliki akses ke daftar delegasi", nil)
+	}
+
+	delegations, err := repository.ListDelegations(scope, subject.LecturerID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil delegasi", err.Error())
+	}
+
+	today := delegation.CurrentDate()
+	status := c.Query("status")
+	result := []model.AdvisorDelegation{}
+	for _, d := range delegations {
+		d.Status = delegation.Status(d, today)
+		if status == "" || d.Status == status {
+			result = append(result, d)
+		}
+	}
+	return helper.Success(c, result, "Daftar delegasi dosen wali")
+}
+
+// CreateAdvisorDelegation godoc
+// @Summary      Buat Delegasi Dosen Wali
+// @Description  Selama start_date s/d end_date (inklusif), dosen pengganti (to_lecturer_id) boleh membaca, memverifikasi
+// @Description  dan menolak prestasi mahasiswa bimbingan dosen wali (from_lecturer_id, default dosen yang login), termasuk
+// @Description  tahap dosen wali pada pipeline verifikasi. student_ids membatasi delegasi ke sebagian mahasiswa bimbingan.
+// @Description  Dosen pengganti tetap membutuhkan permission achievement:verify; history mencatat on_behalf_of.
+// @Description  Delegasi ke dosen pengganti yang sama dengan rentang tanggal beririsan ditolak (409); cabut yang lama dulu.
+// @Tags         Advisor Delegations
+// @Accept       json
+// @Produce      json
+// @Param        body  body      model.DelegationRequest  true  "Data delegasi"
+// @Success      201   {object}  helper.Response{data=model.AdvisorDelegation}
+// @Failure      400   {object}  helper.Response
+// @Failure      403   {object}  helper.Response
+// @Failure      409   {object}  helper.Response
+// @Router       /advisor-delegations [post]
+// @Security     BearerAuth
+func CreateAdvisorDelegation(c *fiber.Ctx) error {
+	var req model.DelegationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+
+	errs := map[string]string{}
+	d := &model.AdvisorDelegation{Reason: strings.TrimSpace(req.Reason), CreatedBy: currentUserID(c)}
+
+	from := strings.TrimSpace(req.FromLecturerID)
+	if from == "" {
+		from = subject.LecturerID
+	}
+	if d.FromLecturerID, err = uuid.Parse(from); err != nil {
+		errs["from_lecturer_id"] = "Dosen wali wajib diisi (UUID dosen)"
+	}
+	if d.ToLecturerID, err = uuid.Parse(strings.TrimSpace(req.ToLecturerID)); err != nil {
+		errs["to_lecturer_id"] = "Dosen pengganti wajib diisi (UUID dosen)"
+	}
+	for field, raw := range map[string]string{"start_date": req.StartDate, "end_date": req.EndDate} {
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(raw))
+		if err != nil {
+			errs[field] = "Format tanggal harus YYYY-MM-DD"
+			continue
+		}
+		if field == "start_date" {
+			d.StartDate = date
+		} else {
+			d.EndDate = date
+		}
+	}
+	seen := map[uuid.UUID]bool{}
+	d.StudentIDs = []uuid.UUID{}
+	for _, raw := range req.StudentIDs {
+		id, err := uuid.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			errs["student_ids"] = "student_ids wajib berisi UUID mahasiswa"
+			break
+		}
+		if !seen[id] {
+			seen[id] = true
+			d.StudentIDs = append(d.StudentIDs, id)
+		}
+	}
+	if len(errs) == 0 {
+		errs = delegation.Validate(*d, delegation.CurrentDate())
+	}
+	if len(errs) > 0 {
+		return helper.Error(c, fiber.StatusBadRequest, "Data delegasi tidak valid", errs)
+	}
+
+	if ok, err := authorizeDelegation(c, subject, d.FromLecturerID.String(), fiber.StatusBadRequest); !ok {
+		return err
+	}
+	if _, err := repository.LecturerPolicyResource(d.ToLecturerID.String()); errors.Is(err, repository.ErrResourceNotFound) {
+		return helper.Error(c, fiber.StatusBadRequest, "Dosen pengganti tidak ditemukan", nil)
+	} else if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data dosen", err.Error())
+	}
+	if overlap, err := repository.HasOverlappingDelegation(d.FromLecturerID, d.ToLecturerID, d.StartDate, d.EndDate); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa delegasi", err.Error())
+	} else if overlap {
+		return helper.Error(c, fiber.StatusConflict, "Delegasi ke dosen pengganti yang sama sudah ada pada rentang tanggal ini", nil)
+	}
+	if len(d.StudentIDs) > 0 {
+		missing, err := repository.StudentsNotAdvisedBy(d.FromLecturerID.String(), d.StudentIDs)
+		if err != nil {
+			return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa mahasiswa bimbingan", err.Error())
+		}
+		if len(missing) > 0 {
+			return helper.Error(c, fiber.StatusBadRequest, "Data delegasi tidak valid",
+				fiber.Map{"student_ids": "bukan mahasiswa bimbingan dosen wali", "invalid": missing})
+		}
+	}
+
+	if err := repository.CreateDelegation(d); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan delegasi", err.Error())
+	}
+	created, err := repository.FindDelegation(d.ID.String())
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil delegasi", err.Error())
+	}
+	created.Status = delegation.Status(*created, delegation.CurrentDate())
+	return helper.Created(c, created, "Delegasi dosen wali berhasil dibuat")
+}
+
+// RevokeAdvisorDelegation godoc
+// @Summary      Cabut Delegasi Dosen Wali
+// @Description  Mengakhiri delegasi seketika (misal dosen wali kembali lebih awal). Persetujuan yang sudah dicatat
+// @Description  atas nama dosen wali tetap berlaku.
+// @Tags         Advisor Delegations
+// @Param        id   path      string  true  "Delegation ID"
+// @Success      200  {object}  helper.Response{data=model.AdvisorDelegation}
+// @Failure      404  {object}  helper.Response
+// @Failure      409  {object}  helper.Response
+// @Router       /advisor-delegations/{id} [delete]
+// @Security     BearerAuth
+func RevokeAdvisorDelegation(c *fiber.Ctx) error {
+	d, err := repository.FindDelegation(c.Params("id"))
+	if errors.Is(err, repository.ErrDelegationNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Delegasi tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil delegasi", err.Error())
+	}
+
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+	if ok, err := authorizeDelegation(c, subject, d.FromLecturerID.String(), fiber.StatusNotFound); !ok {
+		return err
+	}
+	if d.RevokedAt != nil {
+		return helper.Error(c, fiber.StatusConflict, "Delegasi sudah dicabut", nil)
+	}
+
+	err = repository.RevokeDelegation(d, currentUserID(c))
+	if errors.Is(err, repository.ErrDelegationNotFound) {
+		return helper.Error(c, fiber.StatusConflict, "Delegasi sudah dicabut", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mencabut delegasi", err.Error())
+	}
+	d.Status = delegation.Status(*d, delegation.CurrentDate())
+	return helper.Success(c, d, "Delegasi dosen wali berhasil dicabut")
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/helper"
+	"sistempelaporan/keymanager"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Token impersonation sengaja singkat dan tidak bisa di-refresh
+func impersonationTTL() time.Duration {
+	return time.Duration(getEnvInt("IMPERSONATION_TTL_MINUTES", 15)) * time.Minute
+}
+
+// ImpersonateUser godoc
+// @Summary      Login Sebagai User Lain (Admin)
+// @Description  Menerbitkan access token singkat (default 15 menit, tanpa refresh token) untuk melihat sistem sebagai Mahasiswa / Dosen Wali.
+// @Description  Token membawa claim impersonator_id. Akun Admin tidak dapat di-impersonate. Setiap request yang mengubah data
+// @Description  dicatat di audit log dan secara default diblokir (IMPERSONATION_ALLOW_MUTATIONS).
+// @Tags         Users (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true   "User ID"
+// @Param        body  body      object  false  "reason (misal nomor tiket bug)"
+// @Success      200   {object}  helper.Response{data=model.ImpersonationResponse}
+// @Failure      403   {object}  helper.Response
+// @Failure      404   {object}  helper.Response
+// @Router       /users/{id}/impersonate [post]
+// @Security     BearerAuth
+func ImpersonateUser(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+	targetID := c.Params("id")
+
+	if authType, _ := c.Locals("auth_type").(string); authType != model.AuthTypeUser {
+		return helper.Error(c, fiber.StatusForbidden, "Impersonation hanya dapat dimulai oleh akun pengguna", nil)
+	}
+	if impersonatorID, _ := c.Locals("impersonator_id").(string); impersonatorID != "" {
+		return helper.Error(c, fiber.StatusForbidden, "Tidak dapat memulai impersonation dari dalam impersonation", nil)
+	}
+	if targetID == adminID {
+		return helper.Error(c, fiber.StatusBadRequest, "Tidak dapat meng-impersonate akun sendiri", nil)
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+		}
+	}
+
+	target, err := repository.FindUserByID(targetID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "User tidak ditemukan", nil)
+	}
+	if !target.IsActive {
+		return helper.Error(c, fiber.StatusBadRequest, "User tidak aktif", nil)
+	}
+	// Mencegah eskalasi: admin tidak boleh bertindak sebagai admin lain
+	if strings.EqualFold(target.Role.Name, model.RoleAdmin) {
+		return helper.Error(c, fiber.StatusForbidden, "Akun Admin tidak dapat di-impersonate", nil)
+	}
+
+	perms := loadPermissions(target)
+	jti := uuid.NewString()
+	expiresAt := time.Now().Add(impersonationTTL())
+	adminSessionID, _ := c.Locals("sid").(string)
+
+	token, err := keymanager.Default().Sign(jwt.MapClaims{
+		"user_id":          target.ID,
+		"role":             target.Role.Name,
+		"role_id":          target.RoleID,
+		"pv":               target.Role.PermissionVersion,
+		"permissions":      perms,
+		"token_type":       model.TokenTypeAccess,
+		"impersonator_id":  adminID,
+		"impersonator_sid": adminSessionID,
+		"jti":              jti,
+		"exp":              expiresAt.Unix(),
+	})
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal generate token", nil)
+	}
+
+	entry := model.AuditLog{
+		Action:    model.AuditImpersonationStart,
+		UserID:    target.ID,
+		TokenID:   jti,
+		Reason:    strings.TrimSpace(req.Reason),
+		IPAddress: c.IP(),
+		UserAgent: c.Get("User-Agent"),
+	}
+	entry.ImpersonatorID, _ = uuid.Parse(adminID)
+	// Tanpa jejak audit, token tidak diberikan
+	if err := repository.CreateAuditLog(&entry); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mencatat audit log", err.Error())
+	}
+
+	return helper.Success(c, model.ImpersonationResponse{
+		Token:          token,
+		ExpiresAt:      expiresAt,
+		ImpersonatorID: adminID,
+		User: model.UserResponse{
+			ID:          target.ID.String(),
+			Username:    target.Username,
+			FullName:    target.FullName,
+			Role:        target.Role.Name,
+			Permissions: perms,
+		},
+	}, "Impersonation dimulai sebagai "+target.Username)
+}
+
+// GetAuditLogs godoc
+// @Summary      Audit Log Impersonation (Admin)
+// @Description  Menampilkan jejak impersonation terbaru: kapan dimulai, request yang mengubah data, dan request yang diblokir.
+// @Tags         Users (Admin)
+// @Produce      json
+// @Param        impersonator_id  query     string  false  "Filter admin pelaku"
+// @Param        user_id          query     string  false  "Filter user yang di-impersonate"
+// @Param        limit            query     int     false  "Jumlah data (default 100, maks 500)"
+// @Success      200              {object}  helper.Response{data=[]model.AuditLog}
+// @Router       /audit-logs [get]
+// @Security     BearerAuth
+func GetAuditLogs(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 100)
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	impersonatorID := c.Query("impersonator_id")
+	userID := c.Query("user_id")
+	for _, id := range []string{impersonatorID, userID} {
+		if id == "" {
+			continue
+		}
+		if _, err := uuid.Parse(id); err != nil {
+			return helper.Error(c, fiber.StatusBadRequest, "Format ID tidak valid", nil)
+		}
+	}
+
+	logs, err := repository.ListAuditLogs(impersonatorID, userID, limit)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil audit log", err.Error())
+	}
+	return helper.Success(c, logs, "Daftar audit log")
+}
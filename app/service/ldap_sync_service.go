@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"sistempelaporan/app/repository"
+	"sistempelaporan/authenticator"
+	"sistempelaporan/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LDAPSyncResult adalah ringkasan satu kali sinkronisasi user LDAP.
+type LDAPSyncResult struct {
+	DirectoryUsers int      `json:"directory_users"`
+	CheckedUsers   int      `json:"checked_users"`
+	Deactivated    []string `json:"deactivated"`  // username yang dinonaktifkan karena hilang dari direktori
+	RoleUpdated    []string `json:"role_updated"` // username yang role-nya diubah sesuai grup LDAP
+}
+
+var errLDAPNotConfigured = errors.New("LDAP belum dikonfigurasi")
+
+// SyncLDAPUsers mencocokkan akun auth_source = ldap dengan direktori: akun yang sudah tidak ada
+// dinonaktifkan dan role disesuaikan dengan pemetaan grup -> role; keduanya mencabut sesi user.
+func SyncLDAPUsers() (*LDAPSyncResult, error) {
+	authn, err := authenticator.For(authenticator.SourceLDAP)
+	if err != nil {
+		return nil, errLDAPNotConfigured
+	}
+	directory, ok := authn.(authenticator.Directory)
+	if !ok {
+		return nil, errLDAPNotConfigured
+	}
+
+	entries, err := directory.ListUsers()
+	if err != nil {
+		return nil, err
+	}
+	// Direktori kosong hampir pasti salah konfigurasi; jangan nonaktifkan semua akun
+	if len(entries) == 0 {
+		return nil, errors.New("direktori LDAP tidak mengembalikan user, sinkronisasi dibatalkan")
+	}
+
+	inDirectory := make(map[string]authenticator.DirectoryUser, len(entries))
+	for _, e := range entries {
+		inDirectory[strings.ToLower(e.Username)] = e
+	}
+
+	rules, err := repository.ListLDAPGroupRoleMappings()
+	if err != nil {
+		return nil, err
+	}
+	users, err := repository.ListActiveUsersBySource(authenticator.SourceLDAP)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LDAPSyncResult{DirectoryUsers: len(entries), CheckedUsers: len(users), Deactivated: []string{}, RoleUpdated: []string{}}
+	for _, user := range users {
+		entry, found := inDirectory[strings.ToLower(user.Username)]
+		if !found {
+			if err := repository.DeleteUserByID(user.ID.String()); err != nil {
+				return result, err
+			}
+			if _, err := repository.RevokeAllUserSessions(user.ID.String(), "ldap_removed"); err != nil {
+				return result, err
+			}
+			result.Deactivated = append(result.Deactivated, user.Username)
+			continue
+		}
+
+		if rule, ok := authenticator.MapGroupsToRole(entry.Groups, rules); ok && rule.RoleID != user.RoleID.String() {
+			if err := changeUserRole(user.ID.String(), rule.RoleID); err != nil {
+				return result, err
+			}
+			result.RoleUpdated = append(result.RoleUpdated, user.Username)
+		}
+	}
+	return result, nil
+}
+
+// StartLDAPSync menjalankan SyncLDAPUsers secara berkala. Panggil fungsi stop untuk menghentikannya.
+func StartLDAPSync(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				result, err := SyncLDAPUsers()
+				if err != nil {
+					log.Printf("Sinkronisasi LDAP gagal: %v", err)
+					continue
+				}
+				if len(result.Deactivated) > 0 || len(result.RoleUpdated) > 0 {
+					log.Printf("Sinkronisasi LDAP: %d user dinonaktifkan, %d role diperbarui", len(result.Deactivated), len(result.RoleUpdated))
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// TriggerLDAPSync godoc
+// @Summary      Sinkronisasi User LDAP
+// @Description  Menjalankan sinkronisasi akun LDAP sekarang: akun yang hilang dari direktori dinonaktifkan dan role disesuaikan dengan grup.
+// @Tags         Users (Admin)
+// @Produce      json
+// @Success      200  {object}  helper.Response{data=service.LDAPSyncResult}
+// @Failure      404  {object}  helper.Response
+// @Failure      503  {object}  helper.Response
+// @Router       /users/ldap/sync [post]
+// @Security     BearerAuth
+func TriggerLDAPSync(c *fiber.Ctx) error {
+	result, err := SyncLDAPUsers()
+	if errors.Is(err, errLDAPNotConfigured) {
+		return helper.Error(c, fiber.StatusNotFound, "LDAP tidak diaktifkan", nil)
+	}
+	if authenticator.IsUnavailable(err) {
+		return helper.Error(c, fiber.StatusServiceUnavailable, "Direktori LDAP tidak dapat dihubungi", err.Error())
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Sinkronisasi LDAP gagal", err.Error())
+	}
+	return helper.Success(c, result, "Sinkronisasi LDAP selesai")
+}
@@ -0,0 +1,332 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"sistempelaporan/app/lockout"
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/helper"
+	"sistempelaporan/oidc"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	oidcAuthRequestTTL = 10 * time.Minute
+	oidcStateCookie    = "oidc_state"
+)
+
+var (
+	errOIDCNotRegistered = errors.New("akun SSO belum terdaftar")
+	errOIDCLinkRequired  = errors.New("akun harus dihubungkan ke SSO setelah login")
+)
+
+// Role yang boleh dibuat otomatis dari SSO. Akun staf (Admin, Dosen Wali) tetap dibuat oleh admin.
+const oidcProvisionableRole = model.RoleMahasiswa
+
+func oidcJITEnabled() bool {
+	return strings.EqualFold(os.Getenv("OIDC_JIT_PROVISIONING"), "true")
+}
+
+func oidcClaimName(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// OIDCLogin godoc
+// @Summary      Login SSO (OpenID Connect)
+// @Description  Mengarahkan browser ke identity provider kampus (authorization code + PKCE).
+// @Tags         Authentication
+// @Success      302
+// @Failure      404  {object}  helper.Response
+// @Router       /auth/oidc/login [get]
+func OIDCLogin(c *fiber.Ctx) error {
+	authURL, ok, err := startOIDCAuth(c, "")
+	if !ok {
+		return err
+	}
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// LinkOIDCAccount godoc
+// @Summary      Hubungkan Akun ke SSO
+// @Description  Langkah konfirmasi untuk user yang sudah login (password + 2FA): mengembalikan URL identity provider.
+// @Description  Setelah login di provider, callback menghubungkan identitas SSO ke akun ini. Wajib untuk akun staf
+// @Description  dan akun ber-2FA, yang tidak pernah dihubungkan otomatis lewat email.
+// @Tags         Authentication
+// @Produce      json
+// @Success      200  {object}  helper.Response
+// @Failure      404  {object}  helper.Response
+// @Router       /auth/oidc/link [post]
+// @Security     BearerAuth
+func LinkOIDCAccount(c *fiber.Ctx) error {
+	authURL, ok, err := startOIDCAuth(c, c.Locals("user_id").(string))
+	if !ok {
+		return err
+	}
+	return helper.Success(c, fiber.Map{"authorization_url": authURL}, "Lanjutkan login di identity provider untuk menghubungkan akun")
+}
+
+// startOIDCAuth menyimpan state, nonce dan PKCE verifier lalu membuat URL authorize identity provider.
+// Jika ok = false, respon error sudah ditulis.
+func startOIDCAuth(c *fiber.Ctx, linkUserID string) (string, bool, error) {
+	provider, err := oidc.Default()
+	if errors.Is(err, oidc.ErrNotConfigured) {
+		return "", false, helper.Error(c, fiber.StatusNotFound, "Login SSO tidak diaktifkan", nil)
+	}
+	if err != nil {
+		return "", false, helper.Error(c, fiber.StatusInternalServerError, "Gagal memuat konfigurasi SSO", err.Error())
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", false, helper.Error(c, fiber.StatusInternalServerError, "Gagal memulai login SSO", nil)
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", false, helper.Error(c, fiber.StatusInternalServerError, "Gagal memulai login SSO", nil)
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", false, helper.Error(c, fiber.StatusInternalServerError, "Gagal memulai login SSO", nil)
+	}
+
+	if err := repository.SaveOIDCAuthRequest(hashToken(state), nonce, verifier, linkUserID, time.Now().Add(oidcAuthRequestTTL)); err != nil {
+		return "", false, helper.Error(c, fiber.StatusInternalServerError, "Gagal memulai login SSO", err.Error())
+	}
+
+	authURL, err := provider.AuthCodeURL(c.UserContext(), state, nonce, oidc.CodeChallengeS256(verifier))
+	if err != nil {
+		return "", false, helper.Error(c, fiber.StatusBadGateway, "Identity provider tidak dapat dihubungi", err.Error())
+	}
+
+	// State juga diikat ke browser agar callback tidak bisa dipakai untuk login CSRF
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/",
+		Expires:  time.Now().Add(oidcAuthRequestTTL),
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return authURL, true, nil
+}
+
+// OIDCCallback godoc
+// @Summary      Callback SSO (OpenID Connect)
+// @Description  Menukar authorization code, memverifikasi ID token, lalu memetakan identitas ke user (subject atau email).
+// @Description  Email hanya dipakai jika email_verified = true, dan hanya untuk akun Mahasiswa tanpa 2FA; akun lain
+// @Description  dihubungkan lewat /auth/oidc/link. Untuk alur /auth/oidc/link, callback hanya menghubungkan akun.
+// @Description  Jika diaktifkan, akun Mahasiswa baru dibuat otomatis berdasarkan aturan claim -> role.
+// @Description  Respon sama dengan login password (termasuk challenge 2FA bila diperlukan).
+// @Tags         Authentication
+// @Produce      json
+// @Param        code   query     string  true  "Authorization code"
+// @Param        state  query     string  true  "State"
+// @Success      200    {object}  helper.Response{data=model.LoginResponse}
+// @Failure      401    {object}  helper.Response
+// @Failure      403    {object}  helper.Response
+// @Router       /auth/oidc/callback [get]
+func OIDCCallback(c *fiber.Ctx) error {
+	provider, err := oidc.Default()
+	if errors.Is(err, oidc.ErrNotConfigured) {
+		return helper.Error(c, fiber.StatusNotFound, "Login SSO tidak diaktifkan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memuat konfigurasi SSO", err.Error())
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		return helper.Error(c, fiber.StatusUnauthorized, "Login SSO dibatalkan atau ditolak", fiber.Map{
+			"error":             providerErr,
+			"error_description": c.Query("error_description"),
+		})
+	}
+
+	state := c.Query("state")
+	code := c.Query("code")
+	if state == "" || code == "" {
+		return helper.Error(c, fiber.StatusBadRequest, "Parameter code dan state wajib diisi", nil)
+	}
+	if cookieState := c.Cookies(oidcStateCookie); cookieState == "" || cookieState != state {
+		return helper.Error(c, fiber.StatusUnauthorized, "State SSO tidak cocok dengan browser ini", nil)
+	}
+	c.ClearCookie(oidcStateCookie)
+
+	nonce, verifier, linkUserID, err := repository.ConsumeOIDCAuthRequest(hashToken(state))
+	if errors.Is(err, repository.ErrOIDCStateInvalid) {
+		return helper.Error(c, fiber.StatusUnauthorized, "Sesi login SSO tidak valid atau kadaluarsa, silakan ulangi", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memproses login SSO", err.Error())
+	}
+
+	tokens, err := provider.Exchange(c.UserContext(), code, verifier)
+	if err != nil {
+		return helper.Error(c, fiber.StatusUnauthorized, "Gagal menukar authorization code", err.Error())
+	}
+
+	claims, err := provider.VerifyIDToken(c.UserContext(), tokens.IDToken, nonce)
+	if err != nil {
+		return helper.Error(c, fiber.StatusUnauthorized, "ID token tidak valid", err.Error())
+	}
+
+	if linkUserID != "" {
+		err := repository.LinkOIDCIdentity(linkUserID, provider.Config().Issuer, claims.Subject)
+		if errors.Is(err, repository.ErrOIDCIdentityConflict) {
+			return helper.Error(c, fiber.StatusConflict, "Akun ini atau identitas SSO tersebut sudah terhubung dengan akun lain", nil)
+		}
+		if err != nil {
+			return helper.Error(c, fiber.StatusInternalServerError, "Gagal menghubungkan akun SSO", err.Error())
+		}
+		return helper.Success(c, nil, "Akun SSO berhasil dihubungkan")
+	}
+
+	user, err := resolveOIDCUser(provider.Config().Issuer, claims)
+	switch {
+	case errors.Is(err, errOIDCNotRegistered):
+		repository.RecordLoginAttempt(nil, claims.Email, c.IP(), c.Get("User-Agent"), false, "oidc_not_registered")
+		return helper.Error(c, fiber.StatusForbidden, "Akun SSO Anda belum terdaftar di sistem. Hubungi admin.", nil)
+	case errors.Is(err, errOIDCLinkRequired):
+		repository.RecordLoginAttempt(nil, claims.Email, c.IP(), c.Get("User-Agent"), false, "oidc_link_required")
+		return helper.Error(c, fiber.StatusForbidden, "Akun ini harus dihubungkan ke SSO terlebih dahulu: login dengan password lalu buka POST /auth/oidc/link", nil)
+	case errors.Is(err, repository.ErrOIDCIdentityConflict):
+		repository.RecordLoginAttempt(nil, claims.Email, c.IP(), c.Get("User-Agent"), false, "oidc_identity_conflict")
+		return helper.Error(c, fiber.StatusConflict, "Email ini sudah terhubung dengan akun SSO lain", nil)
+	case err != nil:
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memproses akun SSO", err.Error())
+	}
+
+	// Kebijakan akun tetap berlaku walaupun autentikasi dilakukan oleh identity provider
+	if lockout.Locked(user.LockedUntil, time.Now()) {
+		repository.RecordLoginAttempt(&user.ID, user.Username, c.IP(), c.Get("User-Agent"), false, "account_locked")
+		return helper.Error(c, fiber.StatusLocked, "Akun dikunci sementara karena terlalu banyak percobaan login gagal.", fiber.Map{"locked_until": user.LockedUntil})
+	}
+	if user.TwoFactorEnabled || user.Role.Require2FA {
+		return respondTwoFactorChallenge(c, user)
+	}
+
+	response, err := finishLogin(c, user)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal generate token", nil)
+	}
+	return helper.Success(c, response, "Login SSO berhasil")
+}
+
+// resolveOIDCUser memetakan identitas SSO ke user: subject yang sudah terhubung, lalu email,
+// lalu (jika diaktifkan) provisioning Mahasiswa baru.
+func resolveOIDCUser(issuer string, claims *oidc.Claims) (*model.User, error) {
+	user, err := repository.FindUserByOIDCSubject(issuer, claims.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, err
+	}
+
+	// Email hanya dipercaya jika provider menyatakannya terverifikasi (claim email_verified = true)
+	emailTrusted := claims.EmailTrusted()
+	if emailTrusted {
+		user, err = repository.FindLoginUserByEmail(claims.Email)
+		if err == nil {
+			if !oidcAutoLinkAllowed(user) {
+				return nil, errOIDCLinkRequired
+			}
+			if err := repository.LinkOIDCIdentity(user.ID.String(), issuer, claims.Subject); err != nil {
+				return nil, err
+			}
+			return user, nil
+		}
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return nil, err
+		}
+	}
+
+	if !oidcJITEnabled() || !emailTrusted {
+		return nil, errOIDCNotRegistered
+	}
+	return provisionOIDCUser(issuer, claims)
+}
+
+// oidcAutoLinkAllowed: hanya akun Mahasiswa tanpa 2FA yang dihubungkan otomatis lewat email. Akun staf dan
+// akun ber-2FA dihubungkan lewat LinkOIDCAccount setelah login, agar email di IdP saja tidak cukup untuk mengambil alih.
+func oidcAutoLinkAllowed(user *model.User) bool {
+	return user.Role.Name == oidcProvisionableRole && !user.TwoFactorEnabled && !user.Role.Require2FA
+}
+
+// provisionOIDCUser membuat akun Mahasiswa baru (just-in-time) jika aturan claim -> role cocok.
+func provisionOIDCUser(issuer string, claims *oidc.Claims) (*model.User, error) {
+	rules, err := repository.ListOIDCRoleMappings()
+	if err != nil {
+		return nil, err
+	}
+	roleName, ok := oidc.MatchRole(rules, claims.Raw)
+	if !ok || roleName != oidcProvisionableRole {
+		return nil, errOIDCNotRegistered
+	}
+
+	nim := oidc.ClaimString(claims.Raw, oidcClaimName("OIDC_NIM_CLAIM", "nim"))
+	if nim == "" {
+		log.Printf("oidc: provisioning %s dibatalkan, claim NIM kosong", claims.Subject)
+		return nil, errOIDCNotRegistered
+	}
+
+	roleID, err := repository.FindRoleIDByName(roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Password acak yang tidak pernah diberikan ke siapa pun: akun SSO login lewat identity provider
+	randomPassword, err := oidc.RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	username := claims.PreferredUsername
+	if username == "" {
+		username = nim
+	}
+	fullName := claims.Name
+	if fullName == "" {
+		fullName = username
+	}
+
+	newUser := model.User{
+		ID:           uuid.New(),
+		Username:     username,
+		Email:        claims.Email,
+		PasswordHash: string(passwordHash),
+		FullName:     fullName,
+	}
+	newUser.RoleID, _ = uuid.Parse(roleID)
+	student := model.Student{
+		ID:           uuid.New(),
+		StudentID:    nim,
+		ProgramStudy: oidc.ClaimString(claims.Raw, oidcClaimName("OIDC_PROGRAM_CLAIM", "program_study")),
+	}
+
+	if err := repository.CreateUserWithProfile(&newUser, &student, nil); err != nil {
+		return nil, err
+	}
+	if err := repository.LinkOIDCIdentity(newUser.ID.String(), issuer, claims.Subject); err != nil {
+		return nil, err
+	}
+	log.Printf("oidc: akun %s (%s) dibuat otomatis dari SSO", newUser.Username, roleName)
+
+	return repository.FindUserByOIDCSubject(issuer, claims.Subject)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+
+	"sistempelaporan/app/repository"
+	"sistempelaporan/helper"
+	"sistempelaporan/passwordpolicy"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Batas wajar untuk riwayat password (setiap entri berarti satu perbandingan bcrypt saat ganti password)
+const maxPasswordHistoryCount = 24
+
+// GetPasswordPolicies godoc
+// @Summary      Daftar Kebijakan Password
+// @Description  Menampilkan kebijakan password setiap role. Role tanpa kebijakan khusus memakai kebijakan default.
+// @Tags         Password Policy (Admin)
+// @Produce      json
+// @Success      200  {object}  helper.Response{data=[]repository.RolePasswordPolicy}
+// @Router       /password-policies [get]
+// @Security     BearerAuth
+func GetPasswordPolicies(c *fiber.Ctx) error {
+	policies, err := repository.ListPasswordPolicies()
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil kebijakan password", err.Error())
+	}
+	return helper.Success(c, policies, "Daftar kebijakan password")
+}
+
+// UpdatePasswordPolicy godoc
+// @Summary      Atur Kebijakan Password Role
+// @Description  Menyimpan kebijakan password untuk role tertentu. Berlaku untuk password yang diset setelahnya.
+// @Tags         Password Policy (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        roleId  path      string                 true  "Role ID"
+// @Param        body    body      passwordpolicy.Policy  true  "Kebijakan password"
+// @Success      200     {object}  helper.Response
+// @Failure      400     {object}  helper.Response
+// @Failure      404     {object}  helper.Response
+// @Router       /password-policies/{roleId} [put]
+// @Security     BearerAuth
+func UpdatePasswordPolicy(c *fiber.Ctx) error {
+	roleID := c.Params("roleId")
+	if _, err := uuid.Parse(roleID); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Role ID tidak valid", nil)
+	}
+
+	var policy passwordpolicy.Policy
+	if err := c.BodyParser(&policy); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	if policy.MinLength < 6 || policy.MinLength > passwordpolicy.MaxLength {
+		return helper.Error(c, fiber.StatusBadRequest, "min_length harus antara 6 dan 72", nil)
+	}
+	if policy.HistoryCount < 0 || policy.HistoryCount > maxPasswordHistoryCount {
+		return helper.Error(c, fiber.StatusBadRequest, "history_count harus antara 0 dan 24", nil)
+	}
+
+	if err := repository.UpsertPasswordPolicy(roleID, policy); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return helper.Error(c, fiber.StatusNotFound, "Role tidak ditemukan", nil)
+		}
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan kebijakan password", err.Error())
+	}
+	return helper.Success(c, policy, "Kebijakan password berhasil disimpan")
+}
+
+// ResetPasswordPolicy godoc
+// @Summary      Kembalikan Kebijakan Password Default
+// @Description  Menghapus kebijakan khusus role sehingga role kembali memakai kebijakan default.
+// @Tags         Password Policy (Admin)
+// @Produce      json
+// @Param        roleId  path      string  true  "Role ID"
+// @Success      200     {object}  helper.Response
+// @Router       /password-policies/{roleId} [delete]
+// @Security     BearerAuth
+func ResetPasswordPolicy(c *fiber.Ctx) error {
+	roleID := c.Params("roleId")
+	if _, err := uuid.Parse(roleID); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Role ID tidak valid", nil)
+	}
+
+	if err := repository.DeletePasswordPolicy(roleID); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menghapus kebijakan password", err.Error())
+	}
+	return helper.Success(c, passwordpolicy.Default(), "Role kembali memakai kebijakan password default")
+}
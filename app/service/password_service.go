@@ -0,0 +1,281 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"sistempelaporan/app/repository"
+	"sistempelaporan/authenticator"
+	"sistempelaporan/helper"
+	"sistempelaporan/mailer"
+	"sistempelaporan/passwordpolicy"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Konfigurasi reset password
+func passwordResetTTL() time.Duration {
+	return time.Duration(getEnvInt("PASSWORD_RESET_TTL_MINUTES", 30)) * time.Minute
+}
+
+// passwordResetURL adalah halaman frontend yang menerima token lewat query ?token=
+func passwordResetURL() string {
+	if url := os.Getenv("PASSWORD_RESET_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000/reset-password"
+}
+
+// newPasswordResetToken membuat token acak 256-bit (base64url)
+func newPasswordResetToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// checkPasswordPolicy memvalidasi password baru terhadap kebijakan role.
+// userID kosong berarti user baru (belum punya riwayat password).
+func checkPasswordPolicy(roleID, userID, password string, personalInfo ...string) ([]passwordpolicy.Violation, error) {
+	policy, err := repository.GetPasswordPolicyByRoleID(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous []string
+	if userID != "" && policy.HistoryCount > 0 {
+		previous, err = repository.GetRecentPasswordHashes(userID, policy.HistoryCount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return policy.Validate(passwordpolicy.Candidate{
+		Password:       password,
+		PersonalInfo:   personalInfo,
+		PreviousHashes: previous,
+	}), nil
+}
+
+// checkUserPasswordPolicy memvalidasi password baru untuk user yang sudah ada (username & NIM diambil dari database).
+func checkUserPasswordPolicy(userID, password string) ([]passwordpolicy.Violation, error) {
+	user, err := repository.FindUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !authenticator.ManagesPassword(user.AuthSource) {
+		return []passwordpolicy.Violation{{Code: "external_password", Message: "Password akun ini dikelola oleh direktori LDAP"}}, nil
+	}
+
+	personalInfo := []string{user.Username}
+	if student, err := repository.FindStudentByUserID(userID); err == nil {
+		personalInfo = append(personalInfo, student.StudentID)
+	}
+	return checkPasswordPolicy(user.RoleID.String(), userID, password, personalInfo...)
+}
+
+// ChangePassword godoc
+// @Summary      Ganti Password
+// @Description  Mengganti password user yang sedang login. Wajib menyertakan password saat ini.
+// @Description  Semua sesi lain milik user dicabut, sesi saat ini tetap aktif.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "current_password, new_password"
+// @Success      200   {object}  helper.Response
+// @Failure      400   {object}  helper.Response{errors=[]passwordpolicy.Violation}
+// @Failure      401   {object}  helper.Response
+// @Router       /auth/password/change [post]
+// @Security     BearerAuth
+func ChangePassword(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+	if req.NewPassword == "" {
+		return helper.Error(c, fiber.StatusBadRequest, "Password baru wajib diisi", nil)
+	}
+
+	user, err := repository.FindUserByID(userID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "User tidak ditemukan", nil)
+	}
+	if !authenticator.ManagesPassword(user.AuthSource) {
+		return helper.Error(c, fiber.StatusBadRequest, "Password akun ini dikelola oleh direktori LDAP dan tidak dapat diganti di sini", nil)
+	}
+
+	passwordHash, err := repository.GetPasswordHashByUserID(userID)
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.CurrentPassword)) != nil {
+		return helper.Error(c, fiber.StatusUnauthorized, "Password saat ini salah", nil)
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return helper.Error(c, fiber.StatusBadRequest, "Password baru harus berbeda dari password saat ini", nil)
+	}
+
+	violations, err := checkUserPasswordPolicy(userID, req.NewPassword)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa kebijakan password", err.Error())
+	}
+	if len(violations) > 0 {
+		return helper.Error(c, fiber.StatusBadRequest, "Password tidak memenuhi kebijakan", violations)
+	}
+
+	newHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memproses password", nil)
+	}
+	if err := repository.UpdateUserPassword(userID, string(newHash)); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengganti password", err.Error())
+	}
+
+	// Perangkat lain harus login ulang dengan password baru
+	if sessionID, _ := c.Locals("sid").(string); sessionID != "" {
+		_, err = repository.RevokeOtherUserSessions(userID, sessionID, "password_change")
+	} else {
+		_, err = repository.RevokeAllUserSessions(userID, "password_change")
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Password diganti tetapi gagal mencabut sesi lain", err.Error())
+	}
+
+	return helper.Success(c, nil, "Password berhasil diganti")
+}
+
+// ForgotPassword godoc
+// @Summary      Lupa Password
+// @Description  Mengirim link reset password ke email user. Respon selalu sama baik email terdaftar maupun tidak.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "email"
+// @Success      200   {object}  helper.Response
+// @Router       /auth/password/forgot [post]
+func ForgotPassword(c *fiber.Ctx) error {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		return helper.Error(c, fiber.StatusBadRequest, "Email wajib diisi", nil)
+	}
+
+	const message = "Jika email terdaftar, link reset password telah dikirim"
+
+	// Jangan bocorkan apakah email terdaftar: error apa pun tetap dibalas dengan pesan yang sama
+	user, err := repository.FindActiveUserByEmail(email)
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			log.Printf("forgot password: gagal mencari user: %v", err)
+		}
+		return helper.Success(c, nil, message)
+	}
+	// Akun LDAP tidak punya password lokal yang bisa direset
+	if !authenticator.ManagesPassword(user.AuthSource) {
+		return helper.Success(c, nil, message)
+	}
+
+	token, err := newPasswordResetToken()
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal membuat token reset", nil)
+	}
+	expiresAt := time.Now().Add(passwordResetTTL())
+	if err := repository.CreatePasswordResetToken(user.ID.String(), hashToken(token), c.IP(), expiresAt); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan token reset", err.Error())
+	}
+
+	msg := mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Reset Password - Sistem Pelaporan Prestasi Mahasiswa",
+		Body: fmt.Sprintf(
+			"Halo %s,\n\nKami menerima permintaan reset password untuk akun %s.\n"+
+				"Buka link berikut untuk membuat password baru (berlaku %d menit):\n\n%s?token=%s\n\n"+
+				"Abaikan email ini jika Anda tidak meminta reset password.\n",
+			user.FullName, user.Username, int(passwordResetTTL().Minutes()), passwordResetURL(), token,
+		),
+	}
+
+	// Dikirim di background agar waktu respon tidak membedakan email terdaftar atau tidak
+	go func() {
+		if err := mailer.Default().Send(msg); err != nil {
+			log.Printf("forgot password: gagal mengirim email ke user %s: %v", user.ID, err)
+		}
+	}()
+
+	return helper.Success(c, nil, message)
+}
+
+// ResetPassword godoc
+// @Summary      Reset Password
+// @Description  Mengganti password memakai token dari email lupa password. Token hanya berlaku sekali dan
+// @Description  semua sesi user dicabut setelah reset berhasil.
+// @Tags         Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "token, new_password"
+// @Success      200   {object}  helper.Response
+// @Failure      400   {object}  helper.Response{errors=[]passwordpolicy.Violation}
+// @Router       /auth/password/reset [post]
+func ResetPassword(c *fiber.Ctx) error {
+	var req struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+	if req.Token == "" || req.NewPassword == "" {
+		return helper.Error(c, fiber.StatusBadRequest, "Token dan password baru wajib diisi", nil)
+	}
+	tokenHash := hashToken(req.Token)
+
+	tokenUserID, err := repository.FindPasswordResetTokenUser(tokenHash)
+	if errors.Is(err, repository.ErrPasswordResetTokenInvalid) {
+		return helper.Error(c, fiber.StatusBadRequest, "Token reset tidak valid atau sudah kadaluarsa", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal reset password", err.Error())
+	}
+
+	violations, err := checkUserPasswordPolicy(tokenUserID, req.NewPassword)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa kebijakan password", err.Error())
+	}
+	if len(violations) > 0 {
+		return helper.Error(c, fiber.StatusBadRequest, "Password tidak memenuhi kebijakan", violations)
+	}
+
+	newHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memproses password", nil)
+	}
+
+	userID, err := repository.ResetPasswordWithToken(tokenHash, string(newHash))
+	if errors.Is(err, repository.ErrPasswordResetTokenInvalid) {
+		return helper.Error(c, fiber.StatusBadRequest, "Token reset tidak valid atau sudah kadaluarsa", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal reset password", err.Error())
+	}
+
+	if _, err := repository.RevokeAllUserSessions(userID, "password_reset"); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Password diganti tetapi gagal mencabut sesi", err.Error())
+	}
+
+	return helper.Success(c, nil, "Password berhasil direset, silakan login dengan password baru")
+}
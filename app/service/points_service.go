@@ -0,0 +1,327 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/points"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/helper"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Ukuran batch hitung ulang poin
+const recalculateBatchSize = 200
+
+// newPointsAward menghitung poin prestasi dengan rules. Poin sebelumnya diambil dari detail MongoDB.
+func newPointsAward(rules []model.PointsRule, ach *model.AchievementReference, detail *model.AchievementMongo, reason string, actorID *uuid.UUID) *model.AchievementPointsAward {
+	breakdown := points.Calculate(rules, *detail)
+	previous := detail.Points
+	return &model.AchievementPointsAward{
+		AchievementID: ach.ID,
+		Total:         breakdown.Total,
+		PreviousTotal: &previous,
+		Breakdown:     breakdown,
+		Reason:        reason,
+		ActorID:       actorID,
+	}
+}
+
+// scoreAchievement menghitung poin prestasi dengan rules, menyimpan rinciannya, lalu menulis total
+// ke MongoDB.
+func scoreAchievement(rules []model.PointsRule, ach *model.AchievementReference, detail *model.AchievementMongo, reason string, actorID *uuid.UUID) (model.PointsBreakdown, error) {
+	award := newPointsAward(rules, ach, detail, reason, actorID)
+	if err := repository.RecordPointsAward(award); err != nil {
+		return award.Breakdown, err
+	}
+	if err := repository.SetAchievementPoints(ach.MongoAchievementID, award.Total); err != nil {
+		return award.Breakdown, err
+	}
+	return award.Breakdown, nil
+}
+
+// currentUserID mengembalikan ID user yang login (nil untuk API key).
+func currentUserID(c *fiber.Ctx) *uuid.UUID {
+	if userID, _ := c.Locals("user_id").(string); userID != "" {
+		if id, err := uuid.Parse(userID); err == nil {
+			return &id
+		}
+	}
+	return nil
+}
+
+// currentAPIKeyID mengembalikan ID API key jika request diautentikasi dengan API key.
+func currentAPIKeyID(c *fiber.Ctx) *uuid.UUID {
+	if keyID, _ := c.Locals("api_key_id").(string); keyID != "" {
+		if id, err := uuid.Parse(keyID); err == nil {
+			return &id
+		}
+	}
+	return nil
+}
+
+type pointsRuleRequest struct {
+	Name            string                  `json:"name"`
+	Description     string                  `json:"description"`
+	Kind            string                  `json:"kind"`
+	AchievementType string                  `json:"achievement_type"`
+	CompetitionTier string                  `json:"competition_tier"`
+	Conditions      []model.PointsCondition `json:"conditions"`
+	ValidFrom       string                  `json:"valid_from"`
+	ValidUntil      string                  `json:"valid_until"`
+	Value           float64                 `json:"value"`
+	Priority        int                     `json:"priority"`
+	IsActive        *bool                   `json:"is_active"`
+}
+
+// parsePointsRuleRequest membaca dan memvalidasi body aturan poin. Jika ok = false, respon error sudah ditulis.
+func parsePointsRuleRequest(c *fiber.Ctx, id uuid.UUID) (*model.PointsRule, bool, error) {
+	var req pointsRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, false, helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	rule := &model.PointsRule{
+		ID:              id,
+		Name:            strings.TrimSpace(req.Name),
+		Description:     strings.TrimSpace(req.Description),
+		Kind:            strings.ToLower(strings.TrimSpace(req.Kind)),
+		AchievementType: strings.TrimSpace(req.AchievementType),
+		CompetitionTier: strings.TrimSpace(req.CompetitionTier),
+		Conditions:      req.Conditions,
+		Value:           req.Value,
+		Priority:        req.Priority,
+		IsActive:        req.IsActive == nil || *req.IsActive,
+	}
+	if rule.Conditions == nil {
+		rule.Conditions = []model.PointsCondition{}
+	}
+	for i := range rule.Conditions {
+		rule.Conditions[i].Field = strings.TrimSpace(rule.Conditions[i].Field)
+		rule.Conditions[i].Op = strings.ToLower(strings.TrimSpace(rule.Conditions[i].Op))
+	}
+
+	errs := map[string]string{}
+	for field, raw := range map[string]string{"valid_from": req.ValidFrom, "valid_until": req.ValidUntil} {
+		if raw == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			errs[field] = "Format tanggal harus YYYY-MM-DD"
+			continue
+		}
+		if field == "valid_from" {
+			rule.ValidFrom = &date
+		} else {
+			rule.ValidUntil = &date
+		}
+	}
+	for field, msg := range points.ValidateRule(*rule) {
+		if _, exists := errs[field]; !exists {
+			errs[field] = msg
+		}
+	}
+	if len(errs) > 0 {
+		return nil, false, helper.Error(c, fiber.StatusBadRequest, "Data aturan poin tidak valid", errs)
+	}
+	return rule, true, nil
+}
+
+// GetPointsRules godoc
+// @Summary      Daftar Aturan Poin (Admin)
+// @Description  Menampilkan aturan poin prestasi, urut prioritas tertinggi.
+// @Tags         Points Rules (Admin)
+// @Produce      json
+// @Success      200  {object}  helper.Response{data=[]model.PointsRule}
+// @Router       /points-rules [get]
+// @Security     BearerAuth
+func GetPointsRules(c *fiber.Ctx) error {
+	rules, err := repository.ListPointsRules()
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil aturan poin", err.Error())
+	}
+	return helper.Success(c, rules, "Daftar aturan poin")
+}
+
+// CreatePointsRule godoc
+// @Summary      Buat Aturan Poin (Admin)
+// @Description  kind: base (poin dasar, hanya aturan base prioritas tertinggi yang cocok), bonus (ditambahkan)
+// @Description  atau multiplier (value = faktor pengali). Aturan dicocokkan dengan achievement_type, competition_tier,
+// @Description  rentang tanggal kegiatan (valid_from / valid_until, YYYY-MM-DD) dan conditions atas field details
+// @Description  (op: eq, ne, in, gt, gte, lt, lte, exists), misal {"field":"rank","op":"eq","value":1}.
+// @Tags         Points Rules (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "Aturan poin"
+// @Success      201   {object}  helper.Response{data=model.PointsRule}
+// @Failure      400   {object}  helper.Response
+// @Router       /points-rules [post]
+// @Security     BearerAuth
+func CreatePointsRule(c *fiber.Ctx) error {
+	rule, ok, err := parsePointsRuleRequest(c, uuid.New())
+	if !ok {
+		return err
+	}
+	if err := repository.CreatePointsRule(rule); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan aturan poin", err.Error())
+	}
+	return helper.Created(c, rule, "Aturan poin berhasil dibuat")
+}
+
+// UpdatePointsRule godoc
+// @Summary      Ubah Aturan Poin (Admin)
+// @Description  Mengganti seluruh isi aturan. Poin prestasi yang sudah diverifikasi baru berubah setelah /points-rules/recalculate.
+// @Tags         Points Rules (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true  "Rule ID"
+// @Param        body  body      object  true  "Aturan poin"
+// @Success      200   {object}  helper.Response{data=model.PointsRule}
+// @Failure      404   {object}  helper.Response
+// @Router       /points-rules/{id} [put]
+// @Security     BearerAuth
+func UpdatePointsRule(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "Aturan poin tidak ditemukan", nil)
+	}
+
+	rule, ok, err := parsePointsRuleRequest(c, id)
+	if !ok {
+		return err
+	}
+
+	err = repository.UpdatePointsRule(rule)
+	if errors.Is(err, repository.ErrPointsRuleNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Aturan poin tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengubah aturan poin", err.Error())
+	}
+	return helper.Success(c, rule, "Aturan poin berhasil diubah")
+}
+
+// DeletePointsRule godoc
+// @Summary      Hapus Aturan Poin (Admin)
+// @Tags         Points Rules (Admin)
+// @Param        id   path      string  true  "Rule ID"
+// @Success      200  {object}  helper.Response
+// @Failure      404  {object}  helper.Response
+// @Router       /points-rules/{id} [delete]
+// @Security     BearerAuth
+func DeletePointsRule(c *fiber.Ctx) error {
+	err := repository.DeletePointsRule(c.Params("id"))
+	if errors.Is(err, repository.ErrPointsRuleNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Aturan poin tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menghapus aturan poin", err.Error())
+	}
+	return helper.Success(c, nil, "Aturan poin berhasil dihapus")
+}
+
+// RecalculatePoints godoc
+// @Summary      Hitung Ulang Poin Prestasi (Admin)
+// @Description  Menghitung ulang poin semua prestasi berstatus 'verified' dengan aturan saat ini. Prestasi yang poinnya
+// @Description  berubah atau belum punya rincian poin dicatat rinciannya (reason recalculate) dan poin di MongoDB ditulis ulang.
+// @Description  dry_run = true hanya menampilkan perubahan tanpa menyimpan.
+// @Tags         Points Rules (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  false  "dry_run"
+// @Success      200   {object}  helper.Response{data=model.PointsRecalculation}
+// @Router       /points-rules/recalculate [post]
+// @Security     BearerAuth
+func RecalculatePoints(c *fiber.Ctx) error {
+	var req struct {
+		DryRun bool `json:"dry_run"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+		}
+	}
+
+	rules, err := repository.ListPointsRules()
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil aturan poin", err.Error())
+	}
+
+	result := model.PointsRecalculation{DryRun: req.DryRun, Changes: []model.PointsRecalculationRow{}}
+	actorID := currentUserID(c)
+
+	after := uuid.Nil
+	for {
+		refs, err := repository.ListVerifiedAchievements(after, recalculateBatchSize)
+		if err != nil {
+			return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil prestasi terverifikasi", err.Error())
+		}
+
+		for i := range refs {
+			ref := &refs[i].AchievementReference
+			result.Processed++
+
+			detail, err := repository.GetAchievementDetailFromMongo(ref.MongoAchievementID)
+			if err != nil {
+				result.Failed++
+				result.Changes = append(result.Changes, model.PointsRecalculationRow{AchievementID: ref.ID, Error: err.Error()})
+				continue
+			}
+
+			total := points.Calculate(rules, *detail).Total
+			if total == detail.Points && refs[i].HasPointsAward {
+				continue
+			}
+			row := model.PointsRecalculationRow{AchievementID: ref.ID, Previous: detail.Points, Total: total}
+			if !req.DryRun {
+				if _, err := scoreAchievement(rules, ref, detail, model.PointsReasonRecalculate, actorID); err != nil {
+					result.Failed++
+					row.Error = err.Error()
+					result.Changes = append(result.Changes, row)
+					continue
+				}
+			}
+			result.Changed++
+			result.Changes = append(result.Changes, row)
+		}
+
+		if len(refs) < recalculateBatchSize {
+			break
+		}
+		after = refs[len(refs)-1].ID
+	}
+
+	message := "Poin prestasi berhasil dihitung ulang"
+	if req.DryRun {
+		message = "Simulasi hitung ulang poin (tidak disimpan)"
+	}
+	return helper.Success(c, result, message)
+}
+
+// GetAchievementPoints godoc
+// @Summary      Rincian Poin Prestasi
+// @Description  Menampilkan riwayat perhitungan poin prestasi beserta aturan yang membentuk totalnya, terbaru lebih dulu.
+// @Tags         Achievements
+// @Produce      json
+// @Param        id   path      string  true  "Achievement ID"
+// @Success      200  {object}  helper.Response{data=[]model.AchievementPointsAward}
+// @Router       /achievements/{id}/points [get]
+// @Security     BearerAuth
+func GetAchievementPoints(c *fiber.Ctx) error {
+	achievementID := c.Params("id")
+	if ok, err := authorizeAchievement(c, achievementID, policy.ActionRead); !ok {
+		return err
+	}
+
+	awards, err := repository.ListPointsAwards(achievementID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil rincian poin", err.Error())
+	}
+	return helper.Success(c, awards, "Rincian poin prestasi")
+}
@@ -2,10 +2,11 @@ package service
 
 import (
 	"sync"
+	"sistempelaporan/app/policy"
 	"sistempelaporan/app/repository"
 	"sistempelaporan/helper"  // Untuk fungsi perhitungan jika ada
-    "strings"   // WAJIB: Untuk strings.EqualFold     // Untuk WaitGroup
     "time"      // WAJIB: Untuk time.Now()
+	"sistempelaporan/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -20,29 +21,18 @@ import (
 // @Security     BearerAuth
 func GetGeneralStatistics(c *fiber.Ctx) error {
     // 1. Identifikasi User dari Context (Set oleh middleware Protected)
-    userID := c.Locals("user_id").(string)
     role := c.Locals("role").(string)
 
-    // Variabel filter untuk repository
-    var targetID string // Akan berisi StudentID atau LecturerID tergantung role
-    
-    // 2. Logika Penentuan Filter (Aktor FR-011)
-    if strings.EqualFold(role, "Mahasiswa") {
-        // Actor: Mahasiswa (own) -> Ambil Student ID milik user ini
-        mhsID, err := repository.GetStudentIDByUserID(userID)
-        if err != nil {
-            return helper.Error(c, fiber.StatusNotFound, "Data mahasiswa tidak ditemukan", nil)
-        }
-        targetID = mhsID
-    } else if strings.EqualFold(role, "Dosen Wali") {
-        // Actor: Dosen Wali (advisee) -> Ambil Lecturer ID milik user ini
-        lecturerID, err := repository.GetLecturerIDByUserID(userID)
-        if err != nil {
-            return helper.Error(c, fiber.StatusNotFound, "Data dosen tidak ditemukan", nil)
-        }
-        targetID = lecturerID
+    // 2. Cakupan data ditentukan policy (Aktor FR-011): Mahasiswa (own), Dosen Wali (advisee), Admin (all),
+    //    operator fakultas / departemen / prodi (unitnya)
+    subject, err := middleware.PolicySubject(c)
+    if err != nil {
+        return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+    }
+    scope, ok := policy.ListScope(subject, policy.ResourceAchievement, "achievement:read")
+    if !ok {
+        return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: akun ini tidak terhubung dengan data mahasiswa mana pun", nil)
     }
-    // Note: Jika role "Admin" (all), targetID tetap kosong agar repository menarik semua data
 
     var wg sync.WaitGroup
     
@@ -60,19 +50,19 @@ func GetGeneralStatistics(c *fiber.Ctx) error {
     // Tugas 1: Top Mahasiswa Berprestasi (Postgres)
     go func() {
         defer wg.Done()
-        topStudents, err1 = repository.GetTopStudentsStats(targetID, role)
+        topStudents, err1 = repository.GetTopStudentsStats(scope)
     }()
 
     // Tugas 2: Total Prestasi per Periode / Monthly Trend (Postgres)
     go func() {
         defer wg.Done()
-        monthlyTrend, err2 = repository.GetMonthlyTrendStats(targetID, role)
+        monthlyTrend, err2 = repository.GetMonthlyTrendStats(scope)
     }()
 
     // Tugas 3: Total per Tipe & Distribusi Tingkat Kompetisi (Mongo)
     go func() {
         defer wg.Done()
-        typeDist, err3 = repository.GetAchievementTypeDistribution(targetID, role)
+        typeDist, err3 = repository.GetAchievementTypeDistribution(scope)
     }()
 
     // Tunggu semua goroutine selesai
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/helper"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetUserRoleAssignments godoc
+// @Summary      Daftar Role Assignment User (Admin)
+// @Description  Menampilkan role tambahan user beserta unit akademik (fakultas / departemen / prodi) tempat role itu berlaku.
+// @Tags         Users (Admin)
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  helper.Response{data=[]model.RoleAssignment}
+// @Router       /users/{id}/role-assignments [get]
+// @Security     BearerAuth
+func GetUserRoleAssignments(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Format User ID tidak valid", nil)
+	}
+
+	assignments, err := repository.ListRoleAssignments(c.Params("id"))
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil role assignment", err.Error())
+	}
+	return helper.Success(c, assignments, "Daftar role assignment")
+}
+
+// CreateUserRoleAssignment godoc
+// @Summary      Tambah Role Assignment User (Admin)
+// @Description  Memberi user permission sebuah role yang hanya berlaku di dalam satu unit akademik.
+// @Description  scope_type: faculty, department, program, atau kosong untuk semua unit. Permission assignment
+// @Description  hanya berlaku pada data mahasiswa, dosen, prestasi dan laporan; tidak pada administrasi sistem.
+// @Tags         Users (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true  "User ID"
+// @Param        body  body      object  true  "role_id, scope_type, scope_value"
+// @Success      201   {object}  helper.Response{data=model.RoleAssignment}
+// @Failure      400   {object}  helper.Response
+// @Failure      409   {object}  helper.Response
+// @Router       /users/{id}/role-assignments [post]
+// @Security     BearerAuth
+func CreateUserRoleAssignment(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Format User ID tidak valid", nil)
+	}
+
+	var req struct {
+		RoleID     string `json:"role_id"`
+		ScopeType  string `json:"scope_type"`
+		ScopeValue string `json:"scope_value"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	unit := policy.Unit{
+		Level: policy.ScopeLevel(strings.ToLower(strings.TrimSpace(req.ScopeType))),
+		Value: strings.TrimSpace(req.ScopeValue),
+	}
+	errs := map[string]string{}
+	if _, err := uuid.Parse(req.RoleID); err != nil {
+		errs["role_id"] = "role_id wajib berupa UUID role"
+	}
+	if !policy.ValidScopeLevel(unit.Level) {
+		errs["scope_type"] = "scope_type harus faculty, department, program atau kosong"
+	} else if unit.Level == policy.ScopeGlobal && unit.Value != "" {
+		errs["scope_value"] = "scope_value harus kosong jika scope_type kosong"
+	} else if unit.Level != policy.ScopeGlobal && unit.Value == "" {
+		errs["scope_value"] = "scope_value wajib diisi"
+	}
+	if len(errs) > 0 {
+		return helper.Error(c, fiber.StatusBadRequest, "Data role assignment tidak valid", errs)
+	}
+
+	if _, err := repository.FindUserByID(userID.String()); err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "User tidak ditemukan", nil)
+	}
+
+	role, err := repository.FindRoleByID(req.RoleID)
+	if errors.Is(err, repository.ErrRoleNotFound) {
+		return helper.Error(c, fiber.StatusBadRequest, "Role tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data role", err.Error())
+	}
+	// Admin sistem ditentukan dari role utama user, bukan dari assignment
+	if strings.EqualFold(role.Name, model.RoleAdmin) {
+		return helper.Error(c, fiber.StatusBadRequest, "Role Admin tidak dapat diberikan lewat role assignment", nil)
+	}
+
+	if unit.Level != policy.ScopeGlobal {
+		exists, err := repository.AcademicUnitExists(unit)
+		if err != nil {
+			return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa unit akademik", err.Error())
+		}
+		if !exists {
+			return helper.Error(c, fiber.StatusBadRequest, "Unit akademik tidak terdaftar: "+unit.Value, nil)
+		}
+	}
+
+	assignment := model.RoleAssignment{
+		ID:         uuid.New(),
+		UserID:     userID,
+		RoleID:     role.ID,
+		RoleName:   role.Name,
+		ScopeType:  string(unit.Level),
+		ScopeValue: unit.Value,
+	}
+	if creatorID, err := uuid.Parse(c.Locals("user_id").(string)); err == nil {
+		assignment.CreatedBy = &creatorID
+	}
+
+	err = repository.CreateRoleAssignment(&assignment)
+	if errors.Is(err, repository.ErrRoleAssignmentExists) {
+		return helper.Error(c, fiber.StatusConflict, "User sudah memiliki role ini pada unit tersebut", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan role assignment", err.Error())
+	}
+	return helper.Created(c, assignment, "Role assignment berhasil ditambahkan")
+}
+
+// DeleteUserRoleAssignment godoc
+// @Summary      Hapus Role Assignment User (Admin)
+// @Description  Mencabut role assignment; berlaku pada request berikutnya tanpa perlu login ulang.
+// @Tags         Users (Admin)
+// @Param        id            path      string  true  "User ID"
+// @Param        assignmentId  path      string  true  "Role Assignment ID"
+// @Success      200  {object}  helper.Response
+// @Failure      404  {object}  helper.Response
+// @Router       /users/{id}/role-assignments/{assignmentId} [delete]
+// @Security     BearerAuth
+func DeleteUserRoleAssignment(c *fiber.Ctx) error {
+	assignmentID := c.Params("assignmentId")
+	if _, err := uuid.Parse(assignmentID); err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "Role assignment tidak ditemukan", nil)
+	}
+
+	err := repository.DeleteRoleAssignment(c.Params("id"), assignmentID)
+	if errors.Is(err, repository.ErrRoleAssignmentNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Role assignment tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menghapus role assignment", err.Error())
+	}
+	return helper.Success(c, nil, "Role assignment berhasil dihapus")
+}
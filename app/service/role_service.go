@@ -0,0 +1,438 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/helper"
+	"sistempelaporan/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Role bawaan yang namanya dipakai langsung oleh kode (tidak boleh dihapus atau diganti nama)
+var builtinRoles = []string{model.RoleAdmin, model.RoleMahasiswa, model.RoleDosenWali}
+
+// Permission yang tidak boleh dilepas dari Admin agar admin tidak mengunci dirinya sendiri
+const rolePermissionManage = "role:manage"
+
+func isBuiltinRole(name string) bool {
+	for _, r := range builtinRoles {
+		if strings.EqualFold(r, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizePermissionNames merapikan, memvalidasi format, dan membuang duplikat nama permission.
+func normalizePermissionNames(names []string) ([]string, error) {
+	result := make([]string, 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !permissionPattern.MatchString(name) {
+			return nil, errors.New("Format permission tidak valid: " + name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+type roleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Require2FA  bool     `json:"require_2fa"`
+	Permissions []string `json:"permissions"`
+}
+
+// GetRoles godoc
+// @Summary      Daftar Role (Admin)
+// @Description  Menampilkan semua role beserta permission dan versi permission-nya.
+// @Tags         Roles & Permissions (Admin)
+// @Produce      json
+// @Success      200  {object}  helper.Response{data=[]model.Role}
+// @Router       /roles [get]
+// @Security     BearerAuth
+func GetRoles(c *fiber.Ctx) error {
+	roles, err := repository.ListRoles()
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data role", err.Error())
+	}
+	return helper.Success(c, roles, "Daftar role")
+}
+
+// GetRoleByID godoc
+// @Summary      Detail Role (Admin)
+// @Tags         Roles & Permissions (Admin)
+// @Produce      json
+// @Param        id   path      string  true  "Role ID"
+// @Success      200  {object}  helper.Response{data=model.Role}
+// @Failure      404  {object}  helper.Response
+// @Router       /roles/{id} [get]
+// @Security     BearerAuth
+func GetRoleByID(c *fiber.Ctx) error {
+	role, err := repository.FindRoleByID(c.Params("id"))
+	if errors.Is(err, repository.ErrRoleNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Role tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data role", err.Error())
+	}
+	return helper.Success(c, role, "Detail role")
+}
+
+// CreateRole godoc
+// @Summary      Buat Role (Admin)
+// @Tags         Roles & Permissions (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "name, description, require_2fa, permissions"
+// @Success      201   {object}  helper.Response{data=model.Role}
+// @Failure      400   {object}  helper.Response
+// @Failure      409   {object}  helper.Response
+// @Router       /roles [post]
+// @Security     BearerAuth
+func CreateRole(c *fiber.Ctx) error {
+	var req roleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return helper.Error(c, fiber.StatusBadRequest, "Nama role wajib diisi", nil)
+	}
+	perms, err := normalizePermissionNames(req.Permissions)
+	if err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	role := model.Role{ID: uuid.New(), Name: req.Name, Description: req.Description, Require2FA: req.Require2FA}
+	err = repository.CreateRole(&role, perms)
+	switch {
+	case errors.Is(err, repository.ErrRoleNameTaken):
+		return helper.Error(c, fiber.StatusConflict, "Nama role sudah dipakai", nil)
+	case errors.Is(err, repository.ErrPermissionNotFound):
+		return helper.Error(c, fiber.StatusBadRequest, "Ada permission yang belum terdaftar", nil)
+	case err != nil:
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal membuat role", err.Error())
+	}
+
+	created, err := repository.FindRoleByID(role.ID.String())
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data role", err.Error())
+	}
+	return helper.Created(c, created, "Role berhasil dibuat")
+}
+
+// UpdateRole godoc
+// @Summary      Ubah Role (Admin)
+// @Description  Mengubah nama, deskripsi, dan kewajiban 2FA. Role bawaan tidak dapat diganti nama.
+// @Tags         Roles & Permissions (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true  "Role ID"
+// @Param        body  body      object  true  "name, description, require_2fa"
+// @Success      200   {object}  helper.Response{data=model.Role}
+// @Failure      404   {object}  helper.Response
+// @Router       /roles/{id} [put]
+// @Security     BearerAuth
+func UpdateRole(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req roleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return helper.Error(c, fiber.StatusBadRequest, "Nama role wajib diisi", nil)
+	}
+
+	role, err := repository.FindRoleByID(id)
+	if errors.Is(err, repository.ErrRoleNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Role tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data role", err.Error())
+	}
+	if isBuiltinRole(role.Name) && req.Name != role.Name {
+		return helper.Error(c, fiber.StatusBadRequest, "Role bawaan tidak dapat diganti nama", nil)
+	}
+
+	err = repository.UpdateRole(id, req.Name, req.Description, req.Require2FA)
+	if errors.Is(err, repository.ErrRoleNameTaken) {
+		return helper.Error(c, fiber.StatusConflict, "Nama role sudah dipakai", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengubah role", err.Error())
+	}
+
+	updated, err := repository.FindRoleByID(id)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data role", err.Error())
+	}
+	return helper.Success(c, updated, "Role berhasil diubah")
+}
+
+// DeleteRole godoc
+// @Summary      Hapus Role (Admin)
+// @Description  Hanya role non-bawaan yang tidak lagi dipakai user yang bisa dihapus.
+// @Tags         Roles & Permissions (Admin)
+// @Param        id   path      string  true  "Role ID"
+// @Success      200  {object}  helper.Response
+// @Failure      409  {object}  helper.Response
+// @Router       /roles/{id} [delete]
+// @Security     BearerAuth
+func DeleteRole(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	role, err := repository.FindRoleByID(id)
+	if errors.Is(err, repository.ErrRoleNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Role tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data role", err.Error())
+	}
+	if isBuiltinRole(role.Name) {
+		return helper.Error(c, fiber.StatusBadRequest, "Role bawaan tidak dapat dihapus", nil)
+	}
+
+	err = repository.DeleteRole(id)
+	if errors.Is(err, repository.ErrRoleInUse) {
+		return helper.Error(c, fiber.StatusConflict, "Role masih dipakai oleh user atau role assignment, pindahkan terlebih dahulu", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menghapus role", err.Error())
+	}
+
+	middleware.InvalidatePermissionCache(id)
+	return helper.Success(c, nil, "Role berhasil dihapus")
+}
+
+// AttachRolePermissions godoc
+// @Summary      Pasang Permission ke Role (Admin)
+// @Description  Token yang sudah terbit langsung memakai permission baru (versi permission role naik).
+// @Tags         Roles & Permissions (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true  "Role ID"
+// @Param        body  body      object  true  "permissions (daftar nama permission)"
+// @Success      200   {object}  helper.Response{data=model.Role}
+// @Failure      400   {object}  helper.Response
+// @Router       /roles/{id}/permissions [post]
+// @Security     BearerAuth
+func AttachRolePermissions(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+	perms, err := normalizePermissionNames(req.Permissions)
+	if err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+	if len(perms) == 0 {
+		return helper.Error(c, fiber.StatusBadRequest, "Minimal satu permission wajib dipilih", nil)
+	}
+
+	err = repository.AttachPermissionsToRole(id, perms)
+	switch {
+	case errors.Is(err, repository.ErrRoleNotFound):
+		return helper.Error(c, fiber.StatusNotFound, "Role tidak ditemukan", nil)
+	case errors.Is(err, repository.ErrPermissionNotFound):
+		return helper.Error(c, fiber.StatusBadRequest, "Ada permission yang belum terdaftar", nil)
+	case err != nil:
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memasang permission", err.Error())
+	}
+
+	middleware.InvalidatePermissionCache(id)
+	role, err := repository.FindRoleByID(id)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data role", err.Error())
+	}
+	return helper.Success(c, role, "Permission berhasil dipasang")
+}
+
+// DetachRolePermission godoc
+// @Summary      Lepas Permission dari Role (Admin)
+// @Tags         Roles & Permissions (Admin)
+// @Produce      json
+// @Param        id            path      string  true  "Role ID"
+// @Param        permissionId  path      string  true  "Permission ID"
+// @Success      200           {object}  helper.Response{data=model.Role}
+// @Failure      404           {object}  helper.Response
+// @Router       /roles/{id}/permissions/{permissionId} [delete]
+// @Security     BearerAuth
+func DetachRolePermission(c *fiber.Ctx) error {
+	id := c.Params("id")
+	permissionID := c.Params("permissionId")
+
+	role, err := repository.FindRoleByID(id)
+	if errors.Is(err, repository.ErrRoleNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Role tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data role", err.Error())
+	}
+	permission, err := repository.FindPermissionByID(permissionID)
+	if errors.Is(err, repository.ErrPermissionNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Permission tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data permission", err.Error())
+	}
+	if strings.EqualFold(role.Name, model.RoleAdmin) && permission.Name == rolePermissionManage {
+		return helper.Error(c, fiber.StatusBadRequest, "Permission "+rolePermissionManage+" tidak dapat dilepas dari Admin", nil)
+	}
+
+	err = repository.DetachPermissionFromRole(id, permissionID)
+	if errors.Is(err, repository.ErrPermissionNotAttached) {
+		return helper.Error(c, fiber.StatusNotFound, "Permission tidak terpasang pada role ini", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal melepas permission", err.Error())
+	}
+
+	middleware.InvalidatePermissionCache(id)
+	updated, err := repository.FindRoleByID(id)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data role", err.Error())
+	}
+	return helper.Success(c, updated, "Permission berhasil dilepas")
+}
+
+// GetPermissions godoc
+// @Summary      Katalog Permission (Admin)
+// @Description  Semua permission, termasuk yang didaftarkan otomatis dari route. in_use = dipakai oleh route.
+// @Tags         Roles & Permissions (Admin)
+// @Produce      json
+// @Success      200  {object}  helper.Response{data=[]model.Permission}
+// @Router       /permissions [get]
+// @Security     BearerAuth
+func GetPermissions(c *fiber.Ctx) error {
+	perms, err := repository.ListPermissions()
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data permission", err.Error())
+	}
+	for i := range perms {
+		perms[i].InUse = middleware.IsRegisteredPermission(perms[i].Name)
+	}
+	return helper.Success(c, perms, "Daftar permission")
+}
+
+// CreatePermission godoc
+// @Summary      Buat Permission (Admin)
+// @Tags         Roles & Permissions (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "name (resource:action), description"
+// @Success      201   {object}  helper.Response{data=model.Permission}
+// @Failure      409   {object}  helper.Response
+// @Router       /permissions [post]
+// @Security     BearerAuth
+func CreatePermission(c *fiber.Ctx) error {
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if !permissionPattern.MatchString(req.Name) {
+		return helper.Error(c, fiber.StatusBadRequest, "Nama permission harus berformat resource:action", nil)
+	}
+
+	permission := model.Permission{ID: uuid.New(), Name: req.Name, Description: req.Description}
+	err := repository.CreatePermission(&permission)
+	if errors.Is(err, repository.ErrPermissionExists) {
+		return helper.Error(c, fiber.StatusConflict, "Permission sudah ada", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal membuat permission", err.Error())
+	}
+
+	permission.InUse = middleware.IsRegisteredPermission(permission.Name)
+	return helper.Created(c, permission, "Permission berhasil dibuat")
+}
+
+// UpdatePermission godoc
+// @Summary      Ubah Deskripsi Permission (Admin)
+// @Description  Nama permission tidak dapat diubah karena dipakai langsung oleh route.
+// @Tags         Roles & Permissions (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true  "Permission ID"
+// @Param        body  body      object  true  "description"
+// @Success      200   {object}  helper.Response{data=model.Permission}
+// @Failure      404   {object}  helper.Response
+// @Router       /permissions/{id} [put]
+// @Security     BearerAuth
+func UpdatePermission(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req struct {
+		Description string `json:"description"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	err := repository.UpdatePermissionDescription(id, req.Description)
+	if errors.Is(err, repository.ErrPermissionNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Permission tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengubah permission", err.Error())
+	}
+
+	permission, err := repository.FindPermissionByID(id)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data permission", err.Error())
+	}
+	permission.InUse = middleware.IsRegisteredPermission(permission.Name)
+	return helper.Success(c, permission, "Permission berhasil diubah")
+}
+
+// DeletePermission godoc
+// @Summary      Hapus Permission (Admin)
+// @Description  Permission yang masih dipakai route tidak dapat dihapus.
+// @Tags         Roles & Permissions (Admin)
+// @Param        id   path      string  true  "Permission ID"
+// @Success      200  {object}  helper.Response
+// @Failure      409  {object}  helper.Response
+// @Router       /permissions/{id} [delete]
+// @Security     BearerAuth
+func DeletePermission(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	permission, err := repository.FindPermissionByID(id)
+	if errors.Is(err, repository.ErrPermissionNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Permission tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data permission", err.Error())
+	}
+	if middleware.IsRegisteredPermission(permission.Name) {
+		return helper.Error(c, fiber.StatusConflict, "Permission masih dipakai oleh route", nil)
+	}
+
+	roleIDs, err := repository.DeletePermission(id)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menghapus permission", err.Error())
+	}
+	for _, roleID := range roleIDs {
+		middleware.InvalidatePermissionCache(roleID)
+	}
+	return helper.Success(c, nil, "Permission berhasil dihapus")
+}
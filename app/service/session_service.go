@@ -0,0 +1,148 @@
+package service
+
+import (
+	"errors"
+
+	"sistempelaporan/app/repository"
+	"sistempelaporan/app/session"
+	"sistempelaporan/helper"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// GetMySessions godoc
+// @Summary      Daftar Sesi Aktif Saya
+// @Description  Menampilkan semua perangkat/login yang masih aktif (user agent, IP, waktu dibuat, terakhir dipakai).
+// @Tags         Authentication
+// @Produce      json
+// @Success      200  {object}  helper.Response{data=[]model.Session}
+// @Router       /auth/sessions [get]
+// @Security     BearerAuth
+func GetMySessions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	currentSessionID, _ := c.Locals("sid").(string)
+
+	sessions, err := repository.ListActiveSessionsByUser(userID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data sesi", err.Error())
+	}
+
+	session.MarkCurrent(sessions, currentSessionID)
+
+	return helper.Success(c, sessions, "Daftar sesi aktif berhasil diambil")
+}
+
+// RevokeMySession godoc
+// @Summary      Cabut Salah Satu Sesi Saya
+// @Description  Logout dari satu perangkat tertentu. Access token dari sesi tersebut langsung tidak berlaku.
+// @Tags         Authentication
+// @Param        id   path      string  true  "Session ID"
+// @Success      200  {object}  helper.Response
+// @Failure      404  {object}  helper.Response
+// @Router       /auth/sessions/{id} [delete]
+// @Security     BearerAuth
+func RevokeMySession(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := revokeOwnedSession(userID, c.Params("id"), "revoked_by_user"); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return helper.Error(c, fiber.StatusNotFound, "Sesi tidak ditemukan", nil)
+		}
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mencabut sesi", err.Error())
+	}
+
+	return helper.Success(c, nil, "Sesi berhasil dicabut")
+}
+
+// revokeOwnedSession mencabut satu sesi milik userID; sesi user lain atau yang sudah dicabut
+// dilaporkan sebagai ErrSessionNotFound.
+func revokeOwnedSession(userID, sessionID, reason string) error {
+	s, err := repository.FindSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if !session.Revocable(*s, userID) {
+		return repository.ErrSessionNotFound
+	}
+	return repository.RevokeSession(sessionID, reason)
+}
+
+// RevokeAllMySessions godoc
+// @Summary      Logout dari Semua Perangkat
+// @Description  Mencabut semua sesi aktif milik user yang sedang login, termasuk sesi saat ini.
+// @Tags         Authentication
+// @Success      200  {object}  helper.Response
+// @Router       /auth/sessions [delete]
+// @Security     BearerAuth
+func RevokeAllMySessions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	total, err := repository.RevokeAllUserSessions(userID, "logout_all")
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mencabut sesi", err.Error())
+	}
+
+	return helper.Success(c, fiber.Map{"revoked_sessions": total}, "Berhasil logout dari semua perangkat")
+}
+
+// GetUserSessions godoc
+// @Summary      Daftar Sesi Aktif User (Admin)
+// @Description  Admin melihat semua sesi aktif milik user tertentu.
+// @Tags         Users (Admin)
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  helper.Response{data=[]model.Session}
+// @Failure      404  {object}  helper.Response
+// @Router       /users/{id}/sessions [get]
+// @Security     BearerAuth
+func GetUserSessions(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "User tidak ditemukan", nil)
+	}
+	sessions, err := repository.ListActiveSessionsByUser(c.Params("id"))
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data sesi", err.Error())
+	}
+	return helper.Success(c, sessions, "Daftar sesi aktif user berhasil diambil")
+}
+
+// RevokeUserSession godoc
+// @Summary      Cabut Sesi User (Admin)
+// @Description  Admin mencabut satu sesi milik user tertentu.
+// @Tags         Users (Admin)
+// @Param        id         path      string  true  "User ID"
+// @Param        sessionId  path      string  true  "Session ID"
+// @Success      200        {object}  helper.Response
+// @Failure      404        {object}  helper.Response
+// @Router       /users/{id}/sessions/{sessionId} [delete]
+// @Security     BearerAuth
+func RevokeUserSession(c *fiber.Ctx) error {
+	if err := revokeOwnedSession(c.Params("id"), c.Params("sessionId"), "revoked_by_admin"); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return helper.Error(c, fiber.StatusNotFound, "Sesi tidak ditemukan", nil)
+		}
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mencabut sesi", err.Error())
+	}
+	return helper.Success(c, nil, "Sesi user berhasil dicabut")
+}
+
+// RevokeAllUserSessions godoc
+// @Summary      Cabut Semua Sesi User (Admin)
+// @Description  Admin mematikan semua akses user (misal akun yang disusupi). Token yang sudah terbit langsung tidak berlaku.
+// @Tags         Users (Admin)
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  helper.Response
+// @Failure      404  {object}  helper.Response
+// @Router       /users/{id}/sessions [delete]
+// @Security     BearerAuth
+func RevokeAllUserSessions(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "User tidak ditemukan", nil)
+	}
+	total, err := repository.RevokeAllUserSessions(c.Params("id"), "revoked_by_admin")
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mencabut sesi user", err.Error())
+	}
+	return helper.Success(c, fiber.Map{"revoked_sessions": total}, "Semua sesi user berhasil dicabut")
+}
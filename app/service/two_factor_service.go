@@ -0,0 +1,489 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"sistempelaporan/app/lockout"
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/authenticator"
+	"sistempelaporan/helper"
+	"sistempelaporan/keymanager"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	twoFactorChallengeTTL = 5 * time.Minute
+	totpAllowedSkew       = 1 // toleransi +/- 30 detik
+	recoveryCodeCount     = 10
+)
+
+var (
+	errInvalidChallenge = errors.New("challenge token tidak valid atau kadaluarsa")
+	errInvalidTOTPCode  = errors.New("Kode 2FA salah")
+)
+
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "Sistem Pelaporan Prestasi"
+}
+
+// respondTwoFactorChallenge mengirim challenge token setelah password benar.
+// Jika role mewajibkan 2FA tapi user belum mendaftar, challenge-nya untuk enrollment.
+func respondTwoFactorChallenge(c *fiber.Ctx, user *model.User) error {
+	tokenType := model.TokenTypeTwoFactorChallenge
+	if !user.TwoFactorEnabled {
+		tokenType = model.TokenTypeTwoFactorEnroll
+	}
+
+	challenge, err := issueTwoFactorChallenge(user, tokenType)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal membuat challenge 2FA", nil)
+	}
+
+	message := "Masukkan kode autentikator untuk menyelesaikan login"
+	if !user.TwoFactorEnabled {
+		message = "Role Anda mewajibkan 2FA. Daftarkan autentikator untuk menyelesaikan login"
+	}
+
+	return helper.Success(c, model.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		SetupRequired:     !user.TwoFactorEnabled,
+		ChallengeToken:    challenge,
+		ExpiresIn:         int(twoFactorChallengeTTL.Seconds()),
+	}, message)
+}
+
+func issueTwoFactorChallenge(user *model.User, tokenType string) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id":    user.ID,
+		"token_type": tokenType,
+		"jti":        uuid.NewString(),
+		"exp":        time.Now().Add(twoFactorChallengeTTL).Unix(),
+	}
+	return keymanager.Default().Sign(claims)
+}
+
+// parseTwoFactorChallenge memvalidasi challenge token dan memastikan belum dipakai.
+func parseTwoFactorChallenge(tokenString, expectedType string) (userID, jti string, expiresAt time.Time, err error) {
+	token, err := keymanager.Default().Parse(tokenString)
+	if err != nil || !token.Valid {
+		return "", "", time.Time{}, errInvalidChallenge
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", "", time.Time{}, errInvalidChallenge
+	}
+	if tokenType, _ := claims["token_type"].(string); tokenType != expectedType {
+		return "", "", time.Time{}, errInvalidChallenge
+	}
+
+	userID, _ = claims["user_id"].(string)
+	jti, _ = claims["jti"].(string)
+	exp, err := claims.GetExpirationTime()
+	if userID == "" || jti == "" || err != nil || exp == nil {
+		return "", "", time.Time{}, errInvalidChallenge
+	}
+
+	revoked, err := repository.IsTokenRevoked(jti)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	if revoked {
+		return "", "", time.Time{}, errInvalidChallenge
+	}
+
+	return userID, jti, exp.Time, nil
+}
+
+// rejectTwoFactorAttempt mencatat kode 2FA yang salah (login maupun pendaftaran 2FA); kegagalan ini
+// ikut dihitung pada counter lockout akun yang sama dengan login password.
+func rejectTwoFactorAttempt(c *fiber.Ctx, user *model.User, status int) error {
+	repository.RecordLoginAttempt(&user.ID, user.Username, c.IP(), c.Get("User-Agent"), false, "invalid_2fa_code")
+
+	lockedUntil, err := repository.RegisterFailedLogin(user.ID.String(), loginLockoutPolicy())
+	if err == nil && lockedUntil != nil {
+		return helper.Error(c, fiber.StatusLocked, "Akun dikunci sementara karena terlalu banyak percobaan login gagal.", fiber.Map{"locked_until": lockedUntil})
+	}
+	return helper.Error(c, status, errInvalidTOTPCode.Error(), nil)
+}
+
+// accountLocked menulis respon 423 jika akun sedang dikunci.
+func accountLocked(c *fiber.Ctx, user *model.User) (bool, error) {
+	if lockout.Locked(user.LockedUntil, time.Now()) {
+		return true, helper.Error(c, fiber.StatusLocked, "Akun dikunci sementara karena terlalu banyak percobaan login gagal.", fiber.Map{"locked_until": user.LockedUntil})
+	}
+	return false, nil
+}
+
+// VerifyTwoFactorLogin godoc
+// @Summary      Verifikasi 2FA saat Login
+// @Description  Langkah kedua login: tukar challenge token + kode TOTP (atau recovery code) dengan Access & Refresh Token.
+// @Tags         Two-Factor Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "challenge_token, code atau recovery_code"
+// @Success      200   {object}  helper.Response{data=model.LoginResponse}
+// @Failure      401   {object}  helper.Response
+// @Failure      423   {object}  helper.Response
+// @Router       /auth/2fa/verify [post]
+func VerifyTwoFactorLogin(c *fiber.Ctx) error {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recovery_code"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	userID, jti, expiresAt, err := parseTwoFactorChallenge(req.ChallengeToken, model.TokenTypeTwoFactorChallenge)
+	if err != nil {
+		return helper.Error(c, fiber.StatusUnauthorized, errInvalidChallenge.Error(), nil)
+	}
+
+	user, err := repository.FindUserByID(userID)
+	if err != nil || !user.IsActive {
+		return helper.Error(c, fiber.StatusUnauthorized, "User tidak ditemukan", nil)
+	}
+	if locked, err := accountLocked(c, user); locked {
+		return err
+	}
+
+	state, err := repository.GetTOTPState(userID)
+	if err != nil || !state.Enabled {
+		return helper.Error(c, fiber.StatusUnauthorized, "2FA belum aktif untuk akun ini", nil)
+	}
+
+	verified := false
+	if req.RecoveryCode != "" {
+		verified, err = repository.UseRecoveryCode(userID, hashToken(strings.ToLower(strings.TrimSpace(req.RecoveryCode))))
+	} else if step, ok := helper.MatchTOTP(state.Secret, req.Code, time.Now(), totpAllowedSkew); ok {
+		verified, err = repository.ConsumeTOTPStep(userID, step)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memverifikasi kode 2FA", err.Error())
+	}
+	if !verified {
+		return rejectTwoFactorAttempt(c, user, fiber.StatusUnauthorized)
+	}
+
+	// Challenge token hanya boleh dipakai sekali
+	if err := repository.RevokeToken(jti, expiresAt); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyelesaikan login", err.Error())
+	}
+
+	response, err := finishLogin(c, user)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal generate token", nil)
+	}
+	return helper.Success(c, response, "Login berhasil")
+}
+
+// SetupTwoFactor godoc
+// @Summary      Mulai Pendaftaran 2FA
+// @Description  Membuat secret TOTP baru (belum aktif) beserta URI otpauth dan QR code PNG untuk di-scan.
+// @Tags         Two-Factor Authentication
+// @Produce      json
+// @Success      200  {object}  helper.Response{data=model.TwoFactorSetupResponse}
+// @Failure      409  {object}  helper.Response
+// @Router       /auth/2fa/setup [post]
+// @Security     BearerAuth
+func SetupTwoFactor(c *fiber.Ctx) error {
+	return setupTwoFactorFor(c, c.Locals("user_id").(string))
+}
+
+// EnrollSetupTwoFactor godoc
+// @Summary      Pendaftaran 2FA Wajib (saat Login)
+// @Description  Sama seperti /auth/2fa/setup, tetapi diotorisasi dengan challenge token enrollment dari respon login.
+// @Tags         Two-Factor Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "challenge_token"
+// @Success      200   {object}  helper.Response{data=model.TwoFactorSetupResponse}
+// @Router       /auth/2fa/enroll/setup [post]
+func EnrollSetupTwoFactor(c *fiber.Ctx) error {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	userID, _, _, err := parseTwoFactorChallenge(req.ChallengeToken, model.TokenTypeTwoFactorEnroll)
+	if err != nil {
+		return helper.Error(c, fiber.StatusUnauthorized, errInvalidChallenge.Error(), nil)
+	}
+	return setupTwoFactorFor(c, userID)
+}
+
+func setupTwoFactorFor(c *fiber.Ctx, userID string) error {
+	user, err := repository.FindUserByID(userID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "User tidak ditemukan", nil)
+	}
+	if user.TwoFactorEnabled {
+		return helper.Error(c, fiber.StatusConflict, "2FA sudah aktif. Nonaktifkan terlebih dahulu untuk mendaftar ulang.", nil)
+	}
+
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal membuat secret 2FA", nil)
+	}
+	if err := repository.SaveTOTPSecret(userID, secret); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan secret 2FA", err.Error())
+	}
+
+	uri := helper.TOTPAuthURI(totpIssuer(), user.Username, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal membuat QR code", nil)
+	}
+
+	return helper.Success(c, model.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURL: uri,
+		QRCodePNG:  "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, "Scan QR code lalu konfirmasi dengan kode dari aplikasi autentikator")
+}
+
+// EnableTwoFactor godoc
+// @Summary      Aktifkan 2FA
+// @Description  Mengonfirmasi kode TOTP pertama untuk mengaktifkan 2FA dan mengembalikan recovery code (hanya ditampilkan sekali).
+// @Description  Kode yang salah ikut dihitung untuk lockout akun seperti login gagal.
+// @Tags         Two-Factor Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "code"
+// @Success      200   {object}  helper.Response{data=model.TwoFactorEnableResponse}
+// @Failure      423   {object}  helper.Response
+// @Router       /auth/2fa/enable [post]
+// @Security     BearerAuth
+func EnableTwoFactor(c *fiber.Ctx) error {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	user, err := repository.FindUserByID(c.Locals("user_id").(string))
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "User tidak ditemukan", nil)
+	}
+	if locked, err := accountLocked(c, user); locked {
+		return err
+	}
+
+	codes, err := enableTwoFactorFor(user.ID.String(), req.Code)
+	if errors.Is(err, errInvalidTOTPCode) {
+		return rejectTwoFactorAttempt(c, user, fiber.StatusBadRequest)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+	return helper.Success(c, model.TwoFactorEnableResponse{RecoveryCodes: codes}, "2FA berhasil diaktifkan. Simpan recovery code di tempat aman.")
+}
+
+// EnrollEnableTwoFactor godoc
+// @Summary      Aktifkan 2FA Wajib dan Selesaikan Login
+// @Description  Mengonfirmasi kode TOTP pertama dengan challenge token enrollment. Respon berisi recovery code dan token login.
+// @Description  Kode yang salah ikut dihitung untuk lockout akun seperti login gagal.
+// @Tags         Two-Factor Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "challenge_token, code"
+// @Success      200   {object}  helper.Response{data=model.TwoFactorEnableResponse}
+// @Failure      423   {object}  helper.Response
+// @Router       /auth/2fa/enroll/enable [post]
+func EnrollEnableTwoFactor(c *fiber.Ctx) error {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	userID, jti, expiresAt, err := parseTwoFactorChallenge(req.ChallengeToken, model.TokenTypeTwoFactorEnroll)
+	if err != nil {
+		return helper.Error(c, fiber.StatusUnauthorized, errInvalidChallenge.Error(), nil)
+	}
+
+	user, err := repository.FindUserByID(userID)
+	if err != nil || !user.IsActive {
+		return helper.Error(c, fiber.StatusUnauthorized, "User tidak ditemukan", nil)
+	}
+	if locked, err := accountLocked(c, user); locked {
+		return err
+	}
+
+	codes, err := enableTwoFactorFor(userID, req.Code)
+	if errors.Is(err, errInvalidTOTPCode) {
+		return rejectTwoFactorAttempt(c, user, fiber.StatusBadRequest)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, err.Error(), nil)
+	}
+
+	if err := repository.RevokeToken(jti, expiresAt); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyelesaikan login", err.Error())
+	}
+
+	login, err := finishLogin(c, user)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal generate token", nil)
+	}
+	return helper.Success(c, model.TwoFactorEnableResponse{RecoveryCodes: codes, Login: login}, "2FA berhasil diaktifkan dan login berhasil")
+}
+
+func enableTwoFactorFor(userID, code string) ([]string, error) {
+	state, err := repository.GetTOTPState(userID)
+	if err != nil {
+		return nil, err
+	}
+	if state.Enabled {
+		return nil, errors.New("2FA sudah aktif")
+	}
+	if state.Secret == "" {
+		return nil, errors.New("Jalankan setup 2FA terlebih dahulu")
+	}
+
+	step, ok := helper.MatchTOTP(state.Secret, code, time.Now(), totpAllowedSkew)
+	if !ok {
+		return nil, errInvalidTOTPCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.EnableTOTP(userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := helper.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+// verifyCurrentTOTP memastikan kode TOTP saat ini benar untuk aksi sensitif (disable, regenerate).
+func verifyCurrentTOTP(userID, code string) (bool, error) {
+	state, err := repository.GetTOTPState(userID)
+	if err != nil || !state.Enabled {
+		return false, err
+	}
+	step, ok := helper.MatchTOTP(state.Secret, code, time.Now(), totpAllowedSkew)
+	if !ok {
+		return false, nil
+	}
+	return repository.ConsumeTOTPStep(userID, step)
+}
+
+// DisableTwoFactor godoc
+// @Summary      Nonaktifkan 2FA
+// @Description  Menonaktifkan 2FA dengan konfirmasi password dan kode TOTP. Tidak diizinkan untuk role yang mewajibkan 2FA.
+// @Tags         Two-Factor Authentication
+// @Accept       json
+// @Param        body  body      object  true  "password, code"
+// @Success      200   {object}  helper.Response
+// @Failure      403   {object}  helper.Response
+// @Router       /auth/2fa/disable [post]
+// @Security     BearerAuth
+func DisableTwoFactor(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	user, err := repository.FindUserByID(userID)
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "User tidak ditemukan", nil)
+	}
+	if user.Role.Require2FA {
+		return helper.Error(c, fiber.StatusForbidden, "Role Anda mewajibkan 2FA sehingga tidak dapat dinonaktifkan", nil)
+	}
+
+	if err := verifyUserPassword(userID, req.Password); err != nil {
+		if authenticator.IsUnavailable(err) {
+			return helper.Error(c, fiber.StatusServiceUnavailable, "Layanan autentikasi sedang tidak tersedia", nil)
+		}
+		return helper.Error(c, fiber.StatusUnauthorized, "Password salah", nil)
+	}
+
+	ok, err := verifyCurrentTOTP(userID, req.Code)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memverifikasi kode 2FA", err.Error())
+	}
+	if !ok {
+		return helper.Error(c, fiber.StatusUnauthorized, "Kode 2FA salah", nil)
+	}
+
+	if err := repository.DisableTOTP(userID); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menonaktifkan 2FA", err.Error())
+	}
+	return helper.Success(c, nil, "2FA berhasil dinonaktifkan")
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Buat Ulang Recovery Code
+// @Description  Mengganti semua recovery code lama dengan set baru. Membutuhkan kode TOTP saat ini.
+// @Tags         Two-Factor Authentication
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "code"
+// @Success      200   {object}  helper.Response{data=model.TwoFactorEnableResponse}
+// @Router       /auth/2fa/recovery-codes [post]
+// @Security     BearerAuth
+func RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	ok, err := verifyCurrentTOTP(userID, req.Code)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memverifikasi kode 2FA", err.Error())
+	}
+	if !ok {
+		return helper.Error(c, fiber.StatusUnauthorized, "Kode 2FA salah", nil)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal membuat recovery code", nil)
+	}
+	if err := repository.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan recovery code", err.Error())
+	}
+	return helper.Success(c, model.TwoFactorEnableResponse{RecoveryCodes: codes}, "Recovery code baru berhasil dibuat")
+}
@@ -1,8 +1,11 @@
 package service
 
 import (
+	"errors"
+
 	"sistempelaporan/app/model"
 	"sistempelaporan/app/repository"
+	"sistempelaporan/authenticator"
 	"sistempelaporan/helper"
 
 	"github.com/gofiber/fiber/v2"
@@ -14,6 +17,8 @@ import (
 // CreateNewUser godoc
 // @Summary      Buat User Baru (Admin)
 // @Description  Admin dapat membuat user baru beserta profil Mahasiswa atau Dosen dalam satu transaksi.
+// @Description  Password harus memenuhi kebijakan password role user tersebut.
+// @Description  Untuk akun dosen dari direktori fakultas, isi user.auth_source = "ldap" tanpa password.
 // @Tags         Users (Admin)
 // @Accept       json
 // @Produce      json
@@ -35,15 +40,39 @@ func CreateNewUser(c *fiber.Ctx) error {
 		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", err.Error())
 	}
 
-	if req.Password == "" {
-		return helper.Error(c, fiber.StatusBadRequest, "Password wajib diisi.", nil)
-	}
+	switch req.User.AuthSource {
+	case authenticator.SourceLDAP:
+		// Password akun LDAP diverifikasi oleh direktori dan tidak disimpan di aplikasi
+		if req.Password != "" {
+			return helper.Error(c, fiber.StatusBadRequest, "Akun LDAP tidak memakai password lokal.", nil)
+		}
+		req.User.PasswordHash = ""
+	case "", authenticator.SourceLocal:
+		req.User.AuthSource = authenticator.SourceLocal
+		if req.Password == "" {
+			return helper.Error(c, fiber.StatusBadRequest, "Password wajib diisi.", nil)
+		}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return helper.Error(c, fiber.StatusInternalServerError, "Gagal hash password", err.Error())
+		personalInfo := []string{req.User.Username}
+		if req.Student != nil {
+			personalInfo = append(personalInfo, req.Student.StudentID)
+		}
+		violations, err := checkPasswordPolicy(req.User.RoleID.String(), "", req.Password, personalInfo...)
+		if err != nil {
+			return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa kebijakan password", err.Error())
+		}
+		if len(violations) > 0 {
+			return helper.Error(c, fiber.StatusBadRequest, "Password tidak memenuhi kebijakan", violations)
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return helper.Error(c, fiber.StatusInternalServerError, "Gagal hash password", err.Error())
+		}
+		req.User.PasswordHash = string(hashedPassword)
+	default:
+		return helper.Error(c, fiber.StatusBadRequest, "auth_source harus local atau ldap.", nil)
 	}
-	req.User.PasswordHash = string(hashedPassword)
 
 	if req.User.ID == uuid.Nil {
 		req.User.ID = uuid.New()
@@ -144,6 +173,7 @@ func UpdateUser(c *fiber.Ctx) error {
 // UpdateUserRole godoc
 // @Summary      Update Role User
 // @Description  Admin dapat mengubah hak akses (role) user tertentu.
+// @Description  Semua sesi user dicabut agar token dengan role lama tidak bisa dipakai lagi.
 // @Tags         Users (Admin)
 // @Accept       json
 // @Produce      json
@@ -165,16 +195,26 @@ func UpdateUserRole(c *fiber.Ctx) error {
 		return helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", err.Error())
 	}
 
-	if err := repository.UpdateUserRole(id, req.RoleID); err != nil {
+	if err := changeUserRole(id, req.RoleID); err != nil {
 		return helper.Error(c, fiber.StatusInternalServerError, "Gagal update role user", err.Error())
 	}
 
 	return helper.Success(c, nil, "Role user berhasil diupdate")
 }
 
+// changeUserRole mengganti role user lalu mencabut semua sesinya. Token lama masih membawa role
+// sebelumnya (dan permission role itu), jadi user harus login ulang. Dipakai admin maupun sinkronisasi LDAP.
+func changeUserRole(userID, roleID string) error {
+	if err := repository.UpdateUserRole(userID, roleID); err != nil {
+		return err
+	}
+	_, err := repository.RevokeAllUserSessions(userID, "role_changed")
+	return err
+}
+
 // DeleteUser godoc
 // @Summary      Hapus User
-// @Description  Menghapus data user dari sistem (Soft Delete).
+// @Description  Menghapus data user dari sistem (Soft Delete) dan mencabut semua sesi aktifnya.
 // @Tags         Users (Admin)
 // @Param        id    path      string  true  "User ID"
 // @Success      200   {object}  helper.Response
@@ -187,6 +227,11 @@ func DeleteUser(c *fiber.Ctx) error {
 		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menghapus user", err.Error())
 	}
 
+	// User yang dinonaktifkan tidak boleh tetap memakai token yang sudah terbit
+	if _, err := repository.RevokeAllUserSessions(id, "user_deleted"); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mencabut sesi user", err.Error())
+	}
+
 	return helper.Success(c, nil, "User berhasil dihapus (soft delete)")
 }
 
@@ -216,4 +261,25 @@ func SetAdvisor(c *fiber.Ctx) error {
 	}
 
 	return helper.Success(c, nil, "Dosen wali berhasil diassign")
-}
\ No newline at end of file
+}
+// UnlockUser godoc
+// @Summary      Buka Kunci Akun
+// @Description  Admin membuka kunci akun yang terkunci karena terlalu banyak percobaan login gagal.
+// @Tags         Users (Admin)
+// @Param        id    path      string  true  "User ID"
+// @Success      200   {object}  helper.Response
+// @Failure      404   {object}  helper.Response
+// @Router       /users/{id}/unlock [post]
+// @Security     BearerAuth
+func UnlockUser(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if err := repository.UnlockUser(id); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return helper.Error(c, fiber.StatusNotFound, "User tidak ditemukan", nil)
+		}
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal membuka kunci akun", err.Error())
+	}
+
+	return helper.Success(c, nil, "Akun berhasil dibuka kuncinya")
+}
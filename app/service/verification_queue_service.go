@@ -0,0 +1,268 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/app/queue"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/app/workflow"
+	"sistempelaporan/helper"
+	"sistempelaporan/mailer"
+	"sistempelaporan/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Konfigurasi SLA verifikasi: batas waktu per tahap dan ambang eskalasi ke admin departemen
+func verificationSLA() time.Duration {
+	return time.Duration(getEnvInt("VERIFICATION_SLA_HOURS", 72)) * time.Hour
+}
+func verificationEscalationAfter() time.Duration {
+	return time.Duration(getEnvInt("VERIFICATION_ESCALATION_HOURS", int(verificationSLA().Hours()))) * time.Hour
+}
+
+// Klaim eskalasi yang belum ditandai terkirim setelah selama ini dianggap ditinggalkan instance pengklaimnya.
+const escalationClaimTimeout = 10 * time.Minute
+
+// pipelineCache menyimpan hasil penentuan pipeline selama satu proses antrean, karena banyak prestasi
+// memakai pipeline yang sama.
+type pipelineCache map[string]model.VerificationPipeline
+
+func (pc pipelineCache) resolve(ach *model.AchievementReference, detail model.AchievementMongo) (model.VerificationPipeline, error) {
+	key := "match:" + detail.CompetitionTier + "|" + detail.AchievementType
+	if ach.PipelineID != nil {
+		key = ach.PipelineID.String()
+	}
+	if p, ok := pc[key]; ok {
+		return p, nil
+	}
+	p, err := achievementPipeline(ach, &detail)
+	if err != nil {
+		return p, err
+	}
+	pc[key] = p
+	return p, nil
+}
+
+// GetVerificationQueue godoc
+// @Summary      Antrean Verifikasi Saya
+// @Description  Prestasi yang menunggu persetujuan user pada tahap berjalan (user termasuk penyetuju tahap itu dan
+// @Description  belum menyetujuinya). Setiap item memuat lama menunggu sejak tahap dimulai, batas SLA
+// @Description  (VERIFICATION_SLA_HOURS, default 72 jam) dan badge fresh / due_soon / overdue.
+// @Tags         Verification Queue
+// @Produce      json
+// @Param        sort     query     string  false  "waiting (default, paling lama dulu), tier (tertinggi dulu) atau student (nama A-Z)"
+// @Param        order    query     string  false  "asc / desc untuk membalik arah default"
+// @Param        overdue  query     bool    false  "Hanya item yang melewati SLA"
+// @Param        page     query     int     false  "Halaman (default 1)"
+// @Param        limit    query     int     false  "Jumlah per halaman (default 20, maks 100)"
+// @Success      200      {object}  helper.Response{data=[]model.VerificationQueueItem}
+// @Failure      400      {object}  helper.Response
+// @Router       /verification/queue [get]
+// @Security     BearerAuth
+func GetVerificationQueue(c *fiber.Ctx) error {
+	page, limit := helper.Pagination(c)
+
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+
+	items := []model.VerificationQueueItem{}
+	if scope, ok := policy.ListScope(subject, policy.ResourceAchievement, workflow.VerifyPermission); ok {
+		if items, err = verificationQueue(subject, scope); err != nil {
+			return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil antrean verifikasi", err.Error())
+		}
+	}
+
+	if c.Query("overdue") == "true" {
+		overdue := []model.VerificationQueueItem{}
+		for _, item := range items {
+			if item.Badge == model.QueueBadgeOverdue {
+				overdue = append(overdue, item)
+			}
+		}
+		items = overdue
+	}
+	if err := queue.Sort(items, c.Query("sort"), c.Query("order")); err != nil {
+		return helper.Error(c, fiber.StatusBadRequest, "Parameter urutan tidak valid", err.Error())
+	}
+
+	total := len(items)
+	start := (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	return helper.SuccessWithMeta(c, items[start:end], helper.PageMeta(page, limit, int64(total)), "Antrean verifikasi")
+}
+
+// verificationQueue menyusun item antrean: kandidat di dalam scope disaring ke yang tahap berjalannya
+// boleh disetujui subject dan belum ia setujui.
+func verificationQueue(subject policy.Subject, scope policy.Scope) ([]model.VerificationQueueItem, error) {
+	candidates, err := repository.ListVerificationCandidates(scope, subject.UserID)
+	if err != nil {
+		return nil, err
+	}
+	details, err := candidateSummaries(candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sla := verificationSLA()
+	pipelines := pipelineCache{}
+	items := []model.VerificationQueueItem{}
+	for i := range candidates {
+		cand := &candidates[i]
+		if cand.ApprovedByUser {
+			continue
+		}
+		detail := details[cand.Achievement.MongoAchievementID]
+		pipeline, err := pipelines.resolve(&cand.Achievement, detail)
+		if err != nil {
+			return nil, err
+		}
+		stage, found := pipeline.Stage(cand.Achievement.CurrentStage)
+		if !found || !workflow.CanApproveStage(subject, stage, cand.Resource) {
+			continue
+		}
+
+		item := model.VerificationQueueItem{
+			AchievementID:   cand.Achievement.ID,
+			StudentID:       cand.Achievement.StudentID,
+			StudentName:     cand.StudentName,
+			StudentNIM:      cand.StudentNIM,
+			Title:           detail.Title,
+			AchievementType: detail.AchievementType,
+			CompetitionTier: detail.CompetitionTier,
+			Status:          cand.Achievement.Status,
+			Stage:           stage.Position,
+			StageName:       stage.Name,
+			WaitingSince:    cand.WaitingSince,
+			EscalatedAt:     cand.EscalatedAt,
+		}
+		queue.Apply(&item, now, sla)
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func candidateSummaries(candidates []repository.VerificationCandidate) (map[string]model.AchievementMongo, error) {
+	hexIDs := make([]string, 0, len(candidates))
+	for _, cand := range candidates {
+		hexIDs = append(hexIDs, cand.Achievement.MongoAchievementID)
+	}
+	return repository.AchievementSummaries(hexIDs)
+}
+
+// EscalateOverdueVerifications mengeskalasi prestasi yang tahap verifikasinya menunggu lebih lama dari
+// VERIFICATION_ESCALATION_HOURS: admin departemen mahasiswa (role assignment berscope departemen dengan
+// permission achievement:escalation) diberi tahu lewat email, sekali per tahap. Eskalasi diklaim lebih dulu
+// sehingga dengan beberapa instance API hanya satu yang mengirim email; klaim yang emailnya gagal dilepas
+// agar diulang pada putaran berikutnya. Mengembalikan jumlah prestasi yang baru dieskalasi.
+func EscalateOverdueVerifications() (int, error) {
+	threshold := verificationEscalationAfter()
+	overdue, err := repository.ListOverdueVerifications(time.Now().Add(-threshold))
+	if err != nil || len(overdue) == 0 {
+		return 0, err
+	}
+	details, err := candidateSummaries(overdue)
+	if err != nil {
+		return 0, err
+	}
+
+	contactsByDept := map[string][]repository.EscalationContact{}
+	escalated := 0
+	for _, cand := range overdue {
+		dept := cand.Resource.Department
+		contacts, cached := contactsByDept[dept]
+		if !cached && dept != "" {
+			if contacts, err = repository.DepartmentEscalationContacts(dept); err != nil {
+				return escalated, err
+			}
+			contactsByDept[dept] = contacts
+		}
+
+		id, claimed, err := repository.ClaimVerificationEscalation(cand, time.Now().Add(-escalationClaimTimeout))
+		if err != nil {
+			return escalated, err
+		}
+		if !claimed {
+			continue
+		}
+
+		if len(contacts) == 0 {
+			// Tetap ditandai (notified_count = 0) agar tidak diperiksa ulang setiap putaran
+			log.Printf("Eskalasi verifikasi: tidak ada admin departemen %q untuk prestasi %s", dept, cand.Achievement.ID)
+		} else if err := sendEscalationEmail(cand, contacts, details, threshold); err != nil {
+			log.Printf("Eskalasi verifikasi: gagal mengirim email untuk prestasi %s, dicoba lagi nanti: %v", cand.Achievement.ID, err)
+			if err := repository.ReleaseVerificationEscalation(id); err != nil {
+				return escalated, err
+			}
+			continue
+		}
+
+		if err := repository.MarkVerificationEscalationNotified(id, len(contacts)); err != nil {
+			return escalated, err
+		}
+		escalated++
+	}
+	return escalated, nil
+}
+
+// sendEscalationEmail memberi tahu admin departemen tentang satu prestasi yang melewati batas eskalasi.
+func sendEscalationEmail(cand repository.VerificationCandidate, contacts []repository.EscalationContact,
+	details map[string]model.AchievementMongo, threshold time.Duration) error {
+	to := make([]string, 0, len(contacts))
+	for _, contact := range contacts {
+		to = append(to, contact.Email)
+	}
+	msg := mailer.Message{
+		To:      to,
+		Subject: "Eskalasi Verifikasi Prestasi - Sistem Pelaporan Prestasi Mahasiswa",
+		Body: fmt.Sprintf(
+			"Prestasi \"%s\" milik %s (%s) sudah menunggu verifikasi tahap %d sejak %s (lebih dari %d jam).\n"+
+				"ID prestasi: %s\n\nMohon tindak lanjuti verifikasi prestasi ini.\n",
+			details[cand.Achievement.MongoAchievementID].Title, cand.StudentName, cand.StudentNIM,
+			cand.Achievement.CurrentStage, cand.WaitingSince.Format("02-01-2006 15:04"), int(threshold.Hours()),
+			cand.Achievement.ID,
+		),
+	}
+	return mailer.Default().Send(msg)
+}
+
+// StartVerificationEscalation menjalankan EscalateOverdueVerifications secara berkala. Panggil fungsi stop
+// untuk menghentikannya.
+func StartVerificationEscalation(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				escalated, err := EscalateOverdueVerifications()
+				if err != nil {
+					log.Printf("Eskalasi verifikasi gagal: %v", err)
+				}
+				if escalated > 0 {
+					log.Printf("Eskalasi verifikasi: %d prestasi dieskalasi ke admin departemen", escalated)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/app/workflow"
+	"sistempelaporan/helper"
+	"sistempelaporan/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// achievementPipeline mengembalikan pipeline verifikasi prestasi: pipeline yang sudah dipatok,
+// atau pipeline aktif yang cocok dengan detail prestasi, atau pipeline default.
+// detail nil berarti detail diambil dari MongoDB bila diperlukan.
+func achievementPipeline(ach *model.AchievementReference, detail *model.AchievementMongo) (model.VerificationPipeline, error) {
+	if ach.PipelineID != nil {
+		p, err := repository.FindVerificationPipeline(ach.PipelineID.String())
+		if err != nil {
+			return model.VerificationPipeline{}, err
+		}
+		return *p, nil
+	}
+
+	if detail == nil {
+		var err error
+		if detail, err = repository.GetAchievementDetailFromMongo(ach.MongoAchievementID); err != nil {
+			return model.VerificationPipeline{}, err
+		}
+	}
+	p, err := repository.ResolveVerificationPipeline(detail.CompetitionTier, detail.AchievementType)
+	if err != nil {
+		return model.VerificationPipeline{}, err
+	}
+	if p == nil {
+		return workflow.DefaultPipeline(), nil
+	}
+	return *p, nil
+}
+
+// pipelineRef mengembalikan ID pipeline untuk dipatok ke prestasi (nil untuk pipeline default).
+func pipelineRef(p model.VerificationPipeline) *uuid.UUID {
+	if p.ID == uuid.Nil {
+		return nil
+	}
+	return &p.ID
+}
+
+// verificationStage menentukan pipeline dan tahap berjalan prestasi lalu memastikan user termasuk
+// penyetuju tahap itu. Jika ok = false, respon error sudah ditulis.
+func verificationStage(c *fiber.Ctx, ach *model.AchievementReference, detail *model.AchievementMongo) (model.VerificationPipeline, model.VerificationStage, bool, error) {
+	var stage model.VerificationStage
+	if !workflow.InVerification(ach.Status) {
+		return model.VerificationPipeline{}, stage, false, helper.Error(c, fiber.StatusBadRequest,
+			"Aksi tidak dapat dilakukan pada status prestasi saat ini", fiber.Map{"status": ach.Status})
+	}
+
+	pipeline, err := achievementPipeline(ach, detail)
+	if err != nil {
+		return pipeline, stage, false, helper.Error(c, fiber.StatusInternalServerError, "Gagal menentukan pipeline verifikasi", err.Error())
+	}
+	stage, found := pipeline.Stage(ach.CurrentStage)
+	if !found {
+		return pipeline, stage, false, helper.Error(c, fiber.StatusConflict, "Tahap verifikasi prestasi tidak ditemukan pada pipeline", nil)
+	}
+
+	subject, err := middleware.PolicySubject(c)
+	if err != nil {
+		return pipeline, stage, false, helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+	resource, err := repository.AchievementPolicyResource(ach.ID.String())
+	if errors.Is(err, repository.ErrResourceNotFound) {
+		return pipeline, stage, false, helper.Error(c, fiber.StatusNotFound, "Prestasi tidak ditemukan", nil)
+	}
+	if err != nil {
+		return pipeline, stage, false, helper.Error(c, fiber.StatusInternalServerError, "Gagal memeriksa hak akses", err.Error())
+	}
+
+	if !workflow.CanApproveStage(subject, stage, resource) {
+		return pipeline, stage, false, helper.Error(c, fiber.StatusForbidden,
+			"Akses ditolak: bukan penyetuju tahap "+stage.Name, fiber.Map{"stage": stage.Position})
+	}
+	return pipeline, stage, true, nil
+}
+
+// requireStageApprover memastikan penolakan / permintaan revisi hanya dilakukan penyetuju tahap berjalan.
+// Status di luar pipeline dibiarkan ke state machine workflow agar pesan errornya konsisten.
+func requireStageApprover(c *fiber.Ctx, ach *model.AchievementReference) (bool, error) {
+	if !workflow.InVerification(ach.Status) {
+		return true, nil
+	}
+	_, _, ok, err := verificationStage(c, ach, nil)
+	return ok, err
+}
+
+type pipelineStageRequest struct {
+	Name              string `json:"name"`
+	ApproverType      string `json:"approver_type"`
+	ApproverRoleID    string `json:"approver_role_id"`
+	ScopeLevel        string `json:"scope_level"`
+	RequiredApprovals int    `json:"required_approvals"`
+}
+
+type pipelineRequest struct {
+	Name             string                 `json:"name"`
+	Description      string                 `json:"description"`
+	CompetitionTiers []string               `json:"competition_tiers"`
+	AchievementTypes []string               `json:"achievement_types"`
+	IsActive         *bool                  `json:"is_active"`
+	Stages           []pipelineStageRequest `json:"stages"`
+}
+
+// normalizeMatchValues merapikan daftar tingkat / tipe prestasi (lowercase, tanpa duplikat).
+func normalizeMatchValues(values []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v != "" && !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// parsePipelineRequest membaca dan memvalidasi body pipeline. Jika ok = false, respon error sudah ditulis.
+func parsePipelineRequest(c *fiber.Ctx, id uuid.UUID) (*model.VerificationPipeline, bool, error) {
+	var req pipelineRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, false, helper.Error(c, fiber.StatusBadRequest, "Input tidak valid", nil)
+	}
+
+	pipeline := &model.VerificationPipeline{
+		ID:               id,
+		Name:             strings.TrimSpace(req.Name),
+		Description:      strings.TrimSpace(req.Description),
+		CompetitionTiers: normalizeMatchValues(req.CompetitionTiers),
+		AchievementTypes: normalizeMatchValues(req.AchievementTypes),
+		IsActive:         req.IsActive == nil || *req.IsActive,
+	}
+
+	errs := map[string]string{}
+	for i, s := range req.Stages {
+		stage := model.VerificationStage{
+			Position:          i + 1,
+			Name:              strings.TrimSpace(s.Name),
+			ApproverType:      strings.ToLower(strings.TrimSpace(s.ApproverType)),
+			ScopeLevel:        strings.ToLower(strings.TrimSpace(s.ScopeLevel)),
+			RequiredApprovals: s.RequiredApprovals,
+		}
+		if stage.RequiredApprovals == 0 {
+			stage.RequiredApprovals = 1
+		}
+		if s.ApproverRoleID != "" {
+			roleID, err := uuid.Parse(s.ApproverRoleID)
+			if err != nil {
+				errs[fmt.Sprintf("stages[%d].approver_role_id", i)] = "approver_role_id wajib berupa UUID role"
+			} else if role, err := repository.FindRoleByID(roleID.String()); err == nil {
+				stage.ApproverRoleID = &role.ID
+				stage.ApproverRole = role.Name
+			} else if errors.Is(err, repository.ErrRoleNotFound) {
+				return nil, false, helper.Error(c, fiber.StatusBadRequest, "Role penyetuju tidak ditemukan: "+s.ApproverRoleID, nil)
+			} else {
+				return nil, false, helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil data role", err.Error())
+			}
+		}
+		pipeline.Stages = append(pipeline.Stages, stage)
+	}
+
+	for field, msg := range workflow.ValidatePipeline(*pipeline) {
+		if _, exists := errs[field]; !exists {
+			errs[field] = msg
+		}
+	}
+	if len(errs) > 0 {
+		return nil, false, helper.Error(c, fiber.StatusBadRequest, "Data pipeline verifikasi tidak valid", errs)
+	}
+	return pipeline, true, nil
+}
+
+// GetVerificationPipelines godoc
+// @Summary      Daftar Pipeline Verifikasi (Admin)
+// @Description  Menampilkan pipeline verifikasi prestasi beserta tahap, penyetuju dan quorum setiap tahap.
+// @Tags         Verification Pipelines (Admin)
+// @Produce      json
+// @Success      200  {object}  helper.Response{data=[]model.VerificationPipeline}
+// @Router       /verification-pipelines [get]
+// @Security     BearerAuth
+func GetVerificationPipelines(c *fiber.Ctx) error {
+	pipelines, err := repository.ListVerificationPipelines()
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil pipeline verifikasi", err.Error())
+	}
+	return helper.Success(c, pipelines, "Daftar pipeline verifikasi")
+}
+
+// GetVerificationPipeline godoc
+// @Summary      Detail Pipeline Verifikasi (Admin)
+// @Tags         Verification Pipelines (Admin)
+// @Produce      json
+// @Param        id   path      string  true  "Pipeline ID"
+// @Success      200  {object}  helper.Response{data=model.VerificationPipeline}
+// @Failure      404  {object}  helper.Response
+// @Router       /verification-pipelines/{id} [get]
+// @Security     BearerAuth
+func GetVerificationPipeline(c *fiber.Ctx) error {
+	pipeline, err := repository.FindVerificationPipeline(c.Params("id"))
+	if errors.Is(err, repository.ErrPipelineNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Pipeline verifikasi tidak ditemukan", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengambil pipeline verifikasi", err.Error())
+	}
+	return helper.Success(c, pipeline, "Detail pipeline verifikasi")
+}
+
+// CreateVerificationPipeline godoc
+// @Summary      Buat Pipeline Verifikasi (Admin)
+// @Description  Pipeline berlaku untuk prestasi dengan competition_tiers / achievement_types yang cocok (kosong = semua).
+// @Description  Tahap diurutkan sesuai urutan array. approver_type: advisor (dosen wali) atau role (pemegang role
+// @Description  assignment approver_role_id, dibatasi scope_level jika diisi). required_approvals adalah quorum tahap.
+// @Tags         Verification Pipelines (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        body  body      object  true  "name, description, competition_tiers, achievement_types, is_active, stages"
+// @Success      201   {object}  helper.Response{data=model.VerificationPipeline}
+// @Failure      400   {object}  helper.Response
+// @Router       /verification-pipelines [post]
+// @Security     BearerAuth
+func CreateVerificationPipeline(c *fiber.Ctx) error {
+	pipeline, ok, err := parsePipelineRequest(c, uuid.New())
+	if !ok {
+		return err
+	}
+
+	if err := repository.CreateVerificationPipeline(pipeline); err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menyimpan pipeline verifikasi", err.Error())
+	}
+	return helper.Created(c, pipeline, "Pipeline verifikasi berhasil dibuat")
+}
+
+// UpdateVerificationPipeline godoc
+// @Summary      Ubah Pipeline Verifikasi (Admin)
+// @Description  Mengganti data dan seluruh tahap pipeline. Ditolak selama masih ada prestasi yang sedang diverifikasi
+// @Description  dengan pipeline ini.
+// @Tags         Verification Pipelines (Admin)
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true  "Pipeline ID"
+// @Param        body  body      object  true  "name, description, competition_tiers, achievement_types, is_active, stages"
+// @Success      200   {object}  helper.Response{data=model.VerificationPipeline}
+// @Failure      404   {object}  helper.Response
+// @Failure      409   {object}  helper.Response
+// @Router       /verification-pipelines/{id} [put]
+// @Security     BearerAuth
+func UpdateVerificationPipeline(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return helper.Error(c, fiber.StatusNotFound, "Pipeline verifikasi tidak ditemukan", nil)
+	}
+
+	pipeline, ok, err := parsePipelineRequest(c, id)
+	if !ok {
+		return err
+	}
+
+	err = repository.UpdateVerificationPipeline(pipeline)
+	if errors.Is(err, repository.ErrPipelineNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Pipeline verifikasi tidak ditemukan", nil)
+	}
+	if errors.Is(err, repository.ErrPipelineInUse) {
+		return helper.Error(c, fiber.StatusConflict, "Pipeline masih dipakai prestasi yang sedang diverifikasi", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal mengubah pipeline verifikasi", err.Error())
+	}
+	return helper.Success(c, pipeline, "Pipeline verifikasi berhasil diubah")
+}
+
+// DeleteVerificationPipeline godoc
+// @Summary      Hapus Pipeline Verifikasi (Admin)
+// @Description  Menghapus pipeline yang tidak sedang dipakai verifikasi. Untuk menghentikan sementara, set is_active false.
+// @Tags         Verification Pipelines (Admin)
+// @Param        id   path      string  true  "Pipeline ID"
+// @Success      200  {object}  helper.Response
+// @Failure      404  {object}  helper.Response
+// @Failure      409  {object}  helper.Response
+// @Router       /verification-pipelines/{id} [delete]
+// @Security     BearerAuth
+func DeleteVerificationPipeline(c *fiber.Ctx) error {
+	err := repository.DeleteVerificationPipeline(c.Params("id"))
+	if errors.Is(err, repository.ErrPipelineNotFound) {
+		return helper.Error(c, fiber.StatusNotFound, "Pipeline verifikasi tidak ditemukan", nil)
+	}
+	if errors.Is(err, repository.ErrPipelineInUse) {
+		return helper.Error(c, fiber.StatusConflict, "Pipeline masih dipakai prestasi yang sedang diverifikasi", nil)
+	}
+	if err != nil {
+		return helper.Error(c, fiber.StatusInternalServerError, "Gagal menghapus pipeline verifikasi", err.Error())
+	}
+	return helper.Success(c, nil, "Pipeline verifikasi berhasil dihapus")
+}
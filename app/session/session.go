@@ -0,0 +1,48 @@
+// Package session memuat aturan sesi login: keputusan rotasi refresh token (termasuk deteksi reuse)
+// dan kepemilikan sesi. Package ini murni (tanpa akses database).
+package session
+
+import (
+	"time"
+
+	"sistempelaporan/app/model"
+)
+
+// Rotation adalah hasil pemeriksaan refresh token lama sebelum ditukar dengan yang baru.
+type Rotation int
+
+const (
+	RotationAllowed Rotation = iota // token lama ditandai terpakai lalu token baru diterbitkan
+	RotationRevoked                 // sesi sudah dicabut
+	RotationExpired                 // sesi sudah kadaluarsa
+	RotationReused                  // token lama pernah dipakai (kemungkinan dicuri): seluruh family dicabut
+)
+
+// DecideRotation menentukan nasib refresh token lama. usedAt adalah waktu token lama pernah dipakai,
+// revokedAt waktu sesi dicabut (nil = belum). Sesi yang sudah dicabut selalu ditolak lebih dulu,
+// sehingga reuse pada sesi yang sudah dicabut tidak mencabut ulang.
+func DecideRotation(usedAt, revokedAt *time.Time, expiresAt, now time.Time) Rotation {
+	switch {
+	case revokedAt != nil:
+		return RotationRevoked
+	case usedAt != nil:
+		return RotationReused
+	case now.After(expiresAt):
+		return RotationExpired
+	default:
+		return RotationAllowed
+	}
+}
+
+// Revocable memeriksa apakah sesi s boleh dicabut lewat endpoint sesi milik user userID: sesi harus milik
+// user itu dan belum dicabut. Sesi milik user lain diperlakukan seperti tidak ada (404), bukan 403.
+func Revocable(s model.Session, userID string) bool {
+	return s.UserID.String() == userID && s.RevokedAt == nil
+}
+
+// MarkCurrent menandai sesi yang dipakai oleh token saat ini.
+func MarkCurrent(sessions []model.Session, currentSessionID string) {
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == currentSessionID
+	}
+}
@@ -0,0 +1,154 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+)
+
+// VerifyPermission adalah permission yang wajib dimiliki penyetuju di setiap tahap verifikasi.
+const VerifyPermission = "achievement:verify"
+
+// DefaultPipeline dipakai jika tidak ada pipeline aktif yang cocok: satu tahap oleh dosen wali,
+// sama dengan alur verifikasi sebelum pipeline bisa dikonfigurasi.
+func DefaultPipeline() model.VerificationPipeline {
+	return model.VerificationPipeline{
+		Name:     "Default",
+		IsActive: true,
+		Stages: []model.VerificationStage{
+			{Position: 1, Name: "Dosen Wali", ApproverType: model.ApproverAdvisor, RequiredApprovals: 1},
+		},
+	}
+}
+
+// StageEvent menentukan event workflow saat sebuah tahap selesai: tahap terakhir memverifikasi
+// prestasi, tahap lain hanya memajukan prestasi ke status in_review.
+func StageEvent(p model.VerificationPipeline, stage model.VerificationStage) Event {
+	if IsFinalStage(p, stage) {
+		return EventVerify
+	}
+	return EventApproveStage
+}
+
+// IsFinalStage menandai tahap dengan posisi terbesar di pipeline.
+func IsFinalStage(p model.VerificationPipeline, stage model.VerificationStage) bool {
+	for _, s := range p.Stages {
+		if s.Position > stage.Position {
+			return false
+		}
+	}
+	return true
+}
+
+// CanApproveStage memutuskan apakah subject termasuk penyetuju tahap untuk prestasi r.
+// Admin sistem dan API key yang memiliki achievement:verify boleh menyetujui tahap apa pun.
+// Tahap advisor hanya untuk dosen wali pemilik atau dosen yang menerima delegasi aktif darinya.
+// Tahap role hanya untuk pemegang role assignment dengan role itu yang unitnya (pada tingkat
+// ScopeLevel, jika diisi) mencakup prestasi dan memuat achievement:verify.
+func CanApproveStage(s policy.Subject, stage model.VerificationStage, r policy.Resource) bool {
+	if (s.Admin || s.Machine) && s.HasPermission(VerifyPermission) {
+		return true
+	}
+
+	switch stage.ApproverType {
+	case model.ApproverAdvisor:
+		if !s.HasPermission(VerifyPermission) {
+			return false
+		}
+		if s.LecturerID != "" && s.LecturerID == r.AdvisorID {
+			return true
+		}
+		_, delegated := s.DelegationFor(r)
+		return delegated
+	case model.ApproverRole:
+		for _, g := range s.Grants {
+			if !strings.EqualFold(g.Role, stage.ApproverRole) {
+				continue
+			}
+			if stage.ScopeLevel != "" && string(g.Level) != stage.ScopeLevel {
+				continue
+			}
+			if g.Covers(r) && g.HasPermission(VerifyPermission) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CountApprovers menghitung penyetuju berbeda dari persetujuan satu tahap: user lewat ApproverID, API key
+// lewat APIKeyID. Persetujuan yang penyetujunya sudah dihapus (keduanya kosong) dihitung satu per baris.
+func CountApprovers(approvals []model.AchievementStageApproval) int {
+	seen := map[string]bool{}
+	count := 0
+	for _, a := range approvals {
+		var key string
+		switch {
+		case a.ApproverID != nil:
+			key = "user:" + a.ApproverID.String()
+		case a.APIKeyID != nil:
+			key = "api_key:" + a.APIKeyID.String()
+		default:
+			count++
+			continue
+		}
+		if !seen[key] {
+			seen[key] = true
+			count++
+		}
+	}
+	return count
+}
+
+// InVerification menandai status yang sedang berada di dalam pipeline verifikasi.
+func InVerification(status model.AchievementStatus) bool {
+	return status == model.StatusSubmitted || status == model.StatusInReview
+}
+
+// ValidatePipeline memeriksa konfigurasi pipeline (tanpa cek keberadaan role) dan mengembalikan
+// pesan error per field. Posisi tahap harus berurutan mulai dari 1.
+func ValidatePipeline(p model.VerificationPipeline) map[string]string {
+	errs := map[string]string{}
+	if strings.TrimSpace(p.Name) == "" {
+		errs["name"] = "Nama pipeline wajib diisi"
+	}
+	if len(p.Stages) == 0 {
+		errs["stages"] = "Pipeline minimal memiliki satu tahap"
+	}
+
+	for i, s := range p.Stages {
+		field := fmt.Sprintf("stages[%d]", i)
+		if s.Position != i+1 {
+			errs[field+".position"] = "Posisi tahap harus berurutan mulai dari 1"
+		}
+		if strings.TrimSpace(s.Name) == "" {
+			errs[field+".name"] = "Nama tahap wajib diisi"
+		}
+		if s.RequiredApprovals < 1 {
+			errs[field+".required_approvals"] = "Jumlah persetujuan minimal 1"
+		}
+
+		switch s.ApproverType {
+		case model.ApproverAdvisor:
+			// Mahasiswa hanya punya satu dosen wali
+			if s.RequiredApprovals > 1 {
+				errs[field+".required_approvals"] = "Tahap dosen wali hanya membutuhkan 1 persetujuan"
+			}
+			if s.ApproverRoleID != nil || s.ScopeLevel != "" {
+				errs[field+".approver_role_id"] = "Tahap dosen wali tidak memakai role atau scope"
+			}
+		case model.ApproverRole:
+			if s.ApproverRoleID == nil {
+				errs[field+".approver_role_id"] = "Role penyetuju wajib diisi"
+			}
+			if !policy.ValidScopeLevel(policy.ScopeLevel(s.ScopeLevel)) {
+				errs[field+".scope_level"] = "scope_level harus faculty, department, program atau kosong"
+			}
+		default:
+			errs[field+".approver_type"] = "approver_type harus advisor atau role"
+		}
+	}
+	return errs
+}
@@ -0,0 +1,121 @@
+// Package workflow adalah state machine status prestasi: transisi yang sah dari setiap status
+// dan aktor yang boleh menjalankannya. Siapa yang berhubungan dengan prestasi tetap diputuskan
+// package policy; package ini hanya memetakan hasil policy ke aktor workflow.
+package workflow
+
+import (
+	"errors"
+	"fmt"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+)
+
+// Event adalah aksi yang mengubah status prestasi.
+type Event string
+
+const (
+	EventSubmit          Event = "submit"           // ajukan / ajukan ulang untuk verifikasi
+	EventApproveStage    Event = "approve_stage"    // selesaikan tahap verifikasi yang bukan tahap terakhir
+	EventVerify          Event = "verify"           // setujui tahap terakhir verifikasi
+	EventReject          Event = "reject"           // tolak prestasi
+	EventRequestRevision Event = "request_revision" // minta mahasiswa memperbaiki data
+	EventWithdraw        Event = "withdraw"         // mahasiswa menarik pengajuan
+	EventReopen          Event = "reopen"           // buka kembali prestasi yang ditarik menjadi draft
+	EventArchive         Event = "archive"          // arsipkan prestasi yang sudah selesai diproses
+)
+
+// Actor adalah peran pihak yang menjalankan event terhadap satu prestasi.
+type Actor string
+
+const (
+	ActorOwner    Actor = "owner"    // mahasiswa pemilik prestasi
+	ActorVerifier Actor = "verifier" // dosen wali mahasiswa pemilik
+	ActorAdmin    Actor = "admin"    // admin sistem, operator unit (di dalam unitnya) atau API key
+)
+
+var (
+	ErrInvalidTransition = errors.New("transisi status tidak diizinkan")
+	ErrActorNotAllowed   = errors.New("aktor tidak boleh menjalankan transisi ini")
+)
+
+type transition struct {
+	to     model.AchievementStatus
+	actors []Actor
+}
+
+var transitions = map[model.AchievementStatus]map[Event]transition{
+	model.StatusDraft: {
+		EventSubmit: {model.StatusSubmitted, []Actor{ActorOwner, ActorAdmin}},
+	},
+	model.StatusSubmitted: {
+		EventApproveStage:    {model.StatusInReview, []Actor{ActorVerifier, ActorAdmin}},
+		EventVerify:          {model.StatusVerified, []Actor{ActorVerifier, ActorAdmin}},
+		EventReject:          {model.StatusRejected, []Actor{ActorVerifier, ActorAdmin}},
+		EventRequestRevision: {model.StatusRevisionRequested, []Actor{ActorVerifier, ActorAdmin}},
+		EventWithdraw:        {model.StatusWithdrawn, []Actor{ActorOwner}},
+	},
+	model.StatusInReview: {
+		EventApproveStage:    {model.StatusInReview, []Actor{ActorVerifier, ActorAdmin}},
+		EventVerify:          {model.StatusVerified, []Actor{ActorVerifier, ActorAdmin}},
+		EventReject:          {model.StatusRejected, []Actor{ActorVerifier, ActorAdmin}},
+		EventRequestRevision: {model.StatusRevisionRequested, []Actor{ActorVerifier, ActorAdmin}},
+		EventWithdraw:        {model.StatusWithdrawn, []Actor{ActorOwner}},
+	},
+	model.StatusRevisionRequested: {
+		EventSubmit:   {model.StatusSubmitted, []Actor{ActorOwner, ActorAdmin}},
+		EventWithdraw: {model.StatusWithdrawn, []Actor{ActorOwner}},
+	},
+	model.StatusRejected: {
+		EventSubmit:   {model.StatusSubmitted, []Actor{ActorOwner, ActorAdmin}},
+		EventWithdraw: {model.StatusWithdrawn, []Actor{ActorOwner}},
+		EventArchive:  {model.StatusArchived, []Actor{ActorAdmin}},
+	},
+	model.StatusWithdrawn: {
+		EventReopen:  {model.StatusDraft, []Actor{ActorOwner, ActorAdmin}},
+		EventArchive: {model.StatusArchived, []Actor{ActorAdmin}},
+	},
+	model.StatusVerified: {
+		EventArchive: {model.StatusArchived, []Actor{ActorAdmin}},
+	},
+}
+
+// Transition mengembalikan status tujuan jika event sah dari status from dan boleh dijalankan actor.
+func Transition(from model.AchievementStatus, event Event, actor Actor) (model.AchievementStatus, error) {
+	t, ok := transitions[from][event]
+	if !ok {
+		return from, fmt.Errorf("%w: %s dari status %s", ErrInvalidTransition, event, from)
+	}
+	for _, a := range t.actors {
+		if a == actor {
+			return t.to, nil
+		}
+	}
+	return from, fmt.Errorf("%w: %s oleh %s", ErrActorNotAllowed, event, actor)
+}
+
+// Editable menandai status yang isinya masih boleh diubah pemilik (termasuk lampiran).
+func Editable(status model.AchievementStatus) bool {
+	switch status {
+	case model.StatusDraft, model.StatusRevisionRequested, model.StatusRejected:
+		return true
+	}
+	return false
+}
+
+// Deletable menandai status yang boleh dihapus (belum pernah atau tidak lagi dalam proses verifikasi).
+func Deletable(status model.AchievementStatus) bool {
+	return status == model.StatusDraft || status == model.StatusWithdrawn
+}
+
+// ActorFor memetakan keputusan policy yang mengizinkan aksi ke aktor workflow.
+// Admin dan API key lolos tanpa relasi sehingga dianggap ActorAdmin, begitu pula operator unit.
+func ActorFor(decision policy.Decision) Actor {
+	switch decision.Relation {
+	case policy.RelationOwner:
+		return ActorOwner
+	case policy.RelationAdvisor, policy.RelationDelegate:
+		return ActorVerifier
+	}
+	return ActorAdmin
+}
@@ -0,0 +1,85 @@
+// Package authenticator memverifikasi password user sesuai sumber autentikasinya (users.auth_source):
+// bcrypt untuk akun lokal dan bind LDAP untuk akun yang dikelola direktori fakultas.
+package authenticator
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Nilai users.auth_source
+const (
+	SourceLocal = "local"
+	SourceLDAP  = "ldap"
+)
+
+var (
+	// ErrInvalidCredentials: password salah (dihitung sebagai percobaan login gagal).
+	ErrInvalidCredentials = errors.New("authenticator: kredensial tidak valid")
+	// ErrUnavailable: backend tidak bisa dihubungi atau tidak dikonfigurasi (bukan kesalahan user).
+	ErrUnavailable = errors.New("authenticator: backend autentikasi tidak tersedia")
+)
+
+// Account adalah data user yang dibutuhkan untuk verifikasi password.
+type Account struct {
+	Username     string
+	PasswordHash string // hanya dipakai akun lokal
+}
+
+// Authenticator memverifikasi password untuk satu sumber autentikasi.
+type Authenticator interface {
+	Authenticate(account Account, password string) error
+}
+
+// Directory adalah authenticator yang juga bisa mendaftar seluruh user-nya (dipakai sinkronisasi berkala).
+type Directory interface {
+	ListUsers() ([]DirectoryUser, error)
+}
+
+// Bcrypt memverifikasi password terhadap users.password_hash.
+type Bcrypt struct{}
+
+func (Bcrypt) Authenticate(account Account, password string) error {
+	if account.PasswordHash == "" {
+		return ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Authenticator{SourceLocal: Bcrypt{}}
+)
+
+// Register memasang authenticator untuk sebuah auth_source.
+func Register(source string, a Authenticator) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[source] = a
+}
+
+// For mengembalikan authenticator untuk auth_source. Nilai kosong dianggap akun lokal.
+func For(source string) (Authenticator, error) {
+	if source == "" {
+		source = SourceLocal
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	a, ok := registry[source]
+	if !ok {
+		return nil, fmt.Errorf("%w: auth_source %q belum dikonfigurasi", ErrUnavailable, source)
+	}
+	return a, nil
+}
+
+// ManagesPassword bernilai true jika password user disimpan di aplikasi (bisa diganti / direset di sini).
+func ManagesPassword(source string) bool {
+	return source == "" || source == SourceLocal
+}
@@ -0,0 +1,236 @@
+package authenticator
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAPConfig adalah konfigurasi koneksi ke direktori LDAP.
+type LDAPConfig struct {
+	URL                string // ldap://host:389 atau ldaps://host:636
+	StartTLS           bool
+	InsecureSkipVerify bool
+	BindDN             string // akun layanan untuk mencari DN user
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string // filter pencarian satu user, %s = username (di-escape)
+	SyncFilter         string // filter semua user yang disinkronkan
+	UsernameAttr       string
+	EmailAttr          string
+	NameAttr           string
+	GroupAttr          string
+	Timeout            time.Duration
+}
+
+// LDAPConfigFromEnv membaca konfigurasi LDAP_*. ok = false jika LDAP_URL kosong (LDAP tidak dipakai).
+func LDAPConfigFromEnv() (cfg LDAPConfig, ok bool) {
+	cfg = LDAPConfig{
+		URL:                os.Getenv("LDAP_URL"),
+		StartTLS:           strings.EqualFold(os.Getenv("LDAP_START_TLS"), "true"),
+		InsecureSkipVerify: strings.EqualFold(os.Getenv("LDAP_INSECURE_SKIP_VERIFY"), "true"),
+		BindDN:             os.Getenv("LDAP_BIND_DN"),
+		BindPassword:       os.Getenv("LDAP_BIND_PASSWORD"),
+		BaseDN:             os.Getenv("LDAP_BASE_DN"),
+		UserFilter:         envOr("LDAP_USER_FILTER", "(&(objectClass=person)(uid=%s))"),
+		SyncFilter:         envOr("LDAP_SYNC_FILTER", "(objectClass=person)"),
+		UsernameAttr:       envOr("LDAP_USERNAME_ATTR", "uid"),
+		EmailAttr:          envOr("LDAP_EMAIL_ATTR", "mail"),
+		NameAttr:           envOr("LDAP_NAME_ATTR", "cn"),
+		GroupAttr:          envOr("LDAP_GROUP_ATTR", "memberOf"),
+		Timeout:            10 * time.Second,
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("LDAP_TIMEOUT_SECONDS")); err == nil && seconds > 0 {
+		cfg.Timeout = time.Duration(seconds) * time.Second
+	}
+	return cfg, cfg.URL != ""
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// DirectoryUser adalah entri user di direktori.
+type DirectoryUser struct {
+	DN       string
+	Username string
+	Email    string
+	FullName string
+	Groups   []string
+}
+
+// LDAP memverifikasi password dengan bind sebagai DN user; password tidak pernah disimpan di aplikasi.
+type LDAP struct {
+	cfg LDAPConfig
+}
+
+func NewLDAP(cfg LDAPConfig) *LDAP {
+	return &LDAP{cfg: cfg}
+}
+
+func (l *LDAP) Authenticate(account Account, password string) error {
+	// Bind dengan password kosong adalah "unauthenticated bind" yang selalu sukses di banyak server
+	if password == "" {
+		return ErrInvalidCredentials
+	}
+
+	conn, err := l.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	user, err := l.findUser(conn, account.Username)
+	if err != nil {
+		return err
+	}
+
+	if err := conn.Bind(user.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return ErrInvalidCredentials
+		}
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return nil
+}
+
+// ListUsers mengembalikan semua user direktori yang cocok dengan SyncFilter (paged search).
+func (l *LDAP) ListUsers() ([]DirectoryUser, error) {
+	conn, err := l.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	req := ldap.NewSearchRequest(
+		l.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		l.cfg.SyncFilter, l.attributes(), nil,
+	)
+	res, err := conn.SearchWithPaging(req, 500)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	users := make([]DirectoryUser, 0, len(res.Entries))
+	for _, entry := range res.Entries {
+		if u := l.toDirectoryUser(entry); u.Username != "" {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+// connect membuka koneksi dan bind dengan akun layanan (atau anonymous jika BindDN kosong).
+func (l *LDAP) connect() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: l.cfg.InsecureSkipVerify}
+
+	conn, err := ldap.DialURL(l.cfg.URL, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	conn.SetTimeout(l.cfg.Timeout)
+
+	if l.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+	}
+
+	if l.cfg.BindDN != "" {
+		err = conn.Bind(l.cfg.BindDN, l.cfg.BindPassword)
+	} else {
+		err = conn.UnauthenticatedBind("")
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%w: bind akun layanan gagal: %v", ErrUnavailable, err)
+	}
+	return conn, nil
+}
+
+func (l *LDAP) findUser(conn *ldap.Conn, username string) (*DirectoryUser, error) {
+	filter := fmt.Sprintf(l.cfg.UserFilter, ldap.EscapeFilter(username))
+	req := ldap.NewSearchRequest(
+		l.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		filter, l.attributes(), nil,
+	)
+
+	res, err := conn.Search(req)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	// User tidak ada (atau ambigu) diperlakukan sama dengan password salah
+	if len(res.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	u := l.toDirectoryUser(res.Entries[0])
+	return &u, nil
+}
+
+func (l *LDAP) attributes() []string {
+	return []string{l.cfg.UsernameAttr, l.cfg.EmailAttr, l.cfg.NameAttr, l.cfg.GroupAttr}
+}
+
+func (l *LDAP) toDirectoryUser(entry *ldap.Entry) DirectoryUser {
+	return DirectoryUser{
+		DN:       entry.DN,
+		Username: entry.GetAttributeValue(l.cfg.UsernameAttr),
+		Email:    entry.GetAttributeValue(l.cfg.EmailAttr),
+		FullName: entry.GetAttributeValue(l.cfg.NameAttr),
+		Groups:   entry.GetAttributeValues(l.cfg.GroupAttr),
+	}
+}
+
+// GroupRoleRule memetakan grup direktori (DN) ke role aplikasi.
+type GroupRoleRule struct {
+	GroupDN  string
+	RoleID   string
+	RoleName string
+	Priority int // angka kecil dievaluasi lebih dulu
+}
+
+// MapGroupsToRole mengembalikan aturan pertama (urut prioritas) yang grupnya dimiliki user.
+// DN dibandingkan tanpa membedakan huruf besar/kecil dan spasi setelah koma.
+func MapGroupsToRole(groups []string, rules []GroupRoleRule) (GroupRoleRule, bool) {
+	member := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		member[normalizeDN(g)] = true
+	}
+
+	var best GroupRoleRule
+	found := false
+	for _, rule := range rules {
+		if member[normalizeDN(rule.GroupDN)] && (!found || rule.Priority < best.Priority) {
+			best = rule
+			found = true
+		}
+	}
+	return best, found
+}
+
+func normalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, p := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(p))
+	}
+	return strings.Join(parts, ",")
+}
+
+// IsUnavailable membedakan gangguan backend dari password salah.
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrUnavailable)
+}
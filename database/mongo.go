@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -33,6 +34,15 @@ func ConnectMongo() {
 
 	MongoClient = client
 	MongoD = client.Database(os.Getenv("MONGO_DB_NAME"))
-	
+
+	// Satu nomor revisi hanya boleh dimiliki satu snapshot per prestasi
+	_, err = MongoD.Collection("achievement_revisions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "achievementId", Value: 1}, {Key: "revision", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Fatal("Failed to create achievement_revisions index:", err)
+	}
+
 	log.Println("✅ Connected to MongoDB successfully")
 }
\ No newline at end of file
@@ -150,4 +150,705 @@ CREATE TABLE achievement_references (
     rejection_note TEXT,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
\ No newline at end of file
+);
+
+-- ============================================
+-- LOGIN ATTEMPT TRACKING & ACCOUNT LOCKOUT
+-- ============================================
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS failed_login_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS last_login_ip VARCHAR(45);
+
+CREATE TABLE login_attempts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE, -- NULL jika username tidak dikenal
+    username VARCHAR(100) NOT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent TEXT,
+    success BOOLEAN NOT NULL,
+    reason VARCHAR(50),
+    attempted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_login_attempts_ip_time ON login_attempts (ip_address, attempted_at) WHERE success = false;
+CREATE INDEX idx_login_attempts_user_time ON login_attempts (user_id, attempted_at);
+
+
+-- ============================================
+-- TOKEN REVOCATION (shared antar instance API)
+-- ============================================
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+
+-- ============================================
+-- SESSIONS & REFRESH TOKEN ROTATION
+-- ============================================
+-- Satu baris sessions = satu login (device). Baris ini juga menjadi "family"
+-- untuk semua refresh token hasil rotasi dari login tersebut.
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT,
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    revoked_reason VARCHAR(50)
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions (user_id);
+
+-- Refresh token hanya disimpan dalam bentuk hash SHA-256 dan hanya boleh dipakai sekali.
+CREATE TABLE session_refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMP
+);
+
+
+-- ============================================
+-- TWO-FACTOR AUTHENTICATION (TOTP)
+-- ============================================
+ALTER TABLE roles
+    ADD COLUMN IF NOT EXISTS require_2fa BOOLEAN NOT NULL DEFAULT false;
+
+-- Verifikator dan admin wajib memakai 2FA
+UPDATE roles SET require_2fa = true WHERE name IN ('Admin', 'Dosen Wali');
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64),          -- terisi saat setup, aktif setelah totp_enabled = true
+    ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;            -- cegah pemakaian ulang kode yang sama
+
+CREATE TABLE user_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, code_hash)
+);
+
+
+-- ============================================
+-- JWT SIGNING KEYS (rotasi key & JWKS)
+-- ============================================
+-- Hanya dipakai jika JWT_ALGORITHM = RS256/EdDSA atau JWT_KEY_ROTATION_HOURS > 0.
+CREATE TABLE jwt_signing_keys (
+    kid VARCHAR(64) PRIMARY KEY,
+    algorithm VARCHAR(10) NOT NULL,
+    private_key BYTEA NOT NULL,        -- HS256: secret mentah, RS256/EdDSA: PKCS#8 DER
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP               -- NULL = tidak pernah kadaluarsa
+);
+
+
+-- ============================================
+-- PASSWORD RESET
+-- ============================================
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP;
+
+-- Token reset hanya disimpan dalam bentuk hash SHA-256, sekali pakai dan punya masa berlaku.
+CREATE TABLE password_reset_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    requested_ip VARCHAR(45),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+
+
+-- ============================================
+-- PASSWORD POLICY & RIWAYAT PASSWORD
+-- ============================================
+-- Role tanpa baris di tabel ini memakai kebijakan default aplikasi.
+CREATE TABLE password_policies (
+    role_id UUID PRIMARY KEY REFERENCES roles(id) ON DELETE CASCADE,
+    min_length INT NOT NULL DEFAULT 8,
+    require_uppercase BOOLEAN NOT NULL DEFAULT false,
+    require_lowercase BOOLEAN NOT NULL DEFAULT true,
+    require_digit BOOLEAN NOT NULL DEFAULT true,
+    require_symbol BOOLEAN NOT NULL DEFAULT false,
+    history_count INT NOT NULL DEFAULT 3,            -- jumlah password terakhir yang tidak boleh dipakai ulang
+    reject_common BOOLEAN NOT NULL DEFAULT true,     -- tolak password dari daftar password umum
+    reject_personal_info BOOLEAN NOT NULL DEFAULT true, -- tolak password yang memuat username / NIM
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Admin dan verifikator memakai kebijakan lebih ketat
+INSERT INTO password_policies (role_id, min_length, require_uppercase, require_lowercase, require_digit, require_symbol, history_count)
+SELECT id, 12, true, true, true, true, 5 FROM roles WHERE name IN ('Admin', 'Dosen Wali');
+
+INSERT INTO password_policies (role_id, min_length, history_count)
+SELECT id, 8, 3 FROM roles WHERE name = 'Mahasiswa';
+
+-- Hash bcrypt setiap password yang pernah diset (termasuk password saat ini)
+CREATE TABLE password_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_password_history_user_id ON password_history (user_id, created_at DESC);
+
+
+-- ============================================
+-- OPENID CONNECT SSO
+-- ============================================
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS oidc_issuer VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS oidc_subject VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc_identity ON users (oidc_issuer, oidc_subject)
+    WHERE oidc_subject IS NOT NULL;
+
+-- State login yang sedang berjalan (state disimpan sebagai hash, sekali pakai)
+CREATE TABLE oidc_auth_requests (
+    state_hash CHAR(64) PRIMARY KEY,
+    nonce VARCHAR(128) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    link_user_id UUID REFERENCES users(id) ON DELETE CASCADE, -- diisi saat user yang login menghubungkan akun SSO
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- Aturan claim -> role untuk provisioning otomatis (just-in-time) user baru dari SSO
+CREATE TABLE oidc_role_mappings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    claim VARCHAR(100) NOT NULL,       -- nama claim, boleh path bertitik (misal realm_access.roles)
+    value VARCHAR(255) NOT NULL,       -- nilai yang dicocokkan, '*' = claim cukup ada
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    priority INT NOT NULL DEFAULT 100, -- angka kecil dievaluasi lebih dulu
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO oidc_role_mappings (claim, value, role_id, priority)
+SELECT 'affiliation', 'student', id, 10 FROM roles WHERE name = 'Mahasiswa';
+
+
+-- ============================================
+-- SUMBER AUTENTIKASI (LOCAL / LDAP)
+-- ============================================
+-- Akun 'ldap' diverifikasi dengan bind ke direktori fakultas; password_hash-nya dibiarkan kosong.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS auth_source VARCHAR(20) NOT NULL DEFAULT 'local'
+        CHECK (auth_source IN ('local', 'ldap'));
+
+-- Pemetaan grup LDAP (DN) -> role, diterapkan oleh sinkronisasi berkala
+CREATE TABLE ldap_group_role_mappings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    group_dn VARCHAR(255) UNIQUE NOT NULL,
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    priority INT NOT NULL DEFAULT 100, -- angka kecil dievaluasi lebih dulu
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+
+-- ============================================
+-- API KEYS (INTEGRASI MESIN)
+-- ============================================
+-- Key utuh (spk_<prefix>_<secret>) tidak pernah disimpan, hanya prefix dan hash SHA-256.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) UNIQUE NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    allowed_ips TEXT[] NOT NULL DEFAULT '{}',  -- IP atau CIDR, kosong = semua IP
+    expires_at TIMESTAMP,                      -- NULL = tidak kadaluarsa
+    last_used_at TIMESTAMP,
+    last_used_ip VARCHAR(45),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+
+-- ============================================
+-- IMPERSONATION (LOGIN SEBAGAI USER LAIN) & AUDIT LOG
+-- ============================================
+INSERT INTO permissions (id, name, resource, action, description) VALUES
+    (gen_random_uuid(), 'user:impersonate', 'user', 'impersonate', 'Izin untuk login sebagai user lain (support)');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'Admin' AND p.name = 'user:impersonate';
+
+-- Setiap impersonation yang dimulai dan setiap request yang mengubah data selama impersonation
+CREATE TABLE audit_logs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    action VARCHAR(50) NOT NULL,          -- impersonation_start / impersonation_request / impersonation_blocked
+    impersonator_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_id VARCHAR(64) NOT NULL,        -- jti token impersonation
+    method VARCHAR(10),
+    path TEXT,
+    status_code INT,
+    reason TEXT,
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_logs_impersonator ON audit_logs(impersonator_id, created_at DESC);
+CREATE INDEX idx_audit_logs_user ON audit_logs(user_id, created_at DESC);
+
+
+-- ============================================
+-- MANAJEMEN ROLE & PERMISSION
+-- ============================================
+-- Naik setiap kali permission role berubah; access token membawa versi saat terbit (claim pv)
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS permission_version INT NOT NULL DEFAULT 1;
+
+INSERT INTO permissions (id, name, resource, action, description) VALUES
+    (gen_random_uuid(), 'role:manage', 'role', 'manage', 'Izin untuk mengelola role dan permission');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'Admin' AND p.name = 'role:manage';
+-- Permission lain yang dipakai route (misal achievement:submit) didaftarkan otomatis saat aplikasi start
+
+
+-- ============================================
+-- UNIT AKADEMIK & ROLE ASSIGNMENT BERSCOPE
+-- ============================================
+-- Pemetaan program studi -> departemen -> fakultas. name sama dengan students.program_study,
+-- department sama dengan lecturers.department.
+CREATE TABLE study_programs (
+    name VARCHAR(100) PRIMARY KEY,
+    department VARCHAR(100) NOT NULL,
+    faculty VARCHAR(100) NOT NULL
+);
+
+CREATE INDEX idx_study_programs_department ON study_programs(department);
+CREATE INDEX idx_study_programs_faculty ON study_programs(faculty);
+
+INSERT INTO study_programs (name, department, faculty) VALUES
+    ('Information Systems', 'Computer Science Department', 'Faculty of Computer Science');
+
+-- Role tambahan user yang hanya berlaku di satu unit (scope_type kosong = semua unit).
+-- Permission-nya hanya dipakai pada data akademik (mahasiswa, dosen, prestasi, laporan).
+CREATE TABLE role_assignments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES roles(id),
+    scope_type VARCHAR(20) NOT NULL DEFAULT '' CHECK (scope_type IN ('', 'faculty', 'department', 'program')),
+    scope_value VARCHAR(100) NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, role_id, scope_type, scope_value)
+);
+
+CREATE INDEX idx_role_assignments_user ON role_assignments(user_id);
+
+
+-- ============================================
+-- STATE MACHINE STATUS PRESTASI
+-- ============================================
+-- Transisi yang sah diatur package app/workflow. ADD VALUE tidak bisa dijalankan di dalam transaksi.
+ALTER TYPE achievement_status_type ADD VALUE IF NOT EXISTS 'revision_requested';
+ALTER TYPE achievement_status_type ADD VALUE IF NOT EXISTS 'withdrawn';
+ALTER TYPE achievement_status_type ADD VALUE IF NOT EXISTS 'archived';
+
+INSERT INTO permissions (id, name, resource, action, description) VALUES
+    (gen_random_uuid(), 'achievement:archive', 'achievement', 'archive', 'Izin untuk mengarsipkan prestasi yang sudah selesai diproses');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'Admin' AND p.name = 'achievement:archive';
+
+
+-- ============================================
+-- LOG TRANSISI STATUS PRESTASI
+-- ============================================
+-- Penolakan punya timestamp sendiri; verified_at hanya untuk verifikasi
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS rejected_at TIMESTAMP;
+
+UPDATE achievement_references
+SET rejected_at = verified_at, verified_at = NULL
+WHERE status = 'rejected' AND rejected_at IS NULL;
+
+-- Satu baris per transisi. from_status NULL = pembuatan prestasi (-> draft) atau data hasil migrasi
+CREATE TABLE achievement_status_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    achievement_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    from_status achievement_status_type,
+    to_status achievement_status_type NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL untuk API key / data migrasi
+    actor_role VARCHAR(50) NOT NULL DEFAULT '',
+    note TEXT,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_achievement_status_events_achievement ON achievement_status_events(achievement_id, created_at);
+
+-- Data lama: hanya pembuatan dan status terakhir yang masih bisa direkonstruksi
+INSERT INTO achievement_status_events (achievement_id, from_status, to_status, note, created_at)
+SELECT id, NULL, 'draft', 'Migrasi: prestasi dibuat', created_at
+FROM achievement_references;
+
+INSERT INTO achievement_status_events (achievement_id, from_status, to_status, actor_id, note, created_at)
+SELECT id, NULL, status,
+       CASE WHEN status IN ('verified', 'rejected') THEN verified_by END,
+       COALESCE(rejection_note, 'Migrasi: status terakhir sebelum log transisi'),
+       COALESCE(verified_at, rejected_at, submitted_at, updated_at, created_at)
+FROM achievement_references
+WHERE status <> 'draft';
+
+
+-- ============================================
+-- PIPELINE VERIFIKASI BERTINGKAT
+-- ============================================
+-- Sebagian tahap sudah disetujui, prestasi menunggu tahap berikutnya
+ALTER TYPE achievement_status_type ADD VALUE IF NOT EXISTS 'in_review';
+
+-- Pipeline berlaku untuk tingkat kompetisi / tipe prestasi yang cocok (array kosong = semua, nilai lowercase).
+-- Tanpa pipeline yang cocok dipakai pipeline default: satu tahap oleh dosen wali.
+CREATE TABLE verification_pipelines (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    competition_tiers TEXT[] NOT NULL DEFAULT '{}',
+    achievement_types TEXT[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- approver_type advisor = dosen wali pemilik; role = pemegang role assignment approver_role_id
+-- (pada tingkat scope_level jika diisi) yang unitnya mencakup prestasi.
+CREATE TABLE verification_stages (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    pipeline_id UUID NOT NULL REFERENCES verification_pipelines(id) ON DELETE CASCADE,
+    position INT NOT NULL CHECK (position >= 1),
+    name VARCHAR(100) NOT NULL,
+    approver_type VARCHAR(20) NOT NULL CHECK (approver_type IN ('advisor', 'role')),
+    approver_role_id UUID REFERENCES roles(id),
+    scope_level VARCHAR(20) NOT NULL DEFAULT '' CHECK (scope_level IN ('', 'faculty', 'department', 'program')),
+    required_approvals INT NOT NULL DEFAULT 1 CHECK (required_approvals >= 1),
+    UNIQUE (pipeline_id, position)
+);
+
+-- Pipeline dipatok saat persetujuan pertama; diajukan ulang = mulai lagi dari tahap 1
+ALTER TABLE achievement_references
+    ADD COLUMN IF NOT EXISTS pipeline_id UUID REFERENCES verification_pipelines(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS current_stage INT NOT NULL DEFAULT 1;
+
+-- Persetujuan tahap pada pengajuan yang sedang berjalan (dihapus saat prestasi diajukan ulang;
+-- jejak permanennya ada di achievement_status_events)
+CREATE TABLE achievement_stage_approvals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    achievement_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    stage_position INT NOT NULL,
+    stage_name VARCHAR(100) NOT NULL,
+    approver_id UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL untuk API key
+    approver_api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL, -- diisi jika disetujui lewat API key
+    approver_role VARCHAR(50) NOT NULL DEFAULT '',
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Satu penyetuju (user atau API key) hanya sekali per tahap; UNIQUE biasa menganggap NULL selalu berbeda
+CREATE UNIQUE INDEX idx_stage_approvals_approver
+    ON achievement_stage_approvals (achievement_id, stage_position, COALESCE(approver_id, approver_api_key_id));
+
+INSERT INTO permissions (id, name, resource, action, description) VALUES
+    (gen_random_uuid(), 'verification:manage', 'verification', 'manage', 'Izin untuk mengelola pipeline verifikasi prestasi');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'Admin' AND p.name = 'verification:manage';
+
+-- Bagian kemahasiswaan fakultas: diberikan lewat role assignment berscope fakultas
+INSERT INTO roles (id, name, description, created_at) VALUES
+    (gen_random_uuid(), 'Kemahasiswaan Fakultas', 'Penyetuju tahap fakultas untuk prestasi tingkat tinggi', NOW());
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'Kemahasiswaan Fakultas' AND p.name IN ('achievement:read', 'achievement:verify', 'achievement:reject');
+
+WITH pipeline AS (
+    INSERT INTO verification_pipelines (name, description, competition_tiers)
+    VALUES ('Prestasi Tingkat Tinggi', 'Prestasi internasional / nasional disetujui dosen wali lalu kemahasiswaan fakultas',
+            '{internasional,nasional}')
+    RETURNING id
+)
+INSERT INTO verification_stages (pipeline_id, position, name, approver_type, approver_role_id, scope_level, required_approvals)
+SELECT pipeline.id, 1, 'Dosen Wali', 'advisor', NULL, '', 1 FROM pipeline
+UNION ALL
+SELECT pipeline.id, 2, 'Kemahasiswaan Fakultas', 'role', r.id, 'faculty', 1
+FROM pipeline, roles r WHERE r.name = 'Kemahasiswaan Fakultas';
+
+
+-- ============================================
+-- ATURAN POIN PRESTASI
+-- ============================================
+-- kind base: poin dasar (hanya aturan base prioritas tertinggi yang cocok), bonus: ditambahkan,
+-- multiplier: value = faktor pengali. conditions: [{"field": "rank", "op": "eq", "value": 1}, ...]
+-- atas field details; valid_from / valid_until membatasi tanggal kegiatan (details.eventDate).
+CREATE TABLE points_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('base', 'bonus', 'multiplier')),
+    achievement_type VARCHAR(50) NOT NULL DEFAULT '',
+    competition_tier VARCHAR(50) NOT NULL DEFAULT '',
+    conditions JSONB NOT NULL DEFAULT '[]',
+    valid_from DATE,
+    valid_until DATE,
+    value NUMERIC(10, 2) NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Rincian setiap perhitungan poin (saat verifikasi dan hitung ulang)
+CREATE TABLE achievement_points_awards (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    achievement_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    total INT NOT NULL,
+    previous_total INT,
+    breakdown JSONB NOT NULL,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('verify', 'recalculate')),
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_achievement_points_awards_achievement ON achievement_points_awards(achievement_id, created_at);
+
+-- Aturan awal sama dengan perhitungan lama: internasional 100, nasional 50, selainnya 10
+INSERT INTO points_rules (name, description, kind, competition_tier, value, priority) VALUES
+    ('Tingkat Internasional', 'Poin dasar prestasi internasional', 'base', 'internasional', 100, 10),
+    ('Tingkat Nasional', 'Poin dasar prestasi nasional', 'base', 'nasional', 50, 10),
+    ('Poin Dasar Lainnya', 'Poin dasar untuk tingkat kompetisi lain', 'base', '', 10, 0);
+
+INSERT INTO permissions (id, name, resource, action, description) VALUES
+    (gen_random_uuid(), 'points:manage', 'points', 'manage', 'Izin untuk mengelola aturan poin dan menghitung ulang poin prestasi');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'Admin' AND p.name = 'points:manage';
+
+
+-- ============================================
+-- TIPE PRESTASI & SCHEMA DETAILS
+-- ============================================
+-- details_schema: JSON Schema (subset, lihat package app/schema) untuk field details di MongoDB.
+-- required_attachments: kategori lampiran yang wajib diunggah sebelum diajukan.
+-- allowed_tiers: competitionTier yang boleh dipakai (kosong = bebas).
+CREATE TABLE achievement_types (
+    code VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    details_schema JSONB NOT NULL DEFAULT '{"type": "object"}',
+    required_attachments TEXT[] NOT NULL DEFAULT '{}',
+    allowed_tiers TEXT[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO achievement_types (code, name, description, details_schema, required_attachments, allowed_tiers) VALUES
+('competition', 'Kompetisi', 'Lomba / kejuaraan akademik maupun non-akademik',
+ '{"type": "object",
+   "required": ["competitionName", "organizer", "eventDate"],
+   "properties": {
+     "competitionName": {"type": "string", "minLength": 3},
+     "organizer": {"type": "string"},
+     "eventDate": {"type": "string", "format": "date"},
+     "rank": {"type": "integer", "minimum": 1},
+     "rankLabel": {"type": "string"},
+     "teamSize": {"type": "integer", "minimum": 1},
+     "location": {"type": "string"}
+   }}',
+ '{certificate}', '{internasional,nasional,provinsi,regional,lokal}'),
+('publication', 'Publikasi', 'Artikel jurnal, prosiding atau buku',
+ '{"type": "object",
+   "required": ["publicationTitle", "publisher", "publicationDate", "authors"],
+   "properties": {
+     "publicationTitle": {"type": "string", "minLength": 3},
+     "publicationType": {"type": "string", "enum": ["journal", "conference", "book"]},
+     "publisher": {"type": "string"},
+     "publicationDate": {"type": "string", "format": "date"},
+     "authors": {"type": "array", "minItems": 1, "items": {"type": "string"}},
+     "doi": {"type": "string", "pattern": "^10\\.\\d{4,9}/\\S+$"},
+     "url": {"type": "string", "format": "uri"}
+   }}',
+ '{}', '{internasional,nasional}'),
+('organization', 'Organisasi', 'Kepengurusan organisasi kemahasiswaan',
+ '{"type": "object",
+   "required": ["organizationName", "position", "periodStart"],
+   "properties": {
+     "organizationName": {"type": "string"},
+     "position": {"type": "string"},
+     "periodStart": {"type": "string", "format": "date"},
+     "periodEnd": {"type": "string", "format": "date"}
+   }}',
+ '{decree}', '{}'),
+('certification', 'Sertifikasi', 'Sertifikasi kompetensi / profesi',
+ '{"type": "object",
+   "required": ["certificationName", "issuer", "issueDate"],
+   "properties": {
+     "certificationName": {"type": "string"},
+     "issuer": {"type": "string"},
+     "issueDate": {"type": "string", "format": "date"},
+     "expiryDate": {"type": "string", "format": "date"},
+     "certificateNumber": {"type": "string"}
+   }}',
+ '{certificate}', '{}'),
+('community_service', 'Pengabdian Masyarakat', 'Kegiatan pengabdian kepada masyarakat',
+ '{"type": "object",
+   "required": ["activityName", "location", "eventDate"],
+   "properties": {
+     "activityName": {"type": "string"},
+     "location": {"type": "string"},
+     "eventDate": {"type": "string", "format": "date"},
+     "durationHours": {"type": "number", "minimum": 0},
+     "partner": {"type": "string"}
+   }}',
+ '{documentation}', '{}'),
+('ipr', 'Hak Kekayaan Intelektual', 'Paten, hak cipta, merek atau desain industri',
+ '{"type": "object",
+   "required": ["iprType", "registrationNumber", "registrationDate"],
+   "properties": {
+     "iprType": {"type": "string", "enum": ["paten", "hak_cipta", "merek", "desain_industri"]},
+     "registrationNumber": {"type": "string"},
+     "registrationDate": {"type": "string", "format": "date"},
+     "inventors": {"type": "array", "items": {"type": "string"}}
+   }}',
+ '{certificate}', '{}');
+
+INSERT INTO permissions (id, name, resource, action, description) VALUES
+    (gen_random_uuid(), 'achievement_type:manage', 'achievement_type', 'manage', 'Izin untuk mengelola tipe prestasi dan schema details');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'Admin' AND p.name = 'achievement_type:manage';
+
+
+-- ============================================
+-- REVISI KONTEN PRESTASI
+-- ============================================
+-- Snapshot konten disimpan di koleksi MongoDB achievement_revisions (satu dokumen per revisi, immutable).
+-- Index unik {achievementId: 1, revision: 1} dibuat otomatis saat aplikasi terhubung ke MongoDB (database.ConnectMongo).
+-- PostgreSQL mematok revisi yang diajukan dan revisi yang akhirnya disetujui.
+ALTER TABLE achievement_references
+    ADD COLUMN submitted_revision INT,
+    ADD COLUMN verified_revision INT;
+
+ALTER TABLE achievement_status_events
+    ADD COLUMN revision INT;
+
+
+-- ============================================
+-- KOMENTAR REVIEW PRESTASI
+-- ============================================
+-- parent_id NULL = pembuka thread; balasan selalu menunjuk pembuka thread dan mengikuti visibilitasnya.
+-- visibility internal = catatan antar reviewer, tidak terlihat mahasiswa.
+CREATE TABLE achievement_comments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    achievement_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES achievement_comments(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    author_role VARCHAR(50) NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    visibility VARCHAR(20) NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'internal')),
+    anchor_type VARCHAR(20) CHECK (anchor_type IN ('field', 'attachment')),
+    anchor_ref TEXT,
+    revision INT, -- revisi konten saat komentar ditulis
+    resolved_at TIMESTAMP,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_achievement_comments_achievement ON achievement_comments(achievement_id, created_at);
+
+
+-- ============================================
+-- ANTREAN VERIFIKASI & ESKALASI SLA
+-- ============================================
+-- Satu baris per eskalasi tahap berjalan; waiting_since = awal tahap (event submitted / in_review terakhir)
+-- sehingga tahap berikutnya atau pengajuan ulang dapat dieskalasi lagi. Baris diklaim (escalated_at) sebelum
+-- email dikirim agar hanya satu instance yang mengirim; notified_at diisi setelah email terkirim.
+CREATE TABLE verification_escalations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    achievement_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    stage_position INT NOT NULL,
+    waiting_since TIMESTAMP NOT NULL,
+    department VARCHAR(100) NOT NULL DEFAULT '',
+    notified_count INT NOT NULL DEFAULT 0,
+    escalated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    notified_at TIMESTAMP,
+    UNIQUE (achievement_id, stage_position, waiting_since)
+);
+
+INSERT INTO permissions (id, name, resource, action, description) VALUES
+    (gen_random_uuid(), 'achievement:escalation', 'achievement', 'escalation', 'Menerima eskalasi verifikasi prestasi yang melewati SLA');
+
+-- Admin departemen: ditugaskan lewat role_assignments dengan scope_type = 'department'
+INSERT INTO roles (id, name, description, created_at) VALUES
+    (gen_random_uuid(), 'Admin Departemen', 'Pemantau verifikasi prestasi di departemen, penerima eskalasi SLA', NOW());
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'Admin Departemen' AND p.name IN ('achievement:read', 'achievement:escalation');
+
+
+-- ============================================
+-- DELEGASI VERIFIKASI DOSEN WALI
+-- ============================================
+-- Selama start_date s/d end_date (inklusif) dan belum dicabut, dosen pengganti (to) bertindak sebagai dosen wali
+-- untuk mahasiswa bimbingan dosen asal (from); student_ids kosong = semua mahasiswa bimbingan.
+CREATE TABLE advisor_delegations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    from_lecturer_id UUID NOT NULL REFERENCES lecturers(id) ON DELETE CASCADE,
+    to_lecturer_id UUID NOT NULL REFERENCES lecturers(id) ON DELETE CASCADE,
+    student_ids UUID[] NOT NULL DEFAULT '{}',
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP,
+    revoked_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    CHECK (from_lecturer_id <> to_lecturer_id),
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX idx_advisor_delegations_to ON advisor_delegations(to_lecturer_id, start_date, end_date) WHERE revoked_at IS NULL;
+
+-- Aksi dosen pengganti dicatat atas nama dosen wali yang diwakili
+ALTER TABLE achievement_status_events
+    ADD COLUMN on_behalf_of UUID REFERENCES lecturers(id) ON DELETE SET NULL,
+    ADD COLUMN delegation_id UUID REFERENCES advisor_delegations(id) ON DELETE SET NULL;
+
+ALTER TABLE achievement_stage_approvals
+    ADD COLUMN on_behalf_of UUID REFERENCES lecturers(id) ON DELETE SET NULL,
+    ADD COLUMN delegation_id UUID REFERENCES advisor_delegations(id) ON DELETE SET NULL;
+
+INSERT INTO permissions (id, name, resource, action, description) VALUES
+    (gen_random_uuid(), 'delegation:manage', 'delegation', 'manage', 'Izin untuk mengelola delegasi verifikasi dosen wali');
+
+-- Dosen wali mendelegasikan mahasiswa bimbingannya sendiri, admin untuk semua dosen
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name IN ('Admin', 'Dosen Wali') AND p.name = 'delegation:manage';
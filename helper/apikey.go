@@ -0,0 +1,70 @@
+package helper
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net"
+	"strings"
+)
+
+// Prefix semua API key, memudahkan secret scanning mendeteksi key yang bocor
+const APIKeyPrefix = "spk"
+
+// GenerateAPIKey membuat API key baru berformat spk_<prefix>_<secret>.
+// prefix (8 hex) disimpan apa adanya untuk identifikasi, key utuh hanya disimpan sebagai hash.
+func GenerateAPIKey() (key string, prefix string, err error) {
+	prefixBytes := make([]byte, 4)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	key = APIKeyPrefix + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, nil
+}
+
+// HashAPIKey menghasilkan SHA-256 (hex) dari API key.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IPAllowed mengecek ip terhadap daftar IP / CIDR. Daftar kosong berarti semua IP diizinkan.
+func IPAllowed(ip string, allowlist []string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, entry := range allowlist {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(parsed) {
+				return true
+			}
+			continue
+		}
+		if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidIPOrCIDR mengecek format satu entri allowlist.
+func ValidIPOrCIDR(entry string) bool {
+	if strings.Contains(entry, "/") {
+		_, _, err := net.ParseCIDR(entry)
+		return err == nil
+	}
+	return net.ParseIP(entry) != nil
+}
@@ -0,0 +1,42 @@
+package helper
+
+import (
+	"strconv"
+
+	"sistempelaporan/app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Batas pagination endpoint daftar
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Pagination membaca query page & limit. page < 1 menjadi 1, limit kosong / tidak valid menjadi
+// DefaultPageLimit dan limit di atas MaxPageLimit dipotong ke MaxPageLimit.
+func Pagination(c *fiber.Ctx) (page, limit int) {
+	page, _ = strconv.Atoi(c.Query("page", "1"))
+	limit, _ = strconv.Atoi(c.Query("limit", strconv.Itoa(DefaultPageLimit)))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+	return page, limit
+}
+
+// PageMeta membuat meta pagination; total_page dibulatkan ke atas.
+func PageMeta(page, limit int, total int64) *model.Meta {
+	return &model.Meta{
+		Page:      page,
+		Limit:     limit,
+		TotalData: total,
+		TotalPage: int((total + int64(limit) - 1) / int64(limit)),
+	}
+}
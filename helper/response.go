@@ -46,7 +46,10 @@ func Error(c *fiber.Ctx, code int, message string, errs interface{}) error {
 	if code == 401 { statusText = "Unauthorized" }
 	if code == 403 { statusText = "Forbidden" }
 	if code == 404 { statusText = "Not Found" }
+	if code == 423 { statusText = "Locked" }
+	if code == 429 { statusText = "Too Many Requests" }
 	if code == 500 { statusText = "Internal Server Error" }
+	if code == 503 { statusText = "Service Unavailable" }
 
 	return c.Status(code).JSON(Response{
 		Code:    code,
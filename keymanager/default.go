@@ -0,0 +1,96 @@
+package keymanager
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Secret default untuk development (sama dengan nilai lama yang di-hardcode)
+const devFallbackSecret = "rahasia_negara_api"
+
+var (
+	defaultMu      sync.RWMutex
+	defaultManager *Manager
+)
+
+// ConfigFromEnv membaca konfigurasi dari environment:
+//
+//	JWT_ALGORITHM            HS256 (default) | RS256 | EdDSA
+//	JWT_SECRET               secret HS256 statis (dipakai jika tidak ada rotasi)
+//	JWT_KEY_ROTATION_HOURS   umur key sebelum dirotasi, 0 = tanpa rotasi
+//	JWT_KEY_GRACE_HOURS      masa berlaku key lama setelah dirotasi (default 168 = umur refresh token)
+func ConfigFromEnv() Config {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		secret = devFallbackSecret
+	}
+	return Config{
+		Algorithm:        envOr("JWT_ALGORITHM", AlgHS256),
+		RotationInterval: time.Duration(envInt("JWT_KEY_ROTATION_HOURS", 0)) * time.Hour,
+		GracePeriod:      time.Duration(envInt("JWT_KEY_GRACE_HOURS", 168)) * time.Hour,
+		StaticSecret:     []byte(secret),
+	}
+}
+
+// UsesStore menentukan apakah key harus disimpan di store bersama:
+// algoritma asimetris atau rotasi otomatis membutuhkannya.
+func (cfg Config) UsesStore() bool {
+	return cfg.Algorithm != AlgHS256 || cfg.RotationInterval > 0
+}
+
+// Init membuat Manager default dari environment. store hanya dipakai jika konfigurasi membutuhkannya.
+func Init(store Store) (*Manager, error) {
+	cfg := ConfigFromEnv()
+	if !cfg.UsesStore() {
+		store = nil
+	}
+
+	m, err := New(cfg, store)
+	if err != nil {
+		return nil, err
+	}
+	SetDefault(m)
+	return m, nil
+}
+
+func SetDefault(m *Manager) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultManager = m
+}
+
+// Default mengembalikan Manager yang dipakai signing & verifikasi di seluruh aplikasi.
+// Jika Init belum dipanggil, dipakai key HS256 statis dari JWT_SECRET (selalu terisi, sehingga tidak
+// bisa gagal); error konfigurasi key hanya dilaporkan oleh Init saat startup.
+func Default() *Manager {
+	defaultMu.RLock()
+	m := defaultManager
+	defaultMu.RUnlock()
+	if m != nil {
+		return m
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultManager == nil {
+		defaultManager = newStatic(ConfigFromEnv())
+	}
+	return defaultManager
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func envInt(key string, fallback int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v < 0 {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,48 @@
+package keymanager
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWK adalah representasi public key sesuai RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS mengembalikan public key dari semua key asimetris yang masih berlaku.
+// Key HS256 adalah shared secret sehingga tidak pernah dipublikasikan.
+func (m *Manager) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0)}
+	b64 := base64.RawURLEncoding
+
+	for _, k := range m.Keys() {
+		switch pub := k.PublicKey().(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA", Kid: k.ID, Use: "sig", Alg: k.Algorithm,
+				N: b64.EncodeToString(pub.N.Bytes()),
+				E: b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP", Kid: k.ID, Use: "sig", Alg: k.Algorithm,
+				Crv: "Ed25519", X: b64.EncodeToString(pub),
+			})
+		}
+	}
+	return set
+}
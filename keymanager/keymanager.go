@@ -0,0 +1,401 @@
+// Package keymanager mengelola key untuk menandatangani dan memverifikasi JWT.
+// Signing dan verifikasi di seluruh aplikasi memakai Manager yang sama, sehingga
+// beberapa key bisa aktif bersamaan (dipilih lewat header `kid`) dan key bisa dirotasi
+// tanpa membatalkan token yang masih berlaku.
+package keymanager
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algoritma yang didukung
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnknownKey       = errors.New("keymanager: kid tidak dikenal")
+	ErrAlgMismatch      = errors.New("keymanager: algoritma token tidak sesuai dengan key")
+	ErrNoSigningKey     = errors.New("keymanager: tidak ada key aktif untuk signing")
+	ErrUnsupportedAlg   = errors.New("keymanager: algoritma tidak didukung")
+	reloadOnUnknownKeys = 10 * time.Second
+)
+
+// Key adalah satu key yang sudah di-decode dan siap dipakai.
+type Key struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	ExpiresAt time.Time // zero = tidak pernah kadaluarsa
+
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// StoredKey adalah bentuk persisten key. PrivateKey berisi secret mentah untuk HS256
+// atau PKCS#8 DER untuk RS256/EdDSA.
+type StoredKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey []byte
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// Store menyimpan key di tempat yang bisa dibaca semua instance API.
+type Store interface {
+	LoadKeys() ([]StoredKey, error) // hanya key yang belum kadaluarsa
+	SaveKey(key StoredKey) error
+	DeleteExpiredKeys() error
+}
+
+// Config mengatur perilaku Manager.
+type Config struct {
+	Algorithm string
+	// RotationInterval: umur key signing sebelum diganti. 0 = tidak pernah rotasi.
+	RotationInterval time.Duration
+	// GracePeriod: berapa lama key lama tetap diterima untuk verifikasi setelah
+	// digantikan. Minimal harus sama dengan umur token terpanjang (refresh token).
+	GracePeriod time.Duration
+	// StaticSecret dipakai jika Store nil: satu key HS256 tetap (perilaku lama JWT_SECRET).
+	StaticSecret []byte
+}
+
+type Manager struct {
+	mu         sync.RWMutex
+	cfg        Config
+	store      Store
+	keys       map[string]*Key
+	signing    *Key
+	lastReload time.Time
+}
+
+// New membuat Manager. Jika store nil, Manager memakai satu key HS256 statis dari cfg.StaticSecret.
+func New(cfg Config, store Store) (*Manager, error) {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = AlgHS256
+	}
+	if _, err := signingMethod(cfg.Algorithm); err != nil {
+		return nil, err
+	}
+
+	if store == nil {
+		if cfg.Algorithm != AlgHS256 || len(cfg.StaticSecret) == 0 {
+			return nil, errors.New("keymanager: tanpa store hanya HS256 dengan StaticSecret yang didukung")
+		}
+		return newStatic(cfg), nil
+	}
+
+	m := &Manager{cfg: cfg, store: store, keys: make(map[string]*Key)}
+
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	if m.needsRotation(time.Now()) {
+		if err := m.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// newStatic membuat Manager dengan satu key HS256 tetap dari cfg.StaticSecret (tidak boleh kosong).
+func newStatic(cfg Config) *Manager {
+	key := &Key{ID: "static", Algorithm: AlgHS256, signKey: cfg.StaticSecret, verifyKey: cfg.StaticSecret}
+	cfg.Algorithm = AlgHS256
+	return &Manager{cfg: cfg, keys: map[string]*Key{key.ID: key}, signing: key}
+}
+
+// Reload membaca ulang semua key dari store; key signing = key terbaru dengan algoritma yang dikonfigurasi.
+func (m *Manager) Reload() error {
+	if m.store == nil {
+		return nil
+	}
+
+	stored, err := m.store.LoadKeys()
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*Key, len(stored))
+	for _, sk := range stored {
+		key, err := decodeKey(sk)
+		if err != nil {
+			log.Printf("keymanager: melewati key %s: %v", sk.ID, err)
+			continue
+		}
+		keys[key.ID] = key
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys = keys
+	m.signing = newestKey(keys, m.cfg.Algorithm)
+	m.lastReload = time.Now()
+	return nil
+}
+
+// Rotate membuat key baru, menyimpannya ke store, lalu menjadikannya key signing.
+// Key lama tetap bisa dipakai untuk verifikasi sampai ExpiresAt.
+func (m *Manager) Rotate() error {
+	if m.store == nil {
+		return errors.New("keymanager: rotasi membutuhkan store")
+	}
+
+	now := time.Now()
+	sk, err := generateKey(m.cfg.Algorithm, now)
+	if err != nil {
+		return err
+	}
+	if m.cfg.RotationInterval > 0 {
+		sk.ExpiresAt = now.Add(m.cfg.RotationInterval + m.cfg.GracePeriod)
+	}
+
+	if err := m.store.SaveKey(sk); err != nil {
+		return err
+	}
+	log.Printf("keymanager: key JWT baru %s (%s) dibuat", sk.ID, sk.Algorithm)
+	return m.Reload()
+}
+
+func (m *Manager) needsRotation(now time.Time) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.signing == nil {
+		return true
+	}
+	return m.cfg.RotationInterval > 0 && now.Sub(m.signing.CreatedAt) >= m.cfg.RotationInterval
+}
+
+// Start menjalankan goroutine yang secara berkala me-reload key dari store (agar instance
+// lain ikut memakai key hasil rotasi) dan merotasi key jika sudah waktunya.
+func (m *Manager) Start(checkInterval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	if m.store == nil {
+		return func() { close(done) }
+	}
+
+	ticker := time.NewTicker(checkInterval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := m.Reload(); err != nil {
+					log.Printf("keymanager: gagal reload key: %v", err)
+					continue
+				}
+				if m.needsRotation(time.Now()) {
+					if err := m.Rotate(); err != nil {
+						log.Printf("keymanager: gagal rotasi key: %v", err)
+					}
+				}
+				if err := m.store.DeleteExpiredKeys(); err != nil {
+					log.Printf("keymanager: gagal menghapus key kadaluarsa: %v", err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// Sign menandatangani claims dengan key signing saat ini dan mengisi header `kid`.
+func (m *Manager) Sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	key := m.signing
+	m.mu.RUnlock()
+
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	method, err := signingMethod(key.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey)
+}
+
+// Keyfunc memilih key verifikasi berdasarkan header `kid` dan memastikan algoritmanya cocok.
+func (m *Manager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key := m.lookup(kid)
+	if key == nil && m.store != nil && m.canReload() {
+		// Key mungkin baru dibuat oleh instance lain
+		if err := m.Reload(); err == nil {
+			key = m.lookup(kid)
+		}
+	}
+	if key == nil {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrAlgMismatch
+	}
+	if !key.ExpiresAt.IsZero() && time.Now().After(key.ExpiresAt) {
+		return nil, ErrUnknownKey
+	}
+	return key.verifyKey, nil
+}
+
+// Parse memverifikasi token memakai Keyfunc.
+func (m *Manager) Parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, m.Keyfunc, jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA}))
+}
+
+func (m *Manager) lookup(kid string) *Key {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keys[kid]
+}
+
+func (m *Manager) canReload() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return time.Since(m.lastReload) >= reloadOnUnknownKeys
+}
+
+// Keys mengembalikan salinan semua key yang dikenal, terurut dari yang terbaru.
+func (m *Manager) Keys() []*Key {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(m.keys))
+	for _, k := range m.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys
+}
+
+// SigningKeyID mengembalikan kid dari key yang sedang dipakai untuk signing.
+func (m *Manager) SigningKeyID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.signing == nil {
+		return ""
+	}
+	return m.signing.ID
+}
+
+func newestKey(keys map[string]*Key, alg string) *Key {
+	var newest *Key
+	for _, k := range keys {
+		if k.Algorithm != alg {
+			continue
+		}
+		if newest == nil || k.CreatedAt.After(newest.CreatedAt) {
+			newest = k
+		}
+	}
+	return newest
+}
+
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case AlgHS256:
+		return jwt.SigningMethodHS256, nil
+	case AlgRS256:
+		return jwt.SigningMethodRS256, nil
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+}
+
+func newKeyID(now time.Time) string {
+	buf := make([]byte, 4)
+	rand.Read(buf)
+	return now.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(buf)
+}
+
+func generateKey(alg string, now time.Time) (StoredKey, error) {
+	sk := StoredKey{ID: newKeyID(now), Algorithm: alg, CreatedAt: now}
+
+	switch alg {
+	case AlgHS256:
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return sk, err
+		}
+		sk.PrivateKey = secret
+	case AlgRS256:
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return sk, err
+		}
+		if sk.PrivateKey, err = x509.MarshalPKCS8PrivateKey(priv); err != nil {
+			return sk, err
+		}
+	case AlgEdDSA:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return sk, err
+		}
+		if sk.PrivateKey, err = x509.MarshalPKCS8PrivateKey(priv); err != nil {
+			return sk, err
+		}
+	default:
+		return sk, fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+	}
+	return sk, nil
+}
+
+func decodeKey(sk StoredKey) (*Key, error) {
+	key := &Key{ID: sk.ID, Algorithm: sk.Algorithm, CreatedAt: sk.CreatedAt, ExpiresAt: sk.ExpiresAt}
+
+	if sk.Algorithm == AlgHS256 {
+		key.signKey, key.verifyKey = sk.PrivateKey, sk.PrivateKey
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(sk.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	switch priv := parsed.(type) {
+	case *rsa.PrivateKey:
+		if sk.Algorithm != AlgRS256 {
+			return nil, ErrAlgMismatch
+		}
+		key.signKey, key.verifyKey = priv, &priv.PublicKey
+	case ed25519.PrivateKey:
+		if sk.Algorithm != AlgEdDSA {
+			return nil, ErrAlgMismatch
+		}
+		key.signKey, key.verifyKey = priv, priv.Public()
+	default:
+		return nil, fmt.Errorf("%w: tipe key %T", ErrUnsupportedAlg, parsed)
+	}
+	return key, nil
+}
+
+// PublicKey mengembalikan public key untuk algoritma asimetris (nil untuk HS256).
+func (k *Key) PublicKey() crypto.PublicKey {
+	if k.Algorithm == AlgHS256 {
+		return nil
+	}
+	return k.verifyKey
+}
@@ -0,0 +1,50 @@
+package keymanager
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore menyimpan key di memori proses. Cocok untuk testing atau satu instance.
+type MemoryStore struct {
+	mu   sync.Mutex
+	keys map[string]StoredKey
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: make(map[string]StoredKey)}
+}
+
+func (s *MemoryStore) LoadKeys() ([]StoredKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	keys := make([]StoredKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		if k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (s *MemoryStore) SaveKey(key StoredKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = key
+	return nil
+}
+
+func (s *MemoryStore) DeleteExpiredKeys() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, k := range s.keys {
+		if !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt) {
+			delete(s.keys, id)
+		}
+	}
+	return nil
+}
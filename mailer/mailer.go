@@ -0,0 +1,147 @@
+// Package mailer mengirim email notifikasi (misal reset password) lewat implementasi yang bisa diganti.
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message adalah email teks sederhana.
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim email. Implementasi: SMTPMailer (production / SMTP sink lokal) dan LogMailer (development).
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// SMTPMailer mengirim email lewat server SMTP. Jika Username kosong, tidak ada AUTH
+// (cocok untuk SMTP sink lokal seperti MailHog/Mailpit).
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("mailer: penerima kosong")
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.Host, m.Port)
+	return smtp.SendMail(addr, auth, m.From, msg.To, BuildMessage(m.From, msg))
+}
+
+// BuildMessage menyusun email RFC 5322 (text/plain UTF-8).
+func BuildMessage(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(msg.To, ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("Message-ID: <" + uuid.NewString() + "@" + domainOf(from) + ">\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+func domainOf(address string) string {
+	address = strings.TrimSuffix(address, ">")
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
+
+// LogMailer hanya menulis email ke log. Dipakai saat SMTP_HOST tidak diisi.
+// Body (yang bisa berisi token reset password) hanya ditulis jika LogBody aktif (MAIL_LOG_BODY=true,
+// khusus development); selain itu yang dicatat hanya penerima, subject dan panjang body.
+type LogMailer struct {
+	LogBody bool
+}
+
+func (m LogMailer) Send(msg Message) error {
+	if m.LogBody {
+		log.Printf("[mailer] To: %s | Subject: %s\n%s", strings.Join(msg.To, ", "), msg.Subject, msg.Body)
+		return nil
+	}
+	log.Printf("[mailer] To: %s | Subject: %s | Body: [disembunyikan, %d byte]", strings.Join(msg.To, ", "), msg.Subject, len(msg.Body))
+	return nil
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultMailer Mailer
+)
+
+// FromEnv membuat Mailer dari SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM.
+// Tanpa SMTP_HOST dipakai LogMailer; MAIL_LOG_BODY=true ikut menulis body ke log.
+func FromEnv() Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		logBody, _ := strconv.ParseBool(os.Getenv("MAIL_LOG_BODY"))
+		return LogMailer{LogBody: logBody}
+	}
+
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil || port <= 0 {
+		port = 25
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	return &SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+	}
+}
+
+func SetDefault(m Mailer) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultMailer = m
+}
+
+// Default mengembalikan Mailer aplikasi (dibuat dari environment saat pertama dipakai).
+func Default() Mailer {
+	defaultMu.RLock()
+	m := defaultMailer
+	defaultMu.RUnlock()
+	if m != nil {
+		return m
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultMailer == nil {
+		defaultMailer = FromEnv()
+	}
+	return defaultMailer
+}
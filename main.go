@@ -3,8 +3,16 @@ package main
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
+	"sistempelaporan/app/repository"
+	"sistempelaporan/app/service"
+	"sistempelaporan/authenticator"
 	"sistempelaporan/database"
+	"sistempelaporan/keymanager"
+	"sistempelaporan/mailer"
+	"sistempelaporan/middleware"
 	"sistempelaporan/route"
     
 	// [TAMBAHAN 1] Import folder docs yang akan di-generate oleh swag
@@ -34,6 +42,11 @@ swagger "github.com/swaggo/fiber-swagger"
 // @in header
 // @name Authorization
 // @description Masukkan token dengan format -> Bearer <token>
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key integrasi (dibuat admin lewat /api-keys)
 // ===============================================
 func main() {
 	// 1. Load Environment Variables
@@ -45,6 +58,45 @@ func main() {
 	database.ConnectPostgres()
 	database.ConnectMongo()
 
+	// 2b. Background job: bersihkan token yang dicabut dan sudah kadaluarsa
+	sweepMinutes, err := strconv.Atoi(os.Getenv("TOKEN_SWEEP_INTERVAL_MINUTES"))
+	if err != nil || sweepMinutes <= 0 {
+		sweepMinutes = 10
+	}
+	stopSweeper := repository.StartRevocationSweeper(time.Duration(sweepMinutes) * time.Minute)
+	defer stopSweeper()
+
+	// 2c. Key manager JWT: signing & verifikasi memakai key yang sama (rotasi + kid)
+	keys, err := keymanager.Init(repository.JWTKeyStore{})
+	if err != nil {
+		log.Fatal("Failed to initialize JWT key manager:", err)
+	}
+	stopKeyRotation := keys.Start(5 * time.Minute)
+	defer stopKeyRotation()
+
+	// 2d. LDAP: akun dengan auth_source = ldap diverifikasi lewat bind ke direktori fakultas
+	if ldapConfig, ok := authenticator.LDAPConfigFromEnv(); ok {
+		authenticator.Register(authenticator.SourceLDAP, authenticator.NewLDAP(ldapConfig))
+
+		syncMinutes, err := strconv.Atoi(os.Getenv("LDAP_SYNC_INTERVAL_MINUTES"))
+		if err != nil || syncMinutes <= 0 {
+			syncMinutes = 60
+		}
+		stopLDAPSync := service.StartLDAPSync(time.Duration(syncMinutes) * time.Minute)
+		defer stopLDAPSync()
+	}
+
+	// 2e. Mailer (SMTP jika SMTP_HOST diisi, selain itu email ditulis ke log)
+	mailer.SetDefault(mailer.FromEnv())
+
+	// 2f. Background job: eskalasi verifikasi prestasi yang melewati SLA ke admin departemen
+	escalationMinutes, err := strconv.Atoi(os.Getenv("VERIFICATION_ESCALATION_INTERVAL_MINUTES"))
+	if err != nil || escalationMinutes <= 0 {
+		escalationMinutes = 60
+	}
+	stopEscalation := service.StartVerificationEscalation(time.Duration(escalationMinutes) * time.Minute)
+	defer stopEscalation()
+
 	// 3. Init Fiber App
 	app := fiber.New(fiber.Config{
 		AppName: "Sistem Pelaporan Prestasi Mahasiswa API",
@@ -62,6 +114,13 @@ func main() {
 	// 5. SETUP ROUTES
 	route.SetupRoutes(app)
 
+	// Daftarkan ke tabel permissions semua permission yang dipakai CheckPermission di route
+	if added, err := repository.EnsurePermissions(middleware.RegisteredPermissions()); err != nil {
+		log.Printf("Gagal sinkronisasi katalog permission: %v", err)
+	} else if added > 0 {
+		log.Printf("%d permission baru didaftarkan dari route", added)
+	}
+
 	// 6. Start Server
 	port := os.Getenv("APP_PORT")
 	if port == "" {
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderAPIKey adalah header untuk autentikasi integrasi mesin
+const HeaderAPIKey = "X-API-Key"
+
+// authenticateAPIKey memverifikasi API key lalu mengisi locals yang sama dengan JWT
+// (permissions untuk CheckPermission) dengan auth_type = api_key.
+func authenticateAPIKey(c *fiber.Ctx, rawKey string) error {
+	key, err := repository.FindActiveAPIKeyByHash(helper.HashAPIKey(rawKey))
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid API key"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": "Gagal memeriksa API key"})
+	}
+
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "API key has expired"})
+	}
+
+	ip := c.IP()
+	if !helper.IPAllowed(ip, key.AllowedIPs) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "API key tidak diizinkan dari alamat IP ini"})
+	}
+
+	if err := repository.TouchAPIKey(key.ID.String(), ip); err != nil {
+		log.Printf("Gagal mencatat pemakaian API key %s: %v", key.Prefix, err)
+	}
+
+	c.Locals("auth_type", model.AuthTypeAPIKey)
+	c.Locals("api_key_id", key.ID.String())
+	c.Locals("user_id", "")
+	c.Locals("role", model.RoleAPIKey)
+	c.Locals("jti", "")
+	c.Locals("sid", "")
+	c.Locals("impersonator_id", "")
+	c.Locals("permissions", key.Permissions)
+
+	return c.Next()
+}
+
+// ProtectedUser sama dengan Protected tetapi hanya menerima JWT pengguna, bukan API key
+// (untuk endpoint akun seperti sesi, password, 2FA, dan pengelolaan API key itu sendiri).
+func ProtectedUser() fiber.Handler {
+	protected := Protected()
+	return func(c *fiber.Ctx) error {
+		if c.Get(HeaderAPIKey) != "" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "Endpoint ini hanya untuk akun pengguna, bukan API key"})
+		}
+		return protected(c)
+	}
+}
+
+// UserOnly menolak API key pada route di dalam group yang sudah memakai Protected(),
+// untuk aksi yang harus tercatat atas nama pengguna (misal menulis komentar).
+func UserOnly() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if authType, _ := c.Locals("auth_type").(string); authType == model.AuthTypeAPIKey {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "Endpoint ini hanya untuk akun pengguna, bukan API key"})
+		}
+		return c.Next()
+	}
+}
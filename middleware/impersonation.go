@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ImpersonationMutationsAllowed membaca kebijakan IMPERSONATION_ALLOW_MUTATIONS (default: diblokir).
+func ImpersonationMutationsAllowed() bool {
+	return strings.EqualFold(os.Getenv("IMPERSONATION_ALLOW_MUTATIONS"), "true")
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// impersonatedRequest dijalankan Protected untuk token impersonation: sesi admin harus masih aktif,
+// kredensial akun (password, 2FA, sesi) tidak boleh diubah, dan setiap mutasi dicatat di audit log.
+func impersonatedRequest(c *fiber.Ctx, impersonatorID, impersonatorSID string) error {
+	// Logout admin (atau pencabutan sesinya) langsung mengakhiri impersonation
+	if impersonatorSID != "" {
+		active, err := repository.IsSessionActive(impersonatorSID)
+		if err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": "Gagal memeriksa status sesi"})
+		}
+		if !active {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Session has been revoked"})
+		}
+	}
+
+	if !isMutatingMethod(c.Method()) {
+		return c.Next()
+	}
+
+	path := c.Path()
+	// Logout selalu boleh; endpoint akun lain (password, 2FA, sesi) tidak pernah boleh diubah oleh admin
+	isLogout := strings.HasSuffix(path, "/auth/logout")
+	if !isLogout && (strings.Contains(path, "/auth/") || !ImpersonationMutationsAllowed()) {
+		recordImpersonationAudit(c, impersonatorID, model.AuditImpersonationBlocked, fiber.StatusForbidden)
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "Perubahan data tidak diizinkan selama impersonation"})
+	}
+
+	err := c.Next()
+
+	status := c.Response().StatusCode()
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		status = fiberErr.Code
+	} else if err != nil {
+		status = fiber.StatusInternalServerError
+	}
+	recordImpersonationAudit(c, impersonatorID, model.AuditImpersonationRequest, status)
+
+	return err
+}
+
+func recordImpersonationAudit(c *fiber.Ctx, impersonatorID, action string, status int) {
+	entry := model.AuditLog{
+		Action:     action,
+		TokenID:    c.Locals("jti").(string),
+		Method:     c.Method(),
+		Path:       c.OriginalURL(),
+		StatusCode: status,
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+	}
+	entry.ImpersonatorID, _ = uuid.Parse(impersonatorID)
+	entry.UserID, _ = uuid.Parse(c.Locals("user_id").(string))
+
+	if err := repository.CreateAuditLog(&entry); err != nil {
+		log.Printf("Gagal mencatat audit impersonation %s %s: %v", entry.Method, entry.Path, err)
+	}
+}
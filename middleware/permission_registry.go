@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"sistempelaporan/app/repository"
+)
+
+// Katalog permission yang dipakai route: terisi otomatis setiap kali CheckPermission dipanggil saat setup route
+var (
+	registryMu            sync.Mutex
+	registeredPermissions = map[string]bool{}
+)
+
+func registerPermission(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registeredPermissions[name] = true
+}
+
+// RegisteredPermissions mengembalikan semua permission yang dipakai CheckPermission (terurut).
+func RegisteredPermissions() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	names := make([]string, 0, len(registeredPermissions))
+	for name := range registeredPermissions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsRegisteredPermission mengecek apakah permission dipakai oleh salah satu route.
+func IsRegisteredPermission(name string) bool {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return registeredPermissions[name]
+}
+
+/* Cache versi permission per role. Token menyimpan versi saat diterbitkan (claim `pv`);
+   jika versi role sudah naik, Protected memakai permission terbaru dari cache ini. */
+
+type rolePermissionEntry struct {
+	version     int
+	permissions []string
+	fetchedAt   time.Time
+}
+
+var (
+	permissionCacheMu sync.Mutex
+	permissionCache   = map[string]rolePermissionEntry{}
+
+	// Sumber versi & permission role (default: database); bisa diganti lewat SetRolePermissionLoader
+	rolePermissionLoader = repository.GetEffectiveRolePermissions
+)
+
+// SetRolePermissionLoader mengganti sumber versi & permission role (misal di test) dan mengosongkan cache.
+func SetRolePermissionLoader(load func(roleID string) (int, []string, error)) {
+	permissionCacheMu.Lock()
+	defer permissionCacheMu.Unlock()
+	rolePermissionLoader = load
+	permissionCache = map[string]rolePermissionEntry{}
+}
+
+func permissionCacheTTL() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("PERMISSION_CACHE_TTL_SECONDS")); err == nil && v >= 0 {
+		return time.Duration(v) * time.Second
+	}
+	return 30 * time.Second
+}
+
+// currentRolePermissions mengembalikan versi dan permission role terkini (di-cache beberapa detik).
+func currentRolePermissions(roleID string) (int, []string, error) {
+	permissionCacheMu.Lock()
+	entry, ok := permissionCache[roleID]
+	load := rolePermissionLoader
+	permissionCacheMu.Unlock()
+	if ok && time.Since(entry.fetchedAt) < permissionCacheTTL() {
+		return entry.version, entry.permissions, nil
+	}
+
+	version, perms, err := load(roleID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	permissionCacheMu.Lock()
+	permissionCache[roleID] = rolePermissionEntry{version: version, permissions: perms, fetchedAt: time.Now()}
+	permissionCacheMu.Unlock()
+	return version, perms, nil
+}
+
+// InvalidatePermissionCache membuang cache role setelah permission-nya diubah.
+// Instance lain mengikuti setelah TTL cache habis.
+func InvalidatePermissionCache(roleID string) {
+	permissionCacheMu.Lock()
+	defer permissionCacheMu.Unlock()
+	if roleID == "" {
+		permissionCache = map[string]rolePermissionEntry{}
+		return
+	}
+	delete(permissionCache, roleID)
+}
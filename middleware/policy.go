@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"strings"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const policySubjectLocal = "policy_subject"
+
+// PolicySubject membangun subject policy dari locals Protected (dimuat sekali per request).
+func PolicySubject(c *fiber.Ctx) (policy.Subject, error) {
+	if cached, ok := c.Locals(policySubjectLocal).(policy.Subject); ok {
+		return cached, nil
+	}
+
+	permissions, _ := c.Locals("permissions").([]string)
+	userID, _ := c.Locals("user_id").(string)
+	role, _ := c.Locals("role").(string)
+	authType, _ := c.Locals("auth_type").(string)
+
+	subject := policy.Subject{
+		UserID:      userID,
+		Permissions: permissions,
+		Admin:       strings.EqualFold(role, model.RoleAdmin),
+		Machine:     authType == model.AuthTypeAPIKey,
+	}
+	if !subject.Machine && userID != "" {
+		if err := repository.LoadPolicySubject(&subject); err != nil {
+			return subject, err
+		}
+	}
+
+	c.Locals(policySubjectLocal, subject)
+	return subject, nil
+}
+
+func policyDenied(c *fiber.Ctx, decision policy.Decision) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"message": "Akses ditolak: " + decision.Reason,
+	})
+}
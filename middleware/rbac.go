@@ -1,19 +1,25 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
 
-	"sistempelaporan/app/repository" // Perlu di-import untuk Blacklist
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/app/repository" // Perlu di-import untuk cek token yang dicabut
+	"sistempelaporan/keymanager"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	
 )
 
-var jwtKey = []byte("rahasia_negara_api")
-
 func Protected() fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		// Integrasi mesin (dashboard, sinkronisasi SIAKAD) memakai API key, bukan JWT user
+		if apiKey := c.Get(HeaderAPIKey); apiKey != "" {
+			return authenticateAPIKey(c, apiKey)
+		}
 		
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -21,28 +27,58 @@ func Protected() fiber.Handler {
 		}
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
+		// Verifikasi memakai key manager yang sama dengan proses signing (dipilih lewat kid)
+		token, err := keymanager.Default().Parse(tokenString)
 
 		if err != nil || !token.Valid {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid or expired token"})
 		}
-		
-		
-		if repository.IsTokenBlacklisted(tokenString) {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Token has been revoked (logged out)"})
-		}
 
-		
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid token claims"})
 		}
 
+		// Hanya access token yang boleh dipakai untuk mengakses API
+		if tokenType, _ := claims["token_type"].(string); tokenType != model.TokenTypeAccess {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Token type not allowed"})
+		}
+
+		// Token tanpa jti tidak bisa dicabut, jadi tidak diterima
+		jti, _ := claims["jti"].(string)
+		if jti == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid token claims"})
+		}
+
+		revoked, err := repository.IsTokenRevoked(jti)
+		if err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": "Gagal memeriksa status token"})
+		}
+		if revoked {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Token has been revoked (logged out)"})
+		}
+
 		
+		c.Locals("auth_type", model.AuthTypeUser)
 		c.Locals("user_id", claims["user_id"].(string)) 
 		c.Locals("role", claims["role"])
+		c.Locals("jti", jti)
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			c.Locals("token_exp", exp.Time)
+		}
+		sid, _ := claims["sid"].(string)
+		c.Locals("sid", sid)
+
+		// Sesi yang sudah dicabut (logout di perangkat lain / oleh admin) langsung mematikan access token-nya
+		if sid != "" {
+			active, err := repository.IsSessionActive(sid)
+			if err != nil {
+				return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": "Gagal memeriksa status sesi"})
+			}
+			if !active {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Session has been revoked"})
+			}
+		}
 		
 		
 		var permissions []string
@@ -51,14 +87,36 @@ func Protected() fiber.Handler {
 				permissions = append(permissions, p.(string))
 			}
 		}
+
+		// Permission role berubah setelah token terbit: pakai permission terbaru tanpa menunggu token kadaluarsa
+		if roleID, _ := claims["role_id"].(string); roleID != "" {
+			tokenVersion, _ := claims["pv"].(float64)
+			version, current, err := currentRolePermissions(roleID)
+			if err != nil {
+				return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": "Gagal memeriksa permission role"})
+			}
+			if version != int(tokenVersion) {
+				permissions = current
+				c.Set("X-Permissions-Stale", "true")
+			}
+		}
 		c.Locals("permissions", permissions)
 
+		// Token impersonation: user_id adalah user yang ditiru, impersonator_id adalah admin-nya
+		impersonatorID, _ := claims["impersonator_id"].(string)
+		c.Locals("impersonator_id", impersonatorID)
+		if impersonatorID != "" {
+			impersonatorSID, _ := claims["impersonator_sid"].(string)
+			return impersonatedRequest(c, impersonatorID, impersonatorSID)
+		}
+
 		return c.Next()
 	}
 }
 
 
 func CheckPermission(requiredPerm string) fiber.Handler {
+	registerPermission(requiredPerm)
 	return func(c *fiber.Ctx) error {
 		userPerms, ok := c.Locals("permissions").([]string)
 		if !ok {
@@ -82,82 +140,88 @@ func CheckPermission(requiredPerm string) fiber.Handler {
 		return c.Next()
 	}
 }
+
+// CheckScopedPermission seperti CheckPermission, tetapi juga menerima permission yang hanya dimiliki
+// lewat role assignment berscope. Batas unitnya ditegakkan policy di AuthorizeResource / handler,
+// jadi hanya dipakai pada route data akademik yang sudah memeriksa resource atau memakai ListScope.
+func CheckScopedPermission(requiredPerm string) fiber.Handler {
+	registerPermission(requiredPerm)
+	return func(c *fiber.Ctx) error {
+		subject, err := PolicySubject(c)
+		if err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": "Gagal memeriksa hak akses"})
+		}
+		if !subject.HasScopedPermission(requiredPerm) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "Forbidden: You don't have permission " + requiredPerm,
+			})
+		}
+		return c.Next()
+	}
+}
+
+// CanAccessSelf mengizinkan akses ke /:id jika id adalah akun user itu sendiri
+// atau profil dosen miliknya (admin selalu diizinkan). API key tidak pernah lolos untuk akun user;
+// membaca profil dosen membutuhkan permission lecturer:read.
 func CanAccessSelf() fiber.Handler {
-    return func(c *fiber.Ctx) error {
-        requestedID := c.Params("id") 
-        currentUserID := c.Locals("user_id").(string) 
-        role := c.Locals("role").(string)
-
-        if strings.EqualFold(role, "Admin") {
-            return c.Next()
-        }
-
-       
-        if requestedID == currentUserID {
-            return c.Next()
-        }
-
-       
-        if strings.EqualFold(role, "Dosen Wali") {
-            
-            lecturerID, err := repository.GetLecturerIDByUserID(currentUserID)
-            
-            
-            if err == nil && requestedID == lecturerID {
-                return c.Next()
-            }
-        }
-        
-        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-            "message": "Akses ditolak: Anda hanya diperbolehkan mengakses data milik Anda sendiri",
-        })
-    }
+	registerPermission(policy.LecturerReadPermission)
+	return func(c *fiber.Ctx) error {
+		subject, err := PolicySubject(c)
+		if err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": "Gagal memeriksa hak akses"})
+		}
+
+		requestedID := c.Params("id")
+		action := policy.ActionRead
+		if c.Method() != fiber.MethodGet {
+			action = policy.ActionUpdate
+		}
+
+		decision := policy.Can(subject, action, policy.Resource{Kind: policy.ResourceUser, UserID: requestedID})
+		if !decision.Allowed && action == policy.ActionRead {
+			if lecturer, err := repository.LecturerPolicyResource(requestedID); err == nil {
+				decision = policy.Can(subject, policy.ActionRead, lecturer)
+			}
+		}
+		if !decision.Allowed {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "Akses ditolak: Anda hanya diperbolehkan mengakses data milik Anda sendiri",
+			})
+		}
+		return c.Next()
+	}
 }
 
+// AuthorizeResource memeriksa akses baca ke resource /:id, yang bisa berupa ID prestasi atau ID mahasiswa.
+// Aksi yang lebih spesifik (verify, reject, update, ...) diperiksa lagi oleh handler.
+// API key membutuhkan permission student:read untuk data mahasiswa.
 func AuthorizeResource(mode string) fiber.Handler {
-    return func(c *fiber.Ctx) error {
-        resourceID := c.Params("id")
-        currentUserID := c.Locals("user_id").(string)
-        role := c.Locals("role").(string)
-
-        if strings.EqualFold(role, "Admin") { return c.Next() }
-
-        var targetStudentID string
-        ach, err := repository.FindAchievementByID(resourceID)
-        if err == nil && ach != nil {
-            targetStudentID = ach.StudentID.String()
-        } else {
-            targetStudentID = resourceID
-        }
-
-        if mode == "student_read" {
-          
-            if strings.EqualFold(role, "Dosen Wali") {
-                
-                lecturerID, err := repository.GetLecturerIDByUserID(currentUserID)
-                if err != nil {
-                    return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "Akses ditolak: Data dosen tidak ditemukan"})
-                }
-
-                student, err := repository.GetStudentDetail(targetStudentID)
-                if err == nil && student != nil {
-                 
-                    if repository.ExtractAdvisorID(student) == lecturerID {
-                        return c.Next()
-                    }
-                }
-            }
-            
-            if strings.EqualFold(role, "Mahasiswa") {
-                mhsID, _ := repository.GetStudentIDByUserID(currentUserID)
-                if mhsID == targetStudentID {
-                    return c.Next()
-                }
-            }
-        }
-
-        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-            "message": "Akses ditolak: Anda tidak memiliki hak untuk mengakses resource ini",
-        })
-    }
-}
\ No newline at end of file
+	registerPermission(policy.StudentReadPermission)
+	return func(c *fiber.Ctx) error {
+		subject, err := PolicySubject(c)
+		if err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": "Gagal memeriksa hak akses"})
+		}
+
+		resourceID := c.Params("id")
+		resource, err := repository.AchievementPolicyResource(resourceID)
+		if errors.Is(err, repository.ErrResourceNotFound) {
+			resource, err = repository.StudentPolicyResource(resourceID)
+		}
+		if err != nil && !errors.Is(err, repository.ErrResourceNotFound) {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": "Gagal memeriksa hak akses"})
+		}
+		if errors.Is(err, repository.ErrResourceNotFound) {
+			// Tanpa resource (misal daftar mahasiswa) hanya admin / API key dengan student:read yang bisa lolos
+			resource = policy.Resource{Kind: policy.ResourceStudent}
+		}
+
+		if mode != "student_read" {
+			return policyDenied(c, policy.Decision{Reason: "mode otorisasi tidak dikenal"})
+		}
+		if decision := policy.Can(subject, policy.ActionRead, resource); !decision.Allowed {
+			return policyDenied(c, decision)
+		}
+		return c.Next()
+	}
+}
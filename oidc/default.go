@@ -0,0 +1,48 @@
+package oidc
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	defaultMu       sync.Mutex
+	defaultProvider *Provider
+)
+
+// ConfigFromEnv membaca OIDC_ISSUER, OIDC_CLIENT_ID, OIDC_CLIENT_SECRET, OIDC_REDIRECT_URL dan OIDC_SCOPES.
+func ConfigFromEnv() Config {
+	var scopes []string
+	if s := os.Getenv("OIDC_SCOPES"); s != "" {
+		scopes = strings.Fields(strings.ReplaceAll(s, ",", " "))
+	}
+	return Config{
+		Issuer:       os.Getenv("OIDC_ISSUER"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       scopes,
+	}
+}
+
+func SetDefault(p *Provider) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultProvider = p
+}
+
+// Default mengembalikan provider SSO aplikasi, atau ErrNotConfigured jika OIDC_ISSUER / OIDC_CLIENT_ID kosong.
+func Default() (*Provider, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultProvider == nil {
+		cfg := ConfigFromEnv()
+		if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			return nil, ErrNotConfigured
+		}
+		defaultProvider = New(cfg, nil)
+	}
+	return defaultProvider, nil
+}
@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RoleMappingRule memetakan nilai claim ID token ke role aplikasi.
+// Claim boleh berupa path bertitik (misal "realm_access.roles"); Value "*" berarti claim cukup ada.
+type RoleMappingRule struct {
+	Claim    string `json:"claim"`
+	Value    string `json:"value"`
+	Role     string `json:"role"`
+	Priority int    `json:"priority"` // angka kecil dievaluasi lebih dulu
+}
+
+// MatchRole mengembalikan role dari aturan pertama (berdasarkan prioritas) yang cocok dengan claims.
+func MatchRole(rules []RoleMappingRule, claims map[string]interface{}) (string, bool) {
+	sorted := make([]RoleMappingRule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Priority < sorted[j].Priority })
+
+	for _, rule := range sorted {
+		value, ok := lookupClaim(claims, rule.Claim)
+		if !ok {
+			continue
+		}
+		if rule.Value == "*" || claimContains(value, rule.Value) {
+			return rule.Role, true
+		}
+	}
+	return "", false
+}
+
+// ClaimString mengambil claim (path bertitik) sebagai string, misal NIM dari claim "nim".
+func ClaimString(claims map[string]interface{}, path string) string {
+	value, ok := lookupClaim(claims, path)
+	if !ok || value == nil {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	}
+	return ""
+}
+
+func lookupClaim(claims map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// claimContains membandingkan nilai claim (string, angka, bool, atau array) dengan nilai aturan.
+func claimContains(value interface{}, want string) bool {
+	switch v := value.(type) {
+	case string:
+		return strings.EqualFold(v, want)
+	case []interface{}:
+		for _, item := range v {
+			if claimContains(item, want) {
+				return true
+			}
+		}
+	case bool:
+		return strings.EqualFold(fmt.Sprint(v), want)
+	case float64:
+		return fmt.Sprintf("%.0f", v) == want || fmt.Sprint(v) == want
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+// Package oidc adalah client OpenID Connect minimal (authorization code + PKCE) untuk SSO kampus:
+// discovery, pertukaran code, dan verifikasi ID token terhadap JWKS provider.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNotConfigured = errors.New("oidc: provider belum dikonfigurasi")
+	ErrInvalidToken  = errors.New("oidc: id token tidak valid")
+)
+
+// Config adalah konfigurasi client OIDC.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Metadata adalah bagian dari dokumen discovery (/.well-known/openid-configuration) yang dipakai.
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// TokenResponse adalah respon token endpoint.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Claims adalah isi ID token yang sudah diverifikasi.
+type Claims struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     *bool // nil jika provider tidak mengirim claim email_verified
+	Name              string
+	PreferredUsername string
+	Raw               map[string]interface{}
+}
+
+// EmailTrusted bernilai true hanya jika provider menyatakan email terverifikasi (email_verified = true).
+// Claim yang tidak dikirim dianggap belum terverifikasi.
+func (c *Claims) EmailTrusted() bool {
+	return c.Email != "" && c.EmailVerified != nil && *c.EmailVerified
+}
+
+// Provider adalah client untuk satu OIDC provider. Discovery dan JWKS di-cache.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *Metadata
+	keys     map[string]interface{}
+	keysAt   time.Time
+}
+
+func New(cfg Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &Provider{cfg: cfg, client: client}
+}
+
+func (p *Provider) Config() Config { return p.cfg }
+
+// Discover mengambil (dan meng-cache) metadata provider.
+func (p *Provider) Discover(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var md Metadata
+	if err := p.getJSON(ctx, p.cfg.Issuer+"/.well-known/openid-configuration", &md); err != nil {
+		return nil, fmt.Errorf("oidc: discovery gagal: %w", err)
+	}
+	if strings.TrimSuffix(md.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: issuer discovery %q tidak sama dengan %q", md.Issuer, p.cfg.Issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, errors.New("oidc: dokumen discovery tidak lengkap")
+	}
+	p.metadata = &md
+	return p.metadata, nil
+}
+
+// AuthCodeURL membuat URL authorize dengan state, nonce dan PKCE S256.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	md, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(md.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return md.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange menukar authorization code (beserta code_verifier PKCE) dengan token.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*TokenResponse, error) {
+	md, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: token endpoint mengembalikan %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tok TokenResponse
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return nil, err
+	}
+	if tok.IDToken == "" {
+		return nil, errors.New("oidc: respon token tidak berisi id_token")
+	}
+	return &tok, nil
+}
+
+// VerifyIDToken memverifikasi tanda tangan (JWKS), issuer, audience, masa berlaku dan nonce ID token.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	if _, err := p.Discover(ctx); err != nil {
+		return nil, err
+	}
+
+	mapClaims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, mapClaims,
+		func(t *jwt.Token) (interface{}, error) {
+			kid, _ := t.Header["kid"].(string)
+			return p.publicKey(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	// Jika ada beberapa audience, azp harus client ini
+	if aud, _ := mapClaims.GetAudience(); len(aud) > 1 {
+		if azp, _ := mapClaims["azp"].(string); azp != p.cfg.ClientID {
+			return nil, fmt.Errorf("%w: azp tidak sesuai", ErrInvalidToken)
+		}
+	}
+	if got, _ := mapClaims["nonce"].(string); got == "" || got != nonce {
+		return nil, fmt.Errorf("%w: nonce tidak sesuai", ErrInvalidToken)
+	}
+
+	claims := &Claims{Raw: map[string]interface{}(mapClaims)}
+	claims.Issuer, _ = mapClaims["iss"].(string)
+	claims.Subject, _ = mapClaims["sub"].(string)
+	claims.Email, _ = mapClaims["email"].(string)
+	claims.Name, _ = mapClaims["name"].(string)
+	claims.PreferredUsername, _ = mapClaims["preferred_username"].(string)
+	if v, ok := mapClaims["email_verified"].(bool); ok {
+		claims.EmailVerified = &v
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: claim sub kosong", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
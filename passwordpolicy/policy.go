@@ -0,0 +1,170 @@
+// Package passwordpolicy memvalidasi password baru terhadap kebijakan yang bisa diatur per role:
+// panjang minimal, kelas karakter, riwayat password, daftar password umum, dan data pribadi user.
+package passwordpolicy
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxLength adalah batas bcrypt (72 byte); sisa password di atas batas ini akan diabaikan bcrypt.
+const MaxLength = 72
+
+// Policy adalah kebijakan password untuk satu role.
+type Policy struct {
+	MinLength          int  `json:"min_length"`
+	RequireUppercase   bool `json:"require_uppercase"`
+	RequireLowercase   bool `json:"require_lowercase"`
+	RequireDigit       bool `json:"require_digit"`
+	RequireSymbol      bool `json:"require_symbol"`
+	HistoryCount       int  `json:"history_count"`        // jumlah password terakhir yang tidak boleh dipakai ulang
+	RejectCommon       bool `json:"reject_common"`        // tolak password dari daftar password umum
+	RejectPersonalInfo bool `json:"reject_personal_info"` // tolak password yang memuat username / NIM
+}
+
+// Default dipakai untuk role yang belum punya kebijakan sendiri.
+func Default() Policy {
+	return Policy{
+		MinLength:          8,
+		RequireLowercase:   true,
+		RequireDigit:       true,
+		HistoryCount:       3,
+		RejectCommon:       true,
+		RejectPersonalInfo: true,
+	}
+}
+
+// Violation menjelaskan satu aturan yang dilanggar. Dikirim ke client di field errors.
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Kode pelanggaran
+const (
+	CodeTooShort     = "too_short"
+	CodeTooLong      = "too_long"
+	CodeUppercase    = "missing_uppercase"
+	CodeLowercase    = "missing_lowercase"
+	CodeDigit        = "missing_digit"
+	CodeSymbol       = "missing_symbol"
+	CodeCommon       = "common_password"
+	CodePersonalInfo = "contains_personal_info"
+	CodeReused       = "reused_password"
+)
+
+// Candidate adalah password baru beserta konteks user yang diperlukan untuk validasi.
+type Candidate struct {
+	Password       string
+	PersonalInfo   []string // username, NIM, dsb.
+	PreviousHashes []string // hash bcrypt password saat ini + riwayat, terbaru lebih dulu
+}
+
+// Validate mengembalikan semua aturan yang dilanggar (kosong berarti password diterima).
+func (p Policy) Validate(c Candidate) []Violation {
+	var violations []Violation
+	add := func(code, format string, args ...interface{}) {
+		violations = append(violations, Violation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n := utf8.RuneCountInString(c.Password); n < p.MinLength {
+		add(CodeTooShort, "Password minimal %d karakter", p.MinLength)
+	}
+	if len(c.Password) > MaxLength {
+		add(CodeTooLong, "Password maksimal %d byte", MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range c.Password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUppercase && !hasUpper {
+		add(CodeUppercase, "Password harus memuat huruf besar")
+	}
+	if p.RequireLowercase && !hasLower {
+		add(CodeLowercase, "Password harus memuat huruf kecil")
+	}
+	if p.RequireDigit && !hasDigit {
+		add(CodeDigit, "Password harus memuat angka")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		add(CodeSymbol, "Password harus memuat simbol")
+	}
+
+	if p.RejectCommon && IsCommon(c.Password) {
+		add(CodeCommon, "Password terlalu umum dan mudah ditebak")
+	}
+	if p.RejectPersonalInfo && containsPersonalInfo(c.Password, c.PersonalInfo) {
+		add(CodePersonalInfo, "Password tidak boleh memuat username atau NIM")
+	}
+
+	// Dicek terakhir karena bcrypt mahal; tidak perlu jika password sudah ditolak
+	if len(violations) == 0 && p.HistoryCount > 0 && matchesAny(c.Password, c.PreviousHashes, p.HistoryCount) {
+		add(CodeReused, "Password tidak boleh sama dengan %d password terakhir", p.HistoryCount)
+	}
+	return violations
+}
+
+// containsPersonalInfo mengecek (case-insensitive) apakah password memuat data pribadi.
+// Nilai yang terlalu pendek (< 3 karakter) diabaikan agar tidak menolak password secara acak.
+func containsPersonalInfo(password string, info []string) bool {
+	lower := strings.ToLower(password)
+	for _, v := range info {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if utf8.RuneCountInString(v) >= 3 && strings.Contains(lower, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(password string, hashes []string, limit int) bool {
+	if len(hashes) > limit {
+		hashes = hashes[:limit]
+	}
+	for _, h := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(h), []byte(password)) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var commonPasswords = loadCommonPasswords(commonPasswordsFile)
+
+func loadCommonPasswords(data string) map[string]struct{} {
+	set := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = struct{}{}
+	}
+	return set
+}
+
+// IsCommon mengecek password terhadap daftar password umum yang dibundel (tanpa akses jaringan).
+func IsCommon(password string) bool {
+	_, ok := commonPasswords[strings.ToLower(password)]
+	return ok
+}
@@ -10,13 +10,23 @@ import (
 
 func AcademicRoutes(router fiber.Router) {
 	
-    readAllAccess := middleware.CheckPermission("user:read_all") 
+    // Operator fakultas / departemen / prodi mendapat permission ini lewat role assignment berscope;
+    // daftar dan detail dibatasi ke unitnya oleh policy.
+    readAllAccess := middleware.CheckScopedPermission("user:read_all") 
 	students := router.Group("/students", middleware.Protected())
-	students.Get("/", middleware.AuthorizeResource("student_read"), service.GetStudents)
+	students.Get("/", service.GetStudents)
 	students.Get("/:id", middleware.AuthorizeResource("student_read"), service.GetStudentByID)
 	students.Get("/:id/achievements", middleware.AuthorizeResource("student_read"), service.GetStudentAchievements)
-	students.Put("/:id/advisor", middleware.CheckPermission("user:manage"), service.UpdateStudentAdvisor)
+	students.Put("/:id/advisor", middleware.CheckScopedPermission("user:manage"), service.UpdateStudentAdvisor)
 	lecturers := router.Group("/lecturers", middleware.Protected())
 	lecturers.Get("/", readAllAccess, service.GetLecturers)
 	lecturers.Get("/:id/advisees", middleware.CanAccessSelf(), service.GetLecturerAdvisees)
-}
\ No newline at end of file
+}
+// AdvisorDelegationRoutes: delegasi verifikasi dosen wali ke dosen pengganti. Batas dosen yang boleh
+// dikelola (milik sendiri / dosen di unit / semua) diperiksa handler lewat policy.
+func AdvisorDelegationRoutes(router fiber.Router) {
+	delegations := router.Group("/advisor-delegations", middleware.ProtectedUser(), middleware.CheckScopedPermission("delegation:manage"))
+	delegations.Get("/", service.GetAdvisorDelegations)
+	delegations.Post("/", service.CreateAdvisorDelegation)
+	delegations.Delete("/:id", service.RevokeAdvisorDelegation)
+}
@@ -8,16 +8,73 @@ import (
 )
 
 func AchievementRoutes(r fiber.Router) {
-    // Group ini sudah diproteksi oleh JWT
+    // Group ini sudah diproteksi oleh JWT. Permission boleh berasal dari role assignment berscope;
+    // batas unitnya diperiksa AuthorizeResource dan handler lewat policy.
     ach := r.Group("/achievements", middleware.Protected())
-    ach.Get("/", middleware.CheckPermission("achievement:read"), service.GetListAchievements)
-    ach.Get("/:id", middleware.CheckPermission("achievement:read"), middleware.AuthorizeResource("student_read"), service.GetAchievementDetail)
-    ach.Post("/", middleware.CheckPermission("achievement:create"), service.SubmitAchievement)
-    ach.Put("/:id", middleware.CheckPermission("achievement:update"), middleware.AuthorizeResource("student_read"), service.UpdateAchievement)
-    ach.Delete("/:id", middleware.CheckPermission("achievement:delete"),middleware.AuthorizeResource("student_read"), service.DeleteAchievement)
-    ach.Post("/:id/submit", middleware.CheckPermission("achievement:submit"), middleware.AuthorizeResource("student_read"), service.RequestVerification)
-    ach.Post("/:id/verify", middleware.CheckPermission("achievement:verify"), middleware.AuthorizeResource("student_read"), service.VerifyAchievement)
-    ach.Post("/:id/reject", middleware.CheckPermission("achievement:reject"), middleware.AuthorizeResource("student_read"), service.RejectAchievement)
-    ach.Post("/:id/attachments", middleware.CheckPermission("achievement:upload"), middleware.AuthorizeResource("student_read"), service.UploadAttachment)
-    ach.Get("/:id/history", middleware.CheckPermission("achievement:read"), middleware.AuthorizeResource("student_read"), service.GetHistory)
-}
\ No newline at end of file
+    ach.Get("/", middleware.CheckScopedPermission("achievement:read"), service.GetListAchievements)
+    ach.Get("/:id", middleware.CheckScopedPermission("achievement:read"), middleware.AuthorizeResource("student_read"), service.GetAchievementDetail)
+    ach.Post("/", middleware.CheckScopedPermission("achievement:create"), service.SubmitAchievement)
+    // Aksi massal didaftarkan sebelum route /:id agar "bulk" tidak terbaca sebagai ID; otorisasi per item di handler
+    ach.Post("/bulk/verify", middleware.CheckScopedPermission("achievement:verify"), service.BulkVerifyAchievements)
+    ach.Post("/bulk/reject", middleware.CheckScopedPermission("achievement:reject"), service.BulkRejectAchievements)
+    ach.Put("/:id", middleware.CheckScopedPermission("achievement:update"), middleware.AuthorizeResource("student_read"), service.UpdateAchievement)
+    ach.Delete("/:id", middleware.CheckScopedPermission("achievement:delete"),middleware.AuthorizeResource("student_read"), service.DeleteAchievement)
+    ach.Post("/:id/submit", middleware.CheckScopedPermission("achievement:submit"), middleware.AuthorizeResource("student_read"), service.RequestVerification)
+    ach.Post("/:id/verify", middleware.CheckScopedPermission("achievement:verify"), middleware.AuthorizeResource("student_read"), service.VerifyAchievement)
+    ach.Post("/:id/reject", middleware.CheckScopedPermission("achievement:reject"), middleware.AuthorizeResource("student_read"), service.RejectAchievement)
+    ach.Post("/:id/request-revision", middleware.CheckScopedPermission("achievement:verify"), middleware.AuthorizeResource("student_read"), service.RequestRevision)
+    ach.Post("/:id/withdraw", middleware.CheckScopedPermission("achievement:submit"), middleware.AuthorizeResource("student_read"), service.WithdrawAchievement)
+    ach.Post("/:id/reopen", middleware.CheckScopedPermission("achievement:submit"), middleware.AuthorizeResource("student_read"), service.ReopenAchievement)
+    ach.Post("/:id/archive", middleware.CheckScopedPermission("achievement:archive"), middleware.AuthorizeResource("student_read"), service.ArchiveAchievement)
+    ach.Post("/:id/attachments", middleware.CheckScopedPermission("achievement:upload"), middleware.AuthorizeResource("student_read"), service.UploadAttachment)
+    ach.Get("/:id/history", middleware.CheckScopedPermission("achievement:read"), middleware.AuthorizeResource("student_read"), service.GetHistory)
+    ach.Get("/:id/revisions", middleware.CheckScopedPermission("achievement:read"), middleware.AuthorizeResource("student_read"), service.GetAchievementRevisions)
+    ach.Get("/:id/revisions/:rev/diff", middleware.CheckScopedPermission("achievement:read"), middleware.AuthorizeResource("student_read"), service.GetAchievementRevisionDiff)
+    ach.Get("/:id/points", middleware.CheckScopedPermission("achievement:read"), middleware.AuthorizeResource("student_read"), service.GetAchievementPoints)
+    ach.Get("/:id/verification", middleware.CheckScopedPermission("achievement:read"), middleware.AuthorizeResource("student_read"), service.GetVerificationProgress)
+
+    // Komentar review: siapa pun yang boleh membaca prestasi; catatan internal disaring di handler.
+    // Menulis dan resolve thread hanya untuk akun pengguna agar setiap komentar punya penulis.
+    ach.Get("/:id/comments", middleware.CheckScopedPermission("achievement:read"), middleware.AuthorizeResource("student_read"), service.GetAchievementComments)
+    ach.Post("/:id/comments", middleware.UserOnly(), middleware.CheckScopedPermission("achievement:read"), middleware.AuthorizeResource("student_read"), service.CreateAchievementComment)
+    ach.Post("/:id/comments/:commentId/resolve", middleware.UserOnly(), middleware.CheckScopedPermission("achievement:read"), middleware.AuthorizeResource("student_read"), service.ResolveAchievementComment)
+    ach.Post("/:id/comments/:commentId/unresolve", middleware.UserOnly(), middleware.CheckScopedPermission("achievement:read"), middleware.AuthorizeResource("student_read"), service.UnresolveAchievementComment)
+}
+
+// VerificationPipelineRoutes: konfigurasi pipeline verifikasi bertingkat (khusus admin sistem).
+func VerificationPipelineRoutes(r fiber.Router) {
+	pipelines := r.Group("/verification-pipelines", middleware.ProtectedUser(), middleware.CheckPermission("verification:manage"))
+	pipelines.Get("/", service.GetVerificationPipelines)
+	pipelines.Post("/", service.CreateVerificationPipeline)
+	pipelines.Get("/:id", service.GetVerificationPipeline)
+	pipelines.Put("/:id", service.UpdateVerificationPipeline)
+	pipelines.Delete("/:id", service.DeleteVerificationPipeline)
+}
+// VerificationQueueRoutes: antrean prestasi yang menunggu persetujuan reviewer (scope & tahap disaring di handler).
+// Hanya untuk user login: antrean bersifat pribadi per reviewer, API key tidak punya antrean.
+func VerificationQueueRoutes(r fiber.Router) {
+	verification := r.Group("/verification", middleware.ProtectedUser())
+	verification.Get("/queue", middleware.CheckScopedPermission("achievement:verify"), service.GetVerificationQueue)
+}
+// PointsRuleRoutes: aturan poin prestasi dan hitung ulang poin (khusus admin sistem).
+func PointsRuleRoutes(r fiber.Router) {
+	rules := r.Group("/points-rules", middleware.ProtectedUser(), middleware.CheckPermission("points:manage"))
+	rules.Get("/", service.GetPointsRules)
+	rules.Post("/", service.CreatePointsRule)
+	rules.Post("/recalculate", service.RecalculatePoints)
+	rules.Put("/:id", service.UpdatePointsRule)
+	rules.Delete("/:id", service.DeletePointsRule)
+}
+
+// AchievementTypeRoutes: definisi tipe prestasi. Semua pengguna boleh membaca schema-nya,
+// perubahan khusus pengelola tipe prestasi.
+func AchievementTypeRoutes(r fiber.Router) {
+	manage := middleware.CheckPermission("achievement_type:manage")
+
+	types := r.Group("/achievement-types")
+	types.Get("/", middleware.Protected(), service.GetAchievementTypes)
+	types.Get("/:code", middleware.Protected(), service.GetAchievementType)
+	types.Post("/", middleware.ProtectedUser(), manage, service.CreateAchievementType)
+	types.Put("/:code", middleware.ProtectedUser(), manage, service.UpdateAchievementType)
+	types.Delete("/:code", middleware.ProtectedUser(), manage, service.DeleteAchievementType)
+}
@@ -0,0 +1,18 @@
+package route
+
+import (
+	"sistempelaporan/app/service"
+	"sistempelaporan/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func APIKeyRoutes(r fiber.Router) {
+	// API key tidak boleh dipakai untuk membuat / mengubah API key lain
+	keys := r.Group("/api-keys", middleware.ProtectedUser(), middleware.CheckPermission("user:manage"))
+	keys.Get("/", service.GetAPIKeys)
+	keys.Post("/", service.CreateAPIKey)
+	keys.Get("/:id", service.GetAPIKeyByID)
+	keys.Put("/:id", service.UpdateAPIKey)
+	keys.Delete("/:id", service.RevokeAPIKey)
+}
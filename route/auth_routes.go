@@ -10,7 +10,30 @@ import (
 func AuthRoutes(r fiber.Router) {
 
 	auth := r.Group("/auth")
+	auth.Post("/login", service.Login)
 	auth.Post("/refresh", service.RefreshToken)
-	auth.Post("/logout", middleware.Protected(), service.Logout)
-	auth.Get("/profile", middleware.Protected(), service.GetProfile)
+	auth.Post("/logout", middleware.ProtectedUser(), service.Logout)
+	auth.Get("/profile", middleware.ProtectedUser(), service.GetProfile)
+	auth.Get("/sessions", middleware.ProtectedUser(), service.GetMySessions)
+	auth.Delete("/sessions", middleware.ProtectedUser(), service.RevokeAllMySessions)
+	auth.Delete("/sessions/:id", middleware.ProtectedUser(), service.RevokeMySession)
+
+	sso := auth.Group("/oidc")
+	sso.Get("/login", service.OIDCLogin)
+	sso.Get("/callback", service.OIDCCallback)
+	sso.Post("/link", middleware.ProtectedUser(), service.LinkOIDCAccount)
+
+	password := auth.Group("/password")
+	password.Post("/change", middleware.ProtectedUser(), service.ChangePassword)
+	password.Post("/forgot", service.ForgotPassword)
+	password.Post("/reset", service.ResetPassword)
+
+	twoFactor := auth.Group("/2fa")
+	twoFactor.Post("/verify", service.VerifyTwoFactorLogin)
+	twoFactor.Post("/enroll/setup", service.EnrollSetupTwoFactor)
+	twoFactor.Post("/enroll/enable", service.EnrollEnableTwoFactor)
+	twoFactor.Post("/setup", middleware.ProtectedUser(), service.SetupTwoFactor)
+	twoFactor.Post("/enable", middleware.ProtectedUser(), service.EnableTwoFactor)
+	twoFactor.Post("/disable", middleware.ProtectedUser(), service.DisableTwoFactor)
+	twoFactor.Post("/recovery-codes", middleware.ProtectedUser(), service.RegenerateRecoveryCodes)
 }
\ No newline at end of file
@@ -11,10 +11,10 @@ func ReportRoutes(router fiber.Router) {
     reports := router.Group("/reports", middleware.Protected())
 
     reports.Get("/statistics", 
-        middleware.CheckPermission("achievement:read"), 
+        middleware.CheckScopedPermission("achievement:read"), 
         service.GetGeneralStatistics)
     reports.Get("/student/:id", 
-        middleware.CheckPermission("achievement:read"),
+        middleware.CheckScopedPermission("achievement:read"),
         middleware.AuthorizeResource("student_read"), 
         service.GetStudentReport)
 }
\ No newline at end of file
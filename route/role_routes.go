@@ -0,0 +1,27 @@
+package route
+
+import (
+	"sistempelaporan/app/service"
+	"sistempelaporan/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RoleRoutes(r fiber.Router) {
+	roleAccess := middleware.CheckPermission("role:manage")
+
+	roles := r.Group("/roles", middleware.ProtectedUser(), roleAccess)
+	roles.Get("/", service.GetRoles)
+	roles.Post("/", service.CreateRole)
+	roles.Get("/:id", service.GetRoleByID)
+	roles.Put("/:id", service.UpdateRole)
+	roles.Delete("/:id", service.DeleteRole)
+	roles.Post("/:id/permissions", service.AttachRolePermissions)
+	roles.Delete("/:id/permissions/:permissionId", service.DetachRolePermission)
+
+	permissions := r.Group("/permissions", middleware.ProtectedUser(), roleAccess)
+	permissions.Get("/", service.GetPermissions)
+	permissions.Post("/", service.CreatePermission)
+	permissions.Put("/:id", service.UpdatePermission)
+	permissions.Delete("/:id", service.DeletePermission)
+}
@@ -1,6 +1,8 @@
 package route
 
 import (
+	"sistempelaporan/app/service"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -12,6 +14,9 @@ func SetupRoutes(app *fiber.App) {
 	app.Use(logger.New())
 
 
+	// Public key untuk verifikasi JWT oleh layanan lain
+	app.Get("/.well-known/jwks.json", service.GetJWKS)
+
 	api := app.Group("/api/v1")
 
 	AuthRoutes(api)        
@@ -19,4 +24,13 @@ func SetupRoutes(app *fiber.App) {
 	AcademicRoutes(api)
 	ReportRoutes(api)
 	UsersRoutes(api)
+	PasswordPolicyRoutes(api)
+	APIKeyRoutes(api)
+	AuditLogRoutes(api)
+	RoleRoutes(api)
+	VerificationPipelineRoutes(api)
+	VerificationQueueRoutes(api)
+	AdvisorDelegationRoutes(api)
+	PointsRuleRoutes(api)
+	AchievementTypeRoutes(api)
 }
@@ -12,10 +12,30 @@ func UsersRoutes(r fiber.Router) {
 	users := r.Group("/users", middleware.Protected()) 
 	adminAccess := middleware.CheckPermission("user:manage")
 	selfAccess := middleware.CanAccessSelf() 
+	users.Post("/ldap/sync", adminAccess, service.TriggerLDAPSync)
 	users.Get("/", adminAccess, service.GetAllUsers) 
 	users.Get("/:id", selfAccess, service.GetUserByID) 
 	users.Post("/", adminAccess, service.CreateNewUser) 
 	users.Put("/:id", selfAccess, service.UpdateUser) 
 	users.Delete("/:id", adminAccess, service.DeleteUser) 
 	users.Put("/:id/role", adminAccess, service.UpdateUserRole) 
-}
\ No newline at end of file
+	users.Post("/:id/unlock", adminAccess, service.UnlockUser)
+	users.Get("/:id/sessions", adminAccess, service.GetUserSessions)
+	users.Delete("/:id/sessions", adminAccess, service.RevokeAllUserSessions)
+	users.Delete("/:id/sessions/:sessionId", adminAccess, service.RevokeUserSession)
+	users.Get("/:id/role-assignments", adminAccess, service.GetUserRoleAssignments)
+	users.Post("/:id/role-assignments", adminAccess, service.CreateUserRoleAssignment)
+	users.Delete("/:id/role-assignments/:assignmentId", adminAccess, service.DeleteUserRoleAssignment)
+	users.Post("/:id/impersonate", middleware.CheckPermission("user:impersonate"), service.ImpersonateUser)
+}
+
+func AuditLogRoutes(r fiber.Router) {
+	r.Get("/audit-logs", middleware.ProtectedUser(), middleware.CheckPermission("user:manage"), service.GetAuditLogs)
+}
+
+func PasswordPolicyRoutes(r fiber.Router) {
+	policies := r.Group("/password-policies", middleware.Protected(), middleware.CheckPermission("user:manage"))
+	policies.Get("/", service.GetPasswordPolicies)
+	policies.Put("/:roleId", service.UpdatePasswordPolicy)
+	policies.Delete("/:roleId", service.ResetPasswordPolicy)
+}
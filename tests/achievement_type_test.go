@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/schema"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/* ============================================================
+   TEST SCHEMA TIPE PRESTASI
+   ============================================================
+*/
+
+var competitionType = model.AchievementTypeDefinition{
+	Code: "competition",
+	Name: "Kompetisi",
+	DetailsSchema: json.RawMessage(`{
+		"type": "object",
+		"required": ["competitionName", "eventDate"],
+		"additionalProperties": false,
+		"properties": {
+			"competitionName": {"type": "string", "minLength": 3},
+			"eventDate": {"type": "string", "format": "date"},
+			"rank": {"type": "integer", "minimum": 1},
+			"level": {"type": "string", "enum": ["individu", "tim"]},
+			"team": {
+				"type": "object",
+				"properties": {
+					"members": {"type": "array", "minItems": 2, "items": {"type": "string"}}
+				}
+			}
+		}
+	}`),
+	RequiredAttachments: []string{"certificate"},
+	AllowedTiers:        []string{"internasional", "nasional"},
+}
+
+func TestSchemaParse(t *testing.T) {
+	if _, err := schema.Parse(competitionType.DetailsSchema); err != nil {
+		t.Fatalf("Schema valid ditolak: %v", err)
+	}
+
+	invalid := map[string]string{
+		"Bukan JSON":              `{"type":`,
+		"Root bukan object":       `{"type": "array"}`,
+		"Type tidak dikenal":      `{"type": "object", "properties": {"a": {"type": "date"}}}`,
+		"Format tidak dikenal":    `{"type": "object", "properties": {"a": {"type": "string", "format": "phone"}}}`,
+		"Pattern rusak":           `{"type": "object", "properties": {"a": {"type": "string", "pattern": "(["}}}`,
+		"Required tanpa property": `{"type": "object", "required": ["b"], "properties": {"a": {"type": "string"}}}`,
+		"Kata kunci di root":      `{"type": "object", "oneOf": [{"required": ["a"]}]}`,
+		"Kata kunci bersarang":    `{"type": "object", "properties": {"a": {"type": "string", "const": "x"}}}`,
+		"Kata kunci di items":     `{"type": "object", "properties": {"a": {"type": "array", "items": {"$ref": "#/x"}}}}`,
+		"Exclusive minimum":       `{"type": "object", "properties": {"a": {"type": "number", "exclusiveMinimum": 0}}}`,
+	}
+	for name, raw := range invalid {
+		if _, err := schema.Parse([]byte(raw)); err == nil {
+			t.Errorf("[%s] Schema tidak valid harus ditolak", name)
+		}
+	}
+}
+
+func TestSchemaValidateDetails(t *testing.T) {
+	cases := []struct {
+		name    string
+		details map[string]interface{}
+		want    []string
+	}{
+		{"Valid", map[string]interface{}{"competitionName": "Gemastik", "eventDate": "2024-10-12", "rank": 1}, nil},
+		{"Field wajib kosong", map[string]interface{}{"competitionName": ""}, []string{"details.competitionName", "details.eventDate"}},
+		{"Tipe salah", map[string]interface{}{"competitionName": "Gemastik", "eventDate": "2024-10-12", "rank": "satu"}, []string{"details.rank"}},
+		{"Integer pecahan", map[string]interface{}{"competitionName": "Gemastik", "eventDate": "2024-10-12", "rank": 1.5}, []string{"details.rank"}},
+		{"Di bawah minimum", map[string]interface{}{"competitionName": "Gemastik", "eventDate": "2024-10-12", "rank": int32(0)}, []string{"details.rank"}},
+		{"Format tanggal", map[string]interface{}{"competitionName": "Gemastik", "eventDate": "12-10-2024"}, []string{"details.eventDate"}},
+		{"Enum", map[string]interface{}{"competitionName": "Gemastik", "eventDate": "2024-10-12", "level": "kelompok"}, []string{"details.level"}},
+		{"Field tidak dikenal", map[string]interface{}{"competitionName": "Gemastik", "eventDate": "2024-10-12", "juri": "x"}, []string{"details.juri"}},
+		{"Dokumen bersarang MongoDB", map[string]interface{}{"competitionName": "Gemastik", "eventDate": "2024-10-12",
+			"team": primitive.D{{Key: "members", Value: primitive.A{"andi", int64(7)}}}}, []string{"details.team.members[1]"}},
+		{"Array kurang dari minItems", map[string]interface{}{"competitionName": "Gemastik", "eventDate": "2024-10-12",
+			"team": map[string]interface{}{"members": []interface{}{"andi"}}}, []string{"details.team.members"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs, err := schema.ValidateAchievement(competitionType, model.AchievementMongo{
+				AchievementType: "competition", CompetitionTier: "nasional", Details: tc.details,
+			})
+			if err != nil {
+				t.Fatalf("ValidateAchievement error: %v", err)
+			}
+			if len(errs) != len(tc.want) {
+				t.Fatalf("Error = %v, want field %v", errs, tc.want)
+			}
+			for _, field := range tc.want {
+				if _, ok := errs[field]; !ok {
+					t.Errorf("Field %s tidak dilaporkan (%v)", field, errs)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaAllowedTiers(t *testing.T) {
+	details := map[string]interface{}{"competitionName": "Gemastik", "eventDate": "2024-10-12"}
+
+	errs, _ := schema.ValidateAchievement(competitionType, model.AchievementMongo{CompetitionTier: "Internasional", Details: details})
+	if len(errs) != 0 {
+		t.Errorf("Tingkat yang diizinkan (beda huruf besar) ditolak: %v", errs)
+	}
+
+	errs, _ = schema.ValidateAchievement(competitionType, model.AchievementMongo{CompetitionTier: "lokal", Details: details})
+	if _, ok := errs["competitionTier"]; !ok {
+		t.Errorf("Tingkat di luar allowed_tiers harus ditolak, dapat %v", errs)
+	}
+}
+
+func TestSchemaMissingAttachments(t *testing.T) {
+	missing := schema.MissingAttachments(competitionType, []model.Attachment{{FileName: "foto.jpg", Category: "documentation"}})
+	if _, ok := missing["attachments.certificate"]; !ok || len(missing) != 1 {
+		t.Errorf("Sertifikat wajib harus dilaporkan, dapat %v", missing)
+	}
+
+	missing = schema.MissingAttachments(competitionType, []model.Attachment{{FileName: "sertifikat.pdf", Category: "Certificate"}})
+	if len(missing) != 0 {
+		t.Errorf("Lampiran lengkap dianggap kurang: %v", missing)
+	}
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/helper"
+	"sistempelaporan/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/* ============================================================
+   TEST API KEY (FORMAT, HASH & ALLOWLIST IP)
+   ============================================================
+*/
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, err := helper.GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey gagal: %v", err)
+	}
+
+	t.Run("Format", func(t *testing.T) {
+		parts := strings.SplitN(key, "_", 3)
+		if len(parts) != 3 || parts[0] != helper.APIKeyPrefix {
+			t.Fatalf("Format key harus spk_<prefix>_<secret>, got %q", key)
+		}
+		if parts[1] != prefix || len(prefix) != 8 {
+			t.Errorf("Prefix harus 8 hex dan sama dengan bagian kedua key, got %q", prefix)
+		}
+		if len(parts[2]) < 40 {
+			t.Errorf("Secret terlalu pendek: %d karakter", len(parts[2]))
+		}
+	})
+
+	t.Run("Unique", func(t *testing.T) {
+		other, _, _ := helper.GenerateAPIKey()
+		if other == key {
+			t.Error("Dua key yang dibuat berurutan tidak boleh sama")
+		}
+	})
+
+	t.Run("Hash Deterministic", func(t *testing.T) {
+		hash := helper.HashAPIKey(key)
+		if len(hash) != 64 || hash != helper.HashAPIKey(key) {
+			t.Errorf("Hash harus SHA-256 hex yang konsisten, got %q", hash)
+		}
+		if hash == helper.HashAPIKey(key+"x") {
+			t.Error("Key berbeda tidak boleh menghasilkan hash yang sama")
+		}
+	})
+}
+
+func TestIPAllowed(t *testing.T) {
+	allowlist := []string{"10.0.0.0/24", "192.168.1.7", "2001:db8::/32"}
+
+	cases := []struct {
+		name      string
+		ip        string
+		allowlist []string
+		want      bool
+	}{
+		{"Empty Allowlist Allows All", "8.8.8.8", nil, true},
+		{"Inside CIDR", "10.0.0.42", allowlist, true},
+		{"Outside CIDR", "10.0.1.1", allowlist, false},
+		{"Exact IP", "192.168.1.7", allowlist, true},
+		{"Other IP", "192.168.1.8", allowlist, false},
+		{"IPv6 CIDR", "2001:db8::1", allowlist, true},
+		{"Invalid IP", "bukan-ip", allowlist, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := helper.IPAllowed(tc.ip, tc.allowlist); got != tc.want {
+				t.Errorf("IPAllowed(%q) = %v, want %v", tc.ip, got, tc.want)
+			}
+		})
+	}
+
+	t.Run("Validate Entries", func(t *testing.T) {
+		for _, entry := range []string{"10.0.0.1", "10.0.0.0/8", "::1"} {
+			if !helper.ValidIPOrCIDR(entry) {
+				t.Errorf("%q seharusnya valid", entry)
+			}
+		}
+		for _, entry := range []string{"", "10.0.0.0/33", "host.local"} {
+			if helper.ValidIPOrCIDR(entry) {
+				t.Errorf("%q seharusnya tidak valid", entry)
+			}
+		}
+	})
+}
+
+func TestUserOnlyRejectsAPIKey(t *testing.T) {
+	app := fiber.New()
+	app.Post("/comments", func(c *fiber.Ctx) error {
+		c.Locals("auth_type", c.Query("auth_type"))
+		return c.Next()
+	}, middleware.UserOnly(), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	cases := map[string]int{model.AuthTypeAPIKey: fiber.StatusForbidden, model.AuthTypeUser: fiber.StatusCreated}
+	for authType, want := range cases {
+		resp, err := app.Test(httptest.NewRequest("POST", "/comments?auth_type="+authType, nil))
+		if err != nil {
+			t.Fatalf("Request gagal: %v", err)
+		}
+		if resp.StatusCode != want {
+			t.Errorf("auth_type %s: status = %d, want %d", authType, resp.StatusCode, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"sistempelaporan/authenticator"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+/* ============================================================
+   TEST AUTHENTICATOR (BCRYPT & REGISTRY)
+   ============================================================
+*/
+
+func TestBcryptAuthenticator(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("rahasia-123"), bcrypt.MinCost)
+	account := authenticator.Account{Username: "mahasiswa1", PasswordHash: string(hash)}
+
+	local, err := authenticator.For(authenticator.SourceLocal)
+	if err != nil {
+		t.Fatalf("Authenticator lokal harus selalu tersedia: %v", err)
+	}
+
+	t.Run("Correct Password", func(t *testing.T) {
+		if err := local.Authenticate(account, "rahasia-123"); err != nil {
+			t.Errorf("Password benar harus diterima, got %v", err)
+		}
+	})
+
+	t.Run("Wrong Password", func(t *testing.T) {
+		err := local.Authenticate(account, "salah")
+		if !errors.Is(err, authenticator.ErrInvalidCredentials) {
+			t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+		}
+	})
+
+	t.Run("Empty Hash Never Matches", func(t *testing.T) {
+		err := local.Authenticate(authenticator.Account{Username: "dosen1"}, "")
+		if !errors.Is(err, authenticator.ErrInvalidCredentials) {
+			t.Errorf("Akun tanpa password lokal tidak boleh bisa login dengan bcrypt")
+		}
+	})
+}
+
+func TestAuthenticatorRegistry(t *testing.T) {
+	t.Run("Empty Source Is Local", func(t *testing.T) {
+		if _, err := authenticator.For(""); err != nil {
+			t.Errorf("auth_source kosong harus dianggap local")
+		}
+	})
+
+	t.Run("Unconfigured Source Unavailable", func(t *testing.T) {
+		_, err := authenticator.For("kerberos")
+		if !authenticator.IsUnavailable(err) {
+			t.Errorf("Sumber yang tidak terdaftar harus ErrUnavailable, got %v", err)
+		}
+	})
+
+	t.Run("Password Managed Only For Local", func(t *testing.T) {
+		if !authenticator.ManagesPassword(authenticator.SourceLocal) || authenticator.ManagesPassword(authenticator.SourceLDAP) {
+			t.Errorf("Hanya akun lokal yang password-nya dikelola aplikasi")
+		}
+	})
+}
+
+/* ============================================================
+   TEST PEMETAAN GRUP LDAP -> ROLE
+   ============================================================
+*/
+
+func TestLDAPGroupRoleMapping(t *testing.T) {
+	rules := []authenticator.GroupRoleRule{
+		{GroupDN: "cn=dosen,ou=groups,dc=kampus,dc=ac,dc=id", RoleID: "role-dosen", RoleName: "Dosen Wali", Priority: 20},
+		{GroupDN: "cn=admin-akademik,ou=groups,dc=kampus,dc=ac,dc=id", RoleID: "role-admin", RoleName: "Admin", Priority: 10},
+	}
+
+	t.Run("DN Compared Case And Space Insensitive", func(t *testing.T) {
+		rule, ok := authenticator.MapGroupsToRole([]string{"CN=Dosen, OU=Groups, DC=kampus, DC=ac, DC=id"}, rules)
+		if !ok || rule.RoleName != "Dosen Wali" {
+			t.Errorf("Expected Dosen Wali, got %+v (%v)", rule, ok)
+		}
+	})
+
+	t.Run("Lowest Priority Wins", func(t *testing.T) {
+		groups := []string{
+			"cn=dosen,ou=groups,dc=kampus,dc=ac,dc=id",
+			"cn=admin-akademik,ou=groups,dc=kampus,dc=ac,dc=id",
+		}
+		if rule, _ := authenticator.MapGroupsToRole(groups, rules); rule.RoleName != "Admin" {
+			t.Errorf("Aturan prioritas terkecil harus menang, got %s", rule.RoleName)
+		}
+	})
+
+	t.Run("No Matching Group", func(t *testing.T) {
+		if _, ok := authenticator.MapGroupsToRole([]string{"cn=tendik,ou=groups,dc=kampus,dc=ac,dc=id"}, rules); ok {
+			t.Errorf("Grup yang tidak dipetakan tidak boleh menghasilkan role")
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sistempelaporan/app/bulk"
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/service"
+	"sistempelaporan/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/* ============================================================
+   TEST VALIDASI BULK VERIFY / REJECT
+   ============================================================
+*/
+
+func TestBulkActionValidation(t *testing.T) {
+	app := fiber.New()
+	app.Post("/bulk/verify", service.BulkVerifyAchievements)
+	app.Post("/bulk/reject", service.BulkRejectAchievements)
+
+	tooMany := make([]string, 101)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`"00000000-0000-0000-0000-%012d"`, i)
+	}
+
+	cases := []struct {
+		name, path, body string
+	}{
+		{"Tanpa ID", "/bulk/verify", `{"note": "ok"}`},
+		{"ID kosong saja", "/bulk/reject", `{"ids": ["", "  "], "items": [{"id": ""}], "note": "tidak lengkap"}`},
+		{"Lebih dari 100 prestasi", "/bulk/verify", `{"ids": [` + strings.Join(tooMany, ",") + `]}`},
+		{"Body bukan JSON", "/bulk/reject", `ids=1`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Request error: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusBadRequest {
+				t.Errorf("Status = %d, want 400", resp.StatusCode)
+			}
+			var body helper.Response
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Code != fiber.StatusBadRequest {
+				t.Errorf("Respon harus helper.Response 400, dapat %+v (%v)", body, err)
+			}
+		})
+	}
+}
+
+/* ============================================================
+   TEST HASIL PER ITEM AKSI MASSAL
+   ============================================================
+*/
+
+func TestBulkRunPerItemResults(t *testing.T) {
+	app := fiber.New()
+	app.Post("/bulk", func(c *fiber.Ctx) error {
+		items := []model.BulkActionItem{{ID: "diizinkan"}, {ID: "ditolak"}, {ID: "rusak"}}
+		return bulk.Run(c, items, func(item model.BulkActionItem) error {
+			switch item.ID {
+			case "ditolak":
+				// Sama seperti authorizeAchievement saat policy menolak
+				return helper.Error(c, fiber.StatusForbidden, "Akses ditolak: tidak memiliki hubungan dengan resource ini", nil)
+			case "rusak":
+				return errors.New("pq: koneksi database terputus")
+			}
+			return helper.Success(c, fiber.Map{"status": "verified"}, "Prestasi berhasil diverifikasi")
+		})
+	})
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/bulk", nil))
+	if err != nil {
+		t.Fatalf("Request error: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Status = %d, want 200", resp.StatusCode)
+	}
+
+	var body struct {
+		Data model.BulkActionSummary `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Respon tidak valid: %v", err)
+	}
+	summary := body.Data
+	if summary.Total != 3 || summary.Succeeded != 1 || summary.Failed != 2 || len(summary.Results) != 3 {
+		t.Fatalf("Ringkasan = %+v, want total 3, berhasil 1, gagal 2", summary)
+	}
+
+	want := []struct {
+		id      string
+		code    int
+		success bool
+	}{
+		{"diizinkan", fiber.StatusOK, true},
+		{"ditolak", fiber.StatusForbidden, false},
+		{"rusak", fiber.StatusInternalServerError, false},
+	}
+	for i, w := range want {
+		r := summary.Results[i]
+		if r.ID != w.id || r.Code != w.code || r.Success != w.success {
+			t.Errorf("Hasil %d = %+v, want id %s, code %d, success %v", i, r, w.id, w.code, w.success)
+		}
+	}
+	if summary.Results[1].Message == "" {
+		t.Error("Item yang ditolak harus membawa pesan dari respon satuannya")
+	}
+	if msg := summary.Results[2].Message; msg != bulk.ErrorMessage || strings.Contains(msg, "pq:") {
+		t.Errorf("Error internal tidak boleh bocor ke hasil item, got %q", msg)
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"sistempelaporan/app/comment"
+	"sistempelaporan/app/model"
+
+	"github.com/google/uuid"
+)
+
+/* ============================================================
+   TEST KOMENTAR REVIEW PRESTASI
+   ============================================================
+*/
+
+func TestCommentIsReviewer(t *testing.T) {
+	cases := map[string]bool{
+		"dosen_wali":     true,
+		"admin_fakultas": true,
+		"admin":          true,
+		"mahasiswa":      false,
+		"admin_prodi":    false, // hanya boleh membaca
+		"dosen_lain":     false,
+	}
+	for name, want := range cases {
+		if got := comment.IsReviewer(subjects[name], achievementA); got != want {
+			t.Errorf("IsReviewer(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCommentThreads(t *testing.T) {
+	base := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	publik, internal := uuid.New(), uuid.New()
+	comments := []model.AchievementComment{
+		{ID: uuid.New(), ParentID: &publik, Visibility: model.CommentPublic, Body: "Sudah diperbaiki", CreatedAt: base.Add(2 * time.Hour)},
+		{ID: internal, Visibility: model.CommentInternal, Body: "Cek keaslian sertifikat", CreatedAt: base.Add(time.Hour)},
+		{ID: publik, Visibility: model.CommentPublic, Body: "Tanggal lomba salah", AnchorType: model.AnchorField, AnchorRef: "details.eventDate", CreatedAt: base},
+		{ID: uuid.New(), ParentID: &internal, Visibility: model.CommentInternal, Body: "Sudah dicek", CreatedAt: base.Add(3 * time.Hour)},
+	}
+
+	reviewer := comment.Threads(comments, true)
+	if len(reviewer) != 2 || reviewer[0].ID != publik || reviewer[1].ID != internal {
+		t.Fatalf("Thread reviewer harus 2 (urut waktu), dapat %+v", reviewer)
+	}
+	if len(reviewer[0].Replies) != 1 || len(reviewer[1].Replies) != 1 {
+		t.Errorf("Balasan harus menempel ke thread-nya: %+v", reviewer)
+	}
+
+	student := comment.Threads(comments, false)
+	if len(student) != 1 || student[0].ID != publik || len(student[0].Replies) != 1 {
+		t.Errorf("Mahasiswa hanya boleh melihat thread publik, dapat %+v", student)
+	}
+}
+
+func TestCommentCanResolve(t *testing.T) {
+	author := uuid.New()
+	thread := model.AchievementComment{ID: uuid.New(), AuthorID: &author}
+
+	if !comment.CanResolve(thread, author.String(), false) {
+		t.Errorf("Pembuka thread harus boleh menandai selesai")
+	}
+	if !comment.CanResolve(thread, "u-dosen-a", true) {
+		t.Errorf("Reviewer harus boleh menandai selesai")
+	}
+	if comment.CanResolve(thread, "u-mhs-b", false) || comment.CanResolve(model.AchievementComment{}, "", false) {
+		t.Errorf("Pihak lain tidak boleh menandai selesai")
+	}
+}
+
+func TestCommentValidateAnchor(t *testing.T) {
+	attachments := []model.Attachment{{FileName: "sertifikat.pdf", FileURL: "/uploads/a.pdf"}}
+
+	valid := [][2]string{
+		{"", ""},
+		{model.AnchorField, "title"},
+		{model.AnchorField, "details.team.members"},
+		{model.AnchorAttachment, "/uploads/a.pdf"},
+	}
+	for _, v := range valid {
+		if errs := comment.ValidateAnchor(v[0], v[1], attachments); len(errs) != 0 {
+			t.Errorf("Anchor %s=%s harus valid, dapat %v", v[0], v[1], errs)
+		}
+	}
+
+	invalid := []struct{ anchorType, ref, field string }{
+		{"", "title", "anchor_type"},
+		{"paragraph", "title", "anchor_type"},
+		{model.AnchorField, "points", "anchor_ref"},
+		{model.AnchorField, "details.", "anchor_ref"},
+		{model.AnchorAttachment, "/uploads/lain.pdf", "anchor_ref"},
+	}
+	for _, v := range invalid {
+		if _, ok := comment.ValidateAnchor(v.anchorType, v.ref, attachments)[v.field]; !ok {
+			t.Errorf("Anchor %s=%s harus ditolak di field %s", v.anchorType, v.ref, v.field)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"sistempelaporan/app/delegation"
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/app/workflow"
+
+	"github.com/google/uuid"
+)
+
+/* ============================================================
+   TEST DELEGASI VERIFIKASI DOSEN WALI
+   ============================================================
+*/
+
+// Dosen B menggantikan dosen wali A (students kosong = semua mahasiswa bimbingan)
+func delegateSubject(students ...string) policy.Subject {
+	s := subjects["dosen_lain"]
+	s.Delegations = []policy.Delegation{{ID: "d-1", FromLecturerID: lecturerA, StudentIDs: students}}
+	return s
+}
+
+func TestDelegationPolicy(t *testing.T) {
+	full := delegateSubject()
+	for _, action := range []policy.Action{policy.ActionRead, policy.ActionVerify, policy.ActionReject} {
+		d := policy.Can(full, action, achievementA)
+		if !d.Allowed || d.Relation != policy.RelationDelegate || d.Delegation == nil || d.Delegation.ID != "d-1" {
+			t.Errorf("Can(delegate, %s) = %+v, want diizinkan lewat delegasi d-1", action, d)
+		}
+	}
+	if d := policy.Can(full, policy.ActionRead, studentResA); !d.Allowed {
+		t.Errorf("dosen pengganti seharusnya boleh membaca data mahasiswa: %s", d.Reason)
+	}
+	if d := policy.Can(full, policy.ActionUpdate, achievementA); d.Allowed {
+		t.Error("delegasi tidak memberi akses mengubah prestasi")
+	}
+	if actor := workflow.ActorFor(policy.Can(full, policy.ActionVerify, achievementA)); actor != workflow.ActorVerifier {
+		t.Errorf("ActorFor(delegate) = %v, want verifier", actor)
+	}
+
+	// Dosen wali asli tetap memakai relasi advisor
+	if d := policy.Can(subjects["dosen_wali"], policy.ActionVerify, achievementA); d.Relation != policy.RelationAdvisor || d.Delegation != nil {
+		t.Errorf("dosen wali = %+v, want relasi advisor tanpa delegasi", d)
+	}
+
+	// Delegasi sebagian hanya mencakup mahasiswa yang disebut
+	if d := policy.Can(delegateSubject(studentB), policy.ActionVerify, achievementA); d.Allowed {
+		t.Error("delegasi untuk mahasiswa B tidak boleh mencakup prestasi mahasiswa A")
+	}
+	if d := policy.Can(delegateSubject(studentA), policy.ActionVerify, achievementA); !d.Allowed {
+		t.Errorf("delegasi untuk mahasiswa A seharusnya diizinkan: %s", d.Reason)
+	}
+
+	// Delegasi tanpa permission verify tidak memberi akses
+	noPerm := delegateSubject()
+	noPerm.Permissions = []string{"achievement:read"}
+	if d := policy.Can(noPerm, policy.ActionVerify, achievementA); d.Allowed {
+		t.Error("delegasi tetap membutuhkan permission achievement:verify")
+	}
+}
+
+func TestDelegationManagePolicy(t *testing.T) {
+	owner := subjects["dosen_wali"]
+	owner.Permissions = append(append([]string{}, dosenPerms...), "delegation:manage")
+	other := subjects["dosen_lain"]
+	other.Permissions = append(append([]string{}, dosenPerms...), "delegation:manage")
+	unit := policy.Subject{UserID: "u-dept", Grants: []policy.Grant{
+		{Unit: policy.Unit{Level: policy.ScopeDepartment, Value: "Ilmu Komputer"}, Permissions: []string{"delegation:manage"}},
+	}}
+
+	cases := map[string]struct {
+		subject policy.Subject
+		want    bool
+	}{
+		"dosen wali sendiri":      {owner, true},
+		"dosen lain":              {other, false},
+		"dosen tanpa permission":  {subjects["dosen_wali"], false},
+		"admin departemen dosen":  {unit, true},
+		"admin sistem tanpa izin": {subjects["admin"], false},
+	}
+	for name, tc := range cases {
+		if got := policy.Can(tc.subject, policy.ActionDelegate, lecturerResA).Allowed; got != tc.want {
+			t.Errorf("%s: Can(delegate) = %v, want %v", name, got, tc.want)
+		}
+	}
+}
+
+func TestDelegationScopeAndStage(t *testing.T) {
+	s := delegateSubject(studentA)
+	scope, ok := policy.ListScope(s, policy.ResourceAchievement, "achievement:read")
+	if !ok || scope.AdvisorID != lecturerB || len(scope.Delegations) != 1 || scope.Delegations[0].FromLecturerID != lecturerA {
+		t.Errorf("ListScope(delegate) = %+v, %v", scope, ok)
+	}
+
+	advisorStage := model.VerificationStage{Position: 1, Name: "Dosen Wali", ApproverType: model.ApproverAdvisor, RequiredApprovals: 1}
+	roleStage := model.VerificationStage{Position: 2, Name: "Fakultas", ApproverType: model.ApproverRole, ApproverRole: "Kemahasiswaan Fakultas", RequiredApprovals: 1}
+	if !workflow.CanApproveStage(s, advisorStage, achievementA) {
+		t.Error("dosen pengganti seharusnya menyetujui tahap dosen wali")
+	}
+	if workflow.CanApproveStage(s, roleStage, achievementA) {
+		t.Error("delegasi dosen wali tidak mencakup tahap role")
+	}
+	if workflow.CanApproveStage(subjects["dosen_lain"], advisorStage, achievementA) {
+		t.Error("dosen lain tanpa delegasi tidak boleh menyetujui tahap dosen wali")
+	}
+}
+
+func TestDelegationValidateAndStatus(t *testing.T) {
+	today := delegation.Today(time.Date(2024, 10, 10, 15, 30, 0, 0, time.UTC))
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	from, to := uuid.New(), uuid.New()
+	base := model.AdvisorDelegation{FromLecturerID: from, ToLecturerID: to, StartDate: date("2024-10-01"), EndDate: date("2025-03-31")}
+
+	if errs := delegation.Validate(base, today); len(errs) != 0 {
+		t.Errorf("delegasi valid ditolak: %v", errs)
+	}
+	cases := map[string]struct {
+		mutate func(d *model.AdvisorDelegation)
+		field  string
+	}{
+		"dosen sama":         {func(d *model.AdvisorDelegation) { d.ToLecturerID = from }, "to_lecturer_id"},
+		"selesai sebelum":    {func(d *model.AdvisorDelegation) { d.EndDate = date("2024-09-30") }, "end_date"},
+		"sudah berakhir":     {func(d *model.AdvisorDelegation) { d.StartDate, d.EndDate = date("2024-09-01"), date("2024-10-09") }, "end_date"},
+		"lebih dari setahun": {func(d *model.AdvisorDelegation) { d.EndDate = date("2025-10-02") }, "end_date"},
+	}
+	for name, tc := range cases {
+		d := base
+		tc.mutate(&d)
+		if errs := delegation.Validate(d, today); errs[tc.field] == "" {
+			t.Errorf("%s: want error pada %s, got %v", name, tc.field, errs)
+		}
+	}
+
+	// 23:30 UTC sudah tanggal 11 di WIB: tanggal diambil dari zona waktu t
+	wib := time.FixedZone("WIB", 7*60*60)
+	if got := delegation.Today(time.Date(2024, 10, 10, 23, 30, 0, 0, time.UTC).In(wib)); !got.Equal(date("2024-10-11")) {
+		t.Errorf("Today pada 23:30 UTC di WIB = %v, want 2024-10-11", got)
+	}
+
+	revoked := time.Now()
+	statuses := []struct {
+		start, end string
+		revokedAt  *time.Time
+		want       string
+	}{
+		{"2024-10-11", "2024-12-31", nil, model.DelegationScheduled},
+		{"2024-10-01", "2024-10-10", nil, model.DelegationActive}, // tanggal selesai inklusif
+		{"2024-10-10", "2024-10-10", nil, model.DelegationActive},
+		{"2024-09-01", "2024-10-09", nil, model.DelegationExpired},
+		{"2024-10-01", "2024-12-31", &revoked, model.DelegationRevoked},
+	}
+	for _, tc := range statuses {
+		d := model.AdvisorDelegation{StartDate: date(tc.start), EndDate: date(tc.end), RevokedAt: tc.revokedAt}
+		if got := delegation.Status(d, today); got != tc.want {
+			t.Errorf("Status(%s..%s) = %s, want %s", tc.start, tc.end, got, tc.want)
+		}
+	}
+
+	policyDelegation := delegation.ToPolicy(model.AdvisorDelegation{ID: uuid.New(), FromLecturerID: from, StudentIDs: []uuid.UUID{to}})
+	if policyDelegation.FromLecturerID != from.String() || len(policyDelegation.StudentIDs) != 1 || policyDelegation.StudentIDs[0] != to.String() {
+		t.Errorf("ToPolicy = %+v", policyDelegation)
+	}
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"sistempelaporan/app/service"
+	"sistempelaporan/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/* ============================================================
+   TEST PAGINATION HISTORY STATUS PRESTASI
+   ============================================================
+*/
+
+func TestPagination(t *testing.T) {
+	app := fiber.New()
+	app.Get("/page", func(c *fiber.Ctx) error {
+		page, limit := helper.Pagination(c)
+		return c.JSON(fiber.Map{"page": page, "limit": limit})
+	})
+
+	cases := []struct {
+		query       string
+		page, limit int
+	}{
+		{"", 1, 20},
+		{"?page=3&limit=50", 3, 50},
+		{"?page=0&limit=0", 1, 20},
+		{"?page=-2&limit=abc", 1, 20},
+		{"?limit=100", 1, 100},
+		{"?limit=500", 1, 100}, // dipotong ke maksimal 100
+	}
+	for _, tc := range cases {
+		resp, err := app.Test(httptest.NewRequest("GET", "/page"+tc.query, nil))
+		if err != nil {
+			t.Fatalf("Request error: %v", err)
+		}
+		var body struct{ Page, Limit int }
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Respon bukan JSON: %v", err)
+		}
+		if body.Page != tc.page || body.Limit != tc.limit {
+			t.Errorf("Pagination(%q) = page %d limit %d, want page %d limit %d", tc.query, body.Page, body.Limit, tc.page, tc.limit)
+		}
+	}
+}
+
+func TestPageMeta(t *testing.T) {
+	cases := []struct {
+		total     int64
+		limit     int
+		totalPage int
+	}{
+		{0, 20, 0},
+		{1, 20, 1},
+		{20, 20, 1},
+		{21, 20, 2}, // sisa satu event tetap mendapat halaman sendiri
+		{250, 100, 3},
+	}
+	for _, tc := range cases {
+		meta := helper.PageMeta(2, tc.limit, tc.total)
+		if meta.TotalPage != tc.totalPage || meta.Page != 2 || meta.Limit != tc.limit || meta.TotalData != tc.total {
+			t.Errorf("PageMeta(total %d, limit %d) = %+v, want total_page %d", tc.total, tc.limit, meta, tc.totalPage)
+		}
+	}
+}
+
+func TestGetHistoryInvalidID(t *testing.T) {
+	app := fiber.New()
+	app.Get("/achievements/:id/history", service.GetHistory)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/achievements/bukan-uuid/history", nil))
+	if err != nil {
+		t.Fatalf("Request error: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Status = %d, want 404 untuk ID prestasi yang tidak valid", resp.StatusCode)
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/keymanager"
+	"sistempelaporan/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+/* ============================================================
+   TEST IMPERSONATION (CLAIM IMPERSONATOR_ID DI MIDDLEWARE PROTECTED)
+   ============================================================
+*/
+
+func TestProtected_ExposesImpersonator(t *testing.T) {
+	m, err := keymanager.New(keymanager.Config{Algorithm: keymanager.AlgHS256, StaticSecret: []byte("rahasia")}, nil)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	keymanager.SetDefault(m)
+	repository.SetRevocationStore(repository.NewMemoryRevocationStore())
+
+	app := fiber.New()
+	app.Get("/probe", middleware.Protected(), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"user_id":         c.Locals("user_id"),
+			"impersonator_id": c.Locals("impersonator_id"),
+		})
+	})
+
+	sign := func(extra jwt.MapClaims) string {
+		claims := jwt.MapClaims{
+			"user_id":     "mahasiswa-1",
+			"role":        "Mahasiswa",
+			"permissions": []string{"achievement:read"},
+			"token_type":  model.TokenTypeAccess,
+			"jti":         "jti-" + time.Now().Format(time.RFC3339Nano),
+			"exp":         time.Now().Add(15 * time.Minute).Unix(),
+		}
+		for k, v := range extra {
+			claims[k] = v
+		}
+		signed, err := m.Sign(claims)
+		if err != nil {
+			t.Fatalf("Sign error: %v", err)
+		}
+		return signed
+	}
+
+	probe := func(token string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/probe", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request error: %v", err)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Respon bukan JSON: %v", err)
+		}
+		return resp.StatusCode, body
+	}
+
+	t.Run("Impersonation Token Exposes Both IDs", func(t *testing.T) {
+		status, body := probe(sign(jwt.MapClaims{"impersonator_id": "admin-1"}))
+		if status != fiber.StatusOK {
+			t.Fatalf("Expected 200, got %d", status)
+		}
+		if body["user_id"] != "mahasiswa-1" || body["impersonator_id"] != "admin-1" {
+			t.Errorf("Locals user_id dan impersonator_id harus terisi, got %v", body)
+		}
+	})
+
+	t.Run("Regular Token Has Empty Impersonator", func(t *testing.T) {
+		status, body := probe(sign(nil))
+		if status != fiber.StatusOK {
+			t.Fatalf("Expected 200, got %d", status)
+		}
+		if body["impersonator_id"] != "" {
+			t.Errorf("Token biasa tidak boleh punya impersonator, got %v", body["impersonator_id"])
+		}
+	})
+}
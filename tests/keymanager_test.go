@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"sistempelaporan/keymanager"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+/* ============================================================
+   TEST KEY MANAGER (SIGNING, VERIFIKASI KID, ROTASI, JWKS)
+   ============================================================
+*/
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"user_id": "u-1", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func TestKeyManager_StaticHS256(t *testing.T) {
+	m, err := keymanager.New(keymanager.Config{Algorithm: keymanager.AlgHS256, StaticSecret: []byte("rahasia")}, nil)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+
+	signed, err := m.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign error: %v", err)
+	}
+
+	token, err := m.Parse(signed)
+	if err != nil || !token.Valid {
+		t.Fatalf("Token harus valid, got %v", err)
+	}
+	if token.Header["kid"] != "static" {
+		t.Errorf("Header kid harus terisi, got %v", token.Header["kid"])
+	}
+
+	if len(m.JWKS().Keys) != 0 {
+		t.Errorf("Key HS256 tidak boleh dipublikasikan di JWKS")
+	}
+}
+
+func TestKeyManager_RotationKeepsOldTokensValid(t *testing.T) {
+	for _, alg := range []string{keymanager.AlgRS256, keymanager.AlgEdDSA, keymanager.AlgHS256} {
+		t.Run(alg, func(t *testing.T) {
+			store := keymanager.NewMemoryStore()
+			m, err := keymanager.New(keymanager.Config{
+				Algorithm:        alg,
+				RotationInterval: time.Hour,
+				GracePeriod:      time.Hour,
+			}, store)
+			if err != nil {
+				t.Fatalf("New error: %v", err)
+			}
+
+			oldToken, _ := m.Sign(testClaims())
+			oldKid := m.SigningKeyID()
+
+			if err := m.Rotate(); err != nil {
+				t.Fatalf("Rotate error: %v", err)
+			}
+			if m.SigningKeyID() == oldKid {
+				t.Fatalf("Key signing harus berganti setelah rotasi")
+			}
+
+			if _, err := m.Parse(oldToken); err != nil {
+				t.Errorf("Token lama harus tetap valid setelah rotasi: %v", err)
+			}
+
+			newToken, _ := m.Sign(testClaims())
+			if _, err := m.Parse(newToken); err != nil {
+				t.Errorf("Token baru harus valid: %v", err)
+			}
+
+			// Instance lain yang berbagi store harus bisa memverifikasi token yang sama
+			other, err := keymanager.New(keymanager.Config{Algorithm: alg, RotationInterval: time.Hour, GracePeriod: time.Hour}, store)
+			if err != nil {
+				t.Fatalf("New (instance kedua) error: %v", err)
+			}
+			if _, err := other.Parse(newToken); err != nil {
+				t.Errorf("Instance lain harus bisa memverifikasi token: %v", err)
+			}
+
+			wantJWKS := 2
+			if alg == keymanager.AlgHS256 {
+				wantJWKS = 0
+			}
+			if got := len(m.JWKS().Keys); got != wantJWKS {
+				t.Errorf("Expected %d key di JWKS, got %d", wantJWKS, got)
+			}
+		})
+	}
+}
+
+func TestKeyManager_RejectsUnknownKidAndAlgConfusion(t *testing.T) {
+	m, _ := keymanager.New(keymanager.Config{Algorithm: keymanager.AlgRS256}, keymanager.NewMemoryStore())
+
+	t.Run("Unknown Kid", func(t *testing.T) {
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+		forged.Header["kid"] = "tidak-ada"
+		signed, _ := forged.SignedString([]byte("rahasia"))
+		if _, err := m.Parse(signed); err == nil {
+			t.Errorf("Token dengan kid tidak dikenal harus ditolak")
+		}
+	})
+
+	t.Run("HS256 With RSA Kid", func(t *testing.T) {
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+		forged.Header["kid"] = m.SigningKeyID()
+		signed, _ := forged.SignedString([]byte("rahasia"))
+		if _, err := m.Parse(signed); err == nil {
+			t.Errorf("Token HS256 dengan kid milik key RSA harus ditolak")
+		}
+	})
+}
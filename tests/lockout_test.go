@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sistempelaporan/app/lockout"
+	"sistempelaporan/app/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/* ============================================================
+   TEST LOCKOUT LOGIN (PENGUNCIAN AKUN & IP)
+   ============================================================
+*/
+
+var testLockoutPolicy = lockout.Policy{
+	MaxAttempts:   3,
+	LockDuration:  15 * time.Minute,
+	IPMaxAttempts: 20,
+	IPWindow:      10 * time.Minute,
+}
+
+func TestLockoutRegisterFailure(t *testing.T) {
+	now := time.Date(2024, 10, 10, 8, 0, 0, 0, time.UTC)
+
+	failed := 0
+	for attempt := 1; attempt < testLockoutPolicy.MaxAttempts; attempt++ {
+		var lockedUntil *time.Time
+		failed, lockedUntil = testLockoutPolicy.RegisterFailure(failed, now)
+		if lockedUntil != nil || failed != attempt {
+			t.Fatalf("kegagalan ke-%d: count = %d, lockedUntil = %v; akun belum boleh dikunci", attempt, failed, lockedUntil)
+		}
+	}
+
+	count, lockedUntil := testLockoutPolicy.RegisterFailure(failed, now)
+	if lockedUntil == nil || !lockedUntil.Equal(now.Add(15*time.Minute)) {
+		t.Fatalf("kegagalan ke-%d seharusnya mengunci akun sampai %v, dapat %v", testLockoutPolicy.MaxAttempts, now.Add(15*time.Minute), lockedUntil)
+	}
+	if count != 0 {
+		t.Errorf("counter harus di-reset setelah akun dikunci, dapat %d", count)
+	}
+
+	if !lockout.Locked(lockedUntil, now.Add(14*time.Minute)) {
+		t.Error("akun harus tetap terkunci sebelum lockedUntil")
+	}
+	if lockout.Locked(lockedUntil, now.Add(15*time.Minute)) {
+		t.Error("akun harus terbuka tepat saat lockedUntil")
+	}
+	if lockout.Locked(nil, now) {
+		t.Error("akun tanpa locked_until tidak terkunci")
+	}
+}
+
+func TestLockoutIPWindow(t *testing.T) {
+	now := time.Date(2024, 10, 10, 8, 0, 0, 0, time.UTC)
+	if got := testLockoutPolicy.IPWindowStart(now); !got.Equal(now.Add(-10 * time.Minute)) {
+		t.Errorf("IPWindowStart = %v, want %v", got, now.Add(-10*time.Minute))
+	}
+	if testLockoutPolicy.IPBlocked(19) {
+		t.Error("19 kegagalan belum mencapai batas IP")
+	}
+	if !testLockoutPolicy.IPBlocked(20) {
+		t.Error("20 kegagalan harus memblokir IP")
+	}
+}
+
+func TestUnlockUserInvalidID(t *testing.T) {
+	app := fiber.New()
+	app.Post("/users/:id/unlock", service.UnlockUser)
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/users/bukan-uuid/unlock", nil))
+	if err != nil {
+		t.Fatalf("Request error: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("Status = %d, want 404 untuk ID user yang tidak valid", resp.StatusCode)
+	}
+}
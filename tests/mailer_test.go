@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"bufio"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"sistempelaporan/mailer"
+)
+
+/* ============================================================
+   FAKE SMTP SINK (MENERIMA SATU EMAIL LALU SELESAI)
+   ============================================================
+*/
+
+type capturedMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+func startSMTPSink(t *testing.T) (addr string, received <-chan capturedMail) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Gagal membuka listener: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan capturedMail, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		var mail capturedMail
+		reply("220 sink ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimRight(line, "\r\n")
+			upper := strings.ToUpper(cmd)
+
+			switch {
+			case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+				reply("250 sink")
+			case strings.HasPrefix(upper, "MAIL FROM:"):
+				mail.From = strings.Trim(cmd[len("MAIL FROM:"):], "<> ")
+				reply("250 OK")
+			case strings.HasPrefix(upper, "RCPT TO:"):
+				mail.To = append(mail.To, strings.Trim(cmd[len("RCPT TO:"):], "<> "))
+				reply("250 OK")
+			case upper == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				mail.Data = data.String()
+				reply("250 OK")
+			case upper == "QUIT":
+				reply("221 Bye")
+				ch <- mail
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), ch
+}
+
+/* ============================================================
+   TEST SMTP MAILER
+   ============================================================
+*/
+
+func TestSMTPMailerSendsToLocalSink(t *testing.T) {
+	addr, received := startSMTPSink(t)
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := net.LookupPort("tcp", portStr)
+
+	m := &mailer.SMTPMailer{Host: host, Port: port, From: "no-reply@sistempelaporan.local"}
+
+	err := m.Send(mailer.Message{
+		To:      []string{"mahasiswa@kampus.ac.id"},
+		Subject: "Reset Password",
+		Body:    "Klik link berikut:\nhttp://localhost/reset-password?token=abc",
+	})
+	if err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+
+	mail := <-received
+
+	t.Run("Envelope", func(t *testing.T) {
+		if mail.From != "no-reply@sistempelaporan.local" {
+			t.Errorf("MAIL FROM salah: %s", mail.From)
+		}
+		if len(mail.To) != 1 || mail.To[0] != "mahasiswa@kampus.ac.id" {
+			t.Errorf("RCPT TO salah: %v", mail.To)
+		}
+	})
+
+	t.Run("Headers And Body", func(t *testing.T) {
+		if !strings.Contains(mail.Data, "Subject: ") || !strings.Contains(mail.Data, "Reset Password") {
+			t.Errorf("Subject tidak ditemukan di email:\n%s", mail.Data)
+		}
+		if !strings.Contains(mail.Data, "Content-Type: text/plain; charset=UTF-8") {
+			t.Errorf("Content-Type tidak sesuai")
+		}
+		if !strings.Contains(mail.Data, "token=abc\r\n") {
+			t.Errorf("Body harus memakai CRLF dan berisi token")
+		}
+	})
+}
+
+func TestSMTPMailerRejectsEmptyRecipient(t *testing.T) {
+	m := &mailer.SMTPMailer{Host: "127.0.0.1", Port: 1, From: "no-reply@localhost"}
+	if err := m.Send(mailer.Message{Subject: "x"}); err == nil {
+		t.Errorf("Email tanpa penerima harus ditolak")
+	}
+}
+
+func TestLogMailerRedactsBody(t *testing.T) {
+	var buf strings.Builder
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	msg := mailer.Message{
+		To:      []string{"mahasiswa@kampus.ac.id"},
+		Subject: "Reset Password",
+		Body:    "Klik link berikut:\nhttp://localhost/reset-password?token=rahasia",
+	}
+
+	if err := (mailer.LogMailer{}).Send(msg); err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+	if out := buf.String(); strings.Contains(out, "rahasia") || !strings.Contains(out, "mahasiswa@kampus.ac.id") || !strings.Contains(out, "Reset Password") {
+		t.Errorf("Log default hanya boleh berisi penerima dan subject, got:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := (mailer.LogMailer{LogBody: true}).Send(msg); err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "token=rahasia") {
+		t.Errorf("LogBody=true harus menulis body ke log")
+	}
+}
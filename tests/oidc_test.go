@@ -0,0 +1,286 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"sistempelaporan/oidc"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+/* ============================================================
+   MOCK OIDC PROVIDER (DISCOVERY, JWKS, TOKEN ENDPOINT + PKCE)
+   ============================================================
+*/
+
+type mockOIDCProvider struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+
+	mu    sync.Mutex
+	codes map[string]mockAuthCode
+
+	// claims tambahan untuk ID token berikutnya
+	extraClaims jwt.MapClaims
+}
+
+type mockAuthCode struct {
+	challenge string
+	nonce     string
+}
+
+func newMockOIDCProvider(t *testing.T, clientID string) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Gagal membuat key RSA: %v", err)
+	}
+	m := &mockOIDCProvider{key: key, clientID: clientID, codes: map[string]mockAuthCode{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		b64 := base64.RawURLEncoding
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA", "kid": "mock-key", "use": "sig", "alg": "RS256",
+				"n": b64.EncodeToString(key.N.Bytes()),
+				"e": b64.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		m.mu.Lock()
+		code, ok := m.codes[r.PostForm.Get("code")]
+		delete(m.codes, r.PostForm.Get("code"))
+		m.mu.Unlock()
+
+		if !ok || oidc.CodeChallengeS256(r.PostForm.Get("code_verifier")) != code.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "mock-access",
+			"token_type":   "Bearer",
+			"id_token":     m.signIDToken(t, code.nonce, m.clientID),
+		})
+	})
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// authorize mensimulasikan user login di provider: mengembalikan code untuk challenge & nonce dari URL authorize.
+func (m *mockOIDCProvider) authorize(t *testing.T, authURL string) (code, state string) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("URL authorize tidak valid: %v", err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("URL authorize harus memakai PKCE S256: %s", authURL)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code = "code-" + q.Get("state")[:8]
+	m.codes[code] = mockAuthCode{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	return code, q.Get("state")
+}
+
+func (m *mockOIDCProvider) signIDToken(t *testing.T, nonce, audience string) string {
+	claims := jwt.MapClaims{
+		"iss":            m.server.URL,
+		"sub":            "campus-user-001",
+		"aud":            audience,
+		"nonce":          nonce,
+		"email":          "mahasiswa@kampus.ac.id",
+		"email_verified": true,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+	}
+	for k, v := range m.extraClaims {
+		if v == nil {
+			delete(claims, k) // nil = claim tidak dikirim provider
+			continue
+		}
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "mock-key"
+	signed, err := token.SignedString(m.key)
+	if err != nil {
+		t.Fatalf("Gagal sign ID token: %v", err)
+	}
+	return signed
+}
+
+/* ============================================================
+   TEST OIDC AUTHORIZATION CODE + PKCE
+   ============================================================
+*/
+
+func TestOIDCAuthorizationCodeFlow(t *testing.T) {
+	mock := newMockOIDCProvider(t, "sistem-prestasi")
+	mock.extraClaims = jwt.MapClaims{"nim": "2110511001", "affiliation": []interface{}{"member", "student"}}
+
+	provider := oidc.New(oidc.Config{
+		Issuer:      mock.server.URL,
+		ClientID:    "sistem-prestasi",
+		RedirectURL: "http://localhost:3000/api/v1/auth/oidc/callback",
+	}, mock.server.Client())
+	ctx := context.Background()
+
+	newLogin := func(t *testing.T) (code, verifier, nonce string) {
+		verifier, _ = oidc.NewCodeVerifier()
+		nonce, _ = oidc.RandomString(16)
+		state, _ := oidc.RandomString(16)
+
+		authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallengeS256(verifier))
+		if err != nil {
+			t.Fatalf("AuthCodeURL error: %v", err)
+		}
+		code, gotState := mock.authorize(t, authURL)
+		if gotState != state {
+			t.Fatalf("State harus dikirim apa adanya ke provider")
+		}
+		return code, verifier, nonce
+	}
+
+	t.Run("Valid Login Returns Verified Claims", func(t *testing.T) {
+		code, verifier, nonce := newLogin(t)
+
+		tokens, err := provider.Exchange(ctx, code, verifier)
+		if err != nil {
+			t.Fatalf("Exchange error: %v", err)
+		}
+		claims, err := provider.VerifyIDToken(ctx, tokens.IDToken, nonce)
+		if err != nil {
+			t.Fatalf("VerifyIDToken error: %v", err)
+		}
+		if claims.Subject != "campus-user-001" || claims.Email != "mahasiswa@kampus.ac.id" {
+			t.Errorf("Claims tidak sesuai: %+v", claims)
+		}
+		if oidc.ClaimString(claims.Raw, "nim") != "2110511001" {
+			t.Errorf("Claim NIM harus bisa dibaca")
+		}
+	})
+
+	t.Run("Wrong PKCE Verifier Rejected", func(t *testing.T) {
+		code, _, _ := newLogin(t)
+		if _, err := provider.Exchange(ctx, code, "verifier-palsu"); err == nil {
+			t.Errorf("Exchange dengan code_verifier salah harus gagal")
+		}
+	})
+
+	t.Run("Nonce Mismatch Rejected", func(t *testing.T) {
+		code, verifier, _ := newLogin(t)
+		tokens, err := provider.Exchange(ctx, code, verifier)
+		if err != nil {
+			t.Fatalf("Exchange error: %v", err)
+		}
+		if _, err := provider.VerifyIDToken(ctx, tokens.IDToken, "nonce-lain"); err == nil {
+			t.Errorf("ID token dengan nonce berbeda harus ditolak")
+		}
+	})
+
+	t.Run("Missing Email Verified Claim Not Trusted", func(t *testing.T) {
+		mock.extraClaims["email_verified"] = nil
+		defer delete(mock.extraClaims, "email_verified")
+
+		code, verifier, nonce := newLogin(t)
+		tokens, err := provider.Exchange(ctx, code, verifier)
+		if err != nil {
+			t.Fatalf("Exchange error: %v", err)
+		}
+		claims, err := provider.VerifyIDToken(ctx, tokens.IDToken, nonce)
+		if err != nil {
+			t.Fatalf("VerifyIDToken error: %v", err)
+		}
+		if claims.EmailVerified != nil || claims.EmailTrusted() {
+			t.Errorf("Email tanpa claim email_verified tidak boleh dipercaya untuk menghubungkan akun")
+		}
+	})
+
+	t.Run("Token For Other Client Rejected", func(t *testing.T) {
+		idToken := mock.signIDToken(t, "n-1", "aplikasi-lain")
+		if _, err := provider.VerifyIDToken(ctx, idToken, "n-1"); err == nil {
+			t.Errorf("ID token dengan audience lain harus ditolak")
+		}
+	})
+}
+
+func TestOIDCEmailTrusted(t *testing.T) {
+	yes, no := true, false
+	cases := []struct {
+		name   string
+		claims oidc.Claims
+		want   bool
+	}{
+		{"Verified", oidc.Claims{Email: "a@kampus.ac.id", EmailVerified: &yes}, true},
+		{"Not Verified", oidc.Claims{Email: "a@kampus.ac.id", EmailVerified: &no}, false},
+		{"Claim Missing", oidc.Claims{Email: "a@kampus.ac.id"}, false},
+		{"Empty Email", oidc.Claims{EmailVerified: &yes}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.claims.EmailTrusted(); got != tc.want {
+			t.Errorf("%s: EmailTrusted() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+/* ============================================================
+   TEST ATURAN CLAIM -> ROLE
+   ============================================================
+*/
+
+func TestOIDCRoleMapping(t *testing.T) {
+	rules := []oidc.RoleMappingRule{
+		{Claim: "realm_access.roles", Value: "lecturer", Role: "Dosen Wali", Priority: 5},
+		{Claim: "affiliation", Value: "student", Role: "Mahasiswa", Priority: 10},
+	}
+
+	t.Run("Array Claim Matches", func(t *testing.T) {
+		role, ok := oidc.MatchRole(rules, map[string]interface{}{"affiliation": []interface{}{"member", "student"}})
+		if !ok || role != "Mahasiswa" {
+			t.Errorf("Expected Mahasiswa, got %q (%v)", role, ok)
+		}
+	})
+
+	t.Run("Nested Claim And Priority", func(t *testing.T) {
+		claims := map[string]interface{}{
+			"affiliation":  "student",
+			"realm_access": map[string]interface{}{"roles": []interface{}{"lecturer"}},
+		}
+		if role, _ := oidc.MatchRole(rules, claims); role != "Dosen Wali" {
+			t.Errorf("Aturan dengan prioritas lebih kecil harus menang, got %q", role)
+		}
+	})
+
+	t.Run("No Match", func(t *testing.T) {
+		if _, ok := oidc.MatchRole(rules, map[string]interface{}{"affiliation": "alumni"}); ok {
+			t.Errorf("Claim yang tidak cocok tidak boleh menghasilkan role")
+		}
+	})
+}
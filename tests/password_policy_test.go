@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"testing"
+
+	"sistempelaporan/passwordpolicy"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+/* ============================================================
+   TEST PASSWORD POLICY
+   ============================================================
+*/
+
+func hasViolation(violations []passwordpolicy.Violation, code string) bool {
+	for _, v := range violations {
+		if v.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPasswordPolicyRules(t *testing.T) {
+	strict := passwordpolicy.Policy{
+		MinLength:          12,
+		RequireUppercase:   true,
+		RequireLowercase:   true,
+		RequireDigit:       true,
+		RequireSymbol:      true,
+		RejectCommon:       true,
+		RejectPersonalInfo: true,
+	}
+
+	cases := []struct {
+		name     string
+		password string
+		personal []string
+		wantCode string // kosong = password harus diterima
+	}{
+		{"Valid Strong Password", "Prestasi#Juara2024", []string{"budi", "2110511001"}, ""},
+		{"Too Short", "Ab1#xyz", nil, passwordpolicy.CodeTooShort},
+		{"Missing Uppercase", "prestasi#juara2024", nil, passwordpolicy.CodeUppercase},
+		{"Missing Lowercase", "PRESTASI#JUARA2024", nil, passwordpolicy.CodeLowercase},
+		{"Missing Digit", "Prestasi#JuaraSatu", nil, passwordpolicy.CodeDigit},
+		{"Missing Symbol", "PrestasiJuara2024", nil, passwordpolicy.CodeSymbol},
+		{"Contains Username", "Budi#Juara20245", []string{"budi"}, passwordpolicy.CodePersonalInfo},
+		{"Contains NIM", "Xx#2110511001yy", []string{"budi", "2110511001"}, passwordpolicy.CodePersonalInfo},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := strict.Validate(passwordpolicy.Candidate{Password: tc.password, PersonalInfo: tc.personal})
+			if tc.wantCode == "" {
+				if len(violations) != 0 {
+					t.Errorf("Password harus diterima, got %v", violations)
+				}
+				return
+			}
+			if !hasViolation(violations, tc.wantCode) {
+				t.Errorf("Expected pelanggaran %s, got %v", tc.wantCode, violations)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyCommonList(t *testing.T) {
+	policy := passwordpolicy.Policy{MinLength: 6, RejectCommon: true}
+
+	t.Run("Common Password Rejected Case Insensitive", func(t *testing.T) {
+		violations := policy.Validate(passwordpolicy.Candidate{Password: "Password123"})
+		if !hasViolation(violations, passwordpolicy.CodeCommon) {
+			t.Errorf("Password umum harus ditolak")
+		}
+	})
+
+	t.Run("Uncommon Password Accepted", func(t *testing.T) {
+		if passwordpolicy.IsCommon("kopi-tubruk-sore") {
+			t.Errorf("Password acak tidak boleh dianggap umum")
+		}
+	})
+}
+
+func TestPasswordPolicyHistory(t *testing.T) {
+	policy := passwordpolicy.Policy{MinLength: 6, HistoryCount: 2}
+
+	hash := func(p string) string {
+		h, _ := bcrypt.GenerateFromPassword([]byte(p), bcrypt.MinCost)
+		return string(h)
+	}
+	// Terbaru lebih dulu: saat ini, sebelumnya, lalu yang sudah di luar batas riwayat
+	previous := []string{hash("sekarang-1"), hash("kemarin-2"), hash("dulu-sekali-3")}
+
+	t.Run("Recent Password Rejected", func(t *testing.T) {
+		violations := policy.Validate(passwordpolicy.Candidate{Password: "kemarin-2", PreviousHashes: previous})
+		if !hasViolation(violations, passwordpolicy.CodeReused) {
+			t.Errorf("Password yang baru dipakai harus ditolak")
+		}
+	})
+
+	t.Run("Password Outside History Allowed", func(t *testing.T) {
+		violations := policy.Validate(passwordpolicy.Candidate{Password: "dulu-sekali-3", PreviousHashes: previous})
+		if len(violations) != 0 {
+			t.Errorf("Password di luar %d riwayat terakhir boleh dipakai lagi, got %v", policy.HistoryCount, violations)
+		}
+	})
+}
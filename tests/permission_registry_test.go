@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/repository"
+	"sistempelaporan/keymanager"
+	"sistempelaporan/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+/* ============================================================
+   TEST KATALOG PERMISSION (REGISTRASI OTOMATIS DARI CHECKPERMISSION)
+   ============================================================
+*/
+
+func TestCheckPermission_RegistersCatalogue(t *testing.T) {
+	handler := middleware.CheckPermission("report:export")
+
+	t.Run("Registered On Route Setup", func(t *testing.T) {
+		if !middleware.IsRegisteredPermission("report:export") {
+			t.Fatal("Permission dari CheckPermission harus tercatat di katalog")
+		}
+		found := false
+		for _, name := range middleware.RegisteredPermissions() {
+			if name == "report:export" {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("RegisteredPermissions harus memuat report:export")
+		}
+		if middleware.IsRegisteredPermission("report:unknown") {
+			t.Error("Permission yang tidak dipakai route tidak boleh tercatat")
+		}
+	})
+
+	app := fiber.New()
+	app.Get("/export", func(c *fiber.Ctx) error {
+		c.Locals("permissions", []string{c.Query("perm")})
+		return c.Next()
+	}, handler, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	cases := []struct {
+		name string
+		perm string
+		want int
+	}{
+		{"Has Permission", "report:export", fiber.StatusOK},
+		{"Missing Permission", "achievement:read", fiber.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", "/export?perm="+tc.perm, nil))
+			if err != nil {
+				t.Fatalf("Request error: %v", err)
+			}
+			if resp.StatusCode != tc.want {
+				t.Errorf("Expected %d, got %d", tc.want, resp.StatusCode)
+			}
+		})
+	}
+}
+
+/* ============================================================
+   TEST VERSI PERMISSION (CLAIM PV) DI MIDDLEWARE PROTECTED
+   ============================================================
+*/
+
+func TestProtected_RefreshesStalePermissions(t *testing.T) {
+	m, err := keymanager.New(keymanager.Config{Algorithm: keymanager.AlgHS256, StaticSecret: []byte("rahasia")}, nil)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	keymanager.SetDefault(m)
+	repository.SetRevocationStore(repository.NewMemoryRevocationStore())
+
+	// Role sudah di versi 3: achievement:create dicabut, achievement:read tetap
+	middleware.SetRolePermissionLoader(func(roleID string) (int, []string, error) {
+		return 3, []string{"achievement:read"}, nil
+	})
+	t.Cleanup(func() { middleware.SetRolePermissionLoader(repository.GetEffectiveRolePermissions) })
+
+	app := fiber.New()
+	app.Get("/probe", middleware.Protected(), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"permissions": c.Locals("permissions")})
+	})
+
+	probe := func(pv int) (string, string) {
+		signed, err := m.Sign(jwt.MapClaims{
+			"user_id":     "mahasiswa-1",
+			"role":        "Mahasiswa",
+			"role_id":     "role-mahasiswa",
+			"pv":          pv,
+			"permissions": []string{"achievement:read", "achievement:create"},
+			"token_type":  model.TokenTypeAccess,
+			"jti":         "jti-" + time.Now().Format(time.RFC3339Nano),
+			"exp":         time.Now().Add(15 * time.Minute).Unix(),
+		})
+		if err != nil {
+			t.Fatalf("Sign error: %v", err)
+		}
+		req := httptest.NewRequest("GET", "/probe", nil)
+		req.Header.Set("Authorization", "Bearer "+signed)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Request error: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected 200, got %d", resp.StatusCode)
+		}
+		var body struct {
+			Permissions []string `json:"permissions"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("Respon bukan JSON: %v", err)
+		}
+		return strings.Join(body.Permissions, ","), resp.Header.Get("X-Permissions-Stale")
+	}
+
+	t.Run("Old Version Uses Current Role Permissions", func(t *testing.T) {
+		perms, stale := probe(2)
+		if perms != "achievement:read" {
+			t.Errorf("Permission token lama harus diganti permission role terbaru, got %q", perms)
+		}
+		if stale != "true" {
+			t.Errorf("Header X-Permissions-Stale harus true, got %q", stale)
+		}
+	})
+
+	t.Run("Current Version Keeps Token Permissions", func(t *testing.T) {
+		perms, stale := probe(3)
+		if perms != "achievement:read,achievement:create" {
+			t.Errorf("Token versi terbaru memakai permission dari claim, got %q", perms)
+		}
+		if stale != "" {
+			t.Errorf("Header X-Permissions-Stale tidak boleh ada, got %q", stale)
+		}
+	})
+}
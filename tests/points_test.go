@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/points"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/* ============================================================
+   TEST ENGINE ATURAN POIN
+   ============================================================
+*/
+
+func mustDate(s string) *time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+var pointsRules = []model.PointsRule{
+	{Name: "Tingkat Internasional", Kind: model.PointsRuleBase, CompetitionTier: "internasional", Value: 100, Priority: 10, IsActive: true},
+	{Name: "Tingkat Nasional", Kind: model.PointsRuleBase, CompetitionTier: "nasional", Value: 50, Priority: 10, IsActive: true},
+	{Name: "Poin Dasar Lainnya", Kind: model.PointsRuleBase, Value: 10, Priority: 0, IsActive: true},
+	{Name: "Kompetisi Nasional Juara 1", Kind: model.PointsRuleBase, AchievementType: "competition", CompetitionTier: "nasional", Value: 80, Priority: 20, IsActive: true,
+		Conditions: []model.PointsCondition{{Field: "rank", Op: points.OpEq, Value: float64(1)}}},
+	{Name: "Bonus Juara", Kind: model.PointsRuleBonus, Value: 15, IsActive: true,
+		Conditions: []model.PointsCondition{{Field: "rank", Op: points.OpIn, Value: []interface{}{float64(1), float64(2), float64(3)}}}},
+	{Name: "Tim Besar", Kind: model.PointsRuleMultiplier, Value: 0.5, IsActive: true,
+		Conditions: []model.PointsCondition{{Field: "team.members", Op: points.OpGte, Value: float64(4)}}},
+	{Name: "Bonus Semester Genap 2024", Kind: model.PointsRuleBonus, Value: 5, IsActive: true,
+		ValidFrom: mustDate("2024-02-01"), ValidUntil: mustDate("2024-07-31")},
+	{Name: "Aturan Nonaktif", Kind: model.PointsRuleBonus, Value: 1000, IsActive: false},
+}
+
+func TestPointsCalculate(t *testing.T) {
+	created := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name        string
+		achievement model.AchievementMongo
+		want        int
+		wantRules   []string
+	}{
+		{"Internasional tanpa detail", model.AchievementMongo{CompetitionTier: "Internasional", CreatedAt: created},
+			100, []string{"Tingkat Internasional"}},
+		{"Tingkat lain memakai poin dasar", model.AchievementMongo{CompetitionTier: "regional", CreatedAt: created},
+			10, []string{"Poin Dasar Lainnya"}},
+		{"Base prioritas tertinggi menang", model.AchievementMongo{AchievementType: "competition", CompetitionTier: "nasional", CreatedAt: created,
+			Details: map[string]interface{}{"rank": int32(1)}},
+			95, []string{"Kompetisi Nasional Juara 1", "Bonus Juara"}},
+		{"Finalis tidak dapat bonus juara", model.AchievementMongo{AchievementType: "competition", CompetitionTier: "nasional", CreatedAt: created,
+			Details: map[string]interface{}{"rank": "finalist"}},
+			50, []string{"Tingkat Nasional"}},
+		{"Multiplier tim dari panjang array (dokumen bersarang MongoDB)", model.AchievementMongo{CompetitionTier: "internasional", CreatedAt: created,
+			Details: map[string]interface{}{"rank": int64(2), "team": primitive.D{{Key: "members", Value: primitive.A{"a", "b", "c", "d"}}}}},
+			58, []string{"Tingkat Internasional", "Bonus Juara", "Tim Besar"}},
+		{"Rentang tanggal dari details.eventDate", model.AchievementMongo{CompetitionTier: "nasional", CreatedAt: created,
+			Details: map[string]interface{}{"eventDate": "2024-07-31"}},
+			55, []string{"Tingkat Nasional", "Bonus Semester Genap 2024"}},
+		{"Di luar rentang tanggal", model.AchievementMongo{CompetitionTier: "nasional", CreatedAt: created,
+			Details: map[string]interface{}{"eventDate": "2024-08-01T10:00:00Z"}},
+			50, []string{"Tingkat Nasional"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := points.Calculate(pointsRules, tc.achievement)
+			if got.Total != tc.want {
+				t.Errorf("Total = %d, want %d (rincian %+v)", got.Total, tc.want, got.Items)
+			}
+			if len(got.Items) != len(tc.wantRules) {
+				t.Fatalf("Jumlah rincian = %d, want %d (%+v)", len(got.Items), len(tc.wantRules), got.Items)
+			}
+			for i, name := range tc.wantRules {
+				if got.Items[i].RuleName != name {
+					t.Errorf("Rincian[%d] = %s, want %s", i, got.Items[i].RuleName, name)
+				}
+			}
+		})
+	}
+}
+
+func TestPointsCalculateTanpaAturan(t *testing.T) {
+	got := points.Calculate(nil, model.AchievementMongo{CompetitionTier: "nasional"})
+	if got.Total != 0 || len(got.Items) != 0 {
+		t.Errorf("Tanpa aturan harus 0 poin, dapat %+v", got)
+	}
+}
+
+func TestPointsValidateRule(t *testing.T) {
+	valid := model.PointsRule{Name: "Juara", Kind: model.PointsRuleBonus, Value: 10,
+		Conditions: []model.PointsCondition{{Field: "rank", Op: points.OpLte, Value: float64(3)}}}
+	if errs := points.ValidateRule(valid); len(errs) != 0 {
+		t.Fatalf("aturan valid dianggap tidak valid: %v", errs)
+	}
+
+	cases := []struct {
+		name  string
+		rule  model.PointsRule
+		field string
+	}{
+		{"Tanpa nama", model.PointsRule{Kind: model.PointsRuleBase, Value: 1}, "name"},
+		{"Kind tidak dikenal", model.PointsRule{Name: "X", Kind: "penalty", Value: 1}, "kind"},
+		{"Base negatif", model.PointsRule{Name: "X", Kind: model.PointsRuleBase, Value: -5}, "value"},
+		{"Rentang tanggal terbalik", model.PointsRule{Name: "X", Kind: model.PointsRuleBonus, Value: 1,
+			ValidFrom: mustDate("2024-12-31"), ValidUntil: mustDate("2024-01-01")}, "valid_until"},
+		{"Operator tidak dikenal", model.PointsRule{Name: "X", Kind: model.PointsRuleBonus, Value: 1,
+			Conditions: []model.PointsCondition{{Field: "rank", Op: "like", Value: "1"}}}, "conditions[0].op"},
+		{"In bukan array", model.PointsRule{Name: "X", Kind: model.PointsRuleBonus, Value: 1,
+			Conditions: []model.PointsCondition{{Field: "rank", Op: points.OpIn, Value: "1"}}}, "conditions[0].value"},
+		{"Perbandingan bukan angka", model.PointsRule{Name: "X", Kind: model.PointsRuleBonus, Value: 1,
+			Conditions: []model.PointsCondition{{Field: "teamSize", Op: points.OpGt, Value: "banyak"}}}, "conditions[0].value"},
+	}
+	for _, tc := range cases {
+		if _, ok := points.ValidateRule(tc.rule)[tc.field]; !ok {
+			t.Errorf("[%s] ValidateRule tidak melaporkan field %s", tc.name, tc.field)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"sistempelaporan/app/policy"
+)
+
+/* ============================================================
+   TEST POLICY ENGINE (MATRIKS ROLE x RESOURCE x AKSI)
+   ============================================================
+*/
+
+const (
+	studentA  = "11111111-1111-1111-1111-111111111111"
+	studentB  = "22222222-2222-2222-2222-222222222222"
+	lecturerA = "33333333-3333-3333-3333-333333333333"
+	lecturerB = "44444444-4444-4444-4444-444444444444"
+)
+
+var (
+	mahasiswaPerms = []string{"achievement:create", "achievement:read", "achievement:update", "achievement:delete", "achievement:submit", "achievement:upload"}
+	dosenPerms     = []string{"achievement:read", "achievement:verify", "achievement:reject"}
+	adminPerms     = []string{"achievement:create", "achievement:read", "achievement:update", "achievement:delete", "achievement:submit", "achievement:upload", "achievement:verify", "achievement:reject", "user:manage"}
+
+	subjects = map[string]policy.Subject{
+		"admin":          {UserID: "u-admin", Admin: true, Permissions: adminPerms},
+		"mahasiswa":      {UserID: "u-mhs-a", StudentID: studentA, Permissions: mahasiswaPerms},
+		"mahasiswa_lain": {UserID: "u-mhs-b", StudentID: studentB, Permissions: mahasiswaPerms},
+		"dosen_wali":     {UserID: "u-dosen-a", LecturerID: lecturerA, Permissions: dosenPerms},
+		"dosen_lain":     {UserID: "u-dosen-b", LecturerID: lecturerB, Permissions: dosenPerms},
+		"admin_prodi": {UserID: "u-prodi", Grants: []policy.Grant{
+			{Unit: policy.Unit{Level: policy.ScopeProgram, Value: "Informatika"}, Permissions: []string{"achievement:read"}},
+		}},
+		"admin_fakultas": {UserID: "u-fakultas", Grants: []policy.Grant{
+			{Unit: policy.Unit{Level: policy.ScopeFaculty, Value: "FMIPA"}, Permissions: []string{"achievement:read", "achievement:verify", "achievement:reject", "user:manage"}},
+		}},
+		"admin_prodi_lain": {UserID: "u-prodi-lain", Grants: []policy.Grant{
+			{Unit: policy.Unit{Level: policy.ScopeProgram, Value: "Statistika"}, Permissions: adminPerms},
+		}},
+		"api_key_read": {Machine: true, Permissions: []string{"achievement:read"}},
+		// API key dengan permission baca data akademik eksplisit, tetap tanpa akses ke akun user
+		"api_key_akademik": {Machine: true, Permissions: []string{"student:read", "lecturer:read", "user:manage"}},
+		"tanpa_relasi":     {UserID: "u-tamu", Permissions: mahasiswaPerms},
+	}
+
+	// Prestasi milik mahasiswa A (prodi Informatika, departemen Ilmu Komputer, FMIPA) dengan dosen wali A
+	achievementA = policy.Resource{Kind: policy.ResourceAchievement, StudentID: studentA, AdvisorID: lecturerA, Program: "Informatika", Department: "Ilmu Komputer", Faculty: "FMIPA"}
+	studentResA  = policy.Resource{Kind: policy.ResourceStudent, StudentID: studentA, AdvisorID: lecturerA, Program: "Informatika", Department: "Ilmu Komputer", Faculty: "FMIPA"}
+	lecturerResA = policy.Resource{Kind: policy.ResourceLecturer, LecturerID: lecturerA, Department: "Ilmu Komputer", Faculty: "FMIPA"}
+	userResMhsA  = policy.Resource{Kind: policy.ResourceUser, UserID: "u-mhs-a"}
+)
+
+func TestPolicyMatrix(t *testing.T) {
+	// Subject yang diizinkan per (resource, aksi); subject lain harus ditolak
+	cases := []struct {
+		name     string
+		resource policy.Resource
+		action   policy.Action
+		allowed  []string
+	}{
+		{"Achievement Read", achievementA, policy.ActionRead, []string{"admin", "mahasiswa", "dosen_wali", "admin_prodi", "admin_fakultas", "api_key_read"}},
+		{"Achievement Create", achievementA, policy.ActionCreate, []string{"admin", "mahasiswa"}},
+		{"Achievement Update", achievementA, policy.ActionUpdate, []string{"admin", "mahasiswa"}},
+		{"Achievement Delete", achievementA, policy.ActionDelete, []string{"admin", "mahasiswa"}},
+		{"Achievement Submit", achievementA, policy.ActionSubmit, []string{"admin", "mahasiswa"}},
+		{"Achievement Upload", achievementA, policy.ActionUpload, []string{"admin", "mahasiswa"}},
+		{"Achievement Verify", achievementA, policy.ActionVerify, []string{"admin", "dosen_wali", "admin_fakultas"}},
+		{"Achievement Reject", achievementA, policy.ActionReject, []string{"admin", "dosen_wali", "admin_fakultas"}},
+		{"Student Read", studentResA, policy.ActionRead, []string{"admin", "mahasiswa", "dosen_wali", "admin_prodi", "admin_fakultas", "api_key_akademik"}},
+		{"Student Manage", studentResA, policy.ActionManage, []string{"admin", "admin_fakultas", "api_key_akademik"}},
+		{"Lecturer Read", lecturerResA, policy.ActionRead, []string{"admin", "dosen_wali", "admin_fakultas", "api_key_akademik"}},
+		// API key tidak pernah lolos relasi self, walaupun memiliki user:manage
+		{"User Read Self", userResMhsA, policy.ActionRead, []string{"admin", "mahasiswa"}},
+		{"User Update Self", userResMhsA, policy.ActionUpdate, []string{"admin", "mahasiswa"}},
+		{"Unknown Action", achievementA, policy.Action("publish"), nil},
+	}
+
+	for _, tc := range cases {
+		allowed := map[string]bool{}
+		for _, name := range tc.allowed {
+			allowed[name] = true
+		}
+		for name, subject := range subjects {
+			t.Run(tc.name+"/"+name, func(t *testing.T) {
+				decision := policy.Can(subject, tc.action, tc.resource)
+				if decision.Allowed != allowed[name] {
+					t.Errorf("%s %s oleh %s: allowed = %v, want %v (%s)",
+						tc.action, tc.resource.Kind, name, decision.Allowed, allowed[name], decision.Reason)
+				}
+				if !decision.Allowed && decision.Reason == "" {
+					t.Error("Penolakan harus menyertakan alasan")
+				}
+			})
+		}
+	}
+}
+
+func TestPolicyDecisionRelation(t *testing.T) {
+	if d := policy.Can(subjects["dosen_wali"], policy.ActionVerify, achievementA); d.Relation != policy.RelationAdvisor {
+		t.Errorf("Verifikasi dosen wali harus lewat relasi advisor, got %q", d.Relation)
+	}
+	if d := policy.Can(subjects["admin_prodi"], policy.ActionRead, achievementA); d.Relation != policy.RelationUnitAdmin {
+		t.Errorf("Admin prodi harus lewat relasi unit_admin, got %q", d.Relation)
+	}
+}
+
+func TestPolicyListScope(t *testing.T) {
+	globalOperator := policy.Subject{UserID: "u-operator", Grants: []policy.Grant{
+		{Permissions: []string{"achievement:read"}},
+	}}
+
+	cases := []struct {
+		name       string
+		subject    policy.Subject
+		kind       policy.ResourceKind
+		permission string
+		want       policy.Scope
+		ok         bool
+	}{
+		{"admin", subjects["admin"], policy.ResourceAchievement, "achievement:read", policy.Scope{All: true}, true},
+		{"api_key_read", subjects["api_key_read"], policy.ResourceAchievement, "achievement:read", policy.Scope{All: true}, true},
+		{"api_key_read_mahasiswa", subjects["api_key_read"], policy.ResourceStudent, "", policy.Scope{}, false},
+		{"api_key_akademik_mahasiswa", subjects["api_key_akademik"], policy.ResourceStudent, "", policy.Scope{All: true}, true},
+		{"api_key_akademik_prestasi", subjects["api_key_akademik"], policy.ResourceAchievement, "achievement:read", policy.Scope{}, false},
+		{"mahasiswa", subjects["mahasiswa"], policy.ResourceAchievement, "achievement:read", policy.Scope{StudentID: studentA}, true},
+		{"dosen_wali", subjects["dosen_wali"], policy.ResourceAchievement, "achievement:read", policy.Scope{AdvisorID: lecturerA}, true},
+		{"admin_prodi", subjects["admin_prodi"], policy.ResourceAchievement, "achievement:read",
+			policy.Scope{Units: []policy.Unit{{Level: policy.ScopeProgram, Value: "Informatika"}}}, true},
+		{"admin_fakultas_mahasiswa", subjects["admin_fakultas"], policy.ResourceStudent, "",
+			policy.Scope{Units: []policy.Unit{{Level: policy.ScopeFaculty, Value: "FMIPA"}}}, true},
+		{"admin_fakultas_tanpa_permission", subjects["admin_fakultas"], policy.ResourceLecturer, "user:read_all", policy.Scope{}, false},
+		{"admin_prodi_dosen", subjects["admin_prodi"], policy.ResourceLecturer, "", policy.Scope{}, false},
+		{"mahasiswa_daftar_mahasiswa", subjects["mahasiswa"], policy.ResourceStudent, "", policy.Scope{}, false},
+		{"operator_global", globalOperator, policy.ResourceAchievement, "achievement:read", policy.Scope{All: true}, true},
+		{"tanpa_relasi", subjects["tanpa_relasi"], policy.ResourceAchievement, "achievement:read", policy.Scope{}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			scope, ok := policy.ListScope(tc.subject, tc.kind, tc.permission)
+			if ok != tc.ok || !reflect.DeepEqual(scope, tc.want) {
+				t.Errorf("ListScope(%s) = %+v, %v; want %+v, %v", tc.name, scope, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func TestPolicyScopedPermission(t *testing.T) {
+	operator := subjects["admin_fakultas"]
+	if !operator.HasScopedPermission("achievement:verify") {
+		t.Error("Permission dari role assignment harus terhitung oleh HasScopedPermission")
+	}
+	if operator.HasPermission("achievement:verify") {
+		t.Error("Permission dari role assignment tidak boleh menjadi permission global")
+	}
+	if operator.HasScopedPermission("role:manage") {
+		t.Error("Permission yang tidak dimiliki role assignment tidak boleh lolos")
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/queue"
+)
+
+/* ============================================================
+   TEST ANTREAN VERIFIKASI (BADGE SLA & URUTAN)
+   ============================================================
+*/
+
+func TestQueueBadge(t *testing.T) {
+	now := time.Date(2024, 10, 10, 12, 0, 0, 0, time.UTC)
+	sla := 72 * time.Hour
+	cases := []struct {
+		waited time.Duration
+		want   string
+	}{
+		{0, model.QueueBadgeFresh},
+		{35 * time.Hour, model.QueueBadgeFresh},
+		{36 * time.Hour, model.QueueBadgeDueSoon},
+		{71 * time.Hour, model.QueueBadgeDueSoon},
+		{72 * time.Hour, model.QueueBadgeOverdue},
+		{200 * time.Hour, model.QueueBadgeOverdue},
+	}
+	for _, tc := range cases {
+		if got := queue.Badge(now.Add(-tc.waited), now, sla); got != tc.want {
+			t.Errorf("Badge(menunggu %v) = %s, want %s", tc.waited, got, tc.want)
+		}
+	}
+
+	item := model.VerificationQueueItem{WaitingSince: now.Add(-80 * time.Hour)}
+	queue.Apply(&item, now, sla)
+	if item.WaitingHours != 80 || !item.SLADueAt.Equal(now.Add(-8*time.Hour)) || item.Badge != model.QueueBadgeOverdue {
+		t.Errorf("Apply = %+v", item)
+	}
+}
+
+func queueTitles(items []model.VerificationQueueItem) []string {
+	titles := make([]string, len(items))
+	for i, item := range items {
+		titles[i] = item.Title
+	}
+	return titles
+}
+
+func TestQueueSort(t *testing.T) {
+	base := time.Date(2024, 10, 1, 8, 0, 0, 0, time.UTC)
+	items := func() []model.VerificationQueueItem {
+		return []model.VerificationQueueItem{
+			{Title: "A", StudentName: "citra", CompetitionTier: "nasional", WaitingSince: base.Add(2 * time.Hour)},
+			{Title: "B", StudentName: "Budi", CompetitionTier: "internasional", WaitingSince: base.Add(3 * time.Hour)},
+			{Title: "C", StudentName: "Andi", CompetitionTier: "nasional", WaitingSince: base},
+			{Title: "D", StudentName: "Dewi", CompetitionTier: "", WaitingSince: base.Add(time.Hour)},
+		}
+	}
+
+	cases := []struct {
+		by, order string
+		want      []string
+	}{
+		{"", "", []string{"C", "D", "A", "B"}},           // default: paling lama menunggu dulu
+		{"waiting", "asc", []string{"B", "A", "D", "C"}}, // paling baru dulu
+		{"tier", "", []string{"B", "C", "A", "D"}},       // tingkat tertinggi, setara -> paling lama dulu
+		{"tier", "asc", []string{"D", "C", "A", "B"}},
+		{"student", "", []string{"C", "B", "A", "D"}}, // nama A-Z tanpa membedakan huruf besar
+		{"student", "desc", []string{"D", "A", "B", "C"}},
+	}
+	for _, tc := range cases {
+		list := items()
+		if err := queue.Sort(list, tc.by, tc.order); err != nil {
+			t.Fatalf("Sort(%s, %s) error: %v", tc.by, tc.order, err)
+		}
+		got := queueTitles(list)
+		for i := range tc.want {
+			if got[i] != tc.want[i] {
+				t.Errorf("Sort(%s, %s) = %v, want %v", tc.by, tc.order, got, tc.want)
+				break
+			}
+		}
+	}
+
+	if err := queue.Sort(items(), "points", ""); err == nil {
+		t.Error("kunci urutan tidak dikenal seharusnya ditolak")
+	}
+	if err := queue.Sort(items(), "waiting", "up"); err == nil {
+		t.Error("arah urutan tidak dikenal seharusnya ditolak")
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"testing"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/revision"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/* ============================================================
+   TEST DIFF REVISI PRESTASI
+   ============================================================
+*/
+
+func TestRevisionDiff(t *testing.T) {
+	sertifikat := model.Attachment{FileName: "sertifikat.pdf", FileURL: "/uploads/a.pdf", Category: "certificate"}
+	foto := model.Attachment{FileName: "foto.jpg", FileURL: "/uploads/b.jpg"}
+
+	old := model.AchievementSnapshot{
+		Title:           "Juara 2 Gemastik",
+		Description:     "Kategori UX",
+		AchievementType: "competition",
+		CompetitionTier: "nasional",
+		Details: map[string]interface{}{
+			"rank":      int32(2),
+			"organizer": "Puspresnas",
+			"team":      primitive.D{{Key: "name", Value: "Tim A"}, {Key: "members", Value: primitive.A{"andi", "budi"}}},
+		},
+		Tags:        []string{"ux", "design"},
+		Attachments: []model.Attachment{sertifikat, foto},
+	}
+	new := model.AchievementSnapshot{
+		Title:           "Juara 1 Gemastik",
+		AchievementType: "competition",
+		CompetitionTier: "nasional",
+		Details: map[string]interface{}{
+			"rank":      float64(1),
+			"eventDate": "2024-10-12",
+			"team":      map[string]interface{}{"name": "Tim A", "members": []interface{}{"andi", "budi", "caca"}},
+		},
+		Tags:        []string{"ux", "mobile"},
+		Attachments: []model.Attachment{{FileName: "sertifikat-final.pdf", FileURL: "/uploads/a.pdf", Category: "certificate"}},
+	}
+
+	want := []struct{ field, change string }{
+		{"title", model.ChangeModified},
+		{"description", model.ChangeRemoved},
+		{"details.eventDate", model.ChangeAdded},
+		{"details.organizer", model.ChangeRemoved},
+		{"details.rank", model.ChangeModified},
+		{"details.team.members", model.ChangeModified},
+		{"tags", model.ChangeRemoved},
+		{"tags", model.ChangeAdded},
+		{"attachments", model.ChangeRemoved},
+		{"attachments", model.ChangeModified},
+	}
+
+	got := revision.Diff(old, new)
+	if len(got) != len(want) {
+		t.Fatalf("Jumlah perubahan = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Field != w.field || got[i].Change != w.change {
+			t.Errorf("Perubahan[%d] = %s %s, want %s %s", i, got[i].Field, got[i].Change, w.field, w.change)
+		}
+	}
+	if got[6].Old != "design" || got[7].New != "mobile" {
+		t.Errorf("Perubahan tags tidak sesuai: %+v %+v", got[6], got[7])
+	}
+	if att, _ := got[8].Old.(model.Attachment); att.FileURL != foto.FileURL {
+		t.Errorf("Lampiran yang dihapus = %+v, want %s", got[8].Old, foto.FileURL)
+	}
+}
+
+func TestRevisionDiffTanpaPerubahan(t *testing.T) {
+	snapshot := model.AchievementSnapshot{
+		Title:   "Sertifikasi AWS",
+		Details: map[string]interface{}{"issuer": "AWS", "score": int64(900)},
+		Tags:    []string{"cloud"},
+	}
+	same := snapshot
+	same.Details = map[string]interface{}{"issuer": "AWS", "score": float64(900)}
+
+	if changes := revision.Diff(snapshot, same); len(changes) != 0 {
+		t.Errorf("Snapshot identik (beda tipe angka) tidak boleh punya perubahan: %+v", changes)
+	}
+}
+
+func TestRevisionDiffRevisiPertama(t *testing.T) {
+	changes := revision.Diff(model.AchievementSnapshot{}, model.AchievementSnapshot{Title: "Paten", Tags: []string{"hki"}})
+	if len(changes) != 2 || changes[0].Change != model.ChangeAdded || changes[1].Field != "tags" {
+		t.Errorf("Revisi pertama harus berisi field yang ditambahkan, dapat %+v", changes)
+	}
+}
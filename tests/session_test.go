@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/service"
+	"sistempelaporan/app/session"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+/* ============================================================
+   TEST ROTASI REFRESH TOKEN & DETEKSI REUSE
+   ============================================================
+*/
+
+func TestSessionDecideRotation(t *testing.T) {
+	now := time.Date(2024, 10, 10, 8, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	future := now.Add(24 * time.Hour)
+
+	cases := map[string]struct {
+		usedAt, revokedAt *time.Time
+		expiresAt         time.Time
+		want              session.Rotation
+	}{
+		"token baru, sesi aktif":       {nil, nil, future, session.RotationAllowed},
+		"token sudah dipakai (reuse)":  {&earlier, nil, future, session.RotationReused},
+		"reuse pada sesi kadaluarsa":   {&earlier, nil, earlier, session.RotationReused},
+		"sesi sudah dicabut":           {nil, &earlier, future, session.RotationRevoked},
+		"reuse pada sesi yang dicabut": {&earlier, &earlier, future, session.RotationRevoked},
+		"sesi kadaluarsa":              {nil, nil, earlier, session.RotationExpired},
+		"tepat saat kadaluarsa":        {nil, nil, now, session.RotationAllowed},
+	}
+	for name, tc := range cases {
+		if got := session.DecideRotation(tc.usedAt, tc.revokedAt, tc.expiresAt, now); got != tc.want {
+			t.Errorf("%s: DecideRotation = %v, want %v", name, got, tc.want)
+		}
+	}
+}
+
+/* ============================================================
+   TEST KEPEMILIKAN SESI
+   ============================================================
+*/
+
+func TestSessionRevocable(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	revokedAt := time.Now()
+	active := model.Session{ID: uuid.New(), UserID: owner}
+	revoked := model.Session{ID: uuid.New(), UserID: owner, RevokedAt: &revokedAt}
+
+	if !session.Revocable(active, owner.String()) {
+		t.Error("pemilik harus boleh mencabut sesinya sendiri")
+	}
+	if session.Revocable(active, other.String()) {
+		t.Error("user lain tidak boleh mencabut sesi milik orang lain")
+	}
+	if session.Revocable(revoked, owner.String()) {
+		t.Error("sesi yang sudah dicabut tidak bisa dicabut lagi")
+	}
+
+	sessions := []model.Session{active, {ID: uuid.New(), UserID: owner}}
+	session.MarkCurrent(sessions, active.ID.String())
+	if !sessions[0].Current || sessions[1].Current {
+		t.Errorf("MarkCurrent hanya menandai sesi token saat ini, dapat %v / %v", sessions[0].Current, sessions[1].Current)
+	}
+}
+
+func TestSessionEndpointsInvalidID(t *testing.T) {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", uuid.NewString())
+		return c.Next()
+	})
+	app.Delete("/auth/sessions/:id", service.RevokeMySession)
+	app.Get("/users/:id/sessions", service.GetUserSessions)
+	app.Delete("/users/:id/sessions", service.RevokeAllUserSessions)
+	app.Delete("/users/:id/sessions/:sessionId", service.RevokeUserSession)
+
+	cases := []struct{ method, path string }{
+		{"DELETE", "/auth/sessions/bukan-uuid"},
+		{"GET", "/users/bukan-uuid/sessions"},
+		{"DELETE", "/users/bukan-uuid/sessions"},
+		{"DELETE", "/users/" + uuid.NewString() + "/sessions/bukan-uuid"},
+	}
+	for _, tc := range cases {
+		resp, err := app.Test(httptest.NewRequest(tc.method, tc.path, nil))
+		if err != nil {
+			t.Fatalf("%s %s: request error: %v", tc.method, tc.path, err)
+		}
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("%s %s: status = %d, want 404", tc.method, tc.path, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"sistempelaporan/app/repository"
+)
+
+/* ============================================================
+   TEST REVOCATION STORE (IMPLEMENTASI IN-MEMORY)
+   ============================================================
+*/
+
+func TestMemoryRevocationStore(t *testing.T) {
+	store := repository.NewMemoryRevocationStore()
+
+	t.Run("Revoked Token Detected", func(t *testing.T) {
+		store.Revoke("jti-aktif", time.Now().Add(time.Hour))
+
+		revoked, err := store.IsRevoked("jti-aktif")
+		if err != nil || !revoked {
+			t.Errorf("Token harusnya tercatat sebagai revoked")
+		}
+	})
+
+	t.Run("Unknown Token Not Revoked", func(t *testing.T) {
+		revoked, _ := store.IsRevoked("jti-lain")
+		if revoked {
+			t.Errorf("Token yang tidak dicabut tidak boleh dianggap revoked")
+		}
+	})
+
+	t.Run("Sweeper Removes Expired Entries", func(t *testing.T) {
+		store.Revoke("jti-kadaluarsa", time.Now().Add(-time.Minute))
+
+		deleted, err := store.DeleteExpired()
+		if err != nil {
+			t.Fatalf("DeleteExpired error: %v", err)
+		}
+		if deleted != 1 {
+			t.Errorf("Expected 1 entri terhapus, got %d", deleted)
+		}
+
+		revoked, _ := store.IsRevoked("jti-aktif")
+		if !revoked {
+			t.Errorf("Entri yang belum kadaluarsa tidak boleh ikut terhapus")
+		}
+	})
+}
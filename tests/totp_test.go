@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"sistempelaporan/helper"
+)
+
+/* ============================================================
+   TEST TOTP (RFC 6238, SHA-1, 6 DIGIT)
+   ============================================================
+*/
+
+// Secret ASCII "12345678901234567890" dalam base32 (vektor uji RFC 6238)
+const rfcTestSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTP_RFC6238Vectors(t *testing.T) {
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tc := range cases {
+		got, err := helper.TOTPCodeAt(rfcTestSecret, helper.TOTPStep(time.Unix(tc.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCodeAt error: %v", err)
+		}
+		if got != tc.code {
+			t.Errorf("T=%d: expected %s, got %s", tc.unix, tc.code, got)
+		}
+	}
+}
+
+func TestTOTP_MatchWithSkew(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	previous, _ := helper.TOTPCodeAt(rfcTestSecret, helper.TOTPStep(now)-1)
+
+	t.Run("Previous Step Accepted With Skew", func(t *testing.T) {
+		step, ok := helper.MatchTOTP(rfcTestSecret, previous, now, 1)
+		if !ok || step != helper.TOTPStep(now)-1 {
+			t.Errorf("Kode dari step sebelumnya harus diterima dengan skew 1")
+		}
+	})
+
+	t.Run("Previous Step Rejected Without Skew", func(t *testing.T) {
+		if _, ok := helper.MatchTOTP(rfcTestSecret, previous, now, 0); ok {
+			t.Errorf("Kode dari step sebelumnya harus ditolak tanpa skew")
+		}
+	})
+
+	t.Run("Wrong Code Rejected", func(t *testing.T) {
+		if _, ok := helper.MatchTOTP(rfcTestSecret, "000000", now, 1); ok {
+			t.Errorf("Kode salah tidak boleh diterima")
+		}
+	})
+}
+
+func TestTOTP_SecretAndURI(t *testing.T) {
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret error: %v", err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("Secret 160-bit harus 32 karakter base32, got %d", len(secret))
+	}
+
+	uri := helper.TOTPAuthURI("Sistem Pelaporan", "dosenwali1", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("URI otpauth tidak valid: %s", uri)
+	}
+
+	codes, _ := helper.GenerateRecoveryCodes(10)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("Format recovery code salah: %s", code)
+		}
+		seen[code] = true
+	}
+	if len(seen) != 10 {
+		t.Errorf("Recovery code harus unik")
+	}
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"testing"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/app/workflow"
+
+	"github.com/google/uuid"
+)
+
+/* ============================================================
+   TEST PIPELINE VERIFIKASI BERTINGKAT
+   ============================================================
+*/
+
+var (
+	kemahasiswaanRoleID = uuid.MustParse("55555555-5555-5555-5555-555555555555")
+
+	advisorStage = model.VerificationStage{
+		Position: 1, Name: "Dosen Wali", ApproverType: model.ApproverAdvisor, RequiredApprovals: 1,
+	}
+	facultyStage = model.VerificationStage{
+		Position: 2, Name: "Kemahasiswaan Fakultas", ApproverType: model.ApproverRole,
+		ApproverRoleID: &kemahasiswaanRoleID, ApproverRole: "Kemahasiswaan Fakultas",
+		ScopeLevel: string(policy.ScopeFaculty), RequiredApprovals: 2,
+	}
+	highTierPipeline = model.VerificationPipeline{
+		Name: "Prestasi Tingkat Tinggi", CompetitionTiers: []string{"internasional", "nasional"},
+		Stages: []model.VerificationStage{advisorStage, facultyStage},
+	}
+
+	kemahasiswaanPerms = []string{"achievement:read", "achievement:verify", "achievement:reject"}
+)
+
+func TestCanApproveStage(t *testing.T) {
+	stageSubjects := map[string]policy.Subject{
+		"kemahasiswaan_fmipa": {UserID: "u-kmhs", Grants: []policy.Grant{
+			{Unit: policy.Unit{Level: policy.ScopeFaculty, Value: "FMIPA"}, Role: "Kemahasiswaan Fakultas", Permissions: kemahasiswaanPerms},
+		}},
+		"kemahasiswaan_lain": {UserID: "u-kmhs-lain", Grants: []policy.Grant{
+			{Unit: policy.Unit{Level: policy.ScopeFaculty, Value: "FEB"}, Role: "Kemahasiswaan Fakultas", Permissions: kemahasiswaanPerms},
+		}},
+		// Role yang benar tetapi di-assign pada tingkat prodi, bukan fakultas
+		"kemahasiswaan_prodi": {UserID: "u-kmhs-prodi", Grants: []policy.Grant{
+			{Unit: policy.Unit{Level: policy.ScopeProgram, Value: "Informatika"}, Role: "Kemahasiswaan Fakultas", Permissions: kemahasiswaanPerms},
+		}},
+		"kemahasiswaan_tanpa_verify": {UserID: "u-kmhs-read", Grants: []policy.Grant{
+			{Unit: policy.Unit{Level: policy.ScopeFaculty, Value: "FMIPA"}, Role: "Kemahasiswaan Fakultas", Permissions: []string{"achievement:read"}},
+		}},
+		"api_key_verify": {Machine: true, Permissions: []string{"achievement:verify"}},
+	}
+	for name, s := range subjects {
+		stageSubjects[name] = s
+	}
+
+	cases := []struct {
+		name    string
+		stage   model.VerificationStage
+		allowed []string
+	}{
+		{"Tahap Dosen Wali", advisorStage, []string{"admin", "dosen_wali", "api_key_verify"}},
+		{"Tahap Kemahasiswaan Fakultas", facultyStage, []string{"admin", "kemahasiswaan_fmipa", "api_key_verify"}},
+	}
+
+	for _, tc := range cases {
+		allowed := map[string]bool{}
+		for _, name := range tc.allowed {
+			allowed[name] = true
+		}
+		for name, s := range stageSubjects {
+			if got := workflow.CanApproveStage(s, tc.stage, achievementA); got != allowed[name] {
+				t.Errorf("[%s] CanApproveStage(%s) = %v, want %v", tc.name, name, got, allowed[name])
+			}
+		}
+	}
+}
+
+func TestPipelineStageEvent(t *testing.T) {
+	if got := workflow.StageEvent(highTierPipeline, advisorStage); got != workflow.EventApproveStage {
+		t.Errorf("StageEvent(tahap 1) = %s, want %s", got, workflow.EventApproveStage)
+	}
+	if got := workflow.StageEvent(highTierPipeline, facultyStage); got != workflow.EventVerify {
+		t.Errorf("StageEvent(tahap 2) = %s, want %s", got, workflow.EventVerify)
+	}
+
+	// Pipeline default: satu tahap dosen wali yang langsung memverifikasi
+	def := workflow.DefaultPipeline()
+	stage, ok := def.Stage(1)
+	if !ok || stage.ApproverType != model.ApproverAdvisor {
+		t.Fatalf("DefaultPipeline tahap 1 = %+v, ok %v", stage, ok)
+	}
+	if got := workflow.StageEvent(def, stage); got != workflow.EventVerify {
+		t.Errorf("StageEvent(default) = %s, want %s", got, workflow.EventVerify)
+	}
+	if _, ok := def.Stage(2); ok {
+		t.Error("DefaultPipeline tidak boleh memiliki tahap 2")
+	}
+}
+
+func TestCountApprovers(t *testing.T) {
+	dosenA, dosenB, apiKey := uuid.New(), uuid.New(), uuid.New()
+	user := func(id uuid.UUID) model.AchievementStageApproval {
+		return model.AchievementStageApproval{ApproverID: &id}
+	}
+	key := func(id uuid.UUID) model.AchievementStageApproval {
+		return model.AchievementStageApproval{APIKeyID: &id}
+	}
+
+	cases := []struct {
+		name      string
+		approvals []model.AchievementStageApproval
+		want      int
+	}{
+		{"Dua user berbeda", []model.AchievementStageApproval{user(dosenA), user(dosenB)}, 2},
+		// Satu API key tidak boleh memenuhi quorum sendirian
+		{"API key yang sama berulang", []model.AchievementStageApproval{key(apiKey), key(apiKey), key(apiKey)}, 1},
+		{"User dan API key", []model.AchievementStageApproval{user(dosenA), key(apiKey)}, 2},
+		{"Penyetuju sudah dihapus", []model.AchievementStageApproval{{}, {}, user(dosenA)}, 3},
+	}
+	for _, tc := range cases {
+		if got := workflow.CountApprovers(tc.approvals); got != tc.want {
+			t.Errorf("%s: CountApprovers = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestValidatePipeline(t *testing.T) {
+	if errs := workflow.ValidatePipeline(highTierPipeline); len(errs) != 0 {
+		t.Fatalf("pipeline valid dianggap tidak valid: %v", errs)
+	}
+
+	cases := []struct {
+		name  string
+		stage model.VerificationStage
+		field string
+	}{
+		{"Dosen wali dengan quorum", model.VerificationStage{Position: 1, Name: "Wali", ApproverType: model.ApproverAdvisor, RequiredApprovals: 2}, "stages[0].required_approvals"},
+		{"Role tanpa role_id", model.VerificationStage{Position: 1, Name: "Fakultas", ApproverType: model.ApproverRole, RequiredApprovals: 1}, "stages[0].approver_role_id"},
+		{"Scope tidak dikenal", model.VerificationStage{Position: 1, Name: "Fakultas", ApproverType: model.ApproverRole, ApproverRoleID: &kemahasiswaanRoleID, ScopeLevel: "kampus", RequiredApprovals: 1}, "stages[0].scope_level"},
+		{"Jenis penyetuju tidak dikenal", model.VerificationStage{Position: 1, Name: "Rektor", ApproverType: "rektor", RequiredApprovals: 1}, "stages[0].approver_type"},
+		{"Quorum nol", model.VerificationStage{Position: 1, Name: "Fakultas", ApproverType: model.ApproverRole, ApproverRoleID: &kemahasiswaanRoleID, RequiredApprovals: 0}, "stages[0].required_approvals"},
+		{"Posisi tidak berurutan", model.VerificationStage{Position: 3, Name: "Wali", ApproverType: model.ApproverAdvisor, RequiredApprovals: 1}, "stages[0].position"},
+	}
+	for _, tc := range cases {
+		p := model.VerificationPipeline{Name: "Uji", Stages: []model.VerificationStage{tc.stage}}
+		if _, ok := workflow.ValidatePipeline(p)[tc.field]; !ok {
+			t.Errorf("[%s] ValidatePipeline tidak melaporkan field %s", tc.name, tc.field)
+		}
+	}
+
+	if _, ok := workflow.ValidatePipeline(model.VerificationPipeline{Name: "Kosong"})["stages"]; !ok {
+		t.Error("Pipeline tanpa tahap harus ditolak")
+	}
+}
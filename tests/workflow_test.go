@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"sistempelaporan/app/model"
+	"sistempelaporan/app/policy"
+	"sistempelaporan/app/workflow"
+)
+
+/* ============================================================
+   TEST STATE MACHINE STATUS PRESTASI
+   ============================================================
+*/
+
+func TestWorkflowTransition(t *testing.T) {
+	cases := []struct {
+		name    string
+		from    model.AchievementStatus
+		event   workflow.Event
+		actor   workflow.Actor
+		want    model.AchievementStatus
+		wantErr error
+	}{
+		{"Draft diajukan pemilik", model.StatusDraft, workflow.EventSubmit, workflow.ActorOwner, model.StatusSubmitted, nil},
+		{"Submitted diverifikasi dosen wali", model.StatusSubmitted, workflow.EventVerify, workflow.ActorVerifier, model.StatusVerified, nil},
+		{"Submitted ditolak admin", model.StatusSubmitted, workflow.EventReject, workflow.ActorAdmin, model.StatusRejected, nil},
+		{"Submitted diminta revisi", model.StatusSubmitted, workflow.EventRequestRevision, workflow.ActorVerifier, model.StatusRevisionRequested, nil},
+		{"Revisi diajukan ulang", model.StatusRevisionRequested, workflow.EventSubmit, workflow.ActorOwner, model.StatusSubmitted, nil},
+		{"Ditolak diperbaiki lalu diajukan ulang", model.StatusRejected, workflow.EventSubmit, workflow.ActorOwner, model.StatusSubmitted, nil},
+		{"Submitted ditarik pemilik", model.StatusSubmitted, workflow.EventWithdraw, workflow.ActorOwner, model.StatusWithdrawn, nil},
+		{"Withdrawn dibuka kembali", model.StatusWithdrawn, workflow.EventReopen, workflow.ActorOwner, model.StatusDraft, nil},
+		{"Tahap pertama selesai", model.StatusSubmitted, workflow.EventApproveStage, workflow.ActorVerifier, model.StatusInReview, nil},
+		{"Tahap lanjutan selesai", model.StatusInReview, workflow.EventApproveStage, workflow.ActorAdmin, model.StatusInReview, nil},
+		{"Tahap terakhir memverifikasi", model.StatusInReview, workflow.EventVerify, workflow.ActorAdmin, model.StatusVerified, nil},
+		{"In review ditolak", model.StatusInReview, workflow.EventReject, workflow.ActorAdmin, model.StatusRejected, nil},
+		{"In review ditarik pemilik", model.StatusInReview, workflow.EventWithdraw, workflow.ActorOwner, model.StatusWithdrawn, nil},
+		{"Verified diarsipkan admin", model.StatusVerified, workflow.EventArchive, workflow.ActorAdmin, model.StatusArchived, nil},
+
+		{"Draft tidak bisa langsung diverifikasi", model.StatusDraft, workflow.EventVerify, workflow.ActorVerifier, model.StatusDraft, workflow.ErrInvalidTransition},
+		{"Rejected tidak bisa diverifikasi", model.StatusRejected, workflow.EventVerify, workflow.ActorAdmin, model.StatusRejected, workflow.ErrInvalidTransition},
+		{"Verified tidak bisa ditolak", model.StatusVerified, workflow.EventReject, workflow.ActorVerifier, model.StatusVerified, workflow.ErrInvalidTransition},
+		{"Archived final", model.StatusArchived, workflow.EventReopen, workflow.ActorAdmin, model.StatusArchived, workflow.ErrInvalidTransition},
+		{"Pemilik tidak bisa memverifikasi", model.StatusSubmitted, workflow.EventVerify, workflow.ActorOwner, model.StatusSubmitted, workflow.ErrActorNotAllowed},
+		{"Dosen wali tidak bisa mengajukan", model.StatusDraft, workflow.EventSubmit, workflow.ActorVerifier, model.StatusDraft, workflow.ErrActorNotAllowed},
+		{"Hanya pemilik yang bisa menarik", model.StatusSubmitted, workflow.EventWithdraw, workflow.ActorAdmin, model.StatusSubmitted, workflow.ErrActorNotAllowed},
+		{"Draft tidak bisa menyelesaikan tahap", model.StatusDraft, workflow.EventApproveStage, workflow.ActorAdmin, model.StatusDraft, workflow.ErrInvalidTransition},
+		{"Pemilik tidak bisa menyetujui tahap", model.StatusInReview, workflow.EventApproveStage, workflow.ActorOwner, model.StatusInReview, workflow.ErrActorNotAllowed},
+		{"Pemilik tidak bisa mengarsipkan", model.StatusVerified, workflow.EventArchive, workflow.ActorOwner, model.StatusVerified, workflow.ErrActorNotAllowed},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := workflow.Transition(tc.from, tc.event, tc.actor)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Transition(%s, %s, %s) error = %v, want %v", tc.from, tc.event, tc.actor, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("Transition(%s, %s, %s) = %s, want %s", tc.from, tc.event, tc.actor, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowEditableDeletable(t *testing.T) {
+	editable := map[model.AchievementStatus]bool{
+		model.StatusDraft: true, model.StatusRevisionRequested: true, model.StatusRejected: true,
+	}
+	deletable := map[model.AchievementStatus]bool{model.StatusDraft: true, model.StatusWithdrawn: true}
+
+	all := []model.AchievementStatus{
+		model.StatusDraft, model.StatusSubmitted, model.StatusInReview, model.StatusVerified, model.StatusRejected,
+		model.StatusRevisionRequested, model.StatusWithdrawn, model.StatusArchived,
+	}
+	for _, status := range all {
+		if workflow.Editable(status) != editable[status] {
+			t.Errorf("Editable(%s) = %v, want %v", status, !editable[status], editable[status])
+		}
+		if workflow.Deletable(status) != deletable[status] {
+			t.Errorf("Deletable(%s) = %v, want %v", status, !deletable[status], deletable[status])
+		}
+	}
+}
+
+func TestWorkflowActorFor(t *testing.T) {
+	cases := []struct {
+		relation policy.Relation
+		want     workflow.Actor
+	}{
+		{policy.RelationOwner, workflow.ActorOwner},
+		{policy.RelationAdvisor, workflow.ActorVerifier},
+		{policy.RelationUnitAdmin, workflow.ActorAdmin},
+		{"", workflow.ActorAdmin}, // admin sistem / API key
+	}
+	for _, tc := range cases {
+		if got := workflow.ActorFor(policy.Decision{Allowed: true, Relation: tc.relation}); got != tc.want {
+			t.Errorf("ActorFor(%q) = %s, want %s", tc.relation, got, tc.want)
+		}
+	}
+}